@@ -51,6 +51,14 @@ func run() error {
 	}
 	logger := slog.Default()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, config.Database, os.Args[2:]); err != nil {
+			logger.Error("unable to migrate database", slog.String("error", err.Error()))
+			return err
+		}
+		return nil
+	}
+
 	logger.Info("starting application", slog.Any("config", config))
 
 	logger.Info("instantiating API")
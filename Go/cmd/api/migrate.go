@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/r3d5un/rosetta/Go/internal/database"
+	"github.com/r3d5un/rosetta/Go/internal/migrate"
+	"github.com/r3d5un/rosetta/migrations"
+)
+
+var ErrMigrateUsage = errors.New("usage: rosetta migrate up|down|goto <version>|status")
+
+// runMigrate performs the migrate subcommand, given the arguments following it.
+func runMigrate(ctx context.Context, config database.DatabaseConfig, args []string) error {
+	logger := slog.Default()
+
+	if len(args) == 0 {
+		return ErrMigrateUsage
+	}
+
+	db, err := database.OpenPool(ctx, config)
+	if err != nil {
+		logger.Error("unable to open database connection pool", slog.String("error", err.Error()))
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		logger.Error("unable to load migrations", slog.String("error", err.Error()))
+		return err
+	}
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		return migrator.Up(ctx)
+	case args[0] == "down" && len(args) == 1:
+		return migrator.Down(ctx)
+	case args[0] == "goto" && len(args) == 2:
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return ErrMigrateUsage
+		}
+		return migrator.Goto(ctx, version)
+	case args[0] == "status" && len(args) == 1:
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(status)
+		return nil
+	default:
+		return ErrMigrateUsage
+	}
+}
+
+func printStatus(status *migrate.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "version:\t%d\n", status.Version)
+	fmt.Fprintf(w, "latest:\t%d\n", status.Latest)
+	fmt.Fprintf(w, "dirty:\t%t\n", status.Dirty)
+	fmt.Fprintf(w, "pending:\t%d\n", len(status.Pending))
+	for _, m := range status.Pending {
+		fmt.Fprintf(w, "\t%06d_%s\n", m.Version, m.Name)
+	}
+}
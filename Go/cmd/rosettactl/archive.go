@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/r3d5un/rosetta/Go/internal/archive"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+)
+
+func (c *cli) exportArchive(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	forum := uuidVar(flags, "forum", "only export the forum, and the users involved in it")
+	credentials := flags.Bool("credentials", false, "include the password hashes of the users")
+	file := flags.String("file", "-", "file to write the archive to, or - for stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// The archive takes the place of the output when written to stdout.
+	var w io.Writer = os.Stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	summary, err := archive.NewExporter(c.models).Export(ctx, w, archive.ExportOptions{
+		ForumID:     forum.id,
+		Credentials: *credentials,
+	})
+	if err != nil {
+		return err
+	}
+	if *file == "-" {
+		slog.LogAttrs(ctx, slog.LevelInfo, "archive exported", slog.Any("records", summary.Records))
+		return nil
+	}
+
+	return c.out.print(summary, exportTable(summary))
+}
+
+func (c *cli) importArchive(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	file := flags.String("file", "-", "file to read the archive from, or - for stdin")
+	ids := flags.String("ids", data.ImportIDModePreserve, "either preserve or remap the IDs")
+	onConflict := flags.String(
+		"on-conflict", data.ArchiveConflictFail, "either skip, overwrite or fail on conflicts",
+	)
+	resume := uuidVar(flags, "resume", "resume the interrupted import with the given ID")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	summary, err := archive.NewImporter(c.models).Import(ctx, r, archive.ImportOptions{
+		IDMode:     *ids,
+		OnConflict: *onConflict,
+		Resume:     resume.id,
+	})
+	if err != nil {
+		if summary != nil {
+			return fmt.Errorf(
+				"%w\nlines up to %d are imported, continue with: -resume %s",
+				err,
+				summary.Import.Line,
+				summary.Import.ID,
+			)
+		}
+		return err
+	}
+
+	return c.out.print(summary, importTable(summary))
+}
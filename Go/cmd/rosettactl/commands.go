@@ -0,0 +1,634 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+)
+
+var ErrUnknownCommand = errors.New("unknown command, run rosettactl without arguments for usage")
+
+type cli struct {
+	models *data.Models
+	repo   repo.Repository
+	out    *printer
+}
+
+type command struct {
+	resource string
+	action   string
+	summary  string
+	run      func(c *cli, ctx context.Context, flags *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{"user", "list", "list users", (*cli).listUsers},
+	{"user", "create", "create a user", (*cli).createUser},
+	{"user", "delete", "soft delete a user", (*cli).deleteUser},
+	{"user", "restore", "restore a soft deleted user", (*cli).restoreUser},
+	{"user", "purge", "permanently delete a user and its content", (*cli).purgeUser},
+	{"forum", "list", "list forums", (*cli).listForums},
+	{"forum", "create", "create a forum", (*cli).createForum},
+	{"forum", "delete", "soft delete a forum", (*cli).deleteForum},
+	{"forum", "restore", "restore a soft deleted forum", (*cli).restoreForum},
+	{"forum", "purge", "permanently delete a forum and its content", (*cli).purgeForum},
+	{"thread", "list", "list threads", (*cli).listThreads},
+	{"thread", "create", "create a thread with its opening post", (*cli).createThread},
+	{"thread", "delete", "soft delete a thread", (*cli).deleteThread},
+	{"thread", "restore", "restore a soft deleted thread", (*cli).restoreThread},
+	{"thread", "purge", "permanently delete a thread and its posts", (*cli).purgeThread},
+	{"thread", "lock", "lock a thread against changes", (*cli).lockThread},
+	{"thread", "unlock", "unlock a locked thread", (*cli).unlockThread},
+	{"post", "list", "list the posts of a thread", (*cli).listPosts},
+	{"post", "create", "reply to a thread", (*cli).createPost},
+	{"post", "delete", "soft delete a post", (*cli).deletePost},
+	{"post", "restore", "restore a soft deleted post", (*cli).restorePost},
+	{"post", "purge", "permanently delete a post", (*cli).purgePost},
+	{"likes", "recount", "recount the likes of every thread and post", (*cli).recountLikes},
+	{"archive", "export", "export forums as a JSON Lines archive", (*cli).exportArchive},
+	{"archive", "import", "import a JSON Lines archive", (*cli).importArchive},
+	{"seed", "generate", "generate synthetic data for development", (*cli).generateSeed},
+}
+
+func (c *cli) run(ctx context.Context, resource string, action string, args []string) error {
+	for _, cmd := range commands {
+		if cmd.resource != resource || cmd.action != action {
+			continue
+		}
+
+		flags := flag.NewFlagSet(resource+" "+action, flag.ContinueOnError)
+		return cmd.run(c, ctx, flags, args)
+	}
+
+	return ErrUnknownCommand
+}
+
+// uuidFlag is a flag holding a UUID, which is nil until the flag is set.
+type uuidFlag struct {
+	id *uuid.UUID
+}
+
+func (f *uuidFlag) String() string {
+	if f.id == nil {
+		return ""
+	}
+	return f.id.String()
+}
+
+func (f *uuidFlag) Set(s string) error {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return err
+	}
+	f.id = &id
+	return nil
+}
+
+func uuidVar(flags *flag.FlagSet, name string, usage string) *uuidFlag {
+	f := &uuidFlag{}
+	flags.Var(f, name, usage)
+	return f
+}
+
+// required returns the IDs of the given flags, or an error naming the first flag not set.
+func required(flags *flag.FlagSet, ids ...*uuidFlag) error {
+	for _, id := range ids {
+		if id.id != nil {
+			continue
+		}
+		var name string
+		flags.VisitAll(func(f *flag.Flag) {
+			if f.Value == id {
+				name = f.Name
+			}
+		})
+		return fmt.Errorf("flag -%s is required", name)
+	}
+
+	return nil
+}
+
+// listFlags registers the flags shared by every list command.
+type listFlags struct {
+	pageSize *int
+	cursor   *string
+	orderBy  *string
+	deleted  *string
+}
+
+func newListFlags(flags *flag.FlagSet) listFlags {
+	return listFlags{
+		pageSize: flags.Int("page-size", 25, "number of results per page"),
+		cursor:   flags.String("cursor", "", "cursor of the page to list"),
+		orderBy:  flags.String("order-by", "", "comma separated columns to order by"),
+		deleted:  flags.String("deleted", "", "only list deleted (true) or undeleted (false)"),
+	}
+}
+
+func (l listFlags) filters(safeList []string) (data.Filters, error) {
+	filters := data.Filters{PageSize: *l.pageSize, OrderBySafeList: safeList}
+
+	if *l.orderBy != "" {
+		filters.OrderBy = strings.Split(*l.orderBy, ",")
+	}
+	if *l.cursor != "" {
+		cursor, err := data.DecodeCursor(*l.cursor)
+		if err != nil {
+			return filters, err
+		}
+		filters.Cursor = cursor
+	}
+	if *l.deleted != "" {
+		deleted, err := strconv.ParseBool(*l.deleted)
+		if err != nil {
+			return filters, fmt.Errorf("flag -deleted: %w", err)
+		}
+		filters.Deleted = &deleted
+	}
+
+	return filters, nil
+}
+
+func (c *cli) listUsers(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	list := newListFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	filters, err := list.filters(data.UserOrderBySafeList)
+	if err != nil {
+		return err
+	}
+
+	users, metadata, err := c.repo.UserReader.List(ctx, filters, false)
+	if err != nil {
+		return err
+	}
+
+	return c.out.printList(users, metadata, userTable(users...))
+}
+
+func (c *cli) createUser(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	name := flags.String("name", "", "full name of the user")
+	username := flags.String("username", "", "unique username of the user")
+	email := flags.String("email", "", "unique email address of the user")
+	passwordStdin := flags.Bool("password-stdin", false, "read the password from stdin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	input := repo.UserInput{Name: *name, Username: *username, Email: *email}
+	if *passwordStdin {
+		password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && password == "" {
+			return err
+		}
+		input.Password = strings.TrimRight(password, "\r\n")
+	}
+
+	user, err := c.repo.UserWriter.Create(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(user, userTable(user))
+}
+
+func (c *cli) deleteUser(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	id := uuidVar(flags, "id", "ID of the user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, id); err != nil {
+		return err
+	}
+
+	user, err := c.repo.UserWriter.Delete(ctx, *id.id, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(user, userTable(user))
+}
+
+func (c *cli) restoreUser(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	id := uuidVar(flags, "id", "ID of the user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, id); err != nil {
+		return err
+	}
+
+	user, err := c.repo.UserWriter.Restore(ctx, *id.id)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(user, userTable(user))
+}
+
+func (c *cli) purgeUser(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	id := uuidVar(flags, "id", "ID of the user")
+	dryRun := flags.Bool("dry-run", false, "summarise the purge without removing anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, id); err != nil {
+		return err
+	}
+
+	summary, err := c.repo.UserWriter.PermanentlyDelete(ctx, *id.id, nil, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(summary, purgeTable(summary))
+}
+
+func (c *cli) listForums(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	list := newListFlags(flags)
+	owner := uuidVar(flags, "owner", "only list forums owned by the user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	filters, err := list.filters(data.ForumOrderBySafeList)
+	if err != nil {
+		return err
+	}
+	filters.OwnerID = owner.id
+
+	forums, metadata, err := c.repo.ForumReader.List(ctx, filters, false)
+	if err != nil {
+		return err
+	}
+
+	return c.out.printList(forums, metadata, forumTable(forums...))
+}
+
+func (c *cli) createForum(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	owner := uuidVar(flags, "owner", "ID of the user owning the forum")
+	name := flags.String("name", "", "name of the forum")
+	description := flags.String("description", "", "description of the forum")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, owner); err != nil {
+		return err
+	}
+
+	input := repo.ForumInput{OwnerID: *owner.id, Name: *name}
+	if *description != "" {
+		input.Description = description
+	}
+
+	forum, err := c.repo.ForumWriter.Create(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(forum, forumTable(forum))
+}
+
+func (c *cli) deleteForum(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	id := uuidVar(flags, "id", "ID of the forum")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, id); err != nil {
+		return err
+	}
+
+	forum, err := c.repo.ForumWriter.Delete(ctx, *id.id, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(forum, forumTable(forum))
+}
+
+func (c *cli) restoreForum(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	id := uuidVar(flags, "id", "ID of the forum")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, id); err != nil {
+		return err
+	}
+
+	forum, err := c.repo.ForumWriter.Restore(ctx, *id.id)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(forum, forumTable(forum))
+}
+
+func (c *cli) purgeForum(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	id := uuidVar(flags, "id", "ID of the forum")
+	dryRun := flags.Bool("dry-run", false, "summarise the purge without removing anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, id); err != nil {
+		return err
+	}
+
+	summary, err := c.repo.ForumWriter.PermanentlyDelete(ctx, *id.id, nil, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(summary, purgeTable(summary))
+}
+
+func (c *cli) listThreads(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	list := newListFlags(flags)
+	forum := uuidVar(flags, "forum", "only list threads in the forum")
+	author := uuidVar(flags, "author", "only list threads started by the user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	filters, err := list.filters(data.ThreadOrderBySafeList)
+	if err != nil {
+		return err
+	}
+	filters.ForumID = forum.id
+	filters.AuthorID = author.id
+
+	threads, metadata, err := c.repo.ThreadReader.List(ctx, filters, false)
+	if err != nil {
+		return err
+	}
+
+	return c.out.printList(threads, metadata, threadTable(threads...))
+}
+
+func (c *cli) createThread(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	forum := uuidVar(flags, "forum", "ID of the forum")
+	author := uuidVar(flags, "author", "ID of the user starting the thread")
+	title := flags.String("title", "", "title of the thread")
+	content := flags.String("content", "", "content of the opening post")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, forum, author); err != nil {
+		return err
+	}
+
+	thread, err := c.repo.ThreadWriter.Create(ctx, repo.ThreadInput{
+		ForumID:  *forum.id,
+		Title:    *title,
+		AuthorID: *author.id,
+		Content:  *content,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(thread, threadTable(thread))
+}
+
+// threadCommand parses the flags identifying a thread, shared by every command acting on a single
+// thread.
+func threadCommand(
+	flags *flag.FlagSet,
+	args []string,
+	extra func(flags *flag.FlagSet),
+) (uuid.UUID, uuid.UUID, error) {
+	forum := uuidVar(flags, "forum", "ID of the forum")
+	thread := uuidVar(flags, "id", "ID of the thread")
+	if extra != nil {
+		extra(flags)
+	}
+	if err := flags.Parse(args); err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	if err := required(flags, forum, thread); err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return *forum.id, *thread.id, nil
+}
+
+func (c *cli) deleteThread(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	forumID, threadID, err := threadCommand(flags, args, nil)
+	if err != nil {
+		return err
+	}
+
+	thread, err := c.repo.ThreadWriter.Delete(ctx, forumID, threadID, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(thread, threadTable(thread))
+}
+
+func (c *cli) restoreThread(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	forumID, threadID, err := threadCommand(flags, args, nil)
+	if err != nil {
+		return err
+	}
+
+	thread, err := c.repo.ThreadWriter.Restore(ctx, forumID, threadID)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(thread, threadTable(thread))
+}
+
+func (c *cli) purgeThread(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	var dryRun *bool
+	forumID, threadID, err := threadCommand(flags, args, func(flags *flag.FlagSet) {
+		dryRun = flags.Bool("dry-run", false, "summarise the purge without removing anything")
+	})
+	if err != nil {
+		return err
+	}
+
+	summary, err := c.repo.ThreadWriter.PermanentlyDelete(ctx, forumID, threadID, nil, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(summary, purgeTable(summary))
+}
+
+func (c *cli) lockThread(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	var by *uuidFlag
+	forumID, threadID, err := threadCommand(flags, args, func(flags *flag.FlagSet) {
+		by = uuidVar(flags, "by", "ID of the user the thread is locked by")
+	})
+	if err != nil {
+		return err
+	}
+	if err := required(flags, by); err != nil {
+		return err
+	}
+
+	thread, err := c.repo.ThreadWriter.Lock(ctx, forumID, threadID, *by.id)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(thread, threadTable(thread))
+}
+
+func (c *cli) unlockThread(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	forumID, threadID, err := threadCommand(flags, args, nil)
+	if err != nil {
+		return err
+	}
+
+	thread, err := c.repo.ThreadWriter.Unlock(ctx, forumID, threadID)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(thread, threadTable(thread))
+}
+
+func (c *cli) listPosts(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	list := newListFlags(flags)
+	forum := uuidVar(flags, "forum", "ID of the forum")
+	thread := uuidVar(flags, "thread", "ID of the thread")
+	author := uuidVar(flags, "author", "only list posts written by the user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, forum, thread); err != nil {
+		return err
+	}
+	filters, err := list.filters(data.PostOrderBySafeList)
+	if err != nil {
+		return err
+	}
+	filters.ThreadID = thread.id
+	filters.AuthorID = author.id
+
+	posts, metadata, err := c.repo.PostReader.List(ctx, *forum.id, *thread.id, filters, false)
+	if err != nil {
+		return err
+	}
+
+	return c.out.printList(posts, metadata, postTable(posts...))
+}
+
+func (c *cli) createPost(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	forum := uuidVar(flags, "forum", "ID of the forum")
+	thread := uuidVar(flags, "thread", "ID of the thread")
+	author := uuidVar(flags, "author", "ID of the user writing the post")
+	replyTo := uuidVar(flags, "reply-to", "ID of the post replied to")
+	content := flags.String("content", "", "content of the post")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, forum, thread, author); err != nil {
+		return err
+	}
+
+	post, err := c.repo.PostWriter.Create(ctx, repo.PostInput{
+		ForumID:  *forum.id,
+		ThreadID: *thread.id,
+		ReplyTo:  replyTo.id,
+		AuthorID: *author.id,
+		Content:  *content,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(post, postTable(post))
+}
+
+func (c *cli) deletePost(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	id := uuidVar(flags, "id", "ID of the post")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, id); err != nil {
+		return err
+	}
+
+	post, err := c.repo.PostWriter.Delete(ctx, *id.id)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(post, postTable(post))
+}
+
+func (c *cli) restorePost(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	id := uuidVar(flags, "id", "ID of the post")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, id); err != nil {
+		return err
+	}
+
+	post, err := c.repo.PostWriter.Restore(ctx, *id.id)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(post, postTable(post))
+}
+
+func (c *cli) purgePost(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	id := uuidVar(flags, "id", "ID of the post")
+	dryRun := flags.Bool("dry-run", false, "summarise the purge without removing anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := required(flags, id); err != nil {
+		return err
+	}
+
+	summary, err := c.repo.PostWriter.PermanentlyDelete(ctx, *id.id, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(summary, purgeTable(summary))
+}
+
+// LikesRecount is the number of threads and posts whose likes were corrected.
+type LikesRecount struct {
+	Threads int64 `json:"threads"`
+	Posts   int64 `json:"posts"`
+}
+
+func (c *cli) recountLikes(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	threads, err := c.repo.ThreadVoteWriter.RecountLikes(ctx)
+	if err != nil {
+		return err
+	}
+	posts, err := c.repo.PostVoteWriter.RecountLikes(ctx)
+	if err != nil {
+		return err
+	}
+
+	recount := LikesRecount{Threads: threads, Posts: posts}
+	return c.out.print(recount, table{
+		header: []string{"THREADS CORRECTED", "POSTS CORRECTED"},
+		rows: [][]string{{
+			strconv.FormatInt(recount.Threads, 10),
+			strconv.FormatInt(recount.Posts, 10),
+		}},
+	})
+}
@@ -0,0 +1,98 @@
+// Command rosettactl operates the forum from the terminal, through the same repository as the API.
+//
+// Usage:
+//
+//	rosettactl [-o table|json] [-v] <resource> <action> [flags]
+//
+// The configuration is read the same way as by the API, see cfg.New. Run rosettactl without
+// arguments to list every command, and append -h to a command to list its flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/cfg"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/database"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+)
+
+func main() {
+	if err := run(); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+func run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	flags := flag.NewFlagSet("rosettactl", flag.ContinueOnError)
+	output := flags.String("o", outputTable, "output format, either table or json")
+	verbose := flags.Bool("v", false, "log every operation to stderr")
+	flags.Usage = func() { usage(flags) }
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		usage(flags)
+		return flag.ErrHelp
+	}
+
+	// Logs are kept off stdout, which is reserved for the output of the command.
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelInfo
+	}
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	printer, err := newPrinter(*output, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	config, err := cfg.New(ctx)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.OpenPool(ctx, config.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	timeout := config.Database.TimeoutDuration()
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	models := data.NewModels(db, &timeout)
+
+	c := cli{models: &models, repo: repo.NewRepository(&models), out: printer}
+	return c.run(ctx, flags.Arg(0), flags.Arg(1), flags.Args()[2:])
+}
+
+func usage(flags *flag.FlagSet) {
+	w := flags.Output()
+	fmt.Fprintln(w, "usage: rosettactl [-o table|json] [-v] <resource> <action> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	flags.PrintDefaults()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-7s %-8s %s\n", cmd.resource, cmd.action, cmd.summary)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/archive"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/r3d5un/rosetta/Go/internal/seed"
+)
+
+const (
+	outputTable string = "table"
+	outputJSON  string = "json"
+)
+
+// table is the tabular representation of the result of a command.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+func newPrinter(format string, w io.Writer) (*printer, error) {
+	switch format {
+	case outputTable, outputJSON:
+		return &printer{format: format, w: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, expected table or json", format)
+	}
+}
+
+// print writes the result of a command, either as the JSON representation of v, the same as the
+// API would respond with, or as the table t.
+func (p *printer) print(v any, t table) error {
+	if p.format == outputJSON {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// printList writes a page of results. In table output, the cursor of the next page follows the
+// table.
+func (p *printer) printList(v any, metadata *data.Metadata, t table) error {
+	if p.format == outputJSON {
+		return p.print(struct {
+			Data     any            `json:"data"`
+			Metadata *data.Metadata `json:"metadata"`
+		}{v, metadata}, t)
+	}
+
+	if err := p.print(v, t); err != nil {
+		return err
+	}
+	if metadata != nil && metadata.Next {
+		fmt.Fprintf(p.w, "\nnext page: -cursor %s\n", metadata.NextCursor)
+	}
+
+	return nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func formatUUID(id *uuid.UUID) string {
+	if id == nil {
+		return "-"
+	}
+
+	return id.String()
+}
+
+func userTable(users ...*repo.User) table {
+	t := table{header: []string{"ID", "USERNAME", "NAME", "EMAIL", "CREATED", "DELETED"}}
+	for _, u := range users {
+		t.rows = append(t.rows, []string{
+			u.ID.String(),
+			u.Username,
+			u.Name,
+			u.Email,
+			formatTime(&u.CreatedAt),
+			formatTime(u.DeletedAt),
+		})
+	}
+
+	return t
+}
+
+func forumTable(forums ...*repo.Forum) table {
+	t := table{header: []string{"ID", "NAME", "OWNER", "CREATED", "DELETED"}}
+	for _, f := range forums {
+		t.rows = append(t.rows, []string{
+			f.ID.String(),
+			f.Name,
+			f.OwnerID.String(),
+			formatTime(&f.CreatedAt),
+			formatTime(f.DeletedAt),
+		})
+	}
+
+	return t
+}
+
+func threadTable(threads ...*repo.Thread) table {
+	t := table{
+		header: []string{"ID", "FORUM", "TITLE", "AUTHOR", "LIKES", "LOCKED BY", "DELETED"},
+	}
+	for _, th := range threads {
+		t.rows = append(t.rows, []string{
+			th.ID.String(),
+			th.ForumID.String(),
+			th.Title,
+			th.AuthorID.String(),
+			strconv.FormatInt(th.Likes, 10),
+			formatUUID(th.LockedBy),
+			formatTime(th.DeletedAt),
+		})
+	}
+
+	return t
+}
+
+func postTable(posts ...*repo.Post) table {
+	t := table{header: []string{"ID", "THREAD", "AUTHOR", "LIKES", "EDITS", "CONTENT", "DELETED"}}
+	for _, p := range posts {
+		t.rows = append(t.rows, []string{
+			p.ID.String(),
+			p.ThreadID.String(),
+			p.AuthorID.String(),
+			strconv.FormatInt(p.Likes, 10),
+			strconv.Itoa(p.EditCount),
+			truncate(p.Content, 40),
+			formatTime(p.DeletedAt),
+		})
+	}
+
+	return t
+}
+
+func purgeTable(summary *repo.PurgeSummary) table {
+	return table{
+		header: []string{"DRY RUN", "USERS", "FORUMS", "THREADS", "POSTS", "VOTES"},
+		rows: [][]string{{
+			strconv.FormatBool(summary.DryRun),
+			strconv.FormatInt(summary.Users, 10),
+			strconv.FormatInt(summary.Forums, 10),
+			strconv.FormatInt(summary.Threads, 10),
+			strconv.FormatInt(summary.Posts, 10),
+			strconv.FormatInt(summary.ThreadVotes+summary.PostVotes, 10),
+		}},
+	}
+}
+
+func exportTable(summary *archive.ExportSummary) table {
+	t := table{header: []string{"KIND", "RECORDS"}}
+	for _, archived := range data.ArchiveTables {
+		t.rows = append(t.rows, []string{
+			archived.Kind,
+			strconv.FormatInt(summary.Records[archived.Kind], 10),
+		})
+	}
+
+	return t
+}
+
+func importTable(summary *archive.ImportSummary) table {
+	t := table{header: []string{"KIND", "WRITTEN", "SKIPPED"}}
+	for _, archived := range data.ArchiveTables {
+		t.rows = append(t.rows, []string{
+			archived.Kind,
+			strconv.FormatInt(summary.Written[archived.Kind], 10),
+			strconv.FormatInt(summary.Skipped[archived.Kind], 10),
+		})
+	}
+
+	return t
+}
+
+func seedTable(summary *seed.Summary) table {
+	return table{
+		header: []string{"USERS", "FORUMS", "THREADS", "POSTS", "THREAD VOTES", "POST VOTES"},
+		rows: [][]string{{
+			strconv.FormatInt(summary.Users, 10),
+			strconv.FormatInt(summary.Forums, 10),
+			strconv.FormatInt(summary.Threads, 10),
+			strconv.FormatInt(summary.Posts, 10),
+			strconv.FormatInt(summary.ThreadVotes, 10),
+			strconv.FormatInt(summary.PostVotes, 10),
+		}},
+	}
+}
+
+// truncate shortens s to at most n runes on a single line, so that it fits in a column.
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n-3]) + "..."
+}
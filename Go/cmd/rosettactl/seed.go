@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/seed"
+)
+
+func (c *cli) generateSeed(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	defaults := seed.SeedConfig{}.WithDefaults()
+
+	var config seed.SeedConfig
+	flags.Uint64Var(&config.Seed, "seed", 0, "seed every generated row is derived from")
+	flags.IntVar(&config.Users, "users", defaults.Users, "number of users")
+	flags.IntVar(&config.Forums, "forums", defaults.Forums, "number of forums")
+	flags.IntVar(
+		&config.ThreadsPerForum, "threads", defaults.ThreadsPerForum, "average threads per forum",
+	)
+	flags.IntVar(
+		&config.PostsPerThread, "posts", defaults.PostsPerThread, "average posts per thread",
+	)
+	flags.IntVar(&config.MaxDepth, "depth", defaults.MaxDepth, "deepest nesting of replies")
+	flags.Float64Var(&config.VotesPerThread, "thread-votes", 5, "average votes per thread")
+	flags.Float64Var(&config.VotesPerPost, "post-votes", 2, "average votes per post")
+	flags.StringVar(&config.Password, "password", "", "password of every user, or none if empty")
+	start := flags.String(
+		"start", defaults.Start.Format(time.DateOnly), "date the first rows are created on",
+	)
+	flags.DurationVar(&config.Span, "span", defaults.Span, "period the threads are created over")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	config.Start, err = time.Parse(time.DateOnly, *start)
+	if err != nil {
+		return err
+	}
+
+	summary, err := seed.NewGenerator(config, seed.NewPostgresStore(c.models)).Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(summary, seedTable(summary))
+}
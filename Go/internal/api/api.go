@@ -3,7 +3,6 @@ package api
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -16,54 +15,121 @@ import (
 	"github.com/r3d5un/rosetta/Go/internal/cfg"
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/database"
+	"github.com/r3d5un/rosetta/Go/internal/eventstream"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
+	"github.com/r3d5un/rosetta/Go/internal/migrate"
+	"github.com/r3d5un/rosetta/Go/internal/ratelimit"
 	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/r3d5un/rosetta/Go/internal/retention"
+	"github.com/r3d5un/rosetta/Go/internal/webhook"
+	"github.com/r3d5un/rosetta/migrations"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type API struct {
-	mux    *http.ServeMux
-	logger slog.Logger
-	db     *pgxpool.Pool
-	models *data.Models
-	repo   repo.Repository
+	mux     *http.ServeMux
+	logger  slog.Logger
+	db      *pgxpool.Pool
+	models  *data.Models
+	repo    repo.Repository
+	events  *eventstream.Broker
+	hooks   *webhook.Dispatcher
+	purger  *retention.Job
+	limiter *ratelimit.Limiter
+	server  cfg.ServerCfg
 }
 
 func NewAPI(ctx context.Context, config cfg.AppCfg) (*API, error) {
 	logger := logging.LoggerFromContext(ctx)
 
+	server := config.Server.WithDefaults()
+	if _, err := server.TLS(); err != nil {
+		return nil, err
+	}
+
 	logger.Info("opening database connection pool", slog.Any("databaseConfig", config.Database))
 	db, err := database.OpenPool(ctx, config.Database)
 	if err != nil {
 		return nil, err
 	}
 
+	if config.Database.AutoMigrate {
+		logger.LogAttrs(ctx, slog.LevelInfo, "applying pending migrations")
+		migrator, err := migrate.New(db, migrations.FS)
+		if err != nil {
+			return nil, err
+		}
+		if err := migrator.Up(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	logger.LogAttrs(ctx, slog.LevelInfo, "creating data models")
-	timeout := time.Duration(5) * time.Second
+	timeout := config.Database.TimeoutDuration()
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
 	models := data.NewModels(db, &timeout)
 
 	logger.LogAttrs(ctx, slog.LevelInfo, "creating resource repository")
 	repo := repo.NewRepository(&models)
 
+	logger.LogAttrs(ctx, slog.LevelInfo, "creating retention job")
+	purger, err := retention.NewJob(config.Retention, repo.PurgeWriter)
+	if err != nil {
+		return nil, err
+	}
+
 	return &API{
-		mux:    http.NewServeMux(),
-		logger: *slog.Default(),
-		db:     db,
-		models: &models,
-		repo:   repo,
+		mux:     http.NewServeMux(),
+		logger:  *slog.Default(),
+		db:      db,
+		models:  &models,
+		repo:    repo,
+		events:  eventstream.NewBroker(db, &models),
+		hooks:   webhook.NewDispatcher(&models, &http.Client{Timeout: 10 * time.Second}),
+		purger:  purger,
+		limiter: ratelimit.NewLimiter(config.RateLimit, ratelimit.NewPostgresStore(&models)),
+		server:  server,
 	}, nil
 }
 
 func (api *API) Serve() error {
+	tls, err := api.server.TLS()
+	if err != nil {
+		return err
+	}
+
+	// HTTP/2 is otherwise enabled by default over TLS.
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if api.server.HTTP2 {
+		protocols.SetHTTP2(tls)
+		protocols.SetUnencryptedHTTP2(!tls)
+	}
+
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", 4000),
-		Handler:      api.routes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		ErrorLog:     slog.NewLogLogger(api.logger.Handler(), slog.LevelError),
+		Addr:           api.server.Addr(),
+		Handler:        api.routes(),
+		IdleTimeout:    api.server.IdleTimeout(),
+		ReadTimeout:    api.server.ReadTimeout(),
+		WriteTimeout:   api.server.WriteTimeout(),
+		MaxHeaderBytes: api.server.MaxHeaderBytes,
+		Protocols:      protocols,
+		ErrorLog:       slog.NewLogLogger(api.logger.Handler(), slog.LevelError),
 	}
 
+	// Stopping the broker ends every open event stream, which would otherwise hold up the
+	// shutdown of the server.
+	ctx, stopWorkers := context.WithCancel(logging.WithLogger(context.Background(), &api.logger))
+	defer stopWorkers()
+	srv.RegisterOnShutdown(stopWorkers)
+	go api.events.Run(ctx)
+	go api.hooks.Run(ctx)
+	go api.purger.Run(ctx)
+	go api.sweepIdempotencyKeys(ctx)
+	go api.limiter.Run(ctx)
+
 	shutdownError := make(chan error)
 
 	go func() {
@@ -73,14 +139,23 @@ func (api *API) Serve() error {
 
 		slog.Info("shutting down server", "signal", s.String())
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), api.server.ShutdownGrace())
 		defer cancel()
 
 		shutdownError <- srv.Shutdown(ctx)
 	}()
 
-	api.logger.Info("starting server", slog.String("addr", srv.Addr))
-	err := srv.ListenAndServe()
+	api.logger.Info(
+		"starting server",
+		slog.String("addr", srv.Addr),
+		slog.Bool("tls", tls),
+		slog.Bool("http2", api.server.HTTP2),
+	)
+	if tls {
+		err = srv.ListenAndServeTLS(api.server.TLSCertFile, api.server.TLSKeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
@@ -101,6 +176,11 @@ func (api *API) routes() http.Handler {
 		api.recoverPanic,
 		api.enableCORS,
 		api.logRequest,
+		api.limitRequestBody,
+		api.authenticate,
+		api.rateLimit,
+		api.idempotency,
+		api.batchLoads,
 	)
 
 	endpoints := []struct {
@@ -112,35 +192,184 @@ func (api *API) routes() http.Handler {
 		{"GET /debug/pprof/", http.DefaultServeMux.ServeHTTP},
 		{"GET /debug/pprof/profile", http.DefaultServeMux.ServeHTTP},
 		{"GET /debug/pprof/heap", http.DefaultServeMux.ServeHTTP},
+		// authentication
+		{"POST /api/v1/tokens/authentication", api.createAuthenticationTokenHandler},
+		// search
+		{"GET /api/v1/search", api.searchHandler},
 		// user
 		{"POST /api/v1/user", api.postUserHandler},
-		{"PATCH /api/v1/user", api.patchUserHandler},
-		{"DELETE /api/v1/user/{id}/delete", api.deleteUserHandler},
-		{"POST /api/v1/user/{id}/restore", api.restoreUserHandler},
-		{"DELETE /api/v1/user/{id}/purge", api.deletePermanentlyUserHandler},
+		{"PATCH /api/v1/user", api.requirePermission("", repo.RoleMember, api.patchUserHandler)},
+		{
+			"DELETE /api/v1/user/{id}/delete",
+			api.requirePermission("", repo.RoleMember, api.deleteUserHandler),
+		},
+		{
+			"POST /api/v1/user/{id}/restore",
+			api.requirePermission("", repo.RoleMember, api.restoreUserHandler),
+		},
+		{
+			"DELETE /api/v1/user/{id}/purge",
+			api.requirePermission("", repo.RoleAdmin, api.deletePermanentlyUserHandler),
+		},
 		{"GET /api/v1/user", api.listUserHandler},
 		{"GET /api/v1/user/{id}", api.getUserHandler},
+		// admin
+		{"GET /api/v1/admin/user", api.requirePermission("", repo.RoleAdmin, api.listAdminHandler)},
+		{
+			"PUT /api/v1/admin/user/{id}",
+			api.requirePermission("", repo.RoleAdmin, api.putAdminHandler),
+		},
+		{
+			"DELETE /api/v1/admin/user/{id}",
+			api.requirePermission("", repo.RoleAdmin, api.deleteAdminHandler),
+		},
+		{
+			"GET /api/v1/admin/webhook",
+			api.requirePermission("", repo.RoleAdmin, api.listWebhookHandler),
+		},
+		{
+			"POST /api/v1/admin/webhook",
+			api.requirePermission("", repo.RoleAdmin, api.postWebhookHandler),
+		},
+		{
+			"GET /api/v1/admin/webhook/{id}",
+			api.requirePermission("", repo.RoleAdmin, api.getWebhookHandler),
+		},
+		{
+			"PATCH /api/v1/admin/webhook/{id}",
+			api.requirePermission("", repo.RoleAdmin, api.patchWebhookHandler),
+		},
+		{
+			"DELETE /api/v1/admin/webhook/{id}",
+			api.requirePermission("", repo.RoleAdmin, api.deleteWebhookHandler),
+		},
+		{
+			"GET /api/v1/admin/webhook/{id}/delivery",
+			api.requirePermission("", repo.RoleAdmin, api.listWebhookDeliveryHandler),
+		},
+		{
+			"POST /api/v1/admin/webhook/{id}/delivery/{delivery_id}/retry",
+			api.requirePermission("", repo.RoleAdmin, api.retryWebhookDeliveryHandler),
+		},
+		{
+			"GET /api/v1/admin/export",
+			api.requirePermission("", repo.RoleAdmin, api.exportArchiveHandler),
+		},
+		{
+			"POST " + adminImportPath,
+			api.requirePermission("", repo.RoleAdmin, api.importArchiveHandler),
+		},
 		// forum
-		{"POST /api/v1/forum", api.postForumHandler},
-		{"PATCH /api/v1/forum", api.patchForumHandler},
-		{"DELETE /api/v1/forum/{id}/delete", api.deleteForumHandler},
-		{"DELETE /api/v1/forum/{id}/purge", api.deletePermanentlyForumHandler},
-		{"POST /api/v1/forum/{id}/restore", api.restoreForumHandler},
+		{"POST /api/v1/forum", api.requireAuthenticatedUser(api.postForumHandler)},
+		{"PATCH /api/v1/forum", api.requireAuthenticatedUser(api.patchForumHandler)},
+		{
+			"DELETE /api/v1/forum/{id}/delete",
+			api.requirePermission("id", repo.RoleOwner, api.deleteForumHandler),
+		},
+		{
+			"DELETE /api/v1/forum/{id}/purge",
+			api.requirePermission("", repo.RoleAdmin, api.deletePermanentlyForumHandler),
+		},
+		{
+			"POST /api/v1/forum/{id}/restore",
+			api.requirePermission("id", repo.RoleOwner, api.restoreForumHandler),
+		},
 		{"GET /api/v1/forum", api.listForumHandler},
 		{"GET /api/v1/forum/{id}", api.getForumHandler},
+		// moderator
+		{"GET /api/v1/forum/{forum_id}/moderator", api.listModeratorHandler},
+		{
+			"PUT /api/v1/forum/{forum_id}/moderator/{user_id}",
+			api.requirePermission("forum_id", repo.RoleOwner, api.putModeratorHandler),
+		},
+		{
+			"DELETE /api/v1/forum/{forum_id}/moderator/{user_id}",
+			api.requirePermission("forum_id", repo.RoleOwner, api.deleteModeratorHandler),
+		},
 		// thread
-		{"POST /api/v1/forum/{forum_id}/thread", api.postThreadHandler},
-		{"PATCH /api/v1/forum/{forum_id}/thread", api.patchThreadHandler},
-		{"DELETE /api/v1/forum/{forum_id}/thread/{thread_id}/delete", api.deleteThreadHandler},
+		{
+			"POST /api/v1/forum/{forum_id}/thread",
+			api.requireAuthenticatedUser(api.postThreadHandler),
+		},
+		{
+			"PATCH /api/v1/forum/{forum_id}/thread",
+			api.requirePermission("forum_id", repo.RoleMember, api.patchThreadHandler),
+		},
+		{
+			"DELETE /api/v1/forum/{forum_id}/thread/{thread_id}/delete",
+			api.requirePermission("forum_id", repo.RoleModerator, api.deleteThreadHandler),
+		},
 		{
 			"DELETE /api/v1/forum/{forum_id}/thread/{thread_id}/purge",
-			api.deletePermanentlyThreadHandler,
+			api.requirePermission("", repo.RoleAdmin, api.deletePermanentlyThreadHandler),
+		},
+		{
+			"POST /api/v1/forum/{forum_id}/thread/{thread_id}/restore",
+			api.requirePermission("forum_id", repo.RoleModerator, api.restoreThreadHandler),
+		},
+		{
+			"POST /api/v1/forum/{forum_id}/thread/{thread_id}/lock",
+			api.requirePermission("forum_id", repo.RoleModerator, api.lockThreadHandler),
+		},
+		{
+			"POST /api/v1/forum/{forum_id}/thread/{thread_id}/unlock",
+			api.requirePermission("forum_id", repo.RoleModerator, api.unlockThreadHandler),
 		},
-		{"POST /api/v1/forum/{forum_id}/thread/{thread_id}/restore", api.restoreThreadHandler},
 		{"GET /api/v1/forum/{forum_id}/thread", api.listThreadHandler},
 		{"GET /api/v1/forum/{forum_id}/thread/{thread_id}", api.getThreadHandler},
+		{"GET /api/v1/forum/{forum_id}/thread/{thread_id}/events", api.threadEventsHandler},
+		{
+			"GET /api/v1/forum/{forum_id}/thread/{thread_id}/revision",
+			api.listThreadRevisionHandler,
+		},
+		{
+			"PUT /api/v1/forum/{forum_id}/thread/{thread_id}/vote",
+			api.requireAuthenticatedUser(api.putThreadVoteHandler),
+		},
+		{
+			"DELETE /api/v1/forum/{forum_id}/thread/{thread_id}/vote",
+			api.requireAuthenticatedUser(api.deleteThreadVoteHandler),
+		},
 		// post
-		{"POST /api/v1/forum/{forum_id}/thread/{thread_id}", api.postPostHandler},
+		{
+			"POST /api/v1/forum/{forum_id}/thread/{thread_id}",
+			api.requireAuthenticatedUser(api.postPostHandler),
+		},
+		{
+			"PATCH /api/v1/forum/{forum_id}/thread/{thread_id}/post",
+			api.requirePermission("forum_id", repo.RoleMember, api.patchPostHandler),
+		},
+		{
+			"DELETE /api/v1/forum/{forum_id}/thread/{thread_id}/post/{post_id}/delete",
+			api.requirePermission("forum_id", repo.RoleModerator, api.deletePostHandler),
+		},
+		{
+			"DELETE /api/v1/forum/{forum_id}/thread/{thread_id}/post/{post_id}/purge",
+			api.requirePermission("", repo.RoleAdmin, api.deletePermanentlyPostHandler),
+		},
+		{
+			"POST /api/v1/forum/{forum_id}/thread/{thread_id}/post/{post_id}/restore",
+			api.requirePermission("forum_id", repo.RoleModerator, api.restorePostHandler),
+		},
+		{"GET /api/v1/forum/{forum_id}/thread/{thread_id}/post", api.listPostHandler},
+		{"GET /api/v1/forum/{forum_id}/thread/{thread_id}/posts/tree", api.getPostTreeHandler},
+		{"GET /api/v1/forum/{forum_id}/thread/{thread_id}/post/{post_id}", api.getPostHandler},
+		{
+			"GET /api/v1/forum/{forum_id}/thread/{thread_id}/post/{post_id}/revision",
+			api.listPostRevisionHandler,
+		},
+		{
+			"GET /api/v1/forum/{forum_id}/thread/{thread_id}/post/{post_id}/revision/diff",
+			api.diffPostRevisionHandler,
+		},
+		{
+			"PUT /api/v1/forum/{forum_id}/thread/{thread_id}/post/{post_id}/vote",
+			api.requireAuthenticatedUser(api.putPostVoteHandler),
+		},
+		{
+			"DELETE /api/v1/forum/{forum_id}/thread/{thread_id}/post/{post_id}/vote",
+			api.requireAuthenticatedUser(api.deletePostVoteHandler),
+		},
 	}
 
 	api.logger.Info("registering endpoints")
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/archive"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+	"github.com/r3d5un/rosetta/Go/internal/rest"
+	"github.com/r3d5un/rosetta/Go/internal/validator"
+)
+
+// adminImportPath is exempt from the maximum request body size, as archives are expected to be
+// larger than any other request.
+const adminImportPath string = "/api/v1/admin/import"
+
+type ImportResponse struct {
+	Data archive.ImportSummary `json:"data"`
+}
+
+// archiveWriter sends the headers of the archive with its first line, leaving the response
+// untouched if the export fails before anything is written.
+type archiveWriter struct {
+	w       http.ResponseWriter
+	name    string
+	started bool
+}
+
+func (aw *archiveWriter) Write(p []byte) (int, error) {
+	if !aw.started {
+		aw.started = true
+		aw.w.Header().Set("Content-Type", archive.ContentType)
+		aw.w.Header().Set(
+			"Content-Disposition", fmt.Sprintf("attachment; filename=%q", aw.name),
+		)
+		aw.w.WriteHeader(http.StatusOK)
+	}
+
+	return aw.w.Write(p)
+}
+
+func (api *API) exportArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	qs := r.URL.Query()
+
+	v := validator.New()
+	opts := archive.ExportOptions{
+		ForumID:     rest.ReadOptionalQueryUUID(qs, "forumId", v),
+		Credentials: rest.ReadRequiredQueryBoolean(qs, "credentials", false),
+	}
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	// Archives of large forums take longer to stream than the write timeout of the server.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		rest.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	name := "rosetta.jsonl"
+	if opts.ForumID != nil {
+		name = fmt.Sprintf("rosetta-forum-%s.jsonl", opts.ForumID)
+	}
+	aw := &archiveWriter{w: w, name: name}
+
+	_, err := archive.NewExporter(api.models).Export(ctx, aw, opts)
+	switch {
+	case err == nil:
+	case aw.started:
+		// The status is already sent, leaving the archive truncated.
+		logging.LoggerFromContext(ctx).LogAttrs(
+			ctx,
+			slog.LevelError,
+			"archive export interrupted",
+			slog.String("error", err.Error()),
+		)
+	case errors.Is(err, data.ErrRecordNotFound):
+		rest.NotFoundResponse(ctx, w, r)
+	case errors.Is(err, context.DeadlineExceeded):
+		rest.TimeoutResponse(ctx, w, r)
+	default:
+		rest.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (api *API) importArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	qs := r.URL.Query()
+
+	v := validator.New()
+	opts := archive.ImportOptions{
+		IDMode:     qs.Get("idMode"),
+		OnConflict: qs.Get("onConflict"),
+		Resume:     rest.ReadOptionalQueryUUID(qs, "resume", v),
+	}
+	if opts.IDMode == "" {
+		opts.IDMode = data.ImportIDModePreserve
+	}
+	if opts.OnConflict == "" {
+		opts.OnConflict = data.ArchiveConflictFail
+	}
+	if value, ok := validator.PermittedValues([]string{opts.IDMode}, data.ImportIDModes); !ok {
+		v.AddError("idMode", fmt.Sprintf("%s is not a valid ID mode", value))
+	}
+	if value, ok := validator.PermittedValues(
+		[]string{opts.OnConflict}, data.ArchiveConflicts,
+	); !ok {
+		v.AddError("onConflict", fmt.Sprintf("%s is not a valid conflict strategy", value))
+	}
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	// Uploading and writing an archive takes longer than the timeouts of the server.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		rest.ServerErrorResponse(w, r, err)
+		return
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		rest.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	summary, err := archive.NewImporter(api.models).Import(ctx, r.Body, opts)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		var status int
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+			return
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+			return
+		case errors.As(err, &maxBytesErr):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, archive.ErrInvalidArchive),
+			errors.Is(err, archive.ErrUnsupportedVersion):
+			status = http.StatusBadRequest
+		case errors.Is(err, data.ErrUniqueConstraintViolation),
+			errors.Is(err, data.ErrForeignKeyConstraintViolation),
+			errors.Is(err, data.ErrNotNullConstraintViolation),
+			errors.Is(err, data.ErrCheckConstraintViolation):
+			status = http.StatusConflict
+		default:
+			rest.ServerErrorResponse(w, r, err)
+			return
+		}
+		if summary == nil {
+			rest.ErrorResponse(w, r, status, err.Error())
+			return
+		}
+		// The import is resumable from its last checkpoint.
+		rest.ErrorResponse(w, r, status, fmt.Sprintf(
+			"%s, resume import %s to continue after line %d",
+			err,
+			summary.Import.ID,
+			summary.Import.Line,
+		))
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, ImportResponse{Data: *summary}, nil)
+}
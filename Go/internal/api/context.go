@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+)
+
+type contextKey string
+
+const (
+	userContextKey        contextKey = "user"
+	permissionsContextKey contextKey = "permissions"
+)
+
+// contextSetUser returns a copy of the request with the user embedded in its context.
+func contextSetUser(r *http.Request, user *repo.User) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	return r.WithContext(ctx)
+}
+
+// contextGetUser retrieves the user embedded in the request context by the authenticate
+// middleware. It panics if no user is present, as that is only possible if the middleware was
+// not used.
+func contextGetUser(r *http.Request) *repo.User {
+	user, ok := r.Context().Value(userContextKey).(*repo.User)
+	if !ok {
+		panic("missing user value in request context")
+	}
+
+	return user
+}
+
+// contextSetPermissions returns a copy of the request with the permissions of the user embedded
+// in its context.
+func contextSetPermissions(r *http.Request, permissions *repo.Permissions) *http.Request {
+	ctx := context.WithValue(r.Context(), permissionsContextKey, permissions)
+	return r.WithContext(ctx)
+}
+
+// contextGetPermissions retrieves the permissions embedded in the request context by the
+// requirePermission middleware. It panics if no permissions are present, as that is only
+// possible if the middleware was not used.
+func contextGetPermissions(r *http.Request) *repo.Permissions {
+	permissions, ok := r.Context().Value(permissionsContextKey).(*repo.Permissions)
+	if !ok {
+		panic("missing permissions value in request context")
+	}
+
+	return permissions
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/r3d5un/rosetta/Go/internal/rest"
+)
+
+// etag derives a strong entity tag from the time a resource was last updated, alongside any
+// counters which change the representation of the resource without updating it, such as votes.
+func etag(updatedAt time.Time, counters ...int64) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	b.WriteString(strconv.FormatInt(updatedAt.UnixMicro(), 36))
+	for _, c := range counters {
+		b.WriteByte('-')
+		b.WriteString(strconv.FormatInt(c, 36))
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+func forumETag(f *repo.Forum) string {
+	return etag(f.UpdatedAt)
+}
+
+func userETag(u *repo.User) string {
+	return etag(u.UpdatedAt)
+}
+
+// threadETag includes the opening post of the thread, as it is part of every representation of the
+// thread, while editing or voting on it leaves the thread itself untouched.
+func threadETag(t *repo.Thread) string {
+	if t.OpeningPost == nil {
+		return etag(t.UpdatedAt, t.Likes)
+	}
+
+	return etag(
+		t.UpdatedAt,
+		t.Likes,
+		t.OpeningPost.UpdatedAt.UnixMicro(),
+		int64(t.OpeningPost.EditCount),
+		t.OpeningPost.Likes,
+	)
+}
+
+// ifNoneMatch reports whether the If-None-Match header of the request matches the entity tag, in
+// which case the client already holds the current representation of the resource. Entity tags are
+// compared weakly.
+func ifNoneMatch(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatch reports whether the If-Match header of the request matches the entity tag, or is absent.
+// Entity tags are compared strongly, so weak tags never match.
+func ifMatch(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkIfMatch evaluates the If-Match header of the request against the current version of a
+// resource, as returned by read. Without the header, nothing is read and nil is returned.
+// Otherwise, the time the resource was last updated at is returned, so that the change can be
+// made conditional on the resource not having been updated since.
+//
+// If the resource cannot be read or the precondition fails, an error response is written and false
+// is returned.
+func (api *API) checkIfMatch(
+	w http.ResponseWriter,
+	r *http.Request,
+	read func(context.Context) (string, time.Time, error),
+) (*time.Time, bool) {
+	ctx := r.Context()
+
+	if r.Header.Get("If-Match") == "" {
+		return nil, true
+	}
+
+	tag, updatedAt, err := read(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+	if !ifMatch(r, tag) {
+		rest.PreconditionFailedResponse(ctx, w, r)
+		return nil, false
+	}
+
+	return &updatedAt, true
+}
+
+func (api *API) readForumVersion(id uuid.UUID) func(context.Context) (string, time.Time, error) {
+	return func(ctx context.Context) (string, time.Time, error) {
+		forum, err := api.repo.ForumReader.Read(ctx, id, false)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return forumETag(forum), forum.UpdatedAt, nil
+	}
+}
+
+func (api *API) readThreadVersion(
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+) func(context.Context) (string, time.Time, error) {
+	return func(ctx context.Context) (string, time.Time, error) {
+		thread, err := api.repo.ThreadReader.Read(ctx, forumID, threadID, false)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return threadETag(thread), thread.UpdatedAt, nil
+	}
+}
+
+func (api *API) readUserVersion(id uuid.UUID) func(context.Context) (string, time.Time, error) {
+	return func(ctx context.Context) (string, time.Time, error) {
+		user, err := api.repo.UserReader.Read(ctx, id, false)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return userETag(user), user.UpdatedAt, nil
+	}
+}
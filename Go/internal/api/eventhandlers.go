@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/eventstream"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+	"github.com/r3d5un/rosetta/Go/internal/rest"
+)
+
+// heartbeatInterval is how often a comment is sent to keep idle event streams open.
+const heartbeatInterval = 15 * time.Second
+
+// threadEventsHandler streams the activity within a thread as server-sent events.
+//
+// Clients resume a stream by providing the ID of the last event received, either in the
+// Last-Event-ID header, as browsers do when reconnecting, or in the last_event_id query parameter.
+// Events missed since are replayed before the live events, as long as they are within the replay
+// window of the retention job.
+func (api *API) threadEventsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			rest.InvalidParameterResponse(
+				ctx, w, r, "last_event_id", errors.New("must be a positive integer"),
+			)
+			return
+		}
+	}
+
+	_, err = api.repo.ThreadReader.Read(ctx, *forumID, *threadID, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Subscribing before replaying ensures no event is lost between the two.
+	subscription := api.events.Subscribe(*threadID)
+	defer subscription.Close()
+
+	rc := http.NewResponseController(w)
+	// Streams are long-lived, and must not be cut off by the write timeout of the server.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		rest.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	logger := logging.LoggerFromContext(ctx).With(slog.String("threadId", threadID.String()))
+	logger.LogAttrs(
+		ctx, slog.LevelInfo, "streaming thread events", slog.Int64("lastEventId", lastID),
+	)
+
+	replayed, err := api.replayThreadEvents(ctx, rc, w, *threadID, lastID)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to replay thread events",
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.LogAttrs(ctx, slog.LevelInfo, "client closed thread event stream")
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// The client reconnects and resumes from the last event it received.
+				logger.LogAttrs(ctx, slog.LevelInfo, "thread event subscription closed")
+				return
+			}
+			if replayed.Sent(event.ID) {
+				continue
+			}
+			if err := writeThreadEvent(rc, w, event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// replayThreadEvents sends every event of the thread after the given ID, and returns the events
+// sent.
+func (api *API) replayThreadEvents(
+	ctx context.Context,
+	rc *http.ResponseController,
+	w http.ResponseWriter,
+	threadID uuid.UUID,
+	lastID int64,
+) (eventstream.Replayed, error) {
+	replayed := eventstream.Replayed{}
+
+	// Clients without a previous event receive live events only.
+	if lastID == 0 {
+		return replayed, nil
+	}
+
+	for {
+		events, err := api.events.Replay(ctx, threadID, lastID)
+		if err != nil {
+			return replayed, err
+		}
+		for _, event := range events {
+			if err := writeThreadEvent(rc, w, *event); err != nil {
+				return replayed, err
+			}
+			replayed.Add(event.ID)
+			lastID = event.ID
+		}
+		if len(events) < eventstream.ReplayLimit {
+			return replayed, nil
+		}
+	}
+}
+
+func writeThreadEvent(
+	rc *http.ResponseController,
+	w http.ResponseWriter,
+	event data.ThreadEvent,
+) error {
+	// The encoded event never contains line breaks, which would end the data field.
+	js, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, js)
+	if err != nil {
+		return err
+	}
+
+	return rc.Flush()
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"net/http"
 
-	"github.com/google/uuid"
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/repo"
 	"github.com/r3d5un/rosetta/Go/internal/rest"
@@ -21,6 +20,18 @@ type ForumListResponse struct {
 	Metadata *data.Metadata `json:"metadata"`
 }
 
+// PurgeResponse summarises a purge, or what a purge would remove when performed as a dry run.
+type PurgeResponse struct {
+	Data repo.PurgeSummary `json:"data"`
+}
+
+type ForumPostRequestBody struct {
+	// Name is the human readable name of the forum
+	Name string `json:"name"`
+	// Description contains a description about the purposes and topics of a forum.
+	Description *string `json:"description,omitzero"`
+}
+
 func (api *API) getForumHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -46,7 +57,19 @@ func (api *API) getForumHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rest.RespondWithJSON(w, r, http.StatusOK, ForumResponse{Data: *forum}, nil)
+	// Included resources change independently of the forum, so only the plain representation is
+	// tagged.
+	var headers http.Header
+	if !include {
+		tag := forumETag(forum)
+		if ifNoneMatch(r, tag) {
+			rest.NotModifiedResponse(ctx, w, tag)
+			return
+		}
+		headers = http.Header{"ETag": []string{tag}}
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, ForumResponse{Data: *forum}, headers)
 }
 
 func (api *API) listForumHandler(w http.ResponseWriter, r *http.Request) {
@@ -67,9 +90,16 @@ func (api *API) listForumHandler(w http.ResponseWriter, r *http.Request) {
 	filters.Deleted = rest.ReadOptionalQueryBoolean(qs, "deleted")
 	filters.DeletedAtFrom = rest.ReadOptionalQueryDate(qs, "deleted_at_from", v)
 	filters.DeletedAtTo = rest.ReadOptionalQueryDate(qs, "deleted_at_to", v)
-	filters.LastSeen = *rest.ReadRequiredQueryUUID(qs, "deleted_at_to", v, uuid.MustParse("00000000-0000-0000-0000-000000000000"))
+	filters.OrderBySafeList = data.ForumOrderBySafeList
+	filters.OrderBy = rest.ReadOptionalQueryOrderBy(qs, "order_by", filters.OrderBySafeList, v)
+	filters.Cursor = rest.ReadOptionalQueryCursor(qs, "cursor", v)
 	include := rest.ReadRequiredQueryBoolean(qs, "include", false)
 
+	v.Check(
+		filters.Cursor == nil || filters.Cursor.Matches(filters.OrderBy),
+		"cursor",
+		"must be used with the order_by it was issued for",
+	)
 	if !v.Valid() {
 		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
 		return
@@ -78,6 +108,10 @@ func (api *API) listForumHandler(w http.ResponseWriter, r *http.Request) {
 	forums, metadata, err := api.repo.ForumReader.List(ctx, filters, include)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrInvalidCursor):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "cursor", err)
+		case errors.Is(err, data.ErrInvalidOrderBy):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "order_by", err)
 		case errors.Is(err, context.DeadlineExceeded):
 			rest.TimeoutResponse(ctx, w, r)
 		default:
@@ -98,15 +132,19 @@ func (api *API) listForumHandler(w http.ResponseWriter, r *http.Request) {
 func (api *API) postForumHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	var input repo.ForumInput
+	var body ForumPostRequestBody
 
-	err := rest.ReadJSON(r, &input)
+	err := rest.ReadJSON(r, &body)
 	if err != nil {
 		rest.BadRequestResponse(w, r, err, "unable to parse JSON request body")
 		return
 	}
 
-	forum, err := api.repo.ForumWriter.Create(ctx, input)
+	forum, err := api.repo.ForumWriter.Create(ctx, repo.ForumInput{
+		OwnerID:     contextGetUser(r).ID,
+		Name:        body.Name,
+		Description: body.Description,
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrUniqueConstraintViolation):
@@ -135,9 +173,32 @@ func (api *API) patchForumHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	permissions, err := api.repo.PermissionReader.Read(ctx, contextGetUser(r).ID, &input.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	if !permissions.Has(repo.RoleOwner) {
+		rest.NotPermittedResponse(ctx, w, r)
+		return
+	}
+
+	updatedAt, ok := api.checkIfMatch(w, r, api.readForumVersion(input.ID))
+	if !ok {
+		return
+	}
+	input.UpdatedAt = updatedAt
+
 	forum, err := api.repo.ForumWriter.Update(ctx, input)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrRecordNotFound) && input.UpdatedAt != nil:
+			rest.PreconditionFailedResponse(ctx, w, r)
 		case errors.Is(err, data.ErrUniqueConstraintViolation):
 			rest.ConstraintViolationResponse(w, r, err, "forum ID already exists")
 		case errors.Is(err, data.ErrCheckConstraintViolation):
@@ -162,9 +223,16 @@ func (api *API) deleteForumHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	forum, err := api.repo.ForumWriter.Delete(ctx, *id)
+	updatedAt, ok := api.checkIfMatch(w, r, api.readForumVersion(*id))
+	if !ok {
+		return
+	}
+
+	forum, err := api.repo.ForumWriter.Delete(ctx, *id, updatedAt)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrRecordNotFound) && updatedAt != nil:
+			rest.PreconditionFailedResponse(ctx, w, r)
 		case errors.Is(err, data.ErrRecordNotFound):
 			rest.NotFoundResponse(ctx, w, r)
 		case errors.Is(err, context.DeadlineExceeded):
@@ -214,9 +282,22 @@ func (api *API) deletePermanentlyForumHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	forum, err := api.repo.ForumWriter.PermanentlyDelete(ctx, *id)
+	updatedAt, ok := api.checkIfMatch(w, r, api.readForumVersion(*id))
+	if !ok {
+		return
+	}
+
+	dryRun, err := rest.ReadQueryBoolean(r.URL.Query(), "dry_run", false)
+	if err != nil {
+		rest.InvalidQueryParameterResponse(ctx, w, r, "dry_run", err)
+		return
+	}
+
+	summary, err := api.repo.ForumWriter.PermanentlyDelete(ctx, *id, updatedAt, dryRun)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrRecordNotFound) && updatedAt != nil:
+			rest.PreconditionFailedResponse(ctx, w, r)
 		case errors.Is(err, data.ErrRecordNotFound):
 			rest.NotFoundResponse(ctx, w, r)
 		case errors.Is(err, context.DeadlineExceeded):
@@ -229,5 +310,5 @@ func (api *API) deletePermanentlyForumHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	rest.RespondWithJSON(w, r, http.StatusOK, ForumResponse{Data: *forum}, nil)
+	rest.RespondWithJSON(w, r, http.StatusOK, PurgeResponse{Data: *summary}, nil)
 }
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/r3d5un/rosetta/Go/internal/rest"
+)
+
+const (
+	idempotencyKeyHeader string = "Idempotency-Key"
+	// idempotencyKeyTTL is the duration responses are kept for replay, after which the key may be
+	// used for another request.
+	idempotencyKeyTTL time.Duration = 24 * time.Hour
+	// idempotencyKeyLease is the duration a key is reserved for while its request is processed.
+	// Keys left reserved by a server which stopped mid-request are freed once the lease runs out.
+	idempotencyKeyLease time.Duration = 5 * time.Minute
+	// idempotencyKeySweepInterval is the time between every removal of expired keys.
+	idempotencyKeySweepInterval time.Duration = time.Hour
+	idempotencyKeyMaxLength     int           = 256
+)
+
+var ErrIdempotencyKeyTooLong = errors.New("idempotency key too long")
+
+// idempotency makes POST requests carrying an Idempotency-Key header safe to retry. The first
+// request with a key is processed as usual, and its response is stored. Retries with the same key
+// and request are answered with the stored response, without being processed again, while reuse
+// of the key for a different request is rejected.
+//
+// Keys are scoped to the authenticated user, so requests by anonymous users are never replayed.
+// Responses with a server error are not stored, leaving the request free to be retried. Retries
+// arriving while the request is processed are rejected, until the key is released or its lease
+// runs out.
+func (api *API) idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		key := r.Header.Get(idempotencyKeyHeader)
+		user := contextGetUser(r)
+		if r.Method != http.MethodPost || key == "" || user.IsAnonymous() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > idempotencyKeyMaxLength {
+			rest.BadRequestResponse(
+				w, r, ErrIdempotencyKeyTooLong, "idempotency key must not exceed 256 characters",
+			)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			rest.BadRequestResponse(w, r, err, "unable to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := fingerprintRequest(r, body)
+
+		input := repo.IdempotencyKeyInput{
+			UserID:      user.ID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			Lease:       idempotencyKeyLease,
+		}
+		existing, reserved, err := api.repo.IdempotencyKeyWriter.Reserve(ctx, input)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				rest.TimeoutResponse(ctx, w, r)
+			default:
+				rest.ServerErrorResponse(w, r, err)
+			}
+			return
+		}
+		if !reserved {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				rest.IdempotencyKeyReusedResponse(ctx, w, r)
+			case existing.Status == nil:
+				rest.IdempotencyKeyInProgressResponse(ctx, w, r)
+			default:
+				replayResponse(ctx, w, existing)
+			}
+			return
+		}
+
+		// The key is released unless the response is stored, including when the handler panics,
+		// so that the client may retry the request.
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			err := api.repo.IdempotencyKeyWriter.Release(context.WithoutCancel(ctx), user.ID, key)
+			if err != nil {
+				logging.LoggerFromContext(ctx).LogAttrs(
+					ctx,
+					slog.LevelError,
+					"unable to release idempotency key",
+					slog.String("error", err.Error()),
+				)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		err = api.repo.IdempotencyKeyWriter.Complete(
+			context.WithoutCancel(ctx),
+			repo.IdempotencyKeyPatch{
+				UserID:  user.ID,
+				Key:     key,
+				Status:  rec.status,
+				Headers: rec.Header().Clone(),
+				Body:    rec.body.Bytes(),
+				TTL:     idempotencyKeyTTL,
+			},
+		)
+		stored = err == nil
+	})
+}
+
+// fingerprintRequest hashes the parts of a request which must match for a retry to be replayed.
+func fingerprintRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replayResponse(ctx context.Context, w http.ResponseWriter, key *repo.IdempotencyKey) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, "replaying stored response", slog.Any("key", key))
+
+	for name, values := range key.Headers {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(*key.Status)
+	if _, err := w.Write(key.Body); err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to write stored response",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// responseRecorder passes a response through to the client, while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// sweepIdempotencyKeys removes expired idempotency keys at every interval until the context is
+// cancelled.
+func (api *API) sweepIdempotencyKeys(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyKeySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Failures are logged by the repository, and the keys are retried on the next sweep.
+			_, _ = api.repo.IdempotencyKeyWriter.DeleteExpired(ctx)
+		}
+	}
+}
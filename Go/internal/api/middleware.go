@@ -2,12 +2,16 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
 	"github.com/r3d5un/rosetta/Go/internal/rest"
 )
 
@@ -19,7 +23,15 @@ func (api *API) enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set(
+			"Access-Control-Allow-Headers",
+			"Content-Type, Authorization, Idempotency-Key, If-Match, If-None-Match",
+		)
+		w.Header().Set(
+			"Access-Control-Expose-Headers",
+			"ETag, Idempotent-Replayed, Retry-After, Content-Disposition, "+
+				"RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset",
+		)
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
@@ -51,6 +63,28 @@ func (api *API) logRequest(next http.Handler) http.Handler {
 	})
 }
 
+// limitRequestBody rejects request bodies larger than the configured maximum, as they are read.
+// Imported archives are limited separately.
+func (api *API) limitRequestBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := api.server.MaxBodyBytes
+		if r.URL.Path == adminImportPath {
+			limit = api.server.MaxImportBytes
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// batchLoads embeds a loader into the request context, so that the related resources included in
+// the response are loaded in batches, and only once per request.
+func (api *API) batchLoads(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := repo.WithLoader(r.Context(), repo.NewLoader(api.models))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (api *API) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -62,3 +96,102 @@ func (api *API) recoverPanic(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// authenticate embeds the user identified by the bearer token in the Authorization header into
+// the request context. Requests without the header are treated as anonymous, while requests with
+// an invalid or expired token are rejected.
+func (api *API) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		w.Header().Add("Vary", "Authorization")
+
+		authorizationHeader := r.Header.Get("Authorization")
+		if authorizationHeader == "" {
+			next.ServeHTTP(w, contextSetUser(r, repo.AnonymousUser))
+			return
+		}
+
+		scheme, token, ok := strings.Cut(authorizationHeader, " ")
+		if !ok || scheme != "Bearer" || token == "" {
+			rest.InvalidAuthenticationTokenResponse(ctx, w, r)
+			return
+		}
+
+		user, err := api.repo.TokenReader.ReadUser(ctx, token)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				rest.InvalidAuthenticationTokenResponse(ctx, w, r)
+			case errors.Is(err, context.DeadlineExceeded):
+				rest.TimeoutResponse(ctx, w, r)
+			default:
+				rest.ServerErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		logger := logging.LoggerFromContext(ctx).With(slog.String("userId", user.ID.String()))
+		ctx = logging.WithLogger(ctx, logger)
+
+		next.ServeHTTP(w, contextSetUser(r.WithContext(ctx), user))
+	})
+}
+
+// requireAuthenticatedUser rejects requests made by anonymous users.
+func (api *API) requireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := contextGetUser(r)
+		if user.IsAnonymous() {
+			rest.AuthenticationRequiredResponse(r.Context(), w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requirePermission rejects requests made by users not holding the given role, or any role ranked
+// above it. Forum scoped roles are resolved for the forum identified by the path parameter named
+// by param. If param is empty, only global roles are considered.
+//
+// The resolved permissions are embedded in the request context for handlers that need to make
+// further decisions, such as letting authors edit their own posts.
+func (api *API) requirePermission(
+	param string,
+	role repo.Role,
+	next http.HandlerFunc,
+) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var forumID *uuid.UUID
+		if param != "" {
+			id, err := rest.ReadPathParamID(ctx, param, r)
+			if err != nil {
+				rest.InvalidParameterResponse(ctx, w, r, param, err)
+				return
+			}
+			forumID = id
+		}
+
+		permissions, err := api.repo.PermissionReader.Read(ctx, contextGetUser(r).ID, forumID)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				rest.TimeoutResponse(ctx, w, r)
+			default:
+				rest.ServerErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		if !permissions.Has(role) {
+			rest.NotPermittedResponse(ctx, w, r)
+			return
+		}
+
+		next.ServeHTTP(w, contextSetPermissions(r, permissions))
+	}
+
+	return api.requireAuthenticatedUser(fn)
+}
@@ -9,21 +9,243 @@ import (
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/repo"
 	"github.com/r3d5un/rosetta/Go/internal/rest"
+	"github.com/r3d5un/rosetta/Go/internal/validator"
 )
 
 type PostResponse struct {
 	Data repo.Post `json:"data"`
 }
 
+type PostTreeResponse struct {
+	Data     []*repo.PostNode `json:"data"`
+	Metadata *data.Metadata   `json:"metadata"`
+}
+
+type PostListResponse struct {
+	Data     []*repo.Post   `json:"data"`
+	Metadata *data.Metadata `json:"metadata"`
+}
+
+type PostRevisionListResponse struct {
+	Data []*repo.PostRevision `json:"data"`
+}
+
+type PostRevisionDiffResponse struct {
+	Data repo.PostRevisionDiff `json:"data"`
+}
+
 type PostPostRequestBody struct {
 	// ReplyTo is the ID of which this post is a reply to.
 	ReplyTo *uuid.UUID `json:"replyTo"`
-	// AuthorID is the unique identifier of the author of the post.
-	AuthorID uuid.UUID `json:"authorId"`
 	// Content is the actual text content of a post
 	Content string `json:"content"`
 }
 
+func (api *API) getPostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	postID, err := rest.ReadPathParamID(ctx, "post_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "post_id", err)
+		return
+	}
+
+	qs := r.URL.Query()
+	include := rest.ReadRequiredQueryBoolean(qs, "include", false)
+
+	post, err := api.repo.PostReader.Read(ctx, *forumID, *threadID, *postID, include)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = api.redactPosts(r, withThreads([]*repo.Post{post})...)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, PostResponse{Data: *post}, nil)
+}
+
+func (api *API) listPostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	v := validator.New()
+	qs := r.URL.Query()
+	filters := data.Filters{}
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	filters.ThreadID, err = rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	filters.PageSize = rest.ReadRequiredQueryInt(qs, "page_size", 25, v)
+	filters.ID = rest.ReadOptionalQueryUUID(qs, "id", v)
+	filters.AuthorID = rest.ReadOptionalQueryUUID(qs, "author_id", v)
+	filters.CreatedAtFrom = rest.ReadOptionalQueryDate(qs, "created_at_from", v)
+	filters.CreatedAtTo = rest.ReadOptionalQueryDate(qs, "created_at_to", v)
+	filters.UpdatedAtFrom = rest.ReadOptionalQueryDate(qs, "updated_at_from", v)
+	filters.UpdatedAtTo = rest.ReadOptionalQueryDate(qs, "updated_at_to", v)
+	filters.Deleted = rest.ReadOptionalQueryBoolean(qs, "deleted")
+	filters.DeletedAtFrom = rest.ReadOptionalQueryDate(qs, "deleted_at_from", v)
+	filters.DeletedAtTo = rest.ReadOptionalQueryDate(qs, "deleted_at_to", v)
+	filters.OrderBySafeList = data.PostOrderBySafeList
+	filters.OrderBy = rest.ReadOptionalQueryOrderBy(qs, "order_by", filters.OrderBySafeList, v)
+	filters.Cursor = rest.ReadOptionalQueryCursor(qs, "cursor", v)
+	include := rest.ReadRequiredQueryBoolean(qs, "include", false)
+
+	v.Check(
+		filters.Cursor == nil || filters.Cursor.Matches(filters.OrderBy),
+		"cursor",
+		"must be used with the order_by it was issued for",
+	)
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	posts, metadata, err := api.repo.PostReader.List(
+		ctx, *forumID, *filters.ThreadID, filters, include,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidCursor):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "cursor", err)
+		case errors.Is(err, data.ErrInvalidOrderBy):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "order_by", err)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = api.redactPosts(r, withThreads(posts)...)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(
+		w,
+		r,
+		http.StatusOK,
+		PostListResponse{Data: posts, Metadata: metadata},
+		nil,
+	)
+}
+
+func (api *API) getPostTreeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+	options := repo.PostTreeOptions{}
+
+	options.RootID = rest.ReadOptionalQueryUUID(qs, "root_id", v)
+	options.MaxDepth = rest.ReadRequiredQueryInt(qs, "max_depth", 5, v)
+	options.Limit = rest.ReadRequiredQueryInt(qs, "limit", 25, v)
+	options.After = rest.ReadOptionalQueryCursor(qs, "cursor", v)
+
+	v.Check(
+		options.MaxDepth >= 0 && options.MaxDepth <= 20, "max_depth", "must be between 0 and 20",
+	)
+	v.Check(options.Limit >= 1 && options.Limit <= 100, "limit", "must be between 1 and 100")
+	v.Check(
+		options.After == nil || options.After.Matches(data.PostTreeOrderBy),
+		"cursor",
+		"must be a cursor issued by the post tree",
+	)
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	tree, metadata, err := api.repo.PostReader.Tree(ctx, *forumID, *threadID, options)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidCursor):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "cursor", err)
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = api.redactPosts(r, treePosts(tree)...)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(
+		w,
+		r,
+		http.StatusOK,
+		PostTreeResponse{Data: tree, Metadata: metadata},
+		nil,
+	)
+}
+
 func (api *API) postPostHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -51,11 +273,19 @@ func (api *API) postPostHandler(w http.ResponseWriter, r *http.Request) {
 		ForumID:  *forumID,
 		ThreadID: *threadID,
 		ReplyTo:  body.ReplyTo,
-		AuthorID: body.AuthorID,
+		AuthorID: contextGetUser(r).ID,
 		Content:  body.Content,
 	})
 	if err != nil {
 		switch {
+		case errors.Is(err, repo.ErrThreadLocked):
+			rest.LockedResponse(ctx, w, r)
+		case errors.Is(err, repo.ErrInvalidReply):
+			rest.ValidationFailedResponse(
+				ctx, w, r, map[string]string{"replyTo": "must be a post within the same thread"},
+			)
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
 		case errors.Is(err, data.ErrUniqueConstraintViolation):
 			rest.ConstraintViolationResponse(w, r, err, "forum ID already exists")
 		case errors.Is(err, data.ErrCheckConstraintViolation):
@@ -70,3 +300,378 @@ func (api *API) postPostHandler(w http.ResponseWriter, r *http.Request) {
 
 	rest.RespondWithJSON(w, r, http.StatusOK, PostResponse{Data: *post}, nil)
 }
+
+func (api *API) patchPostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	var input repo.PostPatch
+
+	err = rest.ReadJSON(r, &input)
+	if err != nil {
+		rest.BadRequestResponse(w, r, err, "unable to parse JSON request body")
+		return
+	}
+	input.ThreadID = *threadID
+
+	existing, err := api.repo.PostReader.Read(ctx, *forumID, *threadID, input.ID, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	if existing.AuthorID != contextGetUser(r).ID &&
+		!contextGetPermissions(r).Has(repo.RoleModerator) {
+		rest.NotPermittedResponse(ctx, w, r)
+		return
+	}
+
+	post, err := api.repo.PostWriter.Update(ctx, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, data.ErrCheckConstraintViolation):
+			rest.ConstraintViolationResponse(w, r, err, "used failed input checks")
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, PostResponse{Data: *post}, nil)
+}
+
+func (api *API) deletePostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	postID, ok := api.readPostPath(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := api.repo.PostWriter.Delete(ctx, *postID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, PostResponse{Data: *post}, nil)
+}
+
+func (api *API) restorePostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	postID, ok := api.readPostPath(w, r)
+	if !ok {
+		return
+	}
+
+	post, err := api.repo.PostWriter.Restore(ctx, *postID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, PostResponse{Data: *post}, nil)
+}
+
+func (api *API) deletePermanentlyPostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	postID, ok := api.readPostPath(w, r)
+	if !ok {
+		return
+	}
+
+	dryRun, err := rest.ReadQueryBoolean(r.URL.Query(), "dry_run", false)
+	if err != nil {
+		rest.InvalidQueryParameterResponse(ctx, w, r, "dry_run", err)
+		return
+	}
+
+	summary, err := api.repo.PostWriter.PermanentlyDelete(ctx, *postID, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		case errors.Is(err, data.ErrForeignKeyConstraintViolation):
+			rest.ConstraintViolationResponse(w, r, err, "post referenced by other resources")
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, PurgeResponse{Data: *summary}, nil)
+}
+
+func (api *API) listPostRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	post, ok := api.readPost(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := api.repo.PostReader.ListRevisions(ctx, post.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	visible, err := api.contentVisible(r, post)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	if !visible {
+		for _, revision := range revisions {
+			revision.Redact()
+		}
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, PostRevisionListResponse{Data: revisions}, nil)
+}
+
+func (api *API) diffPostRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	from := rest.ReadRequiredQueryInt(qs, "from", 1, v)
+	to := rest.ReadRequiredQueryInt(qs, "to", 0, v)
+
+	v.Check(from >= 1, "from", "must be a positive integer")
+	v.Check(to >= 1, "to", "must be provided as a positive integer")
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	post, ok := api.readPost(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := api.repo.PostReader.DiffRevisions(ctx, post.ID, from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	visible, err := api.contentVisible(r, post)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	if !visible {
+		d.Redact()
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, PostRevisionDiffResponse{Data: *d}, nil)
+}
+
+// readPostPath reads the forum, thread and post IDs from the request path, and verifies that the
+// post belongs to the given thread, and the thread to the given forum. The post writers only
+// address posts by their ID, and permissions are granted per forum, so without this check a post
+// could be moderated through the path of an unrelated thread or forum.
+//
+// If the path is invalid or the post cannot be found, an error response is written and false is
+// returned.
+func (api *API) readPostPath(w http.ResponseWriter, r *http.Request) (*uuid.UUID, bool) {
+	post, ok := api.readPost(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	return &post.ID, true
+}
+
+// readPost is readPostPath, returning the post itself rather than its ID.
+func (api *API) readPost(w http.ResponseWriter, r *http.Request) (*repo.Post, bool) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return nil, false
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return nil, false
+	}
+
+	postID, err := rest.ReadPathParamID(ctx, "post_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "post_id", err)
+		return nil, false
+	}
+
+	post, err := api.repo.PostReader.Read(ctx, *forumID, *threadID, *postID, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return post, true
+}
+
+// contentVisible reports whether the caller may see the content of the post, and of its revisions.
+// The content of deleted posts is only visible to their author and the moderators of the forum in
+// the request path, as the deletion hid it.
+func (api *API) contentVisible(r *http.Request, post *repo.Post) (bool, error) {
+	user := contextGetUser(r)
+	switch {
+	case !post.Deleted:
+		return true, nil
+	case user.IsAnonymous():
+		return false, nil
+	case user.ID == post.AuthorID:
+		return true, nil
+	}
+
+	return api.moderates(r)
+}
+
+// moderates reports whether the caller is a moderator of the forum in the request path.
+func (api *API) moderates(r *http.Request) (bool, error) {
+	ctx := r.Context()
+
+	user := contextGetUser(r)
+	if user.IsAnonymous() {
+		return false, nil
+	}
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		return false, err
+	}
+
+	permissions, err := api.repo.PermissionReader.Read(ctx, user.ID, forumID)
+	if err != nil {
+		return false, err
+	}
+
+	return permissions.Has(repo.RoleModerator), nil
+}
+
+// redactPosts withholds the content of every post the caller may not see, as decided by
+// contentVisible. Missing posts are skipped, and the permissions of the caller are read at most
+// once.
+func (api *API) redactPosts(r *http.Request, posts ...*repo.Post) error {
+	user := contextGetUser(r)
+
+	var moderator *bool
+	for _, post := range posts {
+		if post == nil || !post.Deleted || post.Redacted {
+			continue
+		}
+		if !user.IsAnonymous() && user.ID == post.AuthorID {
+			continue
+		}
+		if moderator == nil {
+			moderates, err := api.moderates(r)
+			if err != nil {
+				return err
+			}
+			moderator = &moderates
+		}
+		if !*moderator {
+			post.Redact()
+		}
+	}
+
+	return nil
+}
+
+// withThreads adds the opening posts of the included threads of the posts.
+func withThreads(posts []*repo.Post) []*repo.Post {
+	all := make([]*repo.Post, 0, 2*len(posts))
+	for _, post := range posts {
+		all = append(all, post)
+		if post.Thread != nil {
+			all = append(all, post.Thread.OpeningPost)
+		}
+	}
+
+	return all
+}
+
+// treePosts flattens the trees into every post within them.
+func treePosts(nodes []*repo.PostNode) []*repo.Post {
+	posts := []*repo.Post{}
+	for _, node := range nodes {
+		posts = append(posts, &node.Post)
+		posts = append(posts, treePosts(node.Replies)...)
+	}
+
+	return posts
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+	"github.com/r3d5un/rosetta/Go/internal/ratelimit"
+	"github.com/r3d5un/rosetta/Go/internal/rest"
+)
+
+// rateLimit throttles the requests of every client, following the policy of the group of routes
+// requested. Authenticated users are limited by their ID, and anonymous users by their IP address.
+// The state of the limit is reported through RateLimit-* headers on every limited response.
+//
+// Requests are permitted if the limit cannot be determined, as an unavailable store should not
+// take the whole API down with it.
+func (api *API) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		result, err := api.limiter.Allow(ctx, routeGroup(r), rateLimitClient(r))
+		if err != nil {
+			level := slog.LevelError
+			if errors.Is(err, context.DeadlineExceeded) {
+				level = slog.LevelWarn
+			}
+			logging.LoggerFromContext(ctx).LogAttrs(
+				ctx, level, "unable to apply rate limit", slog.String("error", err.Error()),
+			)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if result == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			rest.RateLimitExceededResponse(ctx, w, r, max(ceilSeconds(result.RetryAfter), 1))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeGroup returns the group of routes a request belongs to, which decides the policy it is
+// limited by.
+func routeGroup(r *http.Request) string {
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return ratelimit.GroupReads
+	case strings.HasSuffix(r.URL.Path, "/vote"):
+		return ratelimit.GroupVotes
+	default:
+		return ratelimit.GroupWrites
+	}
+}
+
+// rateLimitClient identifies the client making the request. The remote address is used as is, so
+// deployments behind a proxy share a single limit per proxy.
+func rateLimitClient(r *http.Request) string {
+	if user := contextGetUser(r); !user.IsAnonymous() {
+		return "user:" + user.ID.String()
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/r3d5un/rosetta/Go/internal/rest"
+)
+
+type RoleGrantResponse struct {
+	Data repo.RoleGrant `json:"data"`
+}
+
+type RoleGrantListResponse struct {
+	Data []*repo.RoleGrant `json:"data"`
+}
+
+func (api *API) listModeratorHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	grants, err := api.repo.PermissionReader.List(ctx, repo.RoleModerator, forumID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, RoleGrantListResponse{Data: grants}, nil)
+}
+
+func (api *API) putModeratorHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	userID, err := rest.ReadPathParamID(ctx, "user_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "user_id", err)
+		return
+	}
+
+	grant, err := api.repo.PermissionWriter.Grant(ctx, repo.RoleGrant{
+		UserID:  *userID,
+		Role:    repo.RoleModerator,
+		ForumID: forumID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrUniqueConstraintViolation):
+			rest.ConstraintViolationResponse(w, r, err, "user is already a moderator")
+		case errors.Is(err, data.ErrForeignKeyConstraintViolation):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, RoleGrantResponse{Data: *grant}, nil)
+}
+
+func (api *API) deleteModeratorHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	userID, err := rest.ReadPathParamID(ctx, "user_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "user_id", err)
+		return
+	}
+
+	grant, err := api.repo.PermissionWriter.Revoke(ctx, repo.RoleGrant{
+		UserID:  *userID,
+		Role:    repo.RoleModerator,
+		ForumID: forumID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, RoleGrantResponse{Data: *grant}, nil)
+}
+
+func (api *API) listAdminHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	grants, err := api.repo.PermissionReader.List(ctx, repo.RoleAdmin, nil)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, RoleGrantListResponse{Data: grants}, nil)
+}
+
+func (api *API) putAdminHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := rest.ReadPathParamID(ctx, "id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "id", err)
+		return
+	}
+
+	grant, err := api.repo.PermissionWriter.Grant(ctx, repo.RoleGrant{
+		UserID: *userID,
+		Role:   repo.RoleAdmin,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrUniqueConstraintViolation):
+			rest.ConstraintViolationResponse(w, r, err, "user is already an admin")
+		case errors.Is(err, data.ErrForeignKeyConstraintViolation):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, RoleGrantResponse{Data: *grant}, nil)
+}
+
+func (api *API) deleteAdminHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := rest.ReadPathParamID(ctx, "id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "id", err)
+		return
+	}
+
+	grant, err := api.repo.PermissionWriter.Revoke(ctx, repo.RoleGrant{
+		UserID: *userID,
+		Role:   repo.RoleAdmin,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, RoleGrantResponse{Data: *grant}, nil)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/r3d5un/rosetta/Go/internal/rest"
+	"github.com/r3d5un/rosetta/Go/internal/validator"
+)
+
+type SearchResponse struct {
+	Data     []*repo.SearchResult `json:"data"`
+	Metadata *data.Metadata       `json:"metadata"`
+}
+
+func (api *API) searchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	v := validator.New()
+	qs := r.URL.Query()
+	filters := data.Filters{}
+
+	filters.Query = rest.ReadOptionalQueryString(qs, "q")
+	filters.Types = rest.ReadOptionalQueryStrings(qs, "type", data.SearchTypes, v)
+	filters.ForumID = rest.ReadOptionalQueryUUID(qs, "forum_id", v)
+	filters.PageSize = rest.ReadRequiredQueryInt(qs, "page_size", 25, v)
+	filters.Cursor = rest.ReadOptionalQueryCursor(qs, "cursor", v)
+
+	v.Check(filters.Query != nil, "q", "must be provided")
+	v.Check(
+		filters.Query == nil || len(*filters.Query) <= 256, "q", "must not exceed 256 characters",
+	)
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	results, metadata, err := api.repo.SearchReader.Search(ctx, filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidCursor):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "cursor", err)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(
+		w,
+		r,
+		http.StatusOK,
+		SearchResponse{Data: results, Metadata: metadata},
+		nil,
+	)
+}
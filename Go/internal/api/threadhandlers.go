@@ -5,7 +5,6 @@ import (
 	"errors"
 	"net/http"
 
-	"github.com/google/uuid"
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/repo"
 	"github.com/r3d5un/rosetta/Go/internal/rest"
@@ -21,6 +20,17 @@ type ThreadListResponse struct {
 	Metadata *data.Metadata `json:"metadata"`
 }
 
+type ThreadRevisionListResponse struct {
+	Data []*repo.ThreadRevision `json:"data"`
+}
+
+type ThreadPostRequestBody struct {
+	// Title is the subject the thread is about.
+	Title string `json:"title"`
+	// Content is the text content of the opening post of the thread.
+	Content string `json:"content"`
+}
+
 func (api *API) getThreadHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -52,7 +62,61 @@ func (api *API) getThreadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rest.RespondWithJSON(w, r, http.StatusOK, ThreadResponse{Data: *forum}, nil)
+	// Included resources change independently of the thread, so only the plain representation is
+	// tagged.
+	var headers http.Header
+	if !include {
+		tag := threadETag(forum)
+		if ifNoneMatch(r, tag) {
+			rest.NotModifiedResponse(ctx, w, tag)
+			return
+		}
+		headers = http.Header{"ETag": []string{tag}}
+	}
+
+	err = api.redactPosts(r, forum.OpeningPost)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, ThreadResponse{Data: *forum}, headers)
+}
+
+func (api *API) listThreadRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	revisions, err := api.repo.ThreadReader.ListRevisions(ctx, *forumID, *threadID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, ThreadRevisionListResponse{Data: revisions}, nil)
 }
 
 func (api *API) listThreadHandler(w http.ResponseWriter, r *http.Request) {
@@ -79,15 +143,41 @@ func (api *API) listThreadHandler(w http.ResponseWriter, r *http.Request) {
 	filters.Deleted = rest.ReadOptionalQueryBoolean(qs, "deleted")
 	filters.DeletedAtFrom = rest.ReadOptionalQueryDate(qs, "deleted_at_from", v)
 	filters.DeletedAtTo = rest.ReadOptionalQueryDate(qs, "deleted_at_to", v)
-	filters.LastSeen = *rest.ReadRequiredQueryUUID(qs, "deleted_at_to", v, uuid.MustParse("00000000-0000-0000-0000-000000000000"))
+	filters.OrderBySafeList = data.ThreadOrderBySafeList
+	filters.OrderBy = rest.ReadOptionalQueryOrderBy(qs, "order_by", filters.OrderBySafeList, v)
+	filters.Cursor = rest.ReadOptionalQueryCursor(qs, "cursor", v)
 	include := rest.ReadRequiredQueryBoolean(qs, "include", false)
 
+	v.Check(
+		filters.Cursor == nil || filters.Cursor.Matches(filters.OrderBy),
+		"cursor",
+		"must be used with the order_by it was issued for",
+	)
 	if !v.Valid() {
 		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
 		return
 	}
 
 	threads, metadata, err := api.repo.ThreadReader.List(ctx, filters, include)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidCursor):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "cursor", err)
+		case errors.Is(err, data.ErrInvalidOrderBy):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "order_by", err)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	openingPosts := make([]*repo.Post, len(threads))
+	for i, thread := range threads {
+		openingPosts[i] = thread.OpeningPost
+	}
+	err = api.redactPosts(r, openingPosts...)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.DeadlineExceeded):
@@ -116,19 +206,28 @@ func (api *API) postThreadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input repo.ThreadInput
+	var body ThreadPostRequestBody
 
-	err = rest.ReadJSON(r, &input)
+	err = rest.ReadJSON(r, &body)
 	if err != nil {
 		rest.BadRequestResponse(w, r, err, "unable to parse JSON request body")
 		return
 	}
-	if *forumID != input.ForumID {
-		rest.BadRequestResponse(w, r, err, "response body thread ID does not match path parameter")
+
+	v := validator.New()
+	v.Check(body.Title != "", "title", "must be provided")
+	v.Check(body.Content != "", "content", "must be provided")
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
 		return
 	}
 
-	forum, err := api.repo.ThreadWriter.Create(ctx, input)
+	forum, err := api.repo.ThreadWriter.Create(ctx, repo.ThreadInput{
+		ForumID:  *forumID,
+		Title:    body.Title,
+		AuthorID: contextGetUser(r).ID,
+		Content:  body.Content,
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrUniqueConstraintViolation):
@@ -164,9 +263,37 @@ func (api *API) patchThreadHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	input.ForumID = *forumID
 
+	existing, err := api.repo.ThreadReader.Read(ctx, *forumID, input.ID, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	// Authors may edit their own threads, while changing the author is left to moderators.
+	isAuthor := existing.AuthorID == contextGetUser(r).ID && input.AuthorID == nil
+	if !isAuthor && !contextGetPermissions(r).Has(repo.RoleModerator) {
+		rest.NotPermittedResponse(ctx, w, r)
+		return
+	}
+	if !ifMatch(r, threadETag(existing)) {
+		rest.PreconditionFailedResponse(ctx, w, r)
+		return
+	}
+	if r.Header.Get("If-Match") != "" {
+		input.UpdatedAt = &existing.UpdatedAt
+	}
+
 	thread, err := api.repo.ThreadWriter.Update(ctx, input)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrRecordNotFound) && input.UpdatedAt != nil:
+			rest.PreconditionFailedResponse(ctx, w, r)
 		case errors.Is(err, data.ErrUniqueConstraintViolation):
 			rest.ConstraintViolationResponse(w, r, err, "forum ID already exists")
 		case errors.Is(err, data.ErrCheckConstraintViolation):
@@ -197,9 +324,16 @@ func (api *API) deleteThreadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	thread, err := api.repo.ThreadWriter.Delete(ctx, *forumID, *threadID)
+	updatedAt, ok := api.checkIfMatch(w, r, api.readThreadVersion(*forumID, *threadID))
+	if !ok {
+		return
+	}
+
+	thread, err := api.repo.ThreadWriter.Delete(ctx, *forumID, *threadID, updatedAt)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrRecordNotFound) && updatedAt != nil:
+			rest.PreconditionFailedResponse(ctx, w, r)
 		case errors.Is(err, data.ErrRecordNotFound):
 			rest.NotFoundResponse(ctx, w, r)
 		case errors.Is(err, context.DeadlineExceeded):
@@ -261,7 +395,84 @@ func (api *API) deletePermanentlyThreadHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	thread, err := api.repo.ThreadWriter.PermanentlyDelete(ctx, *forumID, *threadID)
+	updatedAt, ok := api.checkIfMatch(w, r, api.readThreadVersion(*forumID, *threadID))
+	if !ok {
+		return
+	}
+
+	dryRun, err := rest.ReadQueryBoolean(r.URL.Query(), "dry_run", false)
+	if err != nil {
+		rest.InvalidQueryParameterResponse(ctx, w, r, "dry_run", err)
+		return
+	}
+
+	summary, err := api.repo.ThreadWriter.PermanentlyDelete(
+		ctx, *forumID, *threadID, updatedAt, dryRun,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound) && updatedAt != nil:
+			rest.PreconditionFailedResponse(ctx, w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, PurgeResponse{Data: *summary}, nil)
+}
+
+func (api *API) lockThreadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	thread, err := api.repo.ThreadWriter.Lock(ctx, *forumID, *threadID, contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, ThreadResponse{Data: *thread}, nil)
+}
+
+func (api *API) unlockThreadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	thread, err := api.repo.ThreadWriter.Unlock(ctx, *forumID, *threadID)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
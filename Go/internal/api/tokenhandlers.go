@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/r3d5un/rosetta/Go/internal/rest"
+	"github.com/r3d5un/rosetta/Go/internal/validator"
+)
+
+type TokenResponse struct {
+	Data repo.Token `json:"data"`
+}
+
+func (api *API) createAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var input repo.TokenInput
+
+	err := rest.ReadJSON(r, &input)
+	if err != nil {
+		rest.BadRequestResponse(w, r, err, "unable to parse JSON request body")
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.Email != "", "email", "must be provided")
+	v.Check(input.Password != "", "password", "must be provided")
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	token, err := api.repo.TokenWriter.Create(ctx, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrInvalidCredentials):
+			rest.InvalidCredentialsResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusCreated, TokenResponse{Data: *token}, nil)
+}
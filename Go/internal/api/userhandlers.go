@@ -3,9 +3,11 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/auth"
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/repo"
 	"github.com/r3d5un/rosetta/Go/internal/rest"
@@ -46,7 +48,19 @@ func (api *API) getUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rest.RespondWithJSON(w, r, http.StatusOK, UserReponse{Data: *user}, nil)
+	// Included resources change independently of the user, so only the plain representation is
+	// tagged.
+	var headers http.Header
+	if !include {
+		tag := userETag(user)
+		if ifNoneMatch(r, tag) {
+			rest.NotModifiedResponse(ctx, w, tag)
+			return
+		}
+		headers = http.Header{"ETag": []string{tag}}
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, UserReponse{Data: *user}, headers)
 }
 
 func (api *API) listUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -65,9 +79,16 @@ func (api *API) listUserHandler(w http.ResponseWriter, r *http.Request) {
 	filters.CreatedAtTo = rest.ReadOptionalQueryDate(qs, "created_at_to", v)
 	filters.UpdatedAtFrom = rest.ReadOptionalQueryDate(qs, "updated_at_from", v)
 	filters.UpdatedAtTo = rest.ReadOptionalQueryDate(qs, "updated_at_to", v)
-	filters.LastSeen = *rest.ReadRequiredQueryUUID(qs, "deleted_at_to", v, uuid.MustParse("00000000-0000-0000-0000-000000000000"))
+	filters.OrderBySafeList = data.UserOrderBySafeList
+	filters.OrderBy = rest.ReadOptionalQueryOrderBy(qs, "order_by", filters.OrderBySafeList, v)
+	filters.Cursor = rest.ReadOptionalQueryCursor(qs, "cursor", v)
 	include := rest.ReadRequiredQueryBoolean(qs, "include", false)
 
+	v.Check(
+		filters.Cursor == nil || filters.Cursor.Matches(filters.OrderBy),
+		"cursor",
+		"must be used with the order_by it was issued for",
+	)
 	if !v.Valid() {
 		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
 		return
@@ -76,6 +97,10 @@ func (api *API) listUserHandler(w http.ResponseWriter, r *http.Request) {
 	users, metadata, err := api.repo.UserReader.List(ctx, filters, include)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrInvalidCursor):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "cursor", err)
+		case errors.Is(err, data.ErrInvalidOrderBy):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "order_by", err)
 		case errors.Is(err, context.DeadlineExceeded):
 			rest.TimeoutResponse(ctx, w, r)
 		default:
@@ -104,6 +129,22 @@ func (api *API) postUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	v := validator.New()
+	v.Check(
+		len(input.Password) >= auth.PasswordMinLength,
+		"password",
+		fmt.Sprintf("must be at least %d bytes long", auth.PasswordMinLength),
+	)
+	v.Check(
+		len(input.Password) <= auth.PasswordMaxLength,
+		"password",
+		fmt.Sprintf("must not be more than %d bytes long", auth.PasswordMaxLength),
+	)
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
 	user, err := api.repo.UserWriter.Create(ctx, input)
 	if err != nil {
 		switch {
@@ -132,10 +173,22 @@ func (api *API) patchUserHandler(w http.ResponseWriter, r *http.Request) {
 		rest.BadRequestResponse(w, r, err, "unable to parse JSON request body")
 		return
 	}
+	if !permittedUser(r, input.ID) {
+		rest.NotPermittedResponse(ctx, w, r)
+		return
+	}
+
+	updatedAt, ok := api.checkIfMatch(w, r, api.readUserVersion(input.ID))
+	if !ok {
+		return
+	}
+	input.UpdatedAt = updatedAt
 
 	user, err := api.repo.UserWriter.Update(ctx, input)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrRecordNotFound) && input.UpdatedAt != nil:
+			rest.PreconditionFailedResponse(ctx, w, r)
 		case errors.Is(err, data.ErrUniqueConstraintViolation):
 			rest.ConstraintViolationResponse(w, r, err, "user ID already exists")
 		case errors.Is(err, data.ErrCheckConstraintViolation):
@@ -159,10 +212,21 @@ func (api *API) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
 		rest.InvalidParameterResponse(ctx, w, r, "id", err)
 		return
 	}
+	if !permittedUser(r, *id) {
+		rest.NotPermittedResponse(ctx, w, r)
+		return
+	}
+
+	updatedAt, ok := api.checkIfMatch(w, r, api.readUserVersion(*id))
+	if !ok {
+		return
+	}
 
-	user, err := api.repo.UserWriter.Delete(ctx, *id)
+	user, err := api.repo.UserWriter.Delete(ctx, *id, updatedAt)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrRecordNotFound) && updatedAt != nil:
+			rest.PreconditionFailedResponse(ctx, w, r)
 		case errors.Is(err, data.ErrRecordNotFound):
 			rest.NotFoundResponse(ctx, w, r)
 		case errors.Is(err, context.DeadlineExceeded):
@@ -186,6 +250,10 @@ func (api *API) restoreUserHandler(w http.ResponseWriter, r *http.Request) {
 		rest.InvalidParameterResponse(ctx, w, r, "id", err)
 		return
 	}
+	if !permittedUser(r, *id) {
+		rest.NotPermittedResponse(ctx, w, r)
+		return
+	}
 
 	user, err := api.repo.UserWriter.Restore(ctx, *id)
 	if err != nil {
@@ -212,13 +280,30 @@ func (api *API) deletePermanentlyUserHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	user, err := api.repo.UserWriter.PermanentlyDelete(ctx, *id)
+	updatedAt, ok := api.checkIfMatch(w, r, api.readUserVersion(*id))
+	if !ok {
+		return
+	}
+
+	dryRun, err := rest.ReadQueryBoolean(r.URL.Query(), "dry_run", false)
+	if err != nil {
+		rest.InvalidQueryParameterResponse(ctx, w, r, "dry_run", err)
+		return
+	}
+
+	summary, err := api.repo.UserWriter.PermanentlyDelete(ctx, *id, updatedAt, dryRun)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrRecordNotFound) && updatedAt != nil:
+			rest.PreconditionFailedResponse(ctx, w, r)
 		case errors.Is(err, data.ErrRecordNotFound):
 			rest.NotFoundResponse(ctx, w, r)
 		case errors.Is(err, context.DeadlineExceeded):
 			rest.TimeoutResponse(ctx, w, r)
+		case errors.Is(err, repo.ErrUserOwnsForums):
+			rest.ConstraintViolationResponse(
+				w, r, err, "user owns forums, which must be transferred or purged first",
+			)
 		case errors.Is(err, data.ErrForeignKeyConstraintViolation):
 			rest.ConstraintViolationResponse(w, r, err, "user referenced by other resources")
 		default:
@@ -227,5 +312,10 @@ func (api *API) deletePermanentlyUserHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	rest.RespondWithJSON(w, r, http.StatusOK, UserReponse{Data: *user}, nil)
+	rest.RespondWithJSON(w, r, http.StatusOK, PurgeResponse{Data: *summary}, nil)
+}
+
+// permittedUser reports whether the authenticated user is either the given user, or an admin.
+func permittedUser(r *http.Request, id uuid.UUID) bool {
+	return contextGetUser(r).ID == id || contextGetPermissions(r).Has(repo.RoleAdmin)
 }
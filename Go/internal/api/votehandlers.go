@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/r3d5un/rosetta/Go/internal/rest"
+	"github.com/r3d5un/rosetta/Go/internal/validator"
+)
+
+type ThreadVoteResponse struct {
+	Data repo.ThreadVote `json:"data"`
+}
+
+type PostVoteResponse struct {
+	Data repo.PostVote `json:"data"`
+}
+
+type VoteRequestBody struct {
+	// Vote is the value of the vote. Must be either -1, 0 or 1, where 0 removes the vote.
+	Vote int8 `json:"vote"`
+}
+
+func (api *API) putThreadVoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	var body VoteRequestBody
+
+	err = rest.ReadJSON(r, &body)
+	if err != nil {
+		rest.BadRequestResponse(w, r, err, "unable to parse JSON request body")
+		return
+	}
+
+	v := validator.New()
+	validateVote(v, body.Vote)
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	vote, err := api.repo.ThreadVoteWriter.Vote(ctx, repo.ThreadVoteInput{
+		ForumID:  *forumID,
+		ThreadID: *threadID,
+		UserID:   contextGetUser(r).ID,
+		Vote:     body.Vote,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrThreadLocked):
+			rest.LockedResponse(ctx, w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, data.ErrForeignKeyConstraintViolation):
+			rest.ConstraintViolationResponse(w, r, err, "user does not exist")
+		case errors.Is(err, data.ErrCheckConstraintViolation):
+			rest.ConstraintViolationResponse(w, r, err, "used failed input checks")
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, ThreadVoteResponse{Data: *vote}, nil)
+}
+
+func (api *API) deleteThreadVoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	vote, err := api.repo.ThreadVoteWriter.Delete(ctx, *forumID, *threadID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrThreadLocked):
+			rest.LockedResponse(ctx, w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, ThreadVoteResponse{Data: *vote}, nil)
+}
+
+func (api *API) putPostVoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	postID, err := rest.ReadPathParamID(ctx, "post_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "post_id", err)
+		return
+	}
+
+	var body VoteRequestBody
+
+	err = rest.ReadJSON(r, &body)
+	if err != nil {
+		rest.BadRequestResponse(w, r, err, "unable to parse JSON request body")
+		return
+	}
+
+	v := validator.New()
+	validateVote(v, body.Vote)
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	vote, err := api.repo.PostVoteWriter.Vote(ctx, repo.PostVoteInput{
+		ForumID:  *forumID,
+		ThreadID: *threadID,
+		PostID:   *postID,
+		UserID:   contextGetUser(r).ID,
+		Vote:     body.Vote,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrThreadLocked):
+			rest.LockedResponse(ctx, w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, data.ErrForeignKeyConstraintViolation):
+			rest.ConstraintViolationResponse(w, r, err, "user does not exist")
+		case errors.Is(err, data.ErrCheckConstraintViolation):
+			rest.ConstraintViolationResponse(w, r, err, "used failed input checks")
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, PostVoteResponse{Data: *vote}, nil)
+}
+
+func (api *API) deletePostVoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	forumID, err := rest.ReadPathParamID(ctx, "forum_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "forum_id", err)
+		return
+	}
+
+	threadID, err := rest.ReadPathParamID(ctx, "thread_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "thread_id", err)
+		return
+	}
+
+	postID, err := rest.ReadPathParamID(ctx, "post_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "post_id", err)
+		return
+	}
+
+	user := contextGetUser(r)
+
+	vote, err := api.repo.PostVoteWriter.Delete(ctx, *forumID, *threadID, *postID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrThreadLocked):
+			rest.LockedResponse(ctx, w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, PostVoteResponse{Data: *vote}, nil)
+}
+
+func validateVote(v *validator.Validator, vote int8) {
+	v.Check(vote >= -1 && vote <= 1, "vote", "must be either -1, 0 or 1")
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/r3d5un/rosetta/Go/internal/rest"
+	"github.com/r3d5un/rosetta/Go/internal/validator"
+)
+
+type WebhookResponse struct {
+	Data repo.Webhook `json:"data"`
+}
+
+type WebhookListResponse struct {
+	Data []*repo.Webhook `json:"data"`
+}
+
+type WebhookDeliveryResponse struct {
+	Data repo.WebhookDelivery `json:"data"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Data     []*repo.WebhookDelivery `json:"data"`
+	Metadata *data.Metadata          `json:"metadata"`
+}
+
+// checkWebhook validates the URL and event types of a webhook, if given.
+func checkWebhook(v *validator.Validator, webhookURL *string, eventTypes []string) {
+	if webhookURL != nil {
+		u, err := url.Parse(*webhookURL)
+		v.Check(
+			err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"url",
+			"must be an absolute http or https URL",
+		)
+	}
+	if value, ok := validator.PermittedValues(eventTypes, data.DomainEventTypes); !ok {
+		v.AddError("eventTypes", fmt.Sprintf("%s is not a valid event type", value))
+	}
+}
+
+func (api *API) listWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhooks, err := api.repo.WebhookReader.List(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, WebhookListResponse{Data: webhooks}, nil)
+}
+
+func (api *API) getWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := rest.ReadPathParamID(ctx, "id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "id", err)
+		return
+	}
+
+	webhook, err := api.repo.WebhookReader.Read(ctx, *id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, WebhookResponse{Data: *webhook}, nil)
+}
+
+func (api *API) postWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var input repo.WebhookInput
+
+	err := rest.ReadJSON(r, &input)
+	if err != nil {
+		rest.BadRequestResponse(w, r, err, "unable to parse JSON request body")
+		return
+	}
+
+	v := validator.New()
+	checkWebhook(v, &input.URL, input.EventTypes)
+	v.Check(input.Secret == nil || *input.Secret != "", "secret", "must not be empty")
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	webhook, err := api.repo.WebhookWriter.Create(ctx, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, WebhookResponse{Data: *webhook}, nil)
+}
+
+func (api *API) patchWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := rest.ReadPathParamID(ctx, "id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "id", err)
+		return
+	}
+
+	var input repo.WebhookPatch
+
+	err = rest.ReadJSON(r, &input)
+	if err != nil {
+		rest.BadRequestResponse(w, r, err, "unable to parse JSON request body")
+		return
+	}
+	input.ID = *id
+
+	v := validator.New()
+	checkWebhook(v, input.URL, input.EventTypes)
+	v.Check(input.Secret == nil || *input.Secret != "", "secret", "must not be empty")
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	webhook, err := api.repo.WebhookWriter.Update(ctx, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, WebhookResponse{Data: *webhook}, nil)
+}
+
+func (api *API) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := rest.ReadPathParamID(ctx, "id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "id", err)
+		return
+	}
+
+	webhook, err := api.repo.WebhookWriter.Delete(ctx, *id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, WebhookResponse{Data: *webhook}, nil)
+}
+
+func (api *API) listWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := rest.ReadPathParamID(ctx, "id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "id", err)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+	filters := data.Filters{WebhookID: id}
+
+	filters.Statuses = rest.ReadOptionalQueryStrings(qs, "status", data.WebhookDeliveryStatuses, v)
+	filters.PageSize = rest.ReadRequiredQueryInt(qs, "page_size", 25, v)
+	filters.Cursor = rest.ReadOptionalQueryCursor(qs, "cursor", v)
+
+	if !v.Valid() {
+		rest.ValidationFailedResponse(ctx, w, r, v.Errors)
+		return
+	}
+
+	deliveries, metadata, err := api.repo.WebhookReader.ListDeliveries(ctx, filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidCursor):
+			rest.InvalidQueryParameterResponse(ctx, w, r, "cursor", err)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(
+		w,
+		r,
+		http.StatusOK,
+		WebhookDeliveryListResponse{Data: deliveries, Metadata: metadata},
+		nil,
+	)
+}
+
+func (api *API) retryWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := rest.ReadPathParamID(ctx, "id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "id", err)
+		return
+	}
+
+	deliveryID, err := rest.ReadPathParamID(ctx, "delivery_id", r)
+	if err != nil {
+		rest.InvalidParameterResponse(ctx, w, r, "delivery_id", err)
+		return
+	}
+
+	delivery, err := api.repo.WebhookWriter.RetryDelivery(ctx, *id, *deliveryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			rest.NotFoundResponse(ctx, w, r)
+		case errors.Is(err, context.DeadlineExceeded):
+			rest.TimeoutResponse(ctx, w, r)
+		default:
+			rest.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rest.RespondWithJSON(w, r, http.StatusOK, WebhookDeliveryResponse{Data: *delivery}, nil)
+}
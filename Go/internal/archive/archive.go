@@ -0,0 +1,129 @@
+// Package archive exports and imports forum data as JSON Lines archives, to move forums between
+// environments and to take logical backups.
+//
+// An archive starts with a header, followed by a record for every archived row. Records are
+// written in the order of data.ArchiveTables, so every record follows the records it refers to:
+// users, forums, threads, posts, and finally thread and post votes.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidArchive     = errors.New("invalid archive")
+	ErrUnsupportedVersion = errors.New("unsupported archive version")
+)
+
+// Version is the version of the archive format written by the exporter.
+const Version int = 1
+
+// KindHeader is the kind of the first record of every archive.
+const KindHeader string = "header"
+
+// ContentType is the media type of an archive.
+const ContentType string = "application/x-ndjson"
+
+// defaultBatchSize is the number of records read from, or written to, the database at a time.
+const defaultBatchSize int = 500
+
+// Record is a single line of an archive.
+type Record struct {
+	// Kind is either KindHeader, or the kind of the archived row.
+	Kind string `json:"kind"`
+	// Data is the header, or the archived row keyed by column name.
+	Data json.RawMessage `json:"data"`
+}
+
+// Header describes the contents of an archive.
+type Header struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// ForumID is the forum the archive is restricted to, or null if it holds every forum.
+	ForumID *uuid.UUID `json:"forumId"`
+	// Credentials denotes whether the password hashes of the users are included.
+	Credentials bool `json:"credentials"`
+}
+
+// reader reads the records of an archive one line at a time, keeping count of the lines read and
+// a hash of their contents.
+type reader struct {
+	r    *bufio.Reader
+	line int64
+	hash hash.Hash
+}
+
+func newReader(r io.Reader) *reader {
+	return &reader{r: bufio.NewReader(r), hash: sha256.New()}
+}
+
+// fingerprint returns the hash of the lines read so far, telling archives apart when resuming an
+// import.
+func (r *reader) fingerprint() []byte {
+	return r.hash.Sum(nil)
+}
+
+// next returns the next record of the archive, skipping blank lines. At the end of the archive,
+// io.EOF is returned.
+func (r *reader) next() (*Record, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return nil, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		r.line++
+		r.hash.Write(line)
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidArchive, r.line, err)
+		}
+		if record.Kind == "" || len(record.Data) == 0 {
+			return nil, fmt.Errorf("%w: line %d: missing kind or data", ErrInvalidArchive, r.line)
+		}
+
+		return &record, nil
+	}
+}
+
+// header reads the header, which must be the first record of the archive.
+func (r *reader) header() (*Header, error) {
+	record, err := r.next()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty archive", ErrInvalidArchive)
+		}
+		return nil, err
+	}
+	if record.Kind != KindHeader {
+		return nil, fmt.Errorf("%w: line %d: expected a header", ErrInvalidArchive, r.line)
+	}
+
+	var header Header
+	if err := json.Unmarshal(record.Data, &header); err != nil {
+		return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidArchive, r.line, err)
+	}
+	if header.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, header.Version)
+	}
+
+	return &header, nil
+}
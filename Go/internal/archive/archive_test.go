@@ -0,0 +1,350 @@
+package archive_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/archive"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/database"
+	"github.com/r3d5un/rosetta/Go/internal/migrate"
+	"github.com/r3d5un/rosetta/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	dbName     string = "postgres"
+	dbUser            = "postgres"
+	dbPassword        = "postgres"
+)
+
+var models data.Models
+
+func TestMain(m *testing.M) {
+	handler := slog.NewJSONHandler(os.Stdout, nil)
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	logger.Info("creating PostgreSQL container")
+	dbContainer, err := postgres.Run(
+		ctx,
+		"postgres:17.4",
+		postgres.WithDatabase(dbName),
+		postgres.WithUsername(dbUser),
+		postgres.WithPassword(dbPassword),
+		testcontainers.WithLogger(log.Default()),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(5*time.Second),
+		),
+	)
+	defer func() {
+		if err := testcontainers.TerminateContainer(dbContainer); err != nil {
+			logger.Info("failed to terminate container", slog.String("error", err.Error()))
+		}
+	}()
+	if err != nil {
+		logger.Error("unable to start container", slog.String("error", err.Error()))
+		return
+	}
+
+	connStr, err := dbContainer.ConnectionString(ctx, "sslmode=disable", "application_name=rosetta")
+	if err != nil {
+		logger.Error("unable to get database connection string", slog.String("error", err.Error()))
+		return
+	}
+
+	dbConfig := database.DatabaseConfig{
+		ConnStr:         connStr,
+		MaxOpenConns:    20,
+		IdleTimeMinutes: 1,
+		TimeoutSeconds:  30,
+	}
+	db, err := database.OpenPool(ctx, dbConfig)
+	if err != nil {
+		logger.Error("unable to create database connection pool", slog.String("error", err.Error()))
+		return
+	}
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		logger.Error("unable to load migrations", slog.String("error", err.Error()))
+		return
+	}
+	if err := migrator.Up(ctx); err != nil {
+		logger.Error("unable to migrate database", slog.String("error", err.Error()))
+		return
+	}
+	timeout := dbConfig.TimeoutDuration()
+	models = data.NewModels(db, &timeout)
+
+	exitCode := m.Run()
+
+	defer os.Exit(exitCode)
+}
+
+func TestArchive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	fixer, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Dexter DeShawn",
+		Username: "dex",
+		Email:    "dex@afterlife.com",
+	})
+	assert.NoError(t, err)
+
+	merc, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Jackie Welles",
+		Username: "jackie",
+		Email:    "jackie@heywood.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := models.Forums.Insert(ctx, data.ForumInput{
+		OwnerID: fixer.ID,
+		Name:    "Konpeki Plaza",
+	})
+	assert.NoError(t, err)
+
+	thread, err := models.Threads.Insert(ctx, data.ThreadInput{
+		AuthorID: fixer.ID,
+		ForumID:  forum.ID,
+		Title:    "The relic heist",
+	})
+	assert.NoError(t, err)
+
+	post, err := models.Posts.Insert(ctx, data.PostInput{
+		ThreadID: thread.ID,
+		Content:  "In and out, nobody gets hurt",
+		AuthorID: fixer.ID,
+	})
+	assert.NoError(t, err)
+
+	_, err = models.Posts.Insert(ctx, data.PostInput{
+		ThreadID: thread.ID,
+		ReplyTo:  uuid.NullUUID{UUID: post.ID, Valid: true},
+		Content:  "Famous last words, choom",
+		AuthorID: merc.ID,
+	})
+	assert.NoError(t, err)
+
+	_, err = models.ThreadVotes.Vote(ctx, data.ThreadVote{
+		ThreadID: thread.ID,
+		UserID:   merc.ID,
+		Vote:     1,
+	})
+	assert.NoError(t, err)
+
+	_, err = models.PostVotes.Vote(ctx, data.PostVote{
+		PostID: post.ID,
+		UserID: merc.ID,
+		Vote:   -1,
+	})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	t.Run("Export", func(t *testing.T) {
+		summary, err := archive.NewExporter(&models).Export(ctx, &buf, archive.ExportOptions{
+			ForumID: &forum.ID,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int64{
+			data.ArchiveKindUsers:       2,
+			data.ArchiveKindForums:      1,
+			data.ArchiveKindThreads:     1,
+			data.ArchiveKindPosts:       2,
+			data.ArchiveKindThreadVotes: 1,
+			data.ArchiveKindPostVotes:   1,
+		}, summary.Records)
+		assert.Equal(t, 9, strings.Count(buf.String(), "\n"))
+
+		missing := uuid.New()
+		_, err = archive.NewExporter(&models).Export(ctx, &bytes.Buffer{}, archive.ExportOptions{
+			ForumID: &missing,
+		})
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("ImportPreserveSkip", func(t *testing.T) {
+		summary, err := archive.NewImporter(&models).Import(
+			ctx,
+			bytes.NewReader(buf.Bytes()),
+			archive.ImportOptions{
+				IDMode:     data.ImportIDModePreserve,
+				OnConflict: data.ArchiveConflictSkip,
+			},
+		)
+		assert.NoError(t, err)
+		assert.Empty(t, summary.Written)
+		assert.Equal(t, int64(2), summary.Skipped[data.ArchiveKindPosts])
+		assert.NotNil(t, summary.Import.CompletedAt)
+	})
+
+	t.Run("ImportPreserveFail", func(t *testing.T) {
+		summary, err := archive.NewImporter(&models).Import(
+			ctx,
+			bytes.NewReader(buf.Bytes()),
+			archive.ImportOptions{
+				IDMode:     data.ImportIDModePreserve,
+				OnConflict: data.ArchiveConflictFail,
+			},
+		)
+		assert.ErrorIs(t, err, data.ErrUniqueConstraintViolation)
+		assert.Equal(t, int64(0), summary.Import.Line)
+		assert.Nil(t, summary.Import.CompletedAt)
+	})
+
+	t.Run("ImportRemapResume", func(t *testing.T) {
+		// The first run fails on a corrupted post, after the records before it are checkpointed.
+		lines := strings.SplitAfter(buf.String(), "\n")
+		corrupted := slices.Clone(lines)
+		corrupted[5] = `{"kind":"posts","data":{"content":"flatlined"}}` + "\n"
+
+		importer := archive.NewImporter(&models)
+		importer.BatchSize = 1
+
+		summary, err := importer.Import(
+			ctx,
+			strings.NewReader(strings.Join(corrupted, "")),
+			archive.ImportOptions{
+				IDMode:     data.ImportIDModeRemap,
+				OnConflict: data.ArchiveConflictSkip,
+			},
+		)
+		assert.ErrorIs(t, err, archive.ErrInvalidArchive)
+		assert.Equal(t, int64(5), summary.Import.Line)
+		assert.Equal(t, int64(2), summary.Skipped[data.ArchiveKindUsers])
+		assert.Equal(t, int64(1), summary.Written[data.ArchiveKindForums])
+
+		// The checkpoint only holds for the archive it was recorded for.
+		tampered := slices.Clone(lines)
+		tampered[3] = strings.Replace(tampered[3], forum.Name, "Arasaka Tower", 1)
+		for _, other := range []string{strings.Join(tampered, ""), strings.Join(lines[:4], "")} {
+			_, err = importer.Import(
+				ctx, strings.NewReader(other), archive.ImportOptions{Resume: &summary.Import.ID},
+			)
+			assert.ErrorIs(t, err, archive.ErrInvalidArchive)
+		}
+
+		resumed, err := importer.Import(
+			ctx,
+			strings.NewReader(buf.String()),
+			archive.ImportOptions{Resume: &summary.Import.ID},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, summary.Import.ID, resumed.Import.ID)
+		assert.NotNil(t, resumed.Import.CompletedAt)
+		assert.Equal(t, map[string]int64{
+			data.ArchiveKindPosts:       2,
+			data.ArchiveKindThreadVotes: 1,
+			data.ArchiveKindPostVotes:   1,
+		}, resumed.Written)
+
+		mappings, err := models.Imports.SelectMappings(ctx, summary.Import.ID)
+		assert.NoError(t, err)
+		// Users colliding on their username are resolved to the existing users.
+		assert.Equal(t, merc.ID, mappings[merc.ID].UUID)
+		assert.NotEqual(t, forum.ID, mappings[forum.ID].UUID)
+
+		copied, err := models.Threads.Select(ctx, mappings[forum.ID].UUID, mappings[thread.ID].UUID)
+		assert.NoError(t, err)
+		assert.Equal(t, thread.Title, copied.Title)
+		assert.Equal(t, int64(1), copied.Likes)
+	})
+
+	t.Run("ImportRemapUnresolved", func(t *testing.T) {
+		// The reply refers to a post which is left out of the archive.
+		lines := strings.SplitAfter(buf.String(), "\n")
+		var partial []string
+		for _, line := range lines {
+			if !strings.Contains(line, post.Content) {
+				partial = append(partial, line)
+			}
+		}
+
+		summary, err := archive.NewImporter(&models).Import(
+			ctx,
+			strings.NewReader(strings.Join(partial, "")),
+			archive.ImportOptions{
+				IDMode:     data.ImportIDModeRemap,
+				OnConflict: data.ArchiveConflictSkip,
+			},
+		)
+		assert.ErrorIs(t, err, archive.ErrInvalidArchive)
+		assert.ErrorContains(t, err, "reply_to")
+		assert.Nil(t, summary.Import.CompletedAt)
+	})
+
+	t.Run("ExportParentsFirst", func(t *testing.T) {
+		// Posts created in the same transaction share their creation time.
+		gig, err := models.Forums.Insert(ctx, data.ForumInput{OwnerID: fixer.ID, Name: "Arasaka"})
+		assert.NoError(t, err)
+		err = models.WithTx(ctx, func(tx data.Models) error {
+			thread, err := tx.Threads.Insert(ctx, data.ThreadInput{
+				AuthorID: fixer.ID,
+				ForumID:  gig.ID,
+				Title:    "Down the rabbit hole",
+			})
+			if err != nil {
+				return err
+			}
+			var replyTo uuid.NullUUID
+			for i := range 8 {
+				post, err := tx.Posts.Insert(ctx, data.PostInput{
+					ThreadID: thread.ID,
+					ReplyTo:  replyTo,
+					Content:  fmt.Sprintf("Deeper %d", i),
+					AuthorID: merc.ID,
+				})
+				if err != nil {
+					return err
+				}
+				replyTo = uuid.NullUUID{UUID: post.ID, Valid: true}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+
+		var exported bytes.Buffer
+		_, err = archive.NewExporter(&models).Export(ctx, &exported, archive.ExportOptions{
+			ForumID: &gig.ID,
+		})
+		assert.NoError(t, err)
+
+		seen := make(map[uuid.UUID]bool)
+		for _, line := range strings.Split(strings.TrimSpace(exported.String()), "\n") {
+			var record archive.Record
+			assert.NoError(t, json.Unmarshal([]byte(line), &record))
+			if record.Kind != data.ArchiveKindPosts {
+				continue
+			}
+			var post struct {
+				ID      uuid.UUID     `json:"id"`
+				ReplyTo uuid.NullUUID `json:"reply_to"`
+			}
+			assert.NoError(t, json.Unmarshal(record.Data, &post))
+			assert.True(t, !post.ReplyTo.Valid || seen[post.ReplyTo.UUID], "reply before parent")
+			seen[post.ID] = true
+		}
+		assert.Len(t, seen, 8)
+	})
+}
@@ -0,0 +1,133 @@
+package archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+type ExportOptions struct {
+	// ForumID restricts the archive to a single forum, along with every user who owns, posted in,
+	// locked threads of, or voted in the forum. Replies to posts outside of the forum are
+	// archived as top level posts.
+	ForumID *uuid.UUID `json:"forumId"`
+	// Credentials includes the password hashes of the users, allowing them to sign in after the
+	// archive is imported.
+	Credentials bool `json:"credentials"`
+}
+
+type ExportSummary struct {
+	// Records is the number of archived rows of every kind.
+	Records map[string]int64 `json:"records"`
+}
+
+type Exporter struct {
+	models *data.Models
+	// BatchSize is the number of rows read from the database at a time.
+	BatchSize int
+}
+
+func NewExporter(models *data.Models) *Exporter {
+	return &Exporter{models: models, BatchSize: defaultBatchSize}
+}
+
+// Export writes an archive to w. Every row is read from the same snapshot of the database, so
+// the archive is consistent even while the forum is in use.
+//
+// If the forum to export does not exist, data.ErrRecordNotFound is returned before anything is
+// written.
+func (e *Exporter) Export(
+	ctx context.Context,
+	w io.Writer,
+	opts ExportOptions,
+) (*ExportSummary, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"parameters",
+		slog.Any("forumId", opts.ForumID),
+		slog.Bool("credentials", opts.Credentials),
+	))
+
+	forumID := uuid.NullUUID{}
+	if opts.ForumID != nil {
+		forumID = uuid.NullUUID{UUID: *opts.ForumID, Valid: true}
+	}
+
+	summary := ExportSummary{Records: make(map[string]int64)}
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "exporting archive")
+	err := e.models.WithTx(ctx, func(tx data.Models) error {
+		if err := tx.Archive.Snapshot(ctx); err != nil {
+			return err
+		}
+
+		if forumID.Valid {
+			forums, err := tx.Archive.Select(ctx, data.ArchiveKindForums, forumID, nil, 1, false)
+			if err != nil {
+				return err
+			}
+			if len(forums) == 0 {
+				return data.ErrRecordNotFound
+			}
+		}
+
+		header, err := json.Marshal(Header{
+			Version:     Version,
+			CreatedAt:   time.Now().UTC(),
+			ForumID:     opts.ForumID,
+			Credentials: opts.Credentials,
+		})
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(Record{Kind: KindHeader, Data: header}); err != nil {
+			return err
+		}
+
+		for _, table := range data.ArchiveTables {
+			var after json.RawMessage
+			for {
+				documents, err := tx.Archive.Select(
+					ctx, table.Kind, forumID, after, e.BatchSize, opts.Credentials,
+				)
+				if err != nil {
+					return err
+				}
+				for _, document := range documents {
+					if err := enc.Encode(Record{Kind: table.Kind, Data: document}); err != nil {
+						return err
+					}
+				}
+				summary.Records[table.Kind] += int64(len(documents))
+
+				if len(documents) < e.BatchSize {
+					break
+				}
+				after = documents[len(documents)-1]
+				if err := buf.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		return buf.Flush()
+	})
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to export archive", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "archive exported", slog.Any("records", summary.Records))
+
+	return &summary, nil
+}
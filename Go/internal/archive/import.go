@@ -0,0 +1,482 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+var ErrInvalidImportOptions = errors.New("invalid import options")
+
+type ImportOptions struct {
+	// IDMode is either data.ImportIDModePreserve, restoring rows under their archived IDs, or
+	// data.ImportIDModeRemap, restoring rows under new IDs. References between the rows of the
+	// archive are rewritten to match. When remapping, rows may only refer to rows of the archive.
+	IDMode string `json:"idMode"`
+	// OnConflict is one of data.ArchiveConflicts, resolving rows colliding with existing rows.
+	// Users colliding on their username are resolved to the existing user when skipped, and
+	// overwrite the existing user when remapping IDs.
+	OnConflict string `json:"onConflict"`
+	// Resume continues an interrupted import from its last checkpoint, using its original options
+	// in place of the ones given. The same archive must be given again, which is verified against
+	// the fingerprint of the checkpoint.
+	Resume *uuid.UUID `json:"resume"`
+}
+
+type ImportSummary struct {
+	// Import is the checkpoint of the import, identifying it when resuming.
+	Import data.Import `json:"import"`
+	// Written is the number of rows of every kind inserted or overwritten by this run.
+	Written map[string]int64 `json:"written"`
+	// Skipped is the number of rows of every kind skipped by this run, either colliding with an
+	// existing row, or depending on a skipped row.
+	Skipped map[string]int64 `json:"skipped"`
+}
+
+type Importer struct {
+	models *data.Models
+	// BatchSize is the number of records written in a single transaction, after which a
+	// checkpoint is recorded.
+	BatchSize int
+}
+
+func NewImporter(models *data.Models) *Importer {
+	return &Importer{models: models, BatchSize: defaultBatchSize}
+}
+
+// Import writes the records of an archive read from r. Records are written in batches, each in a
+// transaction recording a checkpoint, so a failed import leaves the database at the last
+// checkpoint, from which the import may be resumed.
+//
+// Once the import is started, the summary is returned alongside any error, identifying the import
+// to resume.
+func (i *Importer) Import(
+	ctx context.Context,
+	r io.Reader,
+	opts ImportOptions,
+) (*ImportSummary, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"parameters",
+		slog.String("idMode", opts.IDMode),
+		slog.String("onConflict", opts.OnConflict),
+		slog.Any("resume", opts.Resume),
+	))
+
+	checkpoint, mappings, err := i.start(ctx, opts)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to start import", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger = logger.With(slog.String("importId", checkpoint.ID.String()))
+
+	state := &importState{
+		idMode:     checkpoint.IDMode,
+		onConflict: checkpoint.OnConflict,
+		mappings:   mappings,
+		columns:    make(map[string][]string),
+	}
+	summary := &ImportSummary{
+		Import:  *checkpoint,
+		Written: make(map[string]int64),
+		Skipped: make(map[string]int64),
+	}
+	if checkpoint.CompletedAt != nil {
+		logger.LogAttrs(ctx, slog.LevelInfo, "import already completed")
+		return summary, nil
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "importing archive", slog.Int64("line", checkpoint.Line))
+	if err := i.write(ctx, newReader(r), state, summary); err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to import archive",
+			slog.Int64("line", summary.Import.Line),
+			slog.String("error", err.Error()),
+		)
+		return summary, err
+	}
+	logger.LogAttrs(
+		ctx,
+		slog.LevelInfo,
+		"archive imported",
+		slog.Any("written", summary.Written),
+		slog.Any("skipped", summary.Skipped),
+	)
+
+	return summary, nil
+}
+
+// start creates the checkpoint of a new import, or reads the checkpoint and the ID mappings of
+// the import to resume.
+func (i *Importer) start(
+	ctx context.Context,
+	opts ImportOptions,
+) (*data.Import, map[uuid.UUID]uuid.NullUUID, error) {
+	if opts.Resume != nil {
+		checkpoint, err := i.models.Imports.Select(ctx, *opts.Resume)
+		if err != nil {
+			return nil, nil, err
+		}
+		mappings, err := i.models.Imports.SelectMappings(ctx, checkpoint.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return checkpoint, mappings, nil
+	}
+
+	if !slices.Contains(data.ImportIDModes, opts.IDMode) {
+		return nil, nil, fmt.Errorf("%w: unknown ID mode %q", ErrInvalidImportOptions, opts.IDMode)
+	}
+	if !slices.Contains(data.ArchiveConflicts, opts.OnConflict) {
+		return nil, nil, fmt.Errorf(
+			"%w: unknown conflict strategy %q", ErrInvalidImportOptions, opts.OnConflict,
+		)
+	}
+
+	checkpoint, err := i.models.Imports.Insert(ctx, data.ImportInput{
+		IDMode:     opts.IDMode,
+		OnConflict: opts.OnConflict,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return checkpoint, make(map[uuid.UUID]uuid.NullUUID), nil
+}
+
+// write reads the archive in batches, skipping the lines written before the checkpoint, and
+// writes every batch along with a new checkpoint.
+//
+// When resuming, the lines read past must match the fingerprint of the checkpoint, as the
+// checkpoint only holds for the archive it was recorded for.
+func (i *Importer) write(
+	ctx context.Context,
+	r *reader,
+	state *importState,
+	summary *ImportSummary,
+) error {
+	verified := summary.Import.Line == 0
+	verify := func() error {
+		if verified || r.line < summary.Import.Line {
+			return nil
+		}
+		// Imports checkpointed before fingerprints were recorded are resumed unverified.
+		if r.line != summary.Import.Line || (summary.Import.Fingerprint != nil &&
+			!bytes.Equal(r.fingerprint(), summary.Import.Fingerprint)) {
+			return fmt.Errorf(
+				"%w: line %d differs from the archive being resumed",
+				ErrInvalidArchive,
+				summary.Import.Line,
+			)
+		}
+		verified = true
+		return nil
+	}
+
+	if _, err := r.header(); err != nil {
+		return err
+	}
+	if err := verify(); err != nil {
+		return err
+	}
+
+	type numbered struct {
+		line   int64
+		record *Record
+	}
+
+	for done := false; !done; {
+		// Lines written before the checkpoint are read past when resuming.
+		batch := make([]numbered, 0, i.BatchSize)
+		for len(batch) < i.BatchSize {
+			record, err := r.next()
+			if errors.Is(err, io.EOF) && !verified {
+				return fmt.Errorf(
+					"%w: ends before line %d of the archive being resumed",
+					ErrInvalidArchive,
+					summary.Import.Line,
+				)
+			}
+			if errors.Is(err, io.EOF) {
+				done = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err := verify(); err != nil {
+				return err
+			}
+			if r.line > summary.Import.Line {
+				batch = append(batch, numbered{line: r.line, record: record})
+			}
+		}
+
+		batchState := state.batch()
+		var checkpoint *data.Import
+		err := i.models.WithTx(ctx, func(tx data.Models) error {
+			for _, n := range batch {
+				if err := batchState.apply(ctx, tx, n.record); err != nil {
+					return fmt.Errorf("line %d: %w", n.line, err)
+				}
+			}
+
+			var err error
+			checkpoint, err = tx.Imports.Checkpoint(
+				ctx,
+				summary.Import.ID,
+				r.line,
+				r.fingerprint(),
+				batchState.pending,
+				done,
+			)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		summary.Import = *checkpoint
+		state.commit(batchState, summary)
+	}
+
+	return nil
+}
+
+// importState holds the IDs the archived rows were written under, and the columns of every
+// table.
+type importState struct {
+	idMode     string
+	onConflict string
+	mappings   map[uuid.UUID]uuid.NullUUID
+	columns    map[string][]string
+
+	// pending are the mappings of the batch being written, kept apart until it is committed.
+	pending map[uuid.UUID]uuid.NullUUID
+	written map[string]int64
+	skipped map[string]int64
+}
+
+// batch returns the state of a new batch, sharing the committed mappings.
+func (s *importState) batch() *importState {
+	return &importState{
+		idMode:     s.idMode,
+		onConflict: s.onConflict,
+		mappings:   s.mappings,
+		columns:    s.columns,
+		pending:    make(map[uuid.UUID]uuid.NullUUID),
+		written:    make(map[string]int64),
+		skipped:    make(map[string]int64),
+	}
+}
+
+// commit merges the mappings and counts of a committed batch.
+func (s *importState) commit(batch *importState, summary *ImportSummary) {
+	maps.Copy(s.mappings, batch.pending)
+	for kind, n := range batch.written {
+		summary.Written[kind] += n
+	}
+	for kind, n := range batch.skipped {
+		summary.Skipped[kind] += n
+	}
+}
+
+func (s *importState) lookup(id uuid.UUID) (uuid.NullUUID, bool) {
+	if target, ok := s.pending[id]; ok {
+		return target, true
+	}
+	target, ok := s.mappings[id]
+	return target, ok
+}
+
+// apply writes a single record of the archive.
+func (s *importState) apply(ctx context.Context, tx data.Models, record *Record) error {
+	if record.Kind == KindHeader {
+		return fmt.Errorf("%w: unexpected header", ErrInvalidArchive)
+	}
+	table, err := data.LookupArchiveTable(record.Kind)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+
+	var newID *uuid.UUID
+	if s.idMode == data.ImportIDModeRemap {
+		id := uuid.New()
+		newID = &id
+	}
+	document, source, err := remap(table, record.Data, s.lookup, newID)
+	if err != nil {
+		if errors.Is(err, errSkippedReference) {
+			if table.Identified() {
+				s.pending[source] = uuid.NullUUID{}
+			}
+			s.skipped[table.Kind]++
+			return nil
+		}
+		return err
+	}
+
+	names, err := s.names(ctx, tx, table.Kind, document)
+	if err != nil {
+		return err
+	}
+
+	// IDs never collide when remapping, leaving the natural key as the only conflict to resolve.
+	var target []string
+	if s.idMode == data.ImportIDModeRemap &&
+		s.onConflict == data.ArchiveConflictOverwrite &&
+		table.NaturalKey != "" {
+		target = []string{table.NaturalKey}
+	}
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	id, err := tx.Archive.Insert(ctx, table.Kind, names, encoded, target, s.onConflict)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		s.skipped[table.Kind]++
+		if !table.Identified() {
+			return nil
+		}
+		existing, err := tx.Archive.SelectByNaturalKey(ctx, table.Kind, encoded)
+		switch {
+		case err == nil && (*existing != source || s.idMode == data.ImportIDModeRemap):
+			s.pending[source] = uuid.NullUUID{UUID: *existing, Valid: true}
+		case errors.Is(err, data.ErrRecordNotFound) && s.idMode == data.ImportIDModeRemap:
+			// The row collided on something else than its natural key, leaving nothing for
+			// the rows depending on it to refer to.
+			s.pending[source] = uuid.NullUUID{}
+		case err != nil && !errors.Is(err, data.ErrRecordNotFound):
+			return err
+		}
+		return nil
+	case err != nil:
+		return err
+	}
+
+	s.written[table.Kind]++
+	// Rows written under their archived IDs are referred to as they are, unless remapping, where
+	// every row referred to must be mapped.
+	if id.Valid && (id.UUID != source || s.idMode == data.ImportIDModeRemap) {
+		s.pending[source] = id
+	}
+
+	return nil
+}
+
+// names lists the columns of the table present in the document.
+func (s *importState) names(
+	ctx context.Context,
+	tx data.Models,
+	kind string,
+	document map[string]json.RawMessage,
+) ([]string, error) {
+	columns, ok := s.columns[kind]
+	if !ok {
+		var err error
+		columns, err = tx.Archive.Columns(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+		s.columns[kind] = columns
+	}
+
+	names := []string{}
+	for _, column := range columns {
+		if _, ok := document[column]; ok {
+			names = append(names, column)
+		}
+	}
+
+	return names, nil
+}
+
+var errSkippedReference = errors.New("reference to a skipped row")
+
+// remap decodes an archived row, and rewrites its references to the IDs the referred rows were
+// written under, as given by lookup. If newID is set, the row is given the new ID. The archived ID
+// of the row is returned alongside the document, or the zero UUID for rows without an ID column.
+//
+// If the row refers to a skipped row, errSkippedReference is returned. When remapping, that is if
+// newID is set, a reference to a row missing from the archive is invalid, as the row it refers to
+// in the database is unrelated to the imported rows.
+func remap(
+	table data.ArchiveTable,
+	raw json.RawMessage,
+	lookup func(uuid.UUID) (uuid.NullUUID, bool),
+	newID *uuid.UUID,
+) (map[string]json.RawMessage, uuid.UUID, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, uuid.Nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+	}
+
+	var source uuid.UUID
+	if table.Identified() {
+		id, err := decodeID(document, "id")
+		if err != nil || id == nil {
+			return nil, uuid.Nil, fmt.Errorf(
+				"%w: %s without a valid ID", ErrInvalidArchive, table.Kind,
+			)
+		}
+		source = *id
+		if newID != nil {
+			document["id"], _ = json.Marshal(*newID)
+		}
+	}
+
+	for _, column := range table.References {
+		id, err := decodeID(document, column)
+		if err != nil {
+			return nil, uuid.Nil, fmt.Errorf("%w: %s: %s", ErrInvalidArchive, column, err)
+		}
+		if id == nil {
+			continue
+		}
+		target, ok := lookup(*id)
+		switch {
+		case !ok && newID != nil:
+			return nil, source, fmt.Errorf(
+				"%w: %s refers to %s, which is not in the archive", ErrInvalidArchive, column, id,
+			)
+		case !ok:
+			// Rows outside of the archive are referred to as they are.
+		case !target.Valid:
+			return nil, source, errSkippedReference
+		default:
+			document[column], _ = json.Marshal(target.UUID)
+		}
+	}
+
+	return document, source, nil
+}
+
+// decodeID decodes the ID held by a column, returning nil if the column is missing or null.
+func decodeID(document map[string]json.RawMessage, column string) (*uuid.UUID, error) {
+	raw, ok := document[column]
+	if !ok || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var id uuid.UUID
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// PasswordMinLength is the minimum number of bytes in a password.
+	PasswordMinLength int = 8
+	// PasswordMaxLength is the maximum number of bytes in a password. bcrypt ignores anything
+	// beyond 72 bytes.
+	PasswordMaxLength int = 72
+	// passwordCost is the bcrypt cost used when hashing passwords.
+	passwordCost int = 12
+)
+
+// HashPassword creates a bcrypt hash of the plaintext password.
+func HashPassword(plaintext string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(plaintext), passwordCost)
+}
+
+// MatchPassword reports whether the plaintext password matches the bcrypt hash.
+func MatchPassword(hash []byte, plaintext string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword(hash, []byte(plaintext))
+	if err != nil {
+		switch {
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// GenerateToken creates a random plaintext token, and the SHA-256 hash of the token to persist.
+func GenerateToken() (string, []byte, error) {
+	randomBytes := make([]byte, 16)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	plaintext := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+
+	return plaintext, HashToken(plaintext), nil
+}
+
+// HashToken returns the SHA-256 hash of a plaintext token.
+func HashToken(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
+}
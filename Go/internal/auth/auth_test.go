@@ -0,0 +1,36 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/r3d5un/rosetta/Go/internal/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassword(t *testing.T) {
+	hash, err := auth.HashPassword("correct horse battery staple")
+	assert.NoError(t, err)
+
+	t.Run("Match", func(t *testing.T) {
+		ok, err := auth.MatchPassword(hash, "correct horse battery staple")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		ok, err := auth.MatchPassword(hash, "incorrect horse battery staple")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestToken(t *testing.T) {
+	plaintext, hash, err := auth.GenerateToken()
+	assert.NoError(t, err)
+	assert.Len(t, plaintext, 26)
+	assert.Equal(t, hash, auth.HashToken(plaintext))
+
+	other, _, err := auth.GenerateToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, plaintext, other)
+}
@@ -2,15 +2,23 @@ package cfg
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"net"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/r3d5un/rosetta/Go/internal/database"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
+	"github.com/r3d5un/rosetta/Go/internal/ratelimit"
+	"github.com/r3d5un/rosetta/Go/internal/retention"
 	"github.com/r3d5un/rosetta/Go/internal/telemetry"
 	"github.com/spf13/viper"
 )
 
+var ErrIncompleteTLSConfig = errors.New("both a TLS certificate and key must be configured")
+
 type AppCfg struct {
 	Name             string                    `json:"name"`
 	Version          string                    `json:"version"`
@@ -19,10 +27,103 @@ type AppCfg struct {
 	TelemetryEnabled bool                      `json:"telemetryEnabled"`
 	Telemetry        telemetry.TelemetryConfig `json:"telemetry"`
 	Database         database.DatabaseConfig   `json:"database"`
+	Retention        retention.RetentionConfig `json:"retention"`
+	RateLimit        ratelimit.RateLimitConfig `json:"rateLimit"`
 }
 
 type ServerCfg struct {
+	// Address is the host the server listens on. Empty listens on every interface.
+	Address string `json:"address"`
+	// Port is the port the server listens on.
 	Port int `json:"port"`
+	// ReadTimeoutSeconds is the time allowed to read a whole request, including the body.
+	ReadTimeoutSeconds int `json:"readTimeoutSeconds"`
+	// WriteTimeoutSeconds is the time allowed to write a response.
+	WriteTimeoutSeconds int `json:"writeTimeoutSeconds"`
+	// IdleTimeoutSeconds is the time an idle keep-alive connection is kept open.
+	IdleTimeoutSeconds int `json:"idleTimeoutSeconds"`
+	// MaxHeaderBytes is the maximum size of the headers of a request.
+	MaxHeaderBytes int `json:"maxHeaderBytes"`
+	// MaxBodyBytes is the maximum size of the body of a request.
+	MaxBodyBytes int64 `json:"maxBodyBytes"`
+	// MaxImportBytes is the maximum size of an archive uploaded for import, which is exempt from
+	// MaxBodyBytes.
+	MaxImportBytes int64 `json:"maxImportBytes"`
+	// ShutdownGraceSeconds is the time open requests are given to complete when shutting down.
+	ShutdownGraceSeconds int `json:"shutdownGraceSeconds"`
+	// TLSCertFile is the path to the certificate served over TLS. Both the certificate and the
+	// key must be set to serve over TLS.
+	TLSCertFile string `json:"tlsCertFile"`
+	// TLSKeyFile is the path to the private key of the certificate.
+	TLSKeyFile string `json:"tlsKeyFile"`
+	// HTTP2 denotes whether HTTP/2 is served alongside HTTP/1.1. Without TLS, HTTP/2 is served
+	// unencrypted, which is only useful behind a proxy speaking HTTP/2 to the server.
+	HTTP2 bool `json:"http2"`
+}
+
+// Addr returns the address the server listens on.
+func (c *ServerCfg) Addr() string {
+	return net.JoinHostPort(c.Address, strconv.Itoa(c.Port))
+}
+
+func (c *ServerCfg) ReadTimeout() time.Duration {
+	return time.Duration(c.ReadTimeoutSeconds) * time.Second
+}
+
+func (c *ServerCfg) WriteTimeout() time.Duration {
+	return time.Duration(c.WriteTimeoutSeconds) * time.Second
+}
+
+func (c *ServerCfg) IdleTimeout() time.Duration {
+	return time.Duration(c.IdleTimeoutSeconds) * time.Second
+}
+
+func (c *ServerCfg) ShutdownGrace() time.Duration {
+	return time.Duration(c.ShutdownGraceSeconds) * time.Second
+}
+
+// TLS reports whether the server is served over TLS. An error is returned if only one of the
+// certificate and the key is set.
+func (c *ServerCfg) TLS() (bool, error) {
+	switch {
+	case c.TLSCertFile == "" && c.TLSKeyFile == "":
+		return false, nil
+	case c.TLSCertFile == "" || c.TLSKeyFile == "":
+		return false, ErrIncompleteTLSConfig
+	default:
+		return true, nil
+	}
+}
+
+// WithDefaults returns a copy of the configuration where every unset setting is replaced by its
+// default.
+func (c ServerCfg) WithDefaults() ServerCfg {
+	if c.Port == 0 {
+		c.Port = 4000
+	}
+	if c.ReadTimeoutSeconds <= 0 {
+		c.ReadTimeoutSeconds = 5
+	}
+	if c.WriteTimeoutSeconds <= 0 {
+		c.WriteTimeoutSeconds = 10
+	}
+	if c.IdleTimeoutSeconds <= 0 {
+		c.IdleTimeoutSeconds = 60
+	}
+	if c.MaxHeaderBytes <= 0 {
+		c.MaxHeaderBytes = 1 << 20
+	}
+	if c.MaxBodyBytes <= 0 {
+		c.MaxBodyBytes = 1 << 20
+	}
+	if c.MaxImportBytes <= 0 {
+		c.MaxImportBytes = 1 << 30
+	}
+	if c.ShutdownGraceSeconds <= 0 {
+		c.ShutdownGraceSeconds = 30
+	}
+
+	return c
 }
 
 func New(ctx context.Context) (*AppCfg, error) {
@@ -0,0 +1,35 @@
+package cfg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/cfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerCfgWithDefaults(t *testing.T) {
+	server := cfg.ServerCfg{Address: "127.0.0.1", WriteTimeoutSeconds: 60}.WithDefaults()
+
+	assert.Equal(t, "127.0.0.1:4000", server.Addr())
+	assert.Equal(t, 5*time.Second, server.ReadTimeout())
+	assert.Equal(t, time.Minute, server.WriteTimeout())
+	assert.Equal(t, time.Minute, server.IdleTimeout())
+	assert.Equal(t, 30*time.Second, server.ShutdownGrace())
+	assert.Equal(t, 1<<20, server.MaxHeaderBytes)
+	assert.Equal(t, int64(1<<20), server.MaxBodyBytes)
+	assert.Equal(t, int64(1<<30), server.MaxImportBytes)
+}
+
+func TestServerCfgTLS(t *testing.T) {
+	tls, err := (&cfg.ServerCfg{}).TLS()
+	assert.NoError(t, err)
+	assert.False(t, tls)
+
+	tls, err = (&cfg.ServerCfg{TLSCertFile: "arasaka.crt", TLSKeyFile: "arasaka.key"}).TLS()
+	assert.NoError(t, err)
+	assert.True(t, tls)
+
+	_, err = (&cfg.ServerCfg{TLSCertFile: "arasaka.crt"}).TLS()
+	assert.ErrorIs(t, err, cfg.ErrIncompleteTLSConfig)
+}
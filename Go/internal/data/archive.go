@@ -0,0 +1,480 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+var ErrUnknownArchiveKind = errors.New("unknown archive kind")
+
+const (
+	ArchiveKindUsers       string = "users"
+	ArchiveKindForums      string = "forums"
+	ArchiveKindThreads     string = "threads"
+	ArchiveKindPosts       string = "posts"
+	ArchiveKindThreadVotes string = "threadVotes"
+	ArchiveKindPostVotes   string = "postVotes"
+)
+
+const (
+	// ArchiveConflictSkip leaves the existing row untouched.
+	ArchiveConflictSkip string = "skip"
+	// ArchiveConflictOverwrite replaces the existing row with the archived one.
+	ArchiveConflictOverwrite string = "overwrite"
+	// ArchiveConflictFail aborts the insert.
+	ArchiveConflictFail string = "fail"
+)
+
+// ArchiveConflicts lists the ways of resolving an archived row colliding with an existing row.
+var ArchiveConflicts = []string{
+	ArchiveConflictSkip,
+	ArchiveConflictOverwrite,
+	ArchiveConflictFail,
+}
+
+// ArchiveTable describes how the rows of a table are archived. Rows are archived as JSON
+// documents keyed by column name, the same as the output of to_jsonb.
+type ArchiveTable struct {
+	// Kind names the rows of the table in an archive.
+	Kind string
+	// Key lists the columns of the primary key.
+	Key []string
+	// References lists the columns holding the IDs of other archived rows.
+	References []string
+	// NaturalKey is a unique column identifying the row besides the key, if any.
+	NaturalKey string
+
+	name string
+	// order lists the columns rows are archived by. Rows referring to rows of the same table are
+	// ordered after the rows they refer to.
+	order []string
+	// scope restricts the rows to those belonging to the forum given as $1.
+	scope string
+	// document amends the archived document of the row t.
+	document string
+}
+
+// Identified denotes whether the rows of the table are identified by an ID column of their own.
+func (t ArchiveTable) Identified() bool {
+	return slices.Equal(t.Key, []string{"id"})
+}
+
+// ArchiveTables lists every archived table, in the order the rows must be restored in.
+var ArchiveTables = []ArchiveTable{
+	{
+		Kind:       ArchiveKindUsers,
+		Key:        []string{"id"},
+		NaturalKey: "username",
+		name:       "users",
+		order:      []string{"id"},
+		scope: `
+t.id IN (SELECT owner_id
+         FROM forum.forums
+         WHERE id = $1::UUID
+         UNION
+         SELECT author_id
+         FROM forum.threads
+         WHERE forum_id = $1::UUID
+         UNION
+         SELECT locked_by
+         FROM forum.threads
+         WHERE forum_id = $1::UUID
+         UNION
+         SELECT p.author_id
+         FROM forum.posts p
+                  INNER JOIN forum.threads th ON th.id = p.thread_id
+         WHERE th.forum_id = $1::UUID
+         UNION
+         SELECT v.user_id
+         FROM forum.thread_votes v
+                  INNER JOIN forum.threads th ON th.id = v.thread_id
+         WHERE th.forum_id = $1::UUID
+         UNION
+         SELECT v.user_id
+         FROM forum.post_votes v
+                  INNER JOIN forum.posts p ON p.id = v.post_id
+                  INNER JOIN forum.threads th ON th.id = p.thread_id
+         WHERE th.forum_id = $1::UUID)`,
+	},
+	{
+		Kind:       ArchiveKindForums,
+		Key:        []string{"id"},
+		References: []string{"owner_id"},
+		name:       "forums",
+		order:      []string{"id"},
+		scope:      "t.id = $1::UUID",
+	},
+	{
+		Kind:       ArchiveKindThreads,
+		Key:        []string{"id"},
+		References: []string{"forum_id", "author_id", "locked_by"},
+		name:       "threads",
+		order:      []string{"id"},
+		scope:      "t.forum_id = $1::UUID",
+	},
+	{
+		Kind:       ArchiveKindPosts,
+		Key:        []string{"id"},
+		References: []string{"thread_id", "author_id", "reply_to"},
+		name:       "posts",
+		// Replies are always deeper than the post they reply to, even when created in the same
+		// transaction.
+		order: []string{"depth", "created_at", "id"},
+		scope: "t.thread_id IN (SELECT id FROM forum.threads WHERE forum_id = $1::UUID)",
+		// Replies to posts outside of the forum are archived as top level posts.
+		document: `
+|| jsonb_build_object('reply_to',
+                      CASE
+                          WHEN $1::UUID IS NULL OR t.reply_to IN (
+                              SELECT p.id
+                              FROM forum.posts p
+                                       INNER JOIN forum.threads th ON th.id = p.thread_id
+                              WHERE th.forum_id = $1::UUID)
+                              THEN to_jsonb(t.reply_to)
+                          ELSE 'null'::JSONB
+                          END)`,
+	},
+	{
+		Kind:       ArchiveKindThreadVotes,
+		Key:        []string{"thread_id", "user_id"},
+		References: []string{"thread_id", "user_id"},
+		name:       "thread_votes",
+		order:      []string{"thread_id", "user_id"},
+		scope:      "t.thread_id IN (SELECT id FROM forum.threads WHERE forum_id = $1::UUID)",
+	},
+	{
+		Kind:       ArchiveKindPostVotes,
+		Key:        []string{"post_id", "user_id"},
+		References: []string{"post_id", "user_id"},
+		name:       "post_votes",
+		order:      []string{"post_id", "user_id"},
+		scope: `
+t.post_id IN (SELECT p.id
+              FROM forum.posts p
+                       INNER JOIN forum.threads th ON th.id = p.thread_id
+              WHERE th.forum_id = $1::UUID)`,
+	},
+}
+
+// LookupArchiveTable returns the archived table of the given kind.
+func LookupArchiveTable(kind string) (ArchiveTable, error) {
+	i := slices.IndexFunc(ArchiveTables, func(t ArchiveTable) bool { return t.Kind == kind })
+	if i < 0 {
+		return ArchiveTable{}, fmt.Errorf("%w: %q", ErrUnknownArchiveKind, kind)
+	}
+
+	return ArchiveTables[i], nil
+}
+
+func (t ArchiveTable) identifier() string {
+	return pgx.Identifier{"forum", t.name}.Sanitize()
+}
+
+// columns prefixes every column with the alias of the table, and joins them into a list.
+func columns(alias string, names []string) string {
+	list := make([]string, len(names))
+	for i, name := range names {
+		list[i] = alias + "." + pgx.Identifier{name}.Sanitize()
+	}
+
+	return strings.Join(list, ", ")
+}
+
+type ArchiveModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+// Snapshot makes every following query of the enclosing transaction read from the same snapshot
+// of the database, and rejects writes. It must be the first query of the transaction.
+func (m *ArchiveModel) Snapshot(ctx context.Context) error {
+	const query string = `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY;`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	if _, err := m.DB.Exec(ctx, query); err != nil {
+		return handleError(err, logger)
+	}
+	logger.Info("transaction snapshot set")
+
+	return nil
+}
+
+// Columns lists the columns of the table which may be written to, leaving out generated columns.
+func (m *ArchiveModel) Columns(ctx context.Context, kind string) ([]string, error) {
+	const query string = `
+SELECT column_name
+FROM information_schema.columns
+WHERE table_schema = 'forum'
+  AND table_name = $1::TEXT
+  AND is_generated = 'NEVER'
+  AND is_identity = 'NO'
+ORDER BY ordinal_position;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("kind", kind),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	table, err := LookupArchiveTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, table.name)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("archived columns selected", slog.Int("columns", len(names)))
+
+	return names, nil
+}
+
+// Select returns a page of archived documents of the given kind, in the order they are restored
+// in. The page follows the document given as after, or starts from the first row if after is
+// nil. Setting the forum ID restricts the rows to those belonging to the forum, including every
+// user involved in it.
+//
+// Password hashes are left out of the documents, unless credentials is set.
+func (m *ArchiveModel) Select(
+	ctx context.Context,
+	kind string,
+	forumID uuid.NullUUID,
+	after json.RawMessage,
+	limit int,
+	credentials bool,
+) ([]json.RawMessage, error) {
+	table, err := LookupArchiveTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+SELECT CASE WHEN $4::BOOLEAN THEN to_jsonb(t) ELSE to_jsonb(t) - 'password_hash' END
+           - 'search_vector' %s
+FROM %s t
+WHERE ($1::UUID IS NULL OR %s)
+  AND ($2::JSONB IS NULL OR (%s) > (SELECT %s FROM jsonb_populate_record(NULL::%s, $2::JSONB) c))
+ORDER BY %s
+LIMIT $3::INTEGER;
+`,
+		table.document,
+		table.identifier(),
+		table.scope,
+		columns("t", table.order),
+		columns("c", table.order),
+		table.identifier(),
+		columns("t", table.order),
+	)
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("kind", kind),
+		slog.Any("forumId", forumID),
+		slog.Int("limit", limit),
+		slog.Bool("credentials", credentials),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	var cursor *string
+	if after != nil {
+		s := string(after)
+		cursor = &s
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, forumID, cursor, limit, credentials)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+
+	documents, err := pgx.CollectRows(rows, pgx.RowTo[json.RawMessage])
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("archived documents selected", slog.Int("documents", len(documents)))
+
+	return documents, nil
+}
+
+// Insert restores an archived document of the given kind, writing only the given columns. When
+// the document collides with an existing row on the conflict target, the conflict is resolved as
+// given by conflict. Without a target, skipping resolves collisions on any unique constraint,
+// while overwriting resolves collisions on the primary key.
+//
+// The ID of the written row is returned, which is null for rows without an ID column of their
+// own. If the document is skipped, ErrRecordNotFound is returned.
+func (m *ArchiveModel) Insert(
+	ctx context.Context,
+	kind string,
+	names []string,
+	document json.RawMessage,
+	target []string,
+	conflict string,
+) (uuid.NullUUID, error) {
+	table, err := LookupArchiveTable(kind)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	var onConflict string
+	switch {
+	case conflict == ArchiveConflictSkip && len(target) == 0:
+		onConflict = "ON CONFLICT DO NOTHING"
+	case conflict == ArchiveConflictSkip:
+		onConflict = fmt.Sprintf(
+			"ON CONFLICT (%s) DO NOTHING", strings.Join(identifiers(target), ", "),
+		)
+	case conflict == ArchiveConflictOverwrite:
+		if len(target) == 0 {
+			target = table.Key
+		}
+		assignments := []string{}
+		for _, name := range names {
+			if slices.Contains(table.Key, name) || slices.Contains(target, name) {
+				continue
+			}
+			column := pgx.Identifier{name}.Sanitize()
+			assignments = append(assignments, column+" = excluded."+column)
+		}
+		onConflict = fmt.Sprintf(
+			"ON CONFLICT (%s) DO UPDATE SET %s",
+			strings.Join(identifiers(target), ", "),
+			strings.Join(assignments, ", "),
+		)
+		if len(assignments) == 0 {
+			onConflict = fmt.Sprintf(
+				"ON CONFLICT (%s) DO NOTHING", strings.Join(identifiers(target), ", "),
+			)
+		}
+	}
+
+	returning := "NULL::UUID"
+	if table.Identified() {
+		returning = "t.id"
+	}
+
+	query := fmt.Sprintf(`
+INSERT INTO %s AS t (%s)
+SELECT %s
+FROM jsonb_populate_record(NULL::%s, $1::JSONB) c
+%s
+RETURNING %s;
+`,
+		table.identifier(),
+		strings.Join(identifiers(names), ", "),
+		columns("c", names),
+		table.identifier(),
+		onConflict,
+		returning,
+	)
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("kind", kind),
+		slog.String("conflict", conflict),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var id uuid.NullUUID
+	err = m.DB.QueryRow(ctx, query, string(document)).Scan(&id)
+	if err != nil {
+		return uuid.NullUUID{}, handleError(err, logger)
+	}
+	logger.Info("archived document restored", slog.Any("id", id))
+
+	return id, nil
+}
+
+// SelectByNaturalKey returns the ID of the existing row sharing the natural key of the archived
+// document.
+func (m *ArchiveModel) SelectByNaturalKey(
+	ctx context.Context,
+	kind string,
+	document json.RawMessage,
+) (*uuid.UUID, error) {
+	table, err := LookupArchiveTable(kind)
+	if err != nil {
+		return nil, err
+	}
+	if table.NaturalKey == "" || !table.Identified() {
+		return nil, ErrRecordNotFound
+	}
+
+	query := fmt.Sprintf(`
+SELECT t.id
+FROM %s t
+WHERE t.%s = ($1::JSONB ->> $2::TEXT);
+`,
+		table.identifier(),
+		pgx.Identifier{table.NaturalKey}.Sanitize(),
+	)
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("kind", kind),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var id uuid.UUID
+	err = m.DB.QueryRow(ctx, query, string(document), table.NaturalKey).Scan(&id)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("row selected by natural key", slog.String("id", id.String()))
+
+	return &id, nil
+}
+
+func identifiers(names []string) []string {
+	sanitized := make([]string, len(names))
+	for i, name := range names {
+		sanitized[i] = pgx.Identifier{name}.Sanitize()
+	}
+
+	return sanitized
+}
@@ -0,0 +1,124 @@
+package data_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Kerry Eurodyne",
+		Username: "kerry",
+		Email:    "kerry@samurai.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := models.Forums.Insert(ctx, data.ForumInput{
+		OwnerID: user.ID,
+		Name:    "Samurai reunion",
+	})
+	assert.NoError(t, err)
+
+	thread, err := models.Threads.Insert(ctx, data.ThreadInput{
+		AuthorID: user.ID,
+		ForumID:  forum.ID,
+		Title:    "Setlist for the Arasaka show",
+	})
+	assert.NoError(t, err)
+
+	_, err = models.ThreadVotes.Vote(ctx, data.ThreadVote{
+		ThreadID: thread.ID,
+		UserID:   user.ID,
+		Vote:     1,
+	})
+	assert.NoError(t, err)
+
+	forumID := uuid.NullUUID{UUID: forum.ID, Valid: true}
+
+	t.Run("Columns", func(t *testing.T) {
+		columns, err := models.Archive.Columns(ctx, data.ArchiveKindThreads)
+		assert.NoError(t, err)
+		assert.Contains(t, columns, "title")
+		assert.NotContains(t, columns, "search_vector")
+
+		_, err = models.Archive.Columns(ctx, "implants")
+		assert.ErrorIs(t, err, data.ErrUnknownArchiveKind)
+	})
+
+	t.Run("Select", func(t *testing.T) {
+		users, err := models.Archive.Select(ctx, data.ArchiveKindUsers, forumID, nil, 10, false)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+
+		var document map[string]any
+		assert.NoError(t, json.Unmarshal(users[0], &document))
+		assert.Equal(t, user.ID.String(), document["id"])
+		assert.NotContains(t, document, "password_hash")
+
+		votes, err := models.Archive.Select(
+			ctx, data.ArchiveKindThreadVotes, forumID, nil, 10, false,
+		)
+		assert.NoError(t, err)
+		assert.Len(t, votes, 1)
+
+		after, err := models.Archive.Select(
+			ctx, data.ArchiveKindThreadVotes, forumID, votes[0], 10, false,
+		)
+		assert.NoError(t, err)
+		assert.Empty(t, after)
+	})
+
+	t.Run("Insert", func(t *testing.T) {
+		threads, err := models.Archive.Select(ctx, data.ArchiveKindThreads, forumID, nil, 10, false)
+		assert.NoError(t, err)
+		assert.Len(t, threads, 1)
+		names, err := models.Archive.Columns(ctx, data.ArchiveKindThreads)
+		assert.NoError(t, err)
+
+		_, err = models.Archive.Insert(
+			ctx, data.ArchiveKindThreads, names, threads[0], nil, data.ArchiveConflictSkip,
+		)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+
+		_, err = models.Archive.Insert(
+			ctx, data.ArchiveKindThreads, names, threads[0], nil, data.ArchiveConflictFail,
+		)
+		assert.ErrorIs(t, err, data.ErrUniqueConstraintViolation)
+
+		var document map[string]any
+		assert.NoError(t, json.Unmarshal(threads[0], &document))
+		document["title"] = "Setlist for the Arasaka show, final"
+		overwritten, err := json.Marshal(document)
+		assert.NoError(t, err)
+
+		id, err := models.Archive.Insert(
+			ctx, data.ArchiveKindThreads, names, overwritten, nil, data.ArchiveConflictOverwrite,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, thread.ID, id.UUID)
+
+		selected, err := models.Threads.Select(ctx, forum.ID, thread.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Setlist for the Arasaka show, final", selected.Title)
+	})
+
+	t.Run("SelectByNaturalKey", func(t *testing.T) {
+		document := json.RawMessage(`{"id":"` + uuid.New().String() + `","username":"kerry"}`)
+
+		id, err := models.Archive.SelectByNaturalKey(ctx, data.ArchiveKindUsers, document)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, *id)
+
+		_, err = models.Archive.SelectByNaturalKey(ctx, data.ArchiveKindForums, document)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,234 @@
+package data
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrInvalidOrderBy = errors.New("invalid order by column")
+)
+
+// Cursor marks a position within an ordered result set.
+//
+// A cursor carries the ordering it was created for, and the values of every ordered column of
+// the row it points at, so that the next query can continue from the row without relying on
+// offsets. Clients receive cursors as opaque strings, see Encode and DecodeCursor.
+type Cursor struct {
+	// OrderBy is the ordering the cursor was created for.
+	OrderBy []string `json:"o,omitzero"`
+	// Values are the values of the ordered columns of the row, formatted as text.
+	Values []string `json:"v,omitzero"`
+	// ID is the unique identifier of the row, used as the final tie-breaker.
+	ID uuid.UUID `json:"id"`
+	// Backward denotes that rows positioned before the cursor are requested.
+	Backward bool `json:"b,omitzero"`
+}
+
+// Encode returns the cursor as an opaque, URL safe string.
+func (c *Cursor) Encode() string {
+	// Marshalling a struct of strings, UUIDs and booleans cannot fail.
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Matches reports whether the cursor was created for the given ordering.
+func (c *Cursor) Matches(orderBy []string) bool {
+	return slices.Equal(c.OrderBy, orderBy)
+}
+
+// DecodeCursor parses a cursor previously returned by Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if len(c.Values) != len(c.OrderBy) {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// sortColumns maps the columns a result set may be ordered by to their SQL types.
+type sortColumns map[string]string
+
+// safeList returns every permitted order by value, ascending and descending, for the columns.
+func (s sortColumns) safeList() []string {
+	list := make([]string, 0, len(s)*2)
+	for column := range s {
+		list = append(list, column, "-"+column)
+	}
+	sort.Strings(list)
+
+	return list
+}
+
+// keyset is implemented by rows that can be paginated with cursors.
+type keyset interface {
+	keysetID() uuid.UUID
+	keysetValue(column string) any
+}
+
+// createKeysetClauses creates the SQL condition selecting the rows positioned after the cursor
+// in the filters, alongside the matching ORDER BY statement. Placeholders in the condition are
+// numbered from offset, and the values for the placeholders are returned as arguments.
+//
+// The condition is empty if the filters carry no cursor.
+func createKeysetClauses(
+	filters Filters,
+	columns sortColumns,
+	offset int,
+) (string, string, []any, error) {
+	for _, item := range filters.OrderBy {
+		if _, ok := columns[strings.TrimPrefix(item, "-")]; !ok {
+			return "", "", nil, fmt.Errorf("%w: %s", ErrInvalidOrderBy, item)
+		}
+	}
+
+	cursor := filters.Cursor
+	if cursor == nil {
+		return "", CreateOrderByClause(filters.OrderBy, false), nil, nil
+	}
+	if !cursor.Matches(filters.OrderBy) {
+		return "", "", nil, ErrInvalidCursor
+	}
+
+	args := make([]any, 0, len(cursor.Values)+1)
+	equal := make([]string, 0, len(cursor.Values)+1)
+	terms := make([]string, 0, len(cursor.Values)+1)
+
+	for i, item := range cursor.OrderBy {
+		column := strings.TrimPrefix(item, "-")
+		if err := checkCursorValue(columns[column], cursor.Values[i]); err != nil {
+			return "", "", nil, fmt.Errorf("%w: %s %w", ErrInvalidCursor, column, err)
+		}
+		placeholder := fmt.Sprintf("$%d::TEXT::%s", offset+i, columns[column])
+		op := keysetOperator(strings.HasPrefix(item, "-"), cursor.Backward)
+
+		terms = append(terms, strings.Join(append(equal, column+op+placeholder), " AND "))
+		equal = append(equal, column+" = "+placeholder)
+		args = append(args, cursor.Values[i])
+	}
+	placeholder := fmt.Sprintf("$%d::UUID", offset+len(cursor.Values))
+	op := keysetOperator(false, cursor.Backward)
+	terms = append(terms, strings.Join(append(equal, "id"+op+placeholder), " AND "))
+	args = append(args, cursor.ID)
+
+	where := "AND ((" + strings.Join(terms, ") OR (") + "))"
+
+	return where, CreateOrderByClause(filters.OrderBy, cursor.Backward), args, nil
+}
+
+func keysetOperator(descending bool, backward bool) string {
+	if descending != backward {
+		return " < "
+	}
+	return " > "
+}
+
+// paginate trims the extra row fetched to detect further pages, restores the requested order of
+// rows fetched backwards, and creates the metadata describing the page.
+//
+// Queries must fetch one row more than the page size for the metadata to be accurate.
+func paginate[T keyset](rows []T, filters Filters) ([]T, Metadata) {
+	var metadata Metadata
+	backward := filters.Cursor != nil && filters.Cursor.Backward
+
+	more := len(rows) > filters.PageSize
+	if more {
+		rows = rows[:filters.PageSize]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	switch {
+	case backward:
+		metadata.Next = true
+		metadata.Prev = more
+	default:
+		metadata.Next = more
+		metadata.Prev = filters.Cursor != nil
+	}
+
+	length := len(rows)
+	if length > 0 {
+		if metadata.Next {
+			metadata.NextCursor = newCursor(rows[length-1], filters.OrderBy, false).Encode()
+		}
+		if metadata.Prev {
+			metadata.PrevCursor = newCursor(rows[0], filters.OrderBy, true).Encode()
+		}
+	}
+	metadata.ResponseLength = length
+
+	return rows, metadata
+}
+
+func newCursor(row keyset, orderBy []string, backward bool) *Cursor {
+	cursor := Cursor{
+		OrderBy:  orderBy,
+		Values:   make([]string, len(orderBy)),
+		ID:       row.keysetID(),
+		Backward: backward,
+	}
+	for i, item := range orderBy {
+		cursor.Values[i] = formatCursorValue(row.keysetValue(strings.TrimPrefix(item, "-")))
+	}
+
+	return &cursor
+}
+
+// checkCursorValue verifies that the value of a cursor can be cast to the SQL type of its
+// column. Cursors are given by clients, and may have been tampered with, in which case the cast
+// would fail within the query.
+func checkCursorValue(sqlType string, value string) error {
+	var err error
+	switch sqlType {
+	case "TIMESTAMP":
+		_, err = time.Parse(time.RFC3339Nano, value)
+	case "INTEGER":
+		_, err = strconv.ParseInt(value, 10, 32)
+	case "BIGINT":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "REAL":
+		_, err = strconv.ParseFloat(value, 32)
+	default:
+		if strings.ContainsRune(value, 0) {
+			err = errors.New("text contains a null character")
+		}
+	}
+
+	return err
+}
+
+func formatCursorValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
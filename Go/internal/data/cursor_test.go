@@ -0,0 +1,108 @@
+package data_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Kerry Eurodyne",
+		Username: "k.eurodyne",
+		Email:    "k.eurodyne@samurai.com",
+	})
+	assert.NoError(t, err)
+
+	for _, name := range []string{"Chippin' In", "Never Fade Away", "A Like Supreme"} {
+		_, err := models.Forums.Insert(ctx, data.ForumInput{OwnerID: user.ID, Name: name})
+		assert.NoError(t, err)
+	}
+
+	t.Run("EncodeDecode", func(t *testing.T) {
+		cursor := data.Cursor{
+			OrderBy:  []string{"-name"},
+			Values:   []string{"Never Fade Away"},
+			ID:       user.ID,
+			Backward: true,
+		}
+
+		decoded, err := data.DecodeCursor(cursor.Encode())
+		assert.NoError(t, err)
+		assert.Equal(t, cursor, *decoded)
+
+		_, err = data.DecodeCursor("not a cursor")
+		assert.ErrorIs(t, err, data.ErrInvalidCursor)
+	})
+
+	t.Run("Paginate", func(t *testing.T) {
+		filters := data.Filters{OwnerID: &user.ID, OrderBy: []string{"-name"}, PageSize: 2}
+
+		first, metadata, err := models.Forums.SelectAll(ctx, filters)
+		assert.NoError(t, err)
+		assert.Len(t, first, 2)
+		assert.Equal(t, "Never Fade Away", first[0].Name)
+		assert.True(t, metadata.Next)
+		assert.False(t, metadata.Prev)
+
+		filters.Cursor, err = data.DecodeCursor(metadata.NextCursor)
+		assert.NoError(t, err)
+
+		second, metadata, err := models.Forums.SelectAll(ctx, filters)
+		assert.NoError(t, err)
+		assert.Len(t, second, 1)
+		assert.Equal(t, "A Like Supreme", second[0].Name)
+		assert.False(t, metadata.Next)
+		assert.True(t, metadata.Prev)
+
+		filters.Cursor, err = data.DecodeCursor(metadata.PrevCursor)
+		assert.NoError(t, err)
+
+		previous, metadata, err := models.Forums.SelectAll(ctx, filters)
+		assert.NoError(t, err)
+		assert.Equal(t, first, previous)
+		assert.True(t, metadata.Next)
+		assert.False(t, metadata.Prev)
+	})
+
+	t.Run("TamperedValues", func(t *testing.T) {
+		for _, value := range []string{"yesterday", "2077-13-45T00:00:00Z", "\x00"} {
+			_, _, err := models.Forums.SelectAll(ctx, data.Filters{
+				OwnerID:  &user.ID,
+				OrderBy:  []string{"-created_at"},
+				PageSize: 1,
+				Cursor: &data.Cursor{
+					OrderBy: []string{"-created_at"},
+					Values:  []string{value},
+					ID:      user.ID,
+				},
+			})
+			assert.ErrorIs(t, err, data.ErrInvalidCursor, value)
+		}
+
+		_, _, err := models.Forums.SelectAll(ctx, data.Filters{
+			OwnerID:  &user.ID,
+			OrderBy:  []string{"name"},
+			PageSize: 1,
+			Cursor: &data.Cursor{
+				OrderBy: []string{"name"},
+				Values:  []string{"Night\x00City"},
+				ID:      user.ID,
+			},
+		})
+		assert.ErrorIs(t, err, data.ErrInvalidCursor)
+	})
+
+	t.Run("InvalidOrderBy", func(t *testing.T) {
+		_, _, err := models.Forums.SelectAll(
+			ctx, data.Filters{OrderBy: []string{"id; DROP TABLE forum.forums"}, PageSize: 1},
+		)
+		assert.ErrorIs(t, err, data.ErrInvalidOrderBy)
+	})
+}
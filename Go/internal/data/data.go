@@ -1,13 +1,17 @@
 package data
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
 )
 
 var (
@@ -43,29 +47,77 @@ const (
 
 // CreateOrderByClause creates a SQL statement for ordering based on a given
 // list of column names. Column names prefixed in "-" will be in a descending
-// order.
+// order. If backward is set, every direction is reversed, which is used to
+// fetch the rows positioned before a cursor.
 //
 // This function always adds an "id" column at the end to guarantee the order
 // of cursor SQL queries.
-func CreateOrderByClause(orderBy []string) string {
-	length := len(orderBy)
-	if length == 0 {
-		return "ORDER BY id"
+func CreateOrderByClause(orderBy []string, backward bool) string {
+	asc, desc := " ASC", " DESC"
+	if backward {
+		asc, desc = desc, asc
 	}
 
-	orderClauses := make([]string, length+1)
+	orderClauses := make([]string, len(orderBy)+1)
 	for i, item := range orderBy {
 		if strings.HasPrefix(item, "-") {
-			orderClauses[i] = strings.TrimPrefix(item, "-") + " DESC"
+			orderClauses[i] = strings.TrimPrefix(item, "-") + desc
 		} else {
-			orderClauses[i] = item + " ASC"
+			orderClauses[i] = item + asc
 		}
 	}
-	orderClauses[len(orderClauses)-1] = "id ASC"
+	orderClauses[len(orderClauses)-1] = "id" + asc
 
 	return "ORDER BY " + strings.Join(orderClauses, ", ")
 }
 
+// selectTotals performs a query returning an ID alongside a total, such as the sum of votes or the
+// number of posts, for the given IDs. IDs without any rows to total are left out of the result.
+func selectTotals(
+	ctx context.Context,
+	db Querier,
+	timeout time.Duration,
+	query string,
+	ids []uuid.UUID,
+) (map[uuid.UUID]int, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Int("ids", len(ids)),
+		slog.Duration("timeout", timeout),
+	))
+
+	totals := make(map[uuid.UUID]int, len(ids))
+	if len(ids) == 0 {
+		return totals, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var total int
+		if err := rows.Scan(&id, &total); err != nil {
+			return nil, handleError(err, logger)
+		}
+		totals[id] = total
+	}
+	if err := rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("totals selected", slog.Int("length", len(totals)))
+
+	return totals, nil
+}
+
 func handleError(err error, logger *slog.Logger) error {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
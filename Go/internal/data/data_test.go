@@ -5,14 +5,14 @@ import (
 	"log"
 	"log/slog"
 	"os"
-	"path"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/database"
-	"github.com/r3d5un/rosetta/Go/internal/testsuite"
+	"github.com/r3d5un/rosetta/Go/internal/migrate"
+	"github.com/r3d5un/rosetta/migrations"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -35,22 +35,10 @@ func TestMain(m *testing.M) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	projectRoot, err := testsuite.FindProjectRoot()
-	if err != nil {
-		logger.Error("unable to find project root", slog.String("error", err.Error()))
-		return
-	}
-	upMigrationScripts, err := testsuite.ListUpMigrationScrips(path.Join(projectRoot, "migrations"))
-	if err != nil {
-		logger.Error("unable to find project root", slog.String("error", err.Error()))
-		return
-	}
-
 	logger.Info("creating PostgreSQL container")
 	dbContainer, err := postgres.Run(
 		ctx,
 		"postgres:17.4",
-		postgres.WithInitScripts(upMigrationScripts...),
 		postgres.WithDatabase(dbName),
 		postgres.WithUsername(dbUser),
 		postgres.WithPassword(dbPassword),
@@ -88,6 +76,16 @@ func TestMain(m *testing.M) {
 		logger.Error("unable to create database connection pool", slog.String("error", err.Error()))
 		return
 	}
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		logger.Error("unable to load migrations", slog.String("error", err.Error()))
+		return
+	}
+	if err := migrator.Up(ctx); err != nil {
+		logger.Error("unable to migrate database", slog.String("error", err.Error()))
+		return
+	}
 	timeout := dbConfig.TimeoutDuration()
 	models = data.NewModels(db, &timeout)
 
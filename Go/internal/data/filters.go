@@ -7,9 +7,13 @@ import (
 )
 
 type Metadata struct {
-	LastSeen       uuid.UUID `json:"lastSeen,omitzero"`
-	Next           bool      `json:"next"`
-	ResponseLength int       `json:"responseLength"`
+	// NextCursor is the cursor to request the following page with, if any.
+	NextCursor string `json:"nextCursor,omitzero"`
+	// PrevCursor is the cursor to request the preceding page with, if any.
+	PrevCursor     string `json:"prevCursor,omitzero"`
+	Next           bool   `json:"next"`
+	Prev           bool   `json:"prev"`
+	ResponseLength int    `json:"responseLength"`
 }
 
 type Filters struct {
@@ -32,9 +36,13 @@ type Filters struct {
 	DeletedAtTo   *time.Time `json:"deletedAtTo,omitzero"`
 	Deleted       *bool      `json:"deleted,omitzero"`
 	IsLocked      *bool      `json:"isLocked,omitzero"`
+	Query         *string    `json:"query,omitzero"`
+	Types         []string   `json:"types,omitzero"`
+	WebhookID     *uuid.UUID `json:"webhookId,omitzero"`
+	Statuses      []string   `json:"statuses,omitzero"`
 
-	OrderBy         []string  `json:"order_by,omitzero"`
-	OrderBySafeList []string  `json:"order_by_safe_list,omitzero"`
-	LastSeen        uuid.UUID `json:"lastSeen,omitzero"`
-	PageSize        int       `json:"page_size,omitzero"`
+	OrderBy         []string `json:"order_by,omitzero"`
+	OrderBySafeList []string `json:"order_by_safe_list,omitzero"`
+	Cursor          *Cursor  `json:"cursor,omitzero"`
+	PageSize        int      `json:"page_size,omitzero"`
 }
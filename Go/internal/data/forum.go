@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
 )
 
@@ -38,6 +38,32 @@ type Forum struct {
 	DeletedAt sql.NullTime `json:"deletedAt,omitzero"`
 }
 
+var forumSortColumns = sortColumns{
+	"name":       "VARCHAR(256)",
+	"created_at": "TIMESTAMP",
+	"updated_at": "TIMESTAMP",
+}
+
+// ForumOrderBySafeList lists the values forums may be ordered by.
+var ForumOrderBySafeList = forumSortColumns.safeList()
+
+func (f *Forum) keysetID() uuid.UUID {
+	return f.ID
+}
+
+func (f *Forum) keysetValue(column string) any {
+	switch column {
+	case "name":
+		return f.Name
+	case "created_at":
+		return f.CreatedAt
+	case "updated_at":
+		return f.UpdatedAt
+	default:
+		return nil
+	}
+}
+
 type ForumInput struct {
 	// OwnerID is the unique identifier of a forum.
 	OwnerID uuid.UUID `json:"ownerId"`
@@ -59,10 +85,13 @@ type ForumPatch struct {
 	Name sql.NullString `json:"name"`
 	// Description contains a description about the purposes and topics of a forum.
 	Description sql.NullString `json:"description,omitzero"`
+	// UpdatedAt is the time the forum is expected to have last been updated at. If set, the patch
+	// is only applied if the forum has not been updated since.
+	UpdatedAt sql.NullTime `json:"updatedAt,omitzero"`
 }
 
 type ForumModel struct {
-	DB      *pgxpool.Pool
+	DB      Querier
 	Timeout *time.Duration
 }
 
@@ -107,7 +136,67 @@ WHERE id = $1;
 	return &f, nil
 }
 
+// SelectMany retrieves the forums with the given IDs. IDs of forums that do not exist are ignored.
+func (m *ForumModel) SelectMany(ctx context.Context, ids []uuid.UUID) ([]*Forum, error) {
+	const query string = `
+SELECT id, owner_id, name, description, created_at, updated_at, deleted, deleted_at
+FROM forum.forums
+WHERE id = ANY ($1::UUID[]);
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Int("ids", len(ids)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	forums := []*Forum{}
+	if len(ids) == 0 {
+		return forums, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, ids)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f Forum
+		err := rows.Scan(
+			&f.ID,
+			&f.OwnerID,
+			&f.Name,
+			&f.Description,
+			&f.CreatedAt,
+			&f.UpdatedAt,
+			&f.Deleted,
+			&f.DeletedAt,
+		)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		forums = append(forums, &f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("forums selected", slog.Int("length", len(forums)))
+
+	return forums, nil
+}
+
 func (m *ForumModel) SelectAll(ctx context.Context, filters Filters) ([]*Forum, *Metadata, error) {
+	where, orderBy, keysetArgs, err := createKeysetClauses(filters, forumSortColumns, 12)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	query := `
 SELECT id, owner_id, name, description, created_at, updated_at, deleted, deleted_at
 FROM forum.forums
@@ -121,8 +210,8 @@ WHERE ($2::UUID IS NULL OR id = $2::UUID)
   AND ($9::BOOLEAN IS NULL or deleted = $9::BOOLEAN)
   AND ($10::TIMESTAMP IS NULL or deleted_at >= $10::TIMESTAMP)
   AND ($11::TIMESTAMP IS NULL or deleted_at <= $11::TIMESTAMP)
-  AND id > $12::UUID
-` + CreateOrderByClause(filters.OrderBy) + `
+` + where + `
+` + orderBy + `
 LIMIT $1::INTEGER
 `
 
@@ -135,11 +224,8 @@ LIMIT $1::INTEGER
 		slog.Any("filters", filters),
 	))
 
-	logger.Info("performing query")
-	rows, err := m.DB.Query(
-		ctx,
-		query,
-		filters.PageSize,
+	args := append([]any{
+		filters.PageSize + 1,
 		filters.ID,
 		filters.OwnerID,
 		filters.Name,
@@ -150,8 +236,10 @@ LIMIT $1::INTEGER
 		filters.Deleted,
 		filters.DeletedAtFrom,
 		filters.DeletedAtTo,
-		filters.LastSeen,
-	)
+	}, keysetArgs...)
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, args...)
 	if err != nil {
 		logger.Error("unable to perform query", slog.String("error", err.Error()))
 		return nil, nil, err
@@ -180,15 +268,7 @@ LIMIT $1::INTEGER
 	if err = rows.Err(); err != nil {
 		return nil, nil, handleError(err, logger)
 	}
-	length := len(forums)
-	var metadata Metadata
-	if length > 0 {
-		metadata.LastSeen = forums[length-1].ID
-	}
-	if length >= filters.PageSize {
-		metadata.Next = true
-	}
-	metadata.ResponseLength = length
+	forums, metadata := paginate(forums, filters)
 
 	logger.Info("forums selected", slog.Any("metadata", metadata))
 	return forums, &metadata, nil
@@ -245,6 +325,7 @@ SET name = COALESCE($2::VARCHAR(256), name),
     description = COALESCE($4::TEXT, description),
     updated_at = NOW()
 WHERE id = $1
+  AND ($5::TIMESTAMP IS NULL OR updated_at = $5::TIMESTAMP)
 RETURNING id, owner_id, name, description, created_at, updated_at, deleted, deleted_at;
 `
 
@@ -267,6 +348,7 @@ RETURNING id, owner_id, name, description, created_at, updated_at, deleted, dele
 		input.Name,
 		input.OwnerID,
 		input.Description,
+		input.UpdatedAt,
 	).Scan(
 		&f.ID,
 		&f.OwnerID,
@@ -285,13 +367,21 @@ RETURNING id, owner_id, name, description, created_at, updated_at, deleted, dele
 	return &f, nil
 }
 
-func (m *ForumModel) SoftDelete(ctx context.Context, id uuid.UUID) (*Forum, error) {
+// SoftDelete marks a forum as deleted. The threads of the forum, and the posts within them, are
+// marked as deleted alongside it as a single deletion batch. If updatedAt is set, the forum is
+// only deleted if it has not been updated since.
+func (m *ForumModel) SoftDelete(
+	ctx context.Context,
+	id uuid.UUID,
+	updatedAt sql.NullTime,
+) (*Forum, error) {
 	const query string = `
 UPDATE forum.forums
 SET deleted    = TRUE,
     deleted_at = NOW(),
     updated_at = NOW()
 WHERE id = $1
+  AND ($2::TIMESTAMP IS NULL OR updated_at = $2::TIMESTAMP)
 RETURNING id, owner_id, name, description, created_at, updated_at, deleted, deleted_at;
 `
 
@@ -299,6 +389,7 @@ RETURNING id, owner_id, name, description, created_at, updated_at, deleted, dele
 		"query",
 		slog.String("query", logging.MinifySQL(query)),
 		slog.String("id", id.String()),
+		slog.Any("updatedAt", updatedAt),
 		slog.Duration("timeout", *m.Timeout),
 	))
 
@@ -311,6 +402,7 @@ RETURNING id, owner_id, name, description, created_at, updated_at, deleted, dele
 		ctx,
 		query,
 		id,
+		updatedAt,
 	).Scan(
 		&f.ID,
 		&f.OwnerID,
@@ -329,6 +421,8 @@ RETURNING id, owner_id, name, description, created_at, updated_at, deleted, dele
 	return &f, nil
 }
 
+// Restore restores a forum, alongside the threads and posts hidden when the forum was deleted.
+// Threads and posts deleted on their own remain deleted.
 func (m *ForumModel) Restore(ctx context.Context, id uuid.UUID) (*Forum, error) {
 	const query string = `
 UPDATE forum.forums
@@ -414,3 +508,34 @@ RETURNING id, owner_id, name, description, created_at, updated_at, deleted, dele
 
 	return &f, nil
 }
+
+// Copy bulk inserts forums with their IDs and timestamps as given, returning the number of forums
+// inserted.
+func (m *ForumModel) Copy(ctx context.Context, forums []*Forum) (int64, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("table", "forum.forums"),
+		slog.Int("rows", len(forums)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing copy")
+	n, err := m.DB.CopyFrom(
+		ctx,
+		pgx.Identifier{"forum", "forums"},
+		[]string{"id", "owner_id", "name", "description", "created_at", "updated_at"},
+		pgx.CopyFromSlice(len(forums), func(i int) ([]any, error) {
+			f := forums[i]
+			return []any{f.ID, f.OwnerID, f.Name, f.Description, f.CreatedAt, f.UpdatedAt}, nil
+		}),
+	)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("forums copied", slog.Int64("rowsAffected", n))
+
+	return n, nil
+}
@@ -48,18 +48,19 @@ func TestForumModel(t *testing.T) {
 		}
 	})
 
+	t.Run("SelectMany", func(t *testing.T) {
+		forums, err := models.Forums.SelectMany(ctx, []uuid.UUID{forum.ID, uuid.New()})
+		assert.NoError(t, err)
+		assert.Equal(t, []*data.Forum{&forum}, forums)
+	})
+
 	t.Run("SelectAll", func(t *testing.T) {
 		forums, metadata, err := models.Forums.SelectAll(ctx, data.Filters{PageSize: 100})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, forums)
 		assert.NotEmpty(t, metadata)
-		if !assert.Equal(t, forums[len(forums)-1].ID, metadata.LastSeen) {
-			t.Errorf(
-				"last seen ID %s does not match the last selected user ID %s\n",
-				metadata.LastSeen,
-				forums[len(forums)-1].ID,
-			)
-		}
+		assert.Equal(t, len(forums), metadata.ResponseLength)
+		assert.False(t, metadata.Prev)
 	})
 
 	t.Run("Update", func(t *testing.T) {
@@ -73,8 +74,34 @@ func TestForumModel(t *testing.T) {
 		assert.Equal(t, newName, updatedForum.Name)
 	})
 
+	t.Run("UpdateConditionally", func(t *testing.T) {
+		current, err := models.Forums.Select(ctx, forum.ID)
+		assert.NoError(t, err)
+
+		newName := "Surviving Militech, again"
+		updatedForum, err := models.Forums.Update(ctx, data.ForumPatch{
+			ID:        forum.ID,
+			Name:      sql.NullString{Valid: true, String: newName},
+			UpdatedAt: sql.NullTime{Valid: true, Time: current.UpdatedAt},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, newName, updatedForum.Name)
+
+		_, err = models.Forums.Update(ctx, data.ForumPatch{
+			ID:        forum.ID,
+			Name:      sql.NullString{Valid: true, String: "Surviving Arasaka"},
+			UpdatedAt: sql.NullTime{Valid: true, Time: current.UpdatedAt},
+		})
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
 	t.Run("SoftDelete", func(t *testing.T) {
-		deletedForum, err := models.Forums.SoftDelete(ctx, forum.ID)
+		_, err := models.Forums.SoftDelete(
+			ctx, forum.ID, sql.NullTime{Valid: true, Time: time.Unix(0, 0)},
+		)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+
+		deletedForum, err := models.Forums.SoftDelete(ctx, forum.ID, sql.NullTime{})
 		assert.NoError(t, err)
 		assert.Equal(t, deletedForum.Deleted, true)
 	})
@@ -91,3 +118,90 @@ func TestForumModel(t *testing.T) {
 		assert.NotEmpty(t, deletedForum)
 	})
 }
+
+func TestForumModelCascade(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Hanako Arasaka",
+		Username: "h.arasaka",
+		Email:    "h.arasaka@arasaka.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := models.Forums.Insert(ctx, data.ForumInput{
+		OwnerID: user.ID,
+		Name:    "Embers",
+	})
+	assert.NoError(t, err)
+
+	thread, err := models.Threads.Insert(ctx, data.ThreadInput{
+		AuthorID: user.ID,
+		ForumID:  forum.ID,
+		Title:    "Dinner reservations",
+	})
+	assert.NoError(t, err)
+
+	post, err := models.Posts.Insert(ctx, data.PostInput{
+		ThreadID: thread.ID,
+		Content:  "A table for two, overlooking the city",
+		AuthorID: user.ID,
+	})
+	assert.NoError(t, err)
+
+	removedPost, err := models.Posts.Insert(ctx, data.PostInput{
+		ThreadID: thread.ID,
+		Content:  "Bring a gun",
+		AuthorID: user.ID,
+	})
+	assert.NoError(t, err)
+	_, err = models.Posts.SoftDelete(ctx, removedPost.ID)
+	assert.NoError(t, err)
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		_, err := models.Forums.SoftDelete(ctx, forum.ID, sql.NullTime{})
+		assert.NoError(t, err)
+
+		th, err := models.Threads.Select(ctx, forum.ID, thread.ID)
+		assert.NoError(t, err)
+		assert.True(t, th.Deleted)
+
+		p, err := models.Posts.Select(ctx, thread.ID, post.ID)
+		assert.NoError(t, err)
+		assert.True(t, p.Deleted)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		_, err := models.Forums.Restore(ctx, forum.ID)
+		assert.NoError(t, err)
+
+		th, err := models.Threads.Select(ctx, forum.ID, thread.ID)
+		assert.NoError(t, err)
+		assert.False(t, th.Deleted)
+
+		p, err := models.Posts.Select(ctx, thread.ID, post.ID)
+		assert.NoError(t, err)
+		assert.False(t, p.Deleted)
+
+		p, err = models.Posts.Select(ctx, thread.ID, removedPost.ID)
+		assert.NoError(t, err)
+		assert.True(t, p.Deleted)
+	})
+
+	t.Run("RestoreThreadOnly", func(t *testing.T) {
+		_, err := models.Forums.SoftDelete(ctx, forum.ID, sql.NullTime{})
+		assert.NoError(t, err)
+
+		_, err = models.Threads.Restore(ctx, forum.ID, thread.ID)
+		assert.NoError(t, err)
+
+		p, err := models.Posts.Select(ctx, thread.ID, post.ID)
+		assert.NoError(t, err)
+		assert.False(t, p.Deleted)
+
+		f, err := models.Forums.Select(ctx, forum.ID)
+		assert.NoError(t, err)
+		assert.True(t, f.Deleted)
+	})
+}
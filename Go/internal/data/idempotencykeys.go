@@ -0,0 +1,286 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// IdempotencyKey records a request made with an Idempotency-Key header, alongside the response to
+// it, so that retries of the request can be answered with the same response.
+type IdempotencyKey struct {
+	// UserID is the user which made the request. Keys are unique per user.
+	UserID uuid.UUID `json:"userId"`
+	// Key is the value of the Idempotency-Key header chosen by the client.
+	Key string `json:"key"`
+	// Fingerprint is a hash of the request, used to reject reuse of the key for other requests.
+	Fingerprint string `json:"fingerprint"`
+	// Status is the HTTP status code of the response. Null while the request is being processed.
+	Status sql.NullInt32 `json:"status"`
+	// Headers are the headers of the response, as a JSON object of header names to values.
+	Headers json.RawMessage `json:"headers"`
+	// Body is the body of the response.
+	Body []byte `json:"body"`
+	// CreatedAt denotes when the request was first received.
+	CreatedAt time.Time `json:"createdAt"`
+	// ExpiresAt denotes when the key may be reused for another request.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type IdempotencyKeyInput struct {
+	// UserID is the user which made the request.
+	UserID uuid.UUID `json:"userId"`
+	// Key is the value of the Idempotency-Key header chosen by the client.
+	Key string `json:"key"`
+	// Fingerprint is a hash of the request.
+	Fingerprint string `json:"fingerprint"`
+	// Lease is the duration the key is reserved for while the request is processed, after which
+	// it may be taken over by a retry.
+	Lease time.Duration `json:"lease"`
+}
+
+type IdempotencyKeyPatch struct {
+	// UserID is the user which made the request.
+	UserID uuid.UUID `json:"userId"`
+	// Key is the value of the Idempotency-Key header chosen by the client.
+	Key string `json:"key"`
+	// Status is the HTTP status code of the response.
+	Status int32 `json:"status"`
+	// Headers are the headers of the response, as a JSON object of header names to values.
+	Headers json.RawMessage `json:"headers"`
+	// Body is the body of the response.
+	Body []byte `json:"body"`
+	// TTL is the duration the response is kept for.
+	TTL time.Duration `json:"ttl"`
+}
+
+type IdempotencyKeyModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+// Insert reserves a key for a request about to be processed. Expired keys are taken over by the
+// new request. If the key is already reserved, ErrRecordNotFound is returned.
+func (m *IdempotencyKeyModel) Insert(
+	ctx context.Context,
+	input IdempotencyKeyInput,
+) (*IdempotencyKey, error) {
+	const query string = `
+INSERT INTO forum.idempotency_keys (user_id, key, fingerprint, expires_at)
+VALUES ($1::UUID,
+        $2::VARCHAR(256),
+        $3::VARCHAR(64),
+        NOW() + make_interval(secs => $4::DOUBLE PRECISION))
+ON CONFLICT (user_id, key) DO UPDATE
+    SET fingerprint = excluded.fingerprint,
+        status      = NULL,
+        headers     = NULL,
+        body        = NULL,
+        created_at  = NOW(),
+        expires_at  = excluded.expires_at
+WHERE idempotency_keys.expires_at < NOW()
+RETURNING user_id, key, fingerprint, status, headers, body, created_at, expires_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("userId", input.UserID.String()),
+		slog.String("key", input.Key),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var k IdempotencyKey
+	err := m.DB.QueryRow(
+		ctx,
+		query,
+		input.UserID,
+		input.Key,
+		input.Fingerprint,
+		input.Lease.Seconds(),
+	).Scan(
+		&k.UserID,
+		&k.Key,
+		&k.Fingerprint,
+		&k.Status,
+		&k.Headers,
+		&k.Body,
+		&k.CreatedAt,
+		&k.ExpiresAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("idempotency key inserted")
+
+	return &k, nil
+}
+
+func (m *IdempotencyKeyModel) Select(
+	ctx context.Context,
+	userID uuid.UUID,
+	key string,
+) (*IdempotencyKey, error) {
+	const query string = `
+SELECT user_id, key, fingerprint, status, headers, body, created_at, expires_at
+FROM forum.idempotency_keys
+WHERE user_id = $1::UUID
+  AND key = $2::VARCHAR(256);
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("userId", userID.String()),
+		slog.String("key", key),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var k IdempotencyKey
+	err := m.DB.QueryRow(ctx, query, userID, key).Scan(
+		&k.UserID,
+		&k.Key,
+		&k.Fingerprint,
+		&k.Status,
+		&k.Headers,
+		&k.Body,
+		&k.CreatedAt,
+		&k.ExpiresAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("idempotency key selected")
+
+	return &k, nil
+}
+
+// Update stores the response to the request a key was reserved for, extending the expiry of the
+// key by the TTL of the response.
+func (m *IdempotencyKeyModel) Update(
+	ctx context.Context,
+	input IdempotencyKeyPatch,
+) (*IdempotencyKey, error) {
+	const query string = `
+UPDATE forum.idempotency_keys
+SET status     = $3::INTEGER,
+    headers    = $4::JSONB,
+    body       = $5::BYTEA,
+    expires_at = NOW() + make_interval(secs => $6::DOUBLE PRECISION)
+WHERE user_id = $1::UUID
+  AND key = $2::VARCHAR(256)
+RETURNING user_id, key, fingerprint, status, headers, body, created_at, expires_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("userId", input.UserID.String()),
+		slog.String("key", input.Key),
+		slog.Int("status", int(input.Status)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var k IdempotencyKey
+	err := m.DB.QueryRow(
+		ctx,
+		query,
+		input.UserID,
+		input.Key,
+		input.Status,
+		input.Headers,
+		input.Body,
+		input.TTL.Seconds(),
+	).Scan(
+		&k.UserID,
+		&k.Key,
+		&k.Fingerprint,
+		&k.Status,
+		&k.Headers,
+		&k.Body,
+		&k.CreatedAt,
+		&k.ExpiresAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("idempotency key updated")
+
+	return &k, nil
+}
+
+func (m *IdempotencyKeyModel) Delete(ctx context.Context, userID uuid.UUID, key string) error {
+	const query string = `
+DELETE
+FROM forum.idempotency_keys
+WHERE user_id = $1::UUID
+  AND key = $2::VARCHAR(256);
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("userId", userID.String()),
+		slog.String("key", key),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	tag, err := m.DB.Exec(ctx, query, userID, key)
+	if err != nil {
+		return handleError(err, logger)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+	logger.Info("idempotency key deleted")
+
+	return nil
+}
+
+// DeleteExpired deletes every expired key, returning the number of deleted keys.
+func (m *IdempotencyKeyModel) DeleteExpired(ctx context.Context) (int64, error) {
+	const query string = `
+DELETE
+FROM forum.idempotency_keys
+WHERE expires_at < NOW();
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	tag, err := m.DB.Exec(ctx, query)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("expired idempotency keys deleted", slog.Int64("rowsAffected", tag.RowsAffected()))
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,108 @@
+package data_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyKeyModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := models.Users.Insert(ctx, data.UserInput{
+		Name:         "Rogue Amendiares",
+		Username:     "r.amendiares",
+		Email:        "r.amendiares@afterlife.com",
+		PasswordHash: []byte("not-a-real-hash"),
+	})
+	assert.NoError(t, err)
+
+	input := data.IdempotencyKeyInput{
+		UserID:      user.ID,
+		Key:         "afterlife-booth-1",
+		Fingerprint: "f1",
+		Lease:       time.Minute,
+	}
+
+	t.Run("Insert", func(t *testing.T) {
+		inserted, err := models.IdempotencyKeys.Insert(ctx, input)
+		assert.NoError(t, err)
+		assert.Equal(t, input.Fingerprint, inserted.Fingerprint)
+		assert.False(t, inserted.Status.Valid)
+		assert.True(t, inserted.ExpiresAt.After(inserted.CreatedAt))
+	})
+
+	t.Run("InsertReserved", func(t *testing.T) {
+		_, err := models.IdempotencyKeys.Insert(ctx, input)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		updated, err := models.IdempotencyKeys.Update(ctx, data.IdempotencyKeyPatch{
+			UserID:  user.ID,
+			Key:     input.Key,
+			Status:  201,
+			Headers: json.RawMessage(`{"Content-Type":["application/json"]}`),
+			Body:    []byte(`{"data":{}}`),
+			TTL:     time.Hour,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(201), updated.Status.Int32)
+		assert.True(t, updated.ExpiresAt.After(updated.CreatedAt.Add(time.Minute)))
+		assert.Equal(t, []byte(`{"data":{}}`), updated.Body)
+	})
+
+	t.Run("Select", func(t *testing.T) {
+		selected, err := models.IdempotencyKeys.Select(ctx, user.ID, input.Key)
+		assert.NoError(t, err)
+		assert.True(t, selected.Status.Valid)
+		assert.JSONEq(t, `{"Content-Type":["application/json"]}`, string(selected.Headers))
+	})
+
+	t.Run("InsertExpired", func(t *testing.T) {
+		expired := data.IdempotencyKeyInput{
+			UserID:      user.ID,
+			Key:         "afterlife-booth-2",
+			Fingerprint: "f1",
+			Lease:       -time.Hour,
+		}
+		_, err := models.IdempotencyKeys.Insert(ctx, expired)
+		assert.NoError(t, err)
+
+		expired.Fingerprint = "f2"
+		expired.Lease = time.Minute
+		inserted, err := models.IdempotencyKeys.Insert(ctx, expired)
+		assert.NoError(t, err)
+		assert.Equal(t, "f2", inserted.Fingerprint)
+	})
+
+	t.Run("DeleteExpired", func(t *testing.T) {
+		_, err := models.IdempotencyKeys.Insert(ctx, data.IdempotencyKeyInput{
+			UserID:      user.ID,
+			Key:         "afterlife-booth-3",
+			Fingerprint: "f1",
+			Lease:       -time.Hour,
+		})
+		assert.NoError(t, err)
+
+		deleted, err := models.IdempotencyKeys.DeleteExpired(ctx)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(1))
+
+		_, err = models.IdempotencyKeys.Select(ctx, user.ID, "afterlife-booth-3")
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		err := models.IdempotencyKeys.Delete(ctx, user.ID, input.Key)
+		assert.NoError(t, err)
+
+		err = models.IdempotencyKeys.Delete(ctx, user.ID, input.Key)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,240 @@
+package data
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+const (
+	// ImportIDModePreserve restores archived rows under their archived IDs.
+	ImportIDModePreserve string = "preserve"
+	// ImportIDModeRemap restores archived rows under newly generated IDs.
+	ImportIDModeRemap string = "remap"
+)
+
+// ImportIDModes lists the ways of assigning IDs to imported rows.
+var ImportIDModes = []string{ImportIDModePreserve, ImportIDModeRemap}
+
+// Import is the checkpoint of an archive being imported, allowing an interrupted import to be
+// resumed from the last line written.
+type Import struct {
+	ID uuid.UUID `json:"id"`
+	// IDMode denotes how IDs are assigned to imported rows.
+	IDMode string `json:"idMode"`
+	// OnConflict denotes how rows colliding with existing rows are resolved.
+	OnConflict string `json:"onConflict"`
+	// Line is the number of lines of the archive written so far.
+	Line int64 `json:"line"`
+	// Fingerprint is the hash of the lines written so far, verified against the archive given
+	// when resuming.
+	Fingerprint []byte     `json:"fingerprint"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+}
+
+type ImportInput struct {
+	IDMode     string `json:"idMode"`
+	OnConflict string `json:"onConflict"`
+}
+
+type ImportModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+func (m *ImportModel) Insert(ctx context.Context, input ImportInput) (*Import, error) {
+	const query string = `
+INSERT INTO forum.imports (id_mode, on_conflict)
+VALUES ($1::VARCHAR(16), $2::VARCHAR(16))
+RETURNING id, id_mode, on_conflict, line, fingerprint, created_at, updated_at, completed_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Any("input", input),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var i Import
+	err := m.DB.QueryRow(ctx, query, input.IDMode, input.OnConflict).Scan(
+		&i.ID,
+		&i.IDMode,
+		&i.OnConflict,
+		&i.Line,
+		&i.Fingerprint,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("import inserted", slog.Any("import", i))
+
+	return &i, nil
+}
+
+func (m *ImportModel) Select(ctx context.Context, id uuid.UUID) (*Import, error) {
+	const query string = `
+SELECT id, id_mode, on_conflict, line, fingerprint, created_at, updated_at, completed_at
+FROM forum.imports
+WHERE id = $1::UUID;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("id", id.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var i Import
+	err := m.DB.QueryRow(ctx, query, id).Scan(
+		&i.ID,
+		&i.IDMode,
+		&i.OnConflict,
+		&i.Line,
+		&i.Fingerprint,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("import selected", slog.Any("import", i))
+
+	return &i, nil
+}
+
+// Checkpoint records the number of lines of the archive written so far along with their
+// fingerprint, and the IDs the archived rows were written under. Setting completed marks the
+// import as completed.
+//
+// A null target in mappings records a skipped row.
+func (m *ImportModel) Checkpoint(
+	ctx context.Context,
+	id uuid.UUID,
+	line int64,
+	fingerprint []byte,
+	mappings map[uuid.UUID]uuid.NullUUID,
+	completed bool,
+) (*Import, error) {
+	const query string = `
+WITH mapped AS (
+    INSERT INTO forum.import_id_mappings (import_id, source_id, target_id)
+        SELECT $1::UUID, m.source_id::UUID, NULLIF(m.target_id, '')::UUID
+        FROM unnest($4::TEXT[], $5::TEXT[]) AS m(source_id, target_id)
+        ON CONFLICT (import_id, source_id) DO UPDATE SET target_id = excluded.target_id)
+UPDATE forum.imports
+SET line         = $2::BIGINT,
+    fingerprint  = $3::BYTEA,
+    updated_at   = NOW(),
+    completed_at = CASE WHEN $6::BOOLEAN THEN NOW() END
+WHERE id = $1::UUID
+RETURNING id, id_mode, on_conflict, line, fingerprint, created_at, updated_at, completed_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("id", id.String()),
+		slog.Int64("line", line),
+		slog.Int("mappings", len(mappings)),
+		slog.Bool("completed", completed),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	sources := make([]string, 0, len(mappings))
+	targets := make([]string, 0, len(mappings))
+	for source, target := range mappings {
+		sources = append(sources, source.String())
+		if target.Valid {
+			targets = append(targets, target.UUID.String())
+		} else {
+			targets = append(targets, "")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var i Import
+	err := m.DB.QueryRow(ctx, query, id, line, fingerprint, sources, targets, completed).Scan(
+		&i.ID,
+		&i.IDMode,
+		&i.OnConflict,
+		&i.Line,
+		&i.Fingerprint,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("import checkpoint recorded", slog.Any("import", i))
+
+	return &i, nil
+}
+
+// SelectMappings returns the IDs the archived rows of the import were written under, keyed by
+// their archived IDs. Skipped rows are mapped to null.
+func (m *ImportModel) SelectMappings(
+	ctx context.Context,
+	id uuid.UUID,
+) (map[uuid.UUID]uuid.NullUUID, error) {
+	const query string = `
+SELECT source_id, target_id
+FROM forum.import_id_mappings
+WHERE import_id = $1::UUID;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("id", id.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, id)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	defer rows.Close()
+
+	mappings := make(map[uuid.UUID]uuid.NullUUID)
+	for rows.Next() {
+		var source uuid.UUID
+		var target uuid.NullUUID
+		if err := rows.Scan(&source, &target); err != nil {
+			return nil, handleError(err, logger)
+		}
+		mappings[source] = target
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("import ID mappings selected", slog.Int("mappings", len(mappings)))
+
+	return mappings, nil
+}
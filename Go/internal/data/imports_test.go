@@ -0,0 +1,77 @@
+package data_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var imported *data.Import
+
+	t.Run("Insert", func(t *testing.T) {
+		var err error
+		imported, err = models.Imports.Insert(ctx, data.ImportInput{
+			IDMode:     data.ImportIDModeRemap,
+			OnConflict: data.ArchiveConflictSkip,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), imported.Line)
+		assert.Nil(t, imported.CompletedAt)
+
+		_, err = models.Imports.Insert(ctx, data.ImportInput{
+			IDMode:     "braindance",
+			OnConflict: data.ArchiveConflictSkip,
+		})
+		assert.ErrorIs(t, err, data.ErrCheckConstraintViolation)
+	})
+
+	source, target, skipped := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("Checkpoint", func(t *testing.T) {
+		mappings := map[uuid.UUID]uuid.NullUUID{
+			source:  {UUID: target, Valid: true},
+			skipped: {},
+		}
+		checkpoint, err := models.Imports.Checkpoint(
+			ctx, imported.ID, 42, []byte("relic"), mappings, false,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), checkpoint.Line)
+		assert.Equal(t, []byte("relic"), checkpoint.Fingerprint)
+		assert.Nil(t, checkpoint.CompletedAt)
+
+		checkpoint, err = models.Imports.Checkpoint(
+			ctx, imported.ID, 64, []byte("mikoshi"), nil, true,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(64), checkpoint.Line)
+		assert.NotNil(t, checkpoint.CompletedAt)
+	})
+
+	t.Run("Select", func(t *testing.T) {
+		selected, err := models.Imports.Select(ctx, imported.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(64), selected.Line)
+		assert.Equal(t, []byte("mikoshi"), selected.Fingerprint)
+
+		_, err = models.Imports.Select(ctx, uuid.New())
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("SelectMappings", func(t *testing.T) {
+		mappings, err := models.Imports.SelectMappings(ctx, imported.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, map[uuid.UUID]uuid.NullUUID{
+			source:  {UUID: target, Valid: true},
+			skipped: {},
+		}, mappings)
+	})
+}
@@ -1,27 +1,129 @@
 package data
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
 )
 
+// Querier performs queries against the database. It is satisfied by both *pgxpool.Pool and
+// pgx.Tx, allowing every model to run either directly against the pool, or within a transaction.
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	// CopyFrom bulk inserts rows through the COPY protocol.
+	CopyFrom(
+		ctx context.Context,
+		tableName pgx.Identifier,
+		columnNames []string,
+		rowSrc pgx.CopyFromSource,
+	) (int64, error)
+	// Begin starts a transaction. Within a transaction, a savepoint is created instead.
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 type Models struct {
-	Forums      ForumModel
-	Users       UserModel
-	Threads     ThreadModel
-	ThreadVotes ThreadVoteModel
-	Posts       PostModel
-	PostVotes   PostVoteModel
+	Forums            ForumModel
+	Users             UserModel
+	Threads           ThreadModel
+	ThreadVotes       ThreadVoteModel
+	Posts             PostModel
+	PostVotes         PostVoteModel
+	Tokens            TokenModel
+	Roles             RoleModel
+	Search            SearchModel
+	ThreadEvents      ThreadEventModel
+	Webhooks          WebhookModel
+	WebhookDeliveries WebhookDeliveryModel
+	Purges            PurgeModel
+	PostRevisions     PostRevisionModel
+	ThreadRevisions   ThreadRevisionModel
+	IdempotencyKeys   IdempotencyKeyModel
+	RateLimitBuckets  RateLimitBucketModel
+	Archive           ArchiveModel
+	Imports           ImportModel
+
+	db      Querier
+	timeout *time.Duration
 }
 
-func NewModels(pool *pgxpool.Pool, timeout *time.Duration) Models {
+func NewModels(db Querier, timeout *time.Duration) Models {
 	return Models{
-		Forums:      ForumModel{DB: pool, Timeout: timeout},
-		Users:       UserModel{DB: pool, Timeout: timeout},
-		Threads:     ThreadModel{DB: pool, Timeout: timeout},
-		ThreadVotes: ThreadVoteModel{DB: pool, Timeout: timeout},
-		Posts:       PostModel{DB: pool, Timeout: timeout},
-		PostVotes:   PostVoteModel{DB: pool, Timeout: timeout},
+		Forums:            ForumModel{DB: db, Timeout: timeout},
+		Users:             UserModel{DB: db, Timeout: timeout},
+		Threads:           ThreadModel{DB: db, Timeout: timeout},
+		ThreadVotes:       ThreadVoteModel{DB: db, Timeout: timeout},
+		Posts:             PostModel{DB: db, Timeout: timeout},
+		PostVotes:         PostVoteModel{DB: db, Timeout: timeout},
+		Tokens:            TokenModel{DB: db, Timeout: timeout},
+		Roles:             RoleModel{DB: db, Timeout: timeout},
+		Search:            SearchModel{DB: db, Timeout: timeout},
+		ThreadEvents:      ThreadEventModel{DB: db, Timeout: timeout},
+		Webhooks:          WebhookModel{DB: db, Timeout: timeout},
+		WebhookDeliveries: WebhookDeliveryModel{DB: db, Timeout: timeout},
+		Purges:            PurgeModel{DB: db, Timeout: timeout},
+		PostRevisions:     PostRevisionModel{DB: db, Timeout: timeout},
+		ThreadRevisions:   ThreadRevisionModel{DB: db, Timeout: timeout},
+		IdempotencyKeys:   IdempotencyKeyModel{DB: db, Timeout: timeout},
+		RateLimitBuckets:  RateLimitBucketModel{DB: db, Timeout: timeout},
+		Archive:           ArchiveModel{DB: db, Timeout: timeout},
+		Imports:           ImportModel{DB: db, Timeout: timeout},
+		db:                db,
+		timeout:           timeout,
+	}
+}
+
+// WithTx runs fn as a single unit of work. Every model passed to fn performs its queries within
+// the same transaction, which is committed if fn succeeds, and rolled back if fn returns an error
+// or panics. Panics are propagated after rolling back.
+//
+// Calling WithTx on the models passed to fn creates a savepoint, so that the nested unit of work
+// can be rolled back without aborting the enclosing transaction.
+func (m *Models) WithTx(ctx context.Context, fn func(Models) error) (err error) {
+	logger := logging.LoggerFromContext(ctx)
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to begin transaction", slog.String("error", err.Error()),
+		)
+		return err
 	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			logger.LogAttrs(
+				ctx, slog.LevelError, "rolling back transaction after panic", slog.Any("panic", p),
+			)
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+			panic(p)
+		}
+	}()
+
+	err = fn(NewModels(tx, m.timeout))
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelInfo, "rolling back transaction", slog.String("error", err.Error()),
+		)
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %s)", err, rbErr)
+		}
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to commit transaction", slog.String("error", err.Error()),
+		)
+		return err
+	}
+
+	return nil
 }
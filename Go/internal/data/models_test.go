@@ -0,0 +1,209 @@
+package data_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelsWithTx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errAbort := errors.New("abort")
+
+	t.Run("Commit", func(t *testing.T) {
+		var user *data.User
+		err := models.WithTx(ctx, func(tx data.Models) error {
+			var err error
+			user, err = tx.Users.Insert(ctx, data.UserInput{
+				Name:     "Panam Palmer",
+				Username: "nomad",
+				Email:    "ppalmer@aldecaldos.com",
+			})
+			return err
+		})
+		assert.NoError(t, err)
+
+		committed, err := models.Users.Select(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, committed.ID)
+
+		_, err = models.Users.Delete(ctx, user.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("RollbackOnError", func(t *testing.T) {
+		var user *data.User
+		err := models.WithTx(ctx, func(tx data.Models) error {
+			var err error
+			user, err = tx.Users.Insert(ctx, data.UserInput{
+				Name:     "Saul Bright",
+				Username: "aldecaldo",
+				Email:    "sbright@aldecaldos.com",
+			})
+			if err != nil {
+				return err
+			}
+			return errAbort
+		})
+		assert.ErrorIs(t, err, errAbort)
+
+		_, err = models.Users.Select(ctx, user.ID)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("RollbackOnPanic", func(t *testing.T) {
+		var user *data.User
+		assert.Panics(t, func() {
+			_ = models.WithTx(ctx, func(tx data.Models) error {
+				var err error
+				user, err = tx.Users.Insert(ctx, data.UserInput{
+					Name:     "Mitch Anderson",
+					Username: "mitch",
+					Email:    "manderson@aldecaldos.com",
+				})
+				if err != nil {
+					return err
+				}
+				panic("flatlined")
+			})
+		})
+
+		_, err := models.Users.Select(ctx, user.ID)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("NestedRollback", func(t *testing.T) {
+		var outer, inner *data.User
+		err := models.WithTx(ctx, func(tx data.Models) error {
+			var err error
+			outer, err = tx.Users.Insert(ctx, data.UserInput{
+				Name:     "Carol Emeka",
+				Username: "carol",
+				Email:    "cemeka@aldecaldos.com",
+			})
+			if err != nil {
+				return err
+			}
+
+			err = tx.WithTx(ctx, func(tx data.Models) error {
+				inner, err = tx.Users.Insert(ctx, data.UserInput{
+					Name:     "Cassidy Righter",
+					Username: "cassidy",
+					Email:    "crighter@aldecaldos.com",
+				})
+				if err != nil {
+					return err
+				}
+				return errAbort
+			})
+			assert.ErrorIs(t, err, errAbort)
+
+			return nil
+		})
+		assert.NoError(t, err)
+
+		_, err = models.Users.Select(ctx, outer.ID)
+		assert.NoError(t, err)
+		_, err = models.Users.Select(ctx, inner.ID)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+
+		_, err = models.Users.Delete(ctx, outer.ID)
+		assert.NoError(t, err)
+	})
+}
+
+func TestModelsCopy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	user := &data.User{
+		ID:        uuid.New(),
+		Name:      "Viktor Vektor",
+		Username:  "vik",
+		Email:     "vik@ripperdoc.com",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	forum := &data.Forum{
+		ID:        uuid.New(),
+		OwnerID:   user.ID,
+		Name:      "Ripperdocs",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	thread := &data.Thread{
+		ID:        uuid.New(),
+		ForumID:   forum.ID,
+		Title:     "Kiroshi optics, installed",
+		AuthorID:  user.ID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	opening := &data.Post{
+		ID:        uuid.New(),
+		ThreadID:  thread.ID,
+		AuthorID:  user.ID,
+		Content:   "Fresh chrome, no charge",
+		CreatedAt: now,
+		UpdatedAt: now,
+		Opening:   true,
+	}
+	reply := &data.Post{
+		ID:        uuid.New(),
+		ThreadID:  thread.ID,
+		ReplyTo:   uuid.NullUUID{UUID: opening.ID, Valid: true},
+		AuthorID:  user.ID,
+		Content:   "Pay me back when you can",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	n, err := models.Users.Copy(ctx, []*data.User{user}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = models.Forums.Copy(ctx, []*data.Forum{forum})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = models.Threads.Copy(ctx, []*data.Thread{thread})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// Replies are copied in the same batch as the posts they reply to.
+	n, err = models.Posts.Copy(ctx, []*data.Post{opening, reply})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = models.ThreadVotes.Copy(ctx, []*data.ThreadVote{
+		{ThreadID: thread.ID, UserID: user.ID, Vote: 1},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = models.PostVotes.Copy(ctx, []*data.PostVote{
+		{PostID: reply.ID, UserID: user.ID, Vote: -1},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	copied, err := models.Posts.Select(ctx, thread.ID, reply.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, opening.ID, copied.ReplyTo.UUID)
+	assert.Equal(t, int64(-1), copied.Likes)
+
+	openingPost, err := models.Posts.SelectOpening(ctx, thread.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, opening.ID, openingPost.ID)
+
+	_, err = models.Users.Copy(ctx, []*data.User{user}, nil)
+	assert.ErrorIs(t, err, data.ErrUniqueConstraintViolation)
+}
@@ -3,11 +3,12 @@ package data
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
 )
 
@@ -43,6 +44,38 @@ type Post struct {
 	//
 	// This field is ignored when updating or creating new post.
 	DeletedAt sql.NullTime `json:"deletedAt,omitzero"`
+	// EditCount is the number of times the content of the post has been changed.
+	//
+	// This field is maintained by the database, and is ignored when updating or creating posts.
+	EditCount int `json:"editCount"`
+	// Opening denotes whether the post is the opening post of its thread, created alongside it.
+	Opening bool `json:"opening,omitzero"`
+}
+
+var postSortColumns = sortColumns{
+	"created_at": "TIMESTAMP",
+	"updated_at": "TIMESTAMP",
+	"likes":      "INTEGER",
+}
+
+// PostOrderBySafeList lists the values posts may be ordered by.
+var PostOrderBySafeList = postSortColumns.safeList()
+
+func (p *Post) keysetID() uuid.UUID {
+	return p.ID
+}
+
+func (p *Post) keysetValue(column string) any {
+	switch column {
+	case "created_at":
+		return p.CreatedAt
+	case "updated_at":
+		return p.UpdatedAt
+	case "likes":
+		return p.Likes
+	default:
+		return nil
+	}
 }
 
 type PostInput struct {
@@ -54,6 +87,9 @@ type PostInput struct {
 	AuthorID uuid.UUID `json:"authorId"`
 	// Content is the actual text content of a post
 	Content string `json:"content"`
+	// Opening denotes whether the post is the opening post of its thread. A thread has at most
+	// one opening post.
+	Opening bool `json:"opening"`
 }
 
 type PostPatch struct {
@@ -66,7 +102,7 @@ type PostPatch struct {
 }
 
 type PostModel struct {
-	DB      *pgxpool.Pool
+	DB      Querier
 	Timeout *time.Duration
 }
 
@@ -81,7 +117,9 @@ SELECT id,
        updated_at,
        likes,
        deleted,
-       deleted_at
+       deleted_at,
+       edit_count,
+       opening
 FROM forum.posts
 WHERE id = $1::UUID
   AND thread_id = $2::UUID;
@@ -115,6 +153,63 @@ WHERE id = $1::UUID
 		&p.Likes,
 		&p.Deleted,
 		&p.DeletedAt,
+		&p.EditCount,
+		&p.Opening,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("post selected", slog.Any("post", p))
+
+	return &p, nil
+}
+
+// SelectOpening selects the opening post of a thread. If the thread has no opening post,
+// ErrRecordNotFound is returned.
+func (m *PostModel) SelectOpening(ctx context.Context, threadID uuid.UUID) (*Post, error) {
+	const query string = `
+SELECT id,
+       thread_id,
+       reply_to,
+       author_id,
+       content,
+       created_at,
+       updated_at,
+       likes,
+       deleted,
+       deleted_at,
+       edit_count,
+       opening
+FROM forum.posts
+WHERE thread_id = $1::UUID
+  AND opening;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Any("threadId", threadID),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var p Post
+	err := m.DB.QueryRow(ctx, query, threadID).Scan(
+		&p.ID,
+		&p.ThreadID,
+		&p.ReplyTo,
+		&p.AuthorID,
+		&p.Content,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.Likes,
+		&p.Deleted,
+		&p.DeletedAt,
+		&p.EditCount,
+		&p.Opening,
 	)
 	if err != nil {
 		return nil, handleError(err, logger)
@@ -124,7 +219,86 @@ WHERE id = $1::UUID
 	return &p, nil
 }
 
+// SelectOpenings selects the opening posts of the given threads. Threads without an opening post
+// are left out.
+func (m *PostModel) SelectOpenings(ctx context.Context, threadIDs []uuid.UUID) ([]*Post, error) {
+	const query string = `
+SELECT id,
+       thread_id,
+       reply_to,
+       author_id,
+       content,
+       created_at,
+       updated_at,
+       likes,
+       deleted,
+       deleted_at,
+       edit_count,
+       opening
+FROM forum.posts
+WHERE thread_id = ANY ($1::UUID[])
+  AND opening;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Int("threadIds", len(threadIDs)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	posts := []*Post{}
+	if len(threadIDs) == 0 {
+		return posts, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, threadIDs)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p Post
+		err := rows.Scan(
+			&p.ID,
+			&p.ThreadID,
+			&p.ReplyTo,
+			&p.AuthorID,
+			&p.Content,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+			&p.Likes,
+			&p.Deleted,
+			&p.DeletedAt,
+			&p.EditCount,
+			&p.Opening,
+		)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		posts = append(posts, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("opening posts selected", slog.Int("length", len(posts)))
+
+	return posts, nil
+}
+
+// SelectAll selects the replies to threads matching the filters. Opening posts are left out, as
+// they are selected alongside their threads.
 func (m *PostModel) SelectAll(ctx context.Context, filters Filters) ([]*Post, *Metadata, error) {
+	where, orderBy, keysetArgs, err := createKeysetClauses(filters, postSortColumns, 12)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	query := `
 SELECT id,
        thread_id,
@@ -135,7 +309,9 @@ SELECT id,
        updated_at,
        likes,
        deleted,
-       deleted_at
+       deleted_at,
+       edit_count,
+       opening
 FROM forum.posts
 WHERE ($2::UUID IS NULL OR id = $2::UUID)
   AND ($3::UUID IS NULL OR thread_id = $3::UUID)
@@ -147,8 +323,9 @@ WHERE ($2::UUID IS NULL OR id = $2::UUID)
   AND ($9::BOOLEAN IS NULL or deleted = $9::BOOLEAN)
   AND ($10::TIMESTAMP IS NULL or deleted_at >= $10::TIMESTAMP)
   AND ($11::TIMESTAMP IS NULL or deleted_at <= $11::TIMESTAMP)
-  AND id > $12::UUID
-` + CreateOrderByClause(filters.OrderBy) + `
+  AND NOT opening
+` + where + `
+` + orderBy + `
 LIMIT $1::INTEGER;
 `
 
@@ -159,11 +336,8 @@ LIMIT $1::INTEGER;
 		slog.Duration("timeout", *m.Timeout),
 	))
 
-	logger.Info("performing query")
-	rows, err := m.DB.Query(
-		ctx,
-		query,
-		filters.PageSize,
+	args := append([]any{
+		filters.PageSize + 1,
 		filters.ID,
 		filters.ThreadID,
 		filters.AuthorID,
@@ -174,8 +348,10 @@ LIMIT $1::INTEGER;
 		filters.Deleted,
 		filters.DeletedAtFrom,
 		filters.DeletedAtTo,
-		filters.LastSeen,
-	)
+	}, keysetArgs...)
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, args...)
 	if err != nil {
 		logger.Error("unable to perform query", slog.String("error", err.Error()))
 		return nil, nil, err
@@ -197,6 +373,8 @@ LIMIT $1::INTEGER;
 			&p.Likes,
 			&p.Deleted,
 			&p.DeletedAt,
+			&p.EditCount,
+			&p.Opening,
 		)
 		if err != nil {
 			return nil, nil, handleError(err, logger)
@@ -206,20 +384,207 @@ LIMIT $1::INTEGER;
 	if err = rows.Err(); err != nil {
 		return nil, nil, handleError(err, logger)
 	}
-	length := len(posts)
-	var metadata Metadata
-	if length > 0 {
-		metadata.LastSeen = posts[length-1].ID
-	}
-	if length >= filters.PageSize {
-		metadata.Next = true
-	}
-	metadata.ResponseLength = length
+	posts, metadata := paginate(posts, filters)
 
 	logger.Info("posts selected", slog.Any("metadata", metadata))
 	return posts, &metadata, nil
 }
 
+// PostNode is a post positioned within a reply tree.
+type PostNode struct {
+	Post
+	// Depth is the distance from the root of the tree, where the roots have a depth of 0.
+	Depth int `json:"depth"`
+	// ReplyCount is the total number of direct replies to the post, including any replies not
+	// selected due to the reply limit.
+	ReplyCount int `json:"replyCount"`
+}
+
+// PostTreeOrderBy is the ordering of the replies within a reply tree, which the cursors continuing
+// a tree are created for.
+var PostTreeOrderBy = []string{"created_at"}
+
+// NewPostTreeCursor creates a cursor continuing a reply tree after the given post.
+func NewPostTreeCursor(p *Post) *Cursor {
+	return newCursor(p, PostTreeOrderBy, false)
+}
+
+// SelectTree selects the reply trees of a thread. If a root ID is given, only the tree starting at
+// the given post is selected. Otherwise, every reply to the thread is treated as a root, being the
+// posts which are not replies and the replies to the opening post. The opening post itself is left
+// out.
+//
+// If a cursor created by NewPostTreeCursor is given, the replies following the cursor are the
+// roots instead, being the replies to the root post, or to the thread if no root ID is given.
+//
+// At most limit posts are selected at the root level, and at most limit replies are selected for
+// each post, oldest first. Replies deeper than maxDepth are not selected.
+//
+// The nodes are returned ordered by depth, and can be assembled into trees by their ReplyTo. The
+// total number of roots, including any roots not selected due to the limit, is returned alongside
+// the nodes.
+func (m *PostModel) SelectTree(
+	ctx context.Context,
+	threadID uuid.UUID,
+	rootID uuid.NullUUID,
+	after *Cursor,
+	maxDepth int,
+	limit int,
+) ([]*PostNode, int, error) {
+	const query string = `
+WITH RECURSIVE roots AS (SELECT p.id,
+                                p.thread_id,
+                                p.reply_to,
+                                p.author_id,
+                                p.content,
+                                p.created_at,
+                                p.updated_at,
+                                p.likes,
+                                p.deleted,
+                                p.deleted_at,
+                                p.edit_count,
+                                p.opening,
+                                COUNT(*) OVER () AS total
+                         FROM forum.posts p
+                                  LEFT JOIN forum.posts parent ON parent.id = p.reply_to
+                         WHERE p.thread_id = $1::UUID
+                           AND CASE
+                                   WHEN $2::UUID IS NULL
+                                       THEN NOT p.opening
+                                       AND (p.reply_to IS NULL OR parent.opening)
+                                   WHEN $6::UUID IS NULL THEN p.id = $2::UUID
+                                   ELSE p.reply_to = $2::UUID
+                             END
+                           AND ($6::UUID IS NULL OR
+                                (p.created_at, p.id) > ($5::TEXT::TIMESTAMP, $6::UUID))
+                         ORDER BY p.created_at, p.id
+                         LIMIT $4::INTEGER),
+               tree AS (SELECT id,
+                               thread_id,
+                               reply_to,
+                               author_id,
+                               content,
+                               created_at,
+                               updated_at,
+                               likes,
+                               deleted,
+                               deleted_at,
+                               edit_count,
+                               opening,
+                               0 AS depth
+                        FROM roots
+                        UNION ALL
+                        SELECT replies.*, tree.depth + 1
+                        FROM tree
+                                 CROSS JOIN LATERAL (SELECT id,
+                                                            thread_id,
+                                                            reply_to,
+                                                            author_id,
+                                                            content,
+                                                            created_at,
+                                                            updated_at,
+                                                            likes,
+                                                            deleted,
+                                                            deleted_at,
+                                                            edit_count,
+                                                            opening
+                                                     FROM forum.posts p
+                                                     WHERE p.reply_to = tree.id
+                                                       AND p.thread_id = tree.thread_id
+                                                     ORDER BY p.created_at, p.id
+                                                     LIMIT $4::INTEGER) replies
+                        WHERE tree.depth < $3::INTEGER)
+SELECT t.id,
+       t.thread_id,
+       t.reply_to,
+       t.author_id,
+       t.content,
+       t.created_at,
+       t.updated_at,
+       t.likes,
+       t.deleted,
+       t.deleted_at,
+       t.edit_count,
+       t.opening,
+       t.depth,
+       (SELECT COUNT(*) FROM forum.posts r WHERE r.reply_to = t.id) AS reply_count,
+       (SELECT total FROM roots LIMIT 1)                            AS root_count
+FROM tree t
+ORDER BY t.depth, t.created_at, t.id;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("threadId", threadID.String()),
+		slog.Any("rootId", rootID),
+		slog.Any("after", after),
+		slog.Int("maxDepth", maxDepth),
+		slog.Int("limit", limit),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	var afterCreatedAt sql.NullString
+	var afterID uuid.NullUUID
+	if after != nil {
+		if !after.Matches(PostTreeOrderBy) || after.Backward {
+			return nil, 0, ErrInvalidCursor
+		}
+		if err := checkCursorValue(postSortColumns["created_at"], after.Values[0]); err != nil {
+			return nil, 0, fmt.Errorf("%w: created_at %w", ErrInvalidCursor, err)
+		}
+		afterCreatedAt = sql.NullString{String: after.Values[0], Valid: true}
+		afterID = uuid.NullUUID{UUID: after.ID, Valid: true}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(
+		ctx, query, threadID, rootID, maxDepth, limit, afterCreatedAt, afterID,
+	)
+	if err != nil {
+		logger.Error("unable to perform query", slog.String("error", err.Error()))
+		return nil, 0, err
+	}
+
+	nodes := []*PostNode{}
+	var total int
+
+	for rows.Next() {
+		var n PostNode
+
+		err := rows.Scan(
+			&n.ID,
+			&n.ThreadID,
+			&n.ReplyTo,
+			&n.AuthorID,
+			&n.Content,
+			&n.CreatedAt,
+			&n.UpdatedAt,
+			&n.Likes,
+			&n.Deleted,
+			&n.DeletedAt,
+			&n.EditCount,
+			&n.Opening,
+			&n.Depth,
+			&n.ReplyCount,
+			&total,
+		)
+		if err != nil {
+			return nil, 0, handleError(err, logger)
+		}
+		nodes = append(nodes, &n)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, handleError(err, logger)
+	}
+	logger.Info("post tree selected", slog.Int("length", len(nodes)), slog.Int("roots", total))
+
+	return nodes, total, nil
+}
+
 func (m *PostModel) SelectCount(ctx context.Context, filters Filters) (*int, error) {
 	query := `
 SELECT COUNT(*) AS "count"
@@ -270,13 +635,29 @@ WHERE ($1::UUID IS NULL OR id = $1::UUID)
 	return &count, nil
 }
 
+// SelectCounts counts the posts of each of the given threads. Threads without posts are left out.
+func (m *PostModel) SelectCounts(
+	ctx context.Context,
+	threadIDs []uuid.UUID,
+) (map[uuid.UUID]int, error) {
+	const query string = `
+SELECT thread_id, COUNT(*)
+FROM forum.posts
+WHERE thread_id = ANY ($1::UUID[])
+GROUP BY thread_id;
+`
+
+	return selectTotals(ctx, m.DB, *m.Timeout, query, threadIDs)
+}
+
 func (m *PostModel) Insert(ctx context.Context, input PostInput) (*Post, error) {
 	const query string = `
-INSERT INTO forum.posts(thread_id, reply_to, content, author_id)
+INSERT INTO forum.posts(thread_id, reply_to, content, author_id, opening)
 VALUES ($1::UUID,
         $2::UUID,
         $3::VARCHAR(256),
-        $4::UUID)
+        $4::UUID,
+        $5::BOOLEAN)
 RETURNING id,
     thread_id,
     reply_to,
@@ -286,7 +667,9 @@ RETURNING id,
     updated_at,
     likes,
     deleted,
-    deleted_at;
+    deleted_at,
+    edit_count,
+    opening;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -308,6 +691,7 @@ RETURNING id,
 		input.ReplyTo,
 		input.Content,
 		input.AuthorID,
+		input.Opening,
 	).Scan(
 		&p.ID,
 		&p.ThreadID,
@@ -319,6 +703,8 @@ RETURNING id,
 		&p.Likes,
 		&p.Deleted,
 		&p.DeletedAt,
+		&p.EditCount,
+		&p.Opening,
 	)
 	if err != nil {
 		return nil, handleError(err, logger)
@@ -343,7 +729,9 @@ RETURNING id,
     updated_at,
     likes,
     deleted,
-    deleted_at;
+    deleted_at,
+    edit_count,
+    opening;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -375,6 +763,8 @@ RETURNING id,
 		&p.Likes,
 		&p.Deleted,
 		&p.DeletedAt,
+		&p.EditCount,
+		&p.Opening,
 	)
 	if err != nil {
 		return nil, handleError(err, logger)
@@ -400,7 +790,9 @@ RETURNING id,
     updated_at,
     likes,
     deleted,
-    deleted_at;
+    deleted_at,
+    edit_count,
+    opening;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -430,6 +822,8 @@ RETURNING id,
 		&p.Likes,
 		&p.Deleted,
 		&p.DeletedAt,
+		&p.EditCount,
+		&p.Opening,
 	)
 	if err != nil {
 		return nil, handleError(err, logger)
@@ -455,7 +849,9 @@ RETURNING id,
     updated_at,
     likes,
     deleted,
-    deleted_at;
+    deleted_at,
+    edit_count,
+    opening;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -485,6 +881,8 @@ RETURNING id,
 		&p.Likes,
 		&p.Deleted,
 		&p.DeletedAt,
+		&p.EditCount,
+		&p.Opening,
 	)
 	if err != nil {
 		return nil, handleError(err, logger)
@@ -508,7 +906,9 @@ RETURNING id,
     updated_at,
     likes,
     deleted,
-    deleted_at;
+    deleted_at,
+    edit_count,
+    opening;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -538,6 +938,8 @@ RETURNING id,
 		&p.Likes,
 		&p.Deleted,
 		&p.DeletedAt,
+		&p.EditCount,
+		&p.Opening,
 	)
 	if err != nil {
 		return nil, handleError(err, logger)
@@ -546,3 +948,53 @@ RETURNING id,
 
 	return &p, nil
 }
+
+// Copy bulk inserts posts with their IDs and timestamps as given, returning the number of posts
+// inserted. Posts must be ordered after the posts they reply to, unless copied within a
+// transaction.
+func (m *PostModel) Copy(ctx context.Context, posts []*Post) (int64, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("table", "forum.posts"),
+		slog.Int("rows", len(posts)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing copy")
+	n, err := m.DB.CopyFrom(
+		ctx,
+		pgx.Identifier{"forum", "posts"},
+		[]string{
+			"id",
+			"thread_id",
+			"reply_to",
+			"author_id",
+			"content",
+			"created_at",
+			"updated_at",
+			"opening",
+		},
+		pgx.CopyFromSlice(len(posts), func(i int) ([]any, error) {
+			p := posts[i]
+			return []any{
+				p.ID,
+				p.ThreadID,
+				p.ReplyTo,
+				p.AuthorID,
+				p.Content,
+				p.CreatedAt,
+				p.UpdatedAt,
+				p.Opening,
+			}, nil
+		}),
+	)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("posts copied", slog.Int64("rowsAffected", n))
+
+	return n, nil
+}
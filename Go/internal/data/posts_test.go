@@ -43,6 +43,7 @@ func TestPostModel(t *testing.T) {
 			ReplyTo:  uuid.NullUUID{Valid: false},
 			Content:  "A rogue taxi is nearby, here are the precise coordinates",
 			AuthorID: user.ID,
+			Opening:  true,
 		})
 		assert.NoError(t, err)
 
@@ -55,13 +56,104 @@ func TestPostModel(t *testing.T) {
 		assert.Equal(t, post, *selectedPost)
 	})
 
+	t.Run("SelectOpening", func(t *testing.T) {
+		openingPost, err := models.Posts.SelectOpening(ctx, insertedThread.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, post, *openingPost)
+	})
+
+	t.Run("SelectOpenings", func(t *testing.T) {
+		openingPosts, err := models.Posts.SelectOpenings(
+			ctx, []uuid.UUID{insertedThread.ID, uuid.New()},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []*data.Post{&post}, openingPosts)
+	})
+
 	t.Run("SelectAll", func(t *testing.T) {
 		selectedPosts, metadata, err := models.Posts.SelectAll(ctx, data.Filters{PageSize: 25})
 		assert.NoError(t, err)
-		assert.NotEmpty(t, metadata.LastSeen)
+		assert.NotEmpty(t, metadata)
 		assert.GreaterOrEqual(t, len(selectedPosts), 0)
 	})
 
+	t.Run("SelectTree", func(t *testing.T) {
+		reply, err := models.Posts.Insert(ctx, data.PostInput{
+			ThreadID: insertedThread.ID,
+			ReplyTo:  uuid.NullUUID{UUID: post.ID, Valid: true},
+			Content:  "Coordinates received, dispatching a tow truck",
+			AuthorID: user.ID,
+		})
+		assert.NoError(t, err)
+
+		root := uuid.NullUUID{UUID: post.ID, Valid: true}
+		nodes, roots, err := models.Posts.SelectTree(ctx, insertedThread.ID, root, nil, 5, 25)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, roots)
+		assert.Len(t, nodes, 2)
+		assert.Equal(t, post.ID, nodes[0].ID)
+		assert.Equal(t, 0, nodes[0].Depth)
+		assert.Equal(t, 1, nodes[0].ReplyCount)
+		assert.Equal(t, reply.ID, nodes[1].ID)
+		assert.Equal(t, 1, nodes[1].Depth)
+
+		nodes, _, err = models.Posts.SelectTree(ctx, insertedThread.ID, root, nil, 0, 25)
+		assert.NoError(t, err)
+		assert.Len(t, nodes, 1)
+
+		// Without a root, the replies to the opening post are the roots of the thread.
+		nodes, roots, err = models.Posts.SelectTree(
+			ctx, insertedThread.ID, uuid.NullUUID{}, nil, 5, 25,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, roots)
+		assert.Len(t, nodes, 1)
+		assert.Equal(t, reply.ID, nodes[0].ID)
+		assert.Equal(t, 0, nodes[0].Depth)
+
+		next, err := models.Posts.Insert(ctx, data.PostInput{
+			ThreadID: insertedThread.ID,
+			ReplyTo:  uuid.NullUUID{UUID: post.ID, Valid: true},
+			Content:  "Tow truck dispatched, the taxi is refusing to cooperate",
+			AuthorID: user.ID,
+		})
+		assert.NoError(t, err)
+
+		// The replies beyond the limit are continued after the last reply selected.
+		nodes, _, err = models.Posts.SelectTree(ctx, insertedThread.ID, root, nil, 5, 1)
+		assert.NoError(t, err)
+		assert.Len(t, nodes, 2)
+		assert.Equal(t, 2, nodes[0].ReplyCount)
+		assert.Equal(t, reply.ID, nodes[1].ID)
+
+		after := data.NewPostTreeCursor(&nodes[1].Post)
+		nodes, roots, err = models.Posts.SelectTree(ctx, insertedThread.ID, root, after, 5, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, roots)
+		assert.Len(t, nodes, 1)
+		assert.Equal(t, next.ID, nodes[0].ID)
+		assert.Equal(t, 0, nodes[0].Depth)
+
+		_, _, err = models.Posts.SelectTree(
+			ctx, insertedThread.ID, root, &data.Cursor{OrderBy: []string{"likes"}}, 5, 1,
+		)
+		assert.ErrorIs(t, err, data.ErrInvalidCursor)
+
+		_, err = models.Posts.Delete(ctx, next.ID)
+		assert.NoError(t, err)
+
+		replies, _, err := models.Posts.SelectAll(ctx, data.Filters{
+			ThreadID: &insertedThread.ID,
+			PageSize: 25,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, replies, 1)
+		assert.Equal(t, reply.ID, replies[0].ID)
+
+		_, err = models.Posts.Delete(ctx, reply.ID)
+		assert.NoError(t, err)
+	})
+
 	t.Run("SelectCount", func(t *testing.T) {
 		countedPosts, err := models.Posts.SelectCount(
 			ctx,
@@ -71,6 +163,12 @@ func TestPostModel(t *testing.T) {
 		assert.GreaterOrEqual(t, *countedPosts, 0)
 	})
 
+	t.Run("SelectCounts", func(t *testing.T) {
+		counts, err := models.Posts.SelectCounts(ctx, []uuid.UUID{insertedThread.ID, uuid.New()})
+		assert.NoError(t, err)
+		assert.Equal(t, map[uuid.UUID]int{insertedThread.ID: 1}, counts)
+	})
+
 	t.Run("Update", func(t *testing.T) {
 		updatedContent := "A rogue taxi is nearby, here are the precise coordinates: 1.1.1.1"
 		updatedPost, err := models.Posts.Update(ctx, data.PostPatch{
@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
 )
 
@@ -21,10 +21,45 @@ type PostVote struct {
 }
 
 type PostVoteModel struct {
-	DB      *pgxpool.Pool
+	DB      Querier
 	Timeout *time.Duration
 }
 
+// Select retrieves the vote a given user has cast on a post.
+func (m *PostVoteModel) Select(
+	ctx context.Context,
+	postID uuid.UUID,
+	userID uuid.UUID,
+) (*PostVote, error) {
+	const query string = `
+SELECT post_id, user_id, vote
+FROM forum.post_votes
+WHERE post_id = $1::UUID
+  AND user_id = $2::UUID;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("postId", postID.String()),
+		slog.String("userId", userID.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var v PostVote
+	err := m.DB.QueryRow(ctx, query, postID, userID).Scan(&v.PostID, &v.UserID, &v.Vote)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("vote selected", slog.Any("vote", v))
+
+	return &v, nil
+}
+
 func (m *PostVoteModel) SelectSum(ctx context.Context, filters Filters) (*int, error) {
 	const query string = `
 SELECT CASE
@@ -56,6 +91,21 @@ WHERE ($1::UUID IS NULL OR post_id = $1::UUID)
 	return &count, nil
 }
 
+// SelectSums sums the votes of each of the given posts. Posts without votes are left out.
+func (m *PostVoteModel) SelectSums(
+	ctx context.Context,
+	postIDs []uuid.UUID,
+) (map[uuid.UUID]int, error) {
+	const query string = `
+SELECT post_id, SUM(vote)
+FROM forum.post_votes
+WHERE post_id = ANY ($1::UUID[])
+GROUP BY post_id;
+`
+
+	return selectTotals(ctx, m.DB, *m.Timeout, query, postIDs)
+}
+
 // Vote performs a upsert for to record any votes for any post. If the vote is 0, the record is
 // deleted.
 func (m *PostVoteModel) Vote(ctx context.Context, vote PostVote) (*PostVote, error) {
@@ -95,3 +145,68 @@ ON CONFLICT (post_id, user_id) DO UPDATE
 
 	return &vote, nil
 }
+
+// RecountLikes recalculates the likes of every post from the recorded votes, correcting tallies
+// which have drifted, such as after votes were changed by hand. The number of corrected posts is
+// returned.
+func (m *PostVoteModel) RecountLikes(ctx context.Context) (int64, error) {
+	const query string = `
+UPDATE forum.posts AS target
+SET likes = counted.likes
+FROM (SELECT t.id, COALESCE(SUM(v.vote), 0) AS likes
+      FROM forum.posts AS t
+               LEFT JOIN forum.post_votes AS v ON v.post_id = t.id
+      GROUP BY t.id) AS counted
+WHERE target.id = counted.id
+  AND target.likes <> counted.likes;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	tag, err := m.DB.Exec(ctx, query)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("post likes recounted", slog.Int64("rowsAffected", tag.RowsAffected()))
+
+	return tag.RowsAffected(), nil
+}
+
+// Copy bulk inserts post votes, returning the number of votes inserted, while the database keeps
+// the likes of the voted posts up to date.
+func (m *PostVoteModel) Copy(ctx context.Context, votes []*PostVote) (int64, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("table", "forum.post_votes"),
+		slog.Int("rows", len(votes)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing copy")
+	n, err := m.DB.CopyFrom(
+		ctx,
+		pgx.Identifier{"forum", "post_votes"},
+		[]string{"post_id", "user_id", "vote"},
+		pgx.CopyFromSlice(len(votes), func(i int) ([]any, error) {
+			v := votes[i]
+			return []any{v.PostID, v.UserID, v.Vote}, nil
+		}),
+	)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("post votes copied", slog.Int64("rowsAffected", n))
+
+	return n, nil
+}
@@ -54,6 +54,18 @@ func TestPostVoteModel(t *testing.T) {
 		assert.NotEqual(t, newVote, vote)
 	})
 
+	t.Run("Select", func(t *testing.T) {
+		vote, err := models.PostVotes.Select(ctx, post.ID, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, newVote, *vote)
+	})
+
+	t.Run("SelectSums", func(t *testing.T) {
+		sums, err := models.PostVotes.SelectSums(ctx, []uuid.UUID{post.ID, uuid.New()})
+		assert.NoError(t, err)
+		assert.Equal(t, map[uuid.UUID]int{post.ID: 1}, sums)
+	})
+
 	t.Run("SelectSum", func(t *testing.T) {
 		count, err := models.PostVotes.SelectSum(ctx, data.Filters{
 			PostID: &post.ID,
@@ -62,4 +74,17 @@ func TestPostVoteModel(t *testing.T) {
 		assert.NoError(t, err)
 		assert.GreaterOrEqual(t, *count, 0, "post vote less than 0")
 	})
+
+	t.Run("RecountLikes", func(t *testing.T) {
+		_, err := db.Exec(ctx, "UPDATE forum.posts SET likes = 42 WHERE id = $1;", post.ID)
+		assert.NoError(t, err)
+
+		corrected, err := models.PostVotes.RecountLikes(ctx)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, corrected, int64(1))
+
+		recounted, err := models.Posts.Select(ctx, insertedThread.ID, post.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), recounted.Likes)
+	})
 }
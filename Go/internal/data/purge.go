@@ -0,0 +1,409 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+const (
+	PurgeKindUsers   string = "users"
+	PurgeKindForums  string = "forums"
+	PurgeKindThreads string = "threads"
+	PurgeKindPosts   string = "posts"
+)
+
+// PurgeKinds lists the kinds of resources which may be purged, dependants first.
+var PurgeKinds = []string{PurgeKindPosts, PurgeKindThreads, PurgeKindForums, PurgeKindUsers}
+
+// PurgeScope is the root of a purge. Every row depending on the root is purged alongside it. Only
+// one of the IDs is expected to be set.
+type PurgeScope struct {
+	// UserID purges a user, and the threads and posts authored by the user. Forums owned by the
+	// user are not purged, as they hold the content of other users, and are listed in
+	// PurgeSet.OwnedForumIDs instead.
+	UserID uuid.NullUUID `json:"userId,omitzero"`
+	// ForumID purges a forum and its threads.
+	ForumID uuid.NullUUID `json:"forumId,omitzero"`
+	// ThreadID purges a thread and its posts.
+	ThreadID uuid.NullUUID `json:"threadId,omitzero"`
+	// PostID purges a post and its replies.
+	PostID uuid.NullUUID `json:"postId,omitzero"`
+	// UpdatedAt is the time the root is expected to have last been updated at. If set, nothing is
+	// purged if the root has been updated since.
+	UpdatedAt sql.NullTime `json:"updatedAt,omitzero"`
+}
+
+// PurgeSet lists the rows removed when purging a scope. Purging a post removes every reply to the
+// post as well, including replies in other threads.
+type PurgeSet struct {
+	UserIDs   []uuid.UUID `json:"userIds"`
+	ForumIDs  []uuid.UUID `json:"forumIds"`
+	ThreadIDs []uuid.UUID `json:"threadIds"`
+	PostIDs   []uuid.UUID `json:"postIds"`
+	// OwnedForumIDs lists the forums owned by the purged users, which are not purged themselves.
+	// The users cannot be removed while they own forums.
+	OwnedForumIDs []uuid.UUID `json:"ownedForumIds"`
+	// ThreadVotes is the number of thread votes removed through the purged threads and users.
+	ThreadVotes int64 `json:"threadVotes"`
+	// PostVotes is the number of post votes removed through the purged posts and users.
+	PostVotes int64 `json:"postVotes"`
+}
+
+type PurgeModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+// Select lists the rows depending on the root of the scope, including the root itself. The users,
+// forums and threads listed are locked for the rest of the transaction, so that they cannot be
+// updated before they are purged.
+func (m *PurgeModel) Select(ctx context.Context, scope PurgeScope) (*PurgeSet, error) {
+	const query string = `
+WITH RECURSIVE purged_users AS (SELECT id
+                                FROM forum.users
+                                WHERE id = $1::UUID
+                                  AND ($5::TIMESTAMP IS NULL OR updated_at = $5::TIMESTAMP)
+                                FOR UPDATE),
+               purged_forums AS (SELECT id
+                                 FROM forum.forums
+                                 WHERE id = $2::UUID
+                                   AND ($5::TIMESTAMP IS NULL OR updated_at = $5::TIMESTAMP)
+                                 FOR UPDATE),
+               purged_threads AS (SELECT id
+                                  FROM forum.threads
+                                  WHERE (id = $3::UUID AND
+                                         ($5::TIMESTAMP IS NULL OR updated_at = $5::TIMESTAMP))
+                                     OR forum_id IN (SELECT id FROM purged_forums)
+                                     OR author_id IN (SELECT id FROM purged_users)
+                                  FOR UPDATE),
+               purged_posts AS (SELECT id
+                                FROM forum.posts
+                                WHERE (id = $4::UUID AND
+                                       ($5::TIMESTAMP IS NULL OR updated_at = $5::TIMESTAMP))
+                                   OR thread_id IN (SELECT id FROM purged_threads)
+                                   OR author_id IN (SELECT id FROM purged_users)
+                                UNION
+                                SELECT p.id
+                                FROM forum.posts p
+                                         INNER JOIN purged_posts pp ON p.reply_to = pp.id)
+SELECT ARRAY(SELECT id FROM purged_users),
+       ARRAY(SELECT id FROM purged_forums),
+       ARRAY(SELECT id FROM purged_threads),
+       ARRAY(SELECT id FROM purged_posts),
+       ARRAY(SELECT id
+             FROM forum.forums
+             WHERE owner_id IN (SELECT id FROM purged_users)
+               AND id NOT IN (SELECT id FROM purged_forums)),
+       (SELECT COUNT(*)
+        FROM forum.thread_votes
+        WHERE thread_id IN (SELECT id FROM purged_threads)
+           OR user_id IN (SELECT id FROM purged_users)),
+       (SELECT COUNT(*)
+        FROM forum.post_votes
+        WHERE post_id IN (SELECT id FROM purged_posts)
+           OR user_id IN (SELECT id FROM purged_users));
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Any("scope", scope),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var s PurgeSet
+	err := m.DB.QueryRow(
+		ctx,
+		query,
+		scope.UserID,
+		scope.ForumID,
+		scope.ThreadID,
+		scope.PostID,
+		scope.UpdatedAt,
+	).Scan(
+		&s.UserIDs,
+		&s.ForumIDs,
+		&s.ThreadIDs,
+		&s.PostIDs,
+		&s.OwnedForumIDs,
+		&s.ThreadVotes,
+		&s.PostVotes,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("purge set selected", slog.Any("set", s))
+
+	return &s, nil
+}
+
+// SelectExpired selects up to limit resources of the given kind which were soft deleted longer ago
+// than the retention period, oldest first. Each resource is returned as the scope purging it.
+//
+// Only the roots of deletion batches expire. Rows hidden by the deletion of a forum, thread or
+// user share its deletion batch, and are retained for as long as the row deleted in the first
+// place, so that restoring it restores them as well. They are purged alongside it.
+func (m *PurgeModel) SelectExpired(
+	ctx context.Context,
+	kind string,
+	retention time.Duration,
+	limit int,
+) ([]PurgeScope, error) {
+	var query string
+	switch kind {
+	case PurgeKindUsers:
+		query = `
+SELECT id
+FROM forum.users
+WHERE deleted = TRUE
+  AND deleted_at < NOW() - make_interval(secs => $1::DOUBLE PRECISION)
+ORDER BY deleted_at
+LIMIT $2::INTEGER;
+`
+	case PurgeKindForums:
+		query = `
+SELECT id
+FROM forum.forums
+WHERE deleted = TRUE
+  AND deleted_at < NOW() - make_interval(secs => $1::DOUBLE PRECISION)
+ORDER BY deleted_at
+LIMIT $2::INTEGER;
+`
+	case PurgeKindThreads:
+		query = `
+SELECT t.id
+FROM forum.threads t
+WHERE t.deleted = TRUE
+  AND t.deleted_at < NOW() - make_interval(secs => $1::DOUBLE PRECISION)
+  AND NOT EXISTS (SELECT 1
+                  FROM forum.forums f
+                  WHERE f.id = t.forum_id
+                    AND f.deletion_batch_id = t.deletion_batch_id)
+  AND NOT EXISTS (SELECT 1
+                  FROM forum.users u
+                  WHERE u.id = t.author_id
+                    AND u.deletion_batch_id = t.deletion_batch_id)
+ORDER BY t.deleted_at
+LIMIT $2::INTEGER;
+`
+	case PurgeKindPosts:
+		query = `
+SELECT p.id
+FROM forum.posts p
+WHERE p.deleted = TRUE
+  AND p.deleted_at < NOW() - make_interval(secs => $1::DOUBLE PRECISION)
+  AND NOT EXISTS (SELECT 1
+                  FROM forum.threads t
+                  WHERE t.id = p.thread_id
+                    AND t.deletion_batch_id = p.deletion_batch_id)
+  AND NOT EXISTS (SELECT 1
+                  FROM forum.users u
+                  WHERE u.id = p.author_id
+                    AND u.deletion_batch_id = p.deletion_batch_id)
+ORDER BY p.deleted_at
+LIMIT $2::INTEGER;
+`
+	default:
+		return nil, fmt.Errorf("unknown purge kind: %s", kind)
+	}
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("kind", kind),
+		slog.Duration("retention", retention),
+		slog.Int("limit", limit),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, retention.Seconds(), limit)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	defer rows.Close()
+
+	scopes := []PurgeScope{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, handleError(err, logger)
+		}
+
+		root := uuid.NullUUID{UUID: id, Valid: true}
+		switch kind {
+		case PurgeKindUsers:
+			scopes = append(scopes, PurgeScope{UserID: root})
+		case PurgeKindForums:
+			scopes = append(scopes, PurgeScope{ForumID: root})
+		case PurgeKindThreads:
+			scopes = append(scopes, PurgeScope{ThreadID: root})
+		case PurgeKindPosts:
+			scopes = append(scopes, PurgeScope{PostID: root})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("expired resources selected", slog.Int("count", len(scopes)))
+
+	return scopes, nil
+}
+
+// DeletePosts deletes the given posts, returning the number of deleted posts. Replies to the posts
+// must be deleted alongside them.
+func (m *PurgeModel) DeletePosts(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	const query string = `
+DELETE
+FROM forum.posts
+WHERE id = ANY ($1::UUID[]);
+`
+
+	return m.delete(ctx, query, ids)
+}
+
+// DeleteThreads deletes the given threads, returning the number of deleted threads. The posts
+// within the threads must be deleted beforehand.
+func (m *PurgeModel) DeleteThreads(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	const query string = `
+DELETE
+FROM forum.threads
+WHERE id = ANY ($1::UUID[]);
+`
+
+	return m.delete(ctx, query, ids)
+}
+
+// DeleteForums deletes the given forums, returning the number of deleted forums. The threads
+// within the forums must be deleted beforehand.
+func (m *PurgeModel) DeleteForums(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	const query string = `
+DELETE
+FROM forum.forums
+WHERE id = ANY ($1::UUID[]);
+`
+
+	return m.delete(ctx, query, ids)
+}
+
+// DeleteUsers deletes the given users, returning the number of deleted users. The forums, threads
+// and posts of the users must be deleted beforehand.
+func (m *PurgeModel) DeleteUsers(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	const query string = `
+DELETE
+FROM forum.users
+WHERE id = ANY ($1::UUID[]);
+`
+
+	return m.delete(ctx, query, ids)
+}
+
+// DeleteExpiredOutboxEvents deletes up to limit domain events recorded longer ago than the
+// retention period, oldest first, alongside their webhook deliveries. Events with pending
+// deliveries are kept until every delivery is concluded. The number of deleted events is returned.
+func (m *PurgeModel) DeleteExpiredOutboxEvents(
+	ctx context.Context,
+	retention time.Duration,
+	limit int,
+) (int64, error) {
+	const query string = `
+DELETE
+FROM forum.outbox_events
+WHERE id IN (SELECT e.id
+             FROM forum.outbox_events e
+             WHERE e.created_at < NOW() - make_interval(secs => $1::DOUBLE PRECISION)
+               AND NOT EXISTS (SELECT 1
+                               FROM forum.webhook_deliveries d
+                               WHERE d.event_id = e.id
+                                 AND d.status = 'pending')
+             ORDER BY e.created_at
+             LIMIT $2::INTEGER);
+`
+
+	return m.deleteExpired(ctx, query, retention, limit)
+}
+
+// DeleteExpiredThreadEvents deletes up to limit thread events recorded longer ago than the
+// retention period, oldest first. The number of deleted events is returned.
+func (m *PurgeModel) DeleteExpiredThreadEvents(
+	ctx context.Context,
+	retention time.Duration,
+	limit int,
+) (int64, error) {
+	const query string = `
+DELETE
+FROM forum.thread_events
+WHERE id IN (SELECT id
+             FROM forum.thread_events
+             WHERE created_at < NOW() - make_interval(secs => $1::DOUBLE PRECISION)
+             ORDER BY created_at
+             LIMIT $2::INTEGER);
+`
+
+	return m.deleteExpired(ctx, query, retention, limit)
+}
+
+func (m *PurgeModel) deleteExpired(
+	ctx context.Context,
+	query string,
+	retention time.Duration,
+	limit int,
+) (int64, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Duration("retention", retention),
+		slog.Int("limit", limit),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	tag, err := m.DB.Exec(ctx, query, retention.Seconds(), limit)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("expired rows deleted", slog.Int64("rowsAffected", tag.RowsAffected()))
+
+	return tag.RowsAffected(), nil
+}
+
+func (m *PurgeModel) delete(ctx context.Context, query string, ids []uuid.UUID) (int64, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Int("ids", len(ids)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	tag, err := m.DB.Exec(ctx, query, ids)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("rows deleted", slog.Int64("rowsAffected", tag.RowsAffected()))
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,142 @@
+package data_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Rogue Amendiares",
+		Username: "rogue",
+		Email:    "rogue@afterlife.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := models.Forums.Insert(ctx, data.ForumInput{
+		OwnerID: user.ID,
+		Name:    "Afterlife regulars",
+	})
+	assert.NoError(t, err)
+
+	thread, err := models.Threads.Insert(ctx, data.ThreadInput{
+		AuthorID: user.ID,
+		ForumID:  forum.ID,
+		Title:    "House rules",
+	})
+	assert.NoError(t, err)
+
+	post, err := models.Posts.Insert(ctx, data.PostInput{
+		ThreadID: thread.ID,
+		Content:  "No business on the dance floor",
+		AuthorID: user.ID,
+	})
+	assert.NoError(t, err)
+
+	reply, err := models.Posts.Insert(ctx, data.PostInput{
+		ThreadID: thread.ID,
+		ReplyTo:  uuid.NullUUID{UUID: post.ID, Valid: true},
+		Content:  "What about the booths?",
+		AuthorID: user.ID,
+	})
+	assert.NoError(t, err)
+
+	_, err = models.PostVotes.Vote(ctx, data.PostVote{
+		PostID: post.ID,
+		UserID: user.ID,
+		Vote:   1,
+	})
+	assert.NoError(t, err)
+
+	scope := data.PurgeScope{ForumID: uuid.NullUUID{UUID: forum.ID, Valid: true}}
+	var set *data.PurgeSet
+
+	t.Run("Select", func(t *testing.T) {
+		set, err = models.Purges.Select(ctx, scope)
+		assert.NoError(t, err)
+		assert.Empty(t, set.UserIDs)
+		assert.Equal(t, []uuid.UUID{forum.ID}, set.ForumIDs)
+		assert.Equal(t, []uuid.UUID{thread.ID}, set.ThreadIDs)
+		assert.ElementsMatch(t, []uuid.UUID{post.ID, reply.ID}, set.PostIDs)
+		assert.Equal(t, int64(0), set.ThreadVotes)
+		assert.Equal(t, int64(1), set.PostVotes)
+	})
+
+	t.Run("SelectUpdated", func(t *testing.T) {
+		stale := scope
+		stale.UpdatedAt = sql.NullTime{Valid: true, Time: time.Unix(0, 0)}
+		staleSet, err := models.Purges.Select(ctx, stale)
+		assert.NoError(t, err)
+		assert.Empty(t, staleSet.ForumIDs)
+		assert.Empty(t, staleSet.PostIDs)
+	})
+
+	t.Run("SelectPost", func(t *testing.T) {
+		postSet, err := models.Purges.Select(
+			ctx, data.PurgeScope{PostID: uuid.NullUUID{UUID: post.ID, Valid: true}},
+		)
+		assert.NoError(t, err)
+		assert.Empty(t, postSet.ThreadIDs)
+		assert.ElementsMatch(t, []uuid.UUID{post.ID, reply.ID}, postSet.PostIDs)
+	})
+
+	t.Run("SelectUser", func(t *testing.T) {
+		userSet, err := models.Purges.Select(
+			ctx, data.PurgeScope{UserID: uuid.NullUUID{UUID: user.ID, Valid: true}},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{user.ID}, userSet.UserIDs)
+		assert.Empty(t, userSet.ForumIDs)
+		assert.Equal(t, []uuid.UUID{forum.ID}, userSet.OwnedForumIDs)
+	})
+
+	t.Run("SelectExpired", func(t *testing.T) {
+		_, err := models.Threads.SoftDelete(ctx, forum.ID, thread.ID, sql.NullTime{})
+		assert.NoError(t, err)
+
+		expired := func(kind string) []uuid.UUID {
+			scopes, err := models.Purges.SelectExpired(ctx, kind, 0, 1000)
+			assert.NoError(t, err)
+			ids := make([]uuid.UUID, 0, len(scopes))
+			for _, scope := range scopes {
+				ids = append(ids, scope.ThreadID.UUID, scope.PostID.UUID)
+			}
+			return ids
+		}
+
+		assert.Contains(t, expired(data.PurgeKindThreads), thread.ID)
+		posts := expired(data.PurgeKindPosts)
+		assert.NotContains(t, posts, post.ID, "post expired apart from its thread")
+		assert.NotContains(t, posts, reply.ID, "reply expired apart from its thread")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		n, err := models.Purges.DeletePosts(ctx, set.PostIDs)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		n, err = models.Purges.DeleteThreads(ctx, set.ThreadIDs)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		n, err = models.Purges.DeleteForums(ctx, set.ForumIDs)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		n, err = models.Purges.DeleteUsers(ctx, set.UserIDs)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		_, err = models.Forums.Select(ctx, forum.ID)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,143 @@
+package data
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// RateLimitBucket is a token bucket limiting the requests made by a client. Every request takes a
+// token from the bucket, which is refilled at a steady rate up to its capacity.
+type RateLimitBucket struct {
+	// Key identifies the client and the policy the bucket belongs to.
+	Key string `json:"key"`
+	// Tokens is the number of tokens left in the bucket as of UpdatedAt.
+	Tokens float64 `json:"tokens"`
+	// UpdatedAt denotes when tokens were last taken from the bucket.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type RateLimitBucketModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+// Take refills the bucket by the time passed since it was last updated, and takes a token from
+// it. Buckets are created full. The refill is calculated by the database, so that every instance
+// of the application shares the same clock.
+//
+// If the bucket holds less than a single token, the bucket is left untouched and
+// ErrRecordNotFound is returned.
+func (m *RateLimitBucketModel) Take(
+	ctx context.Context,
+	key string,
+	rate float64,
+	capacity float64,
+) (*RateLimitBucket, error) {
+	const query string = `
+INSERT INTO forum.rate_limit_buckets AS b (key, tokens, updated_at)
+VALUES ($1::VARCHAR(256), $3::DOUBLE PRECISION - 1, NOW())
+ON CONFLICT (key) DO UPDATE
+    SET tokens     = LEAST($3::DOUBLE PRECISION,
+                           b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::DOUBLE PRECISION
+                               * $2::DOUBLE PRECISION) - 1,
+        updated_at = NOW()
+WHERE LEAST($3::DOUBLE PRECISION,
+            b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::DOUBLE PRECISION
+                * $2::DOUBLE PRECISION) >= 1
+RETURNING key, tokens, updated_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("key", key),
+		slog.Float64("rate", rate),
+		slog.Float64("capacity", capacity),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var b RateLimitBucket
+	err := m.DB.QueryRow(ctx, query, key, rate, capacity).Scan(&b.Key, &b.Tokens, &b.UpdatedAt)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("token taken from rate limit bucket", slog.Float64("tokens", b.Tokens))
+
+	return &b, nil
+}
+
+// Select returns the bucket as it is refilled at this moment, without taking a token from it.
+func (m *RateLimitBucketModel) Select(
+	ctx context.Context,
+	key string,
+	rate float64,
+	capacity float64,
+) (*RateLimitBucket, error) {
+	const query string = `
+SELECT key,
+       LEAST($3::DOUBLE PRECISION,
+             tokens + EXTRACT(EPOCH FROM NOW() - updated_at)::DOUBLE PRECISION
+                 * $2::DOUBLE PRECISION),
+       NOW()::TIMESTAMP
+FROM forum.rate_limit_buckets
+WHERE key = $1::VARCHAR(256);
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("key", key),
+		slog.Float64("rate", rate),
+		slog.Float64("capacity", capacity),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var b RateLimitBucket
+	err := m.DB.QueryRow(ctx, query, key, rate, capacity).Scan(&b.Key, &b.Tokens, &b.UpdatedAt)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("rate limit bucket selected")
+
+	return &b, nil
+}
+
+// DeleteIdle deletes every bucket which has not been used within the given duration, returning
+// the number of deleted buckets.
+func (m *RateLimitBucketModel) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	const query string = `
+DELETE
+FROM forum.rate_limit_buckets
+WHERE updated_at < NOW() - make_interval(secs => $1::DOUBLE PRECISION);
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Duration("idle", idle),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	tag, err := m.DB.Exec(ctx, query, idle.Seconds())
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("idle rate limit buckets deleted", slog.Int64("rowsAffected", tag.RowsAffected()))
+
+	return tag.RowsAffected(), nil
+}
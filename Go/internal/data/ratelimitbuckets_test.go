@@ -0,0 +1,59 @@
+package data_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitBucketModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	const key string = "writes:ip:10.0.0.77"
+	// A token per hour keeps the bucket from being refilled during the test.
+	const perHour float64 = 1.0 / 3600
+
+	t.Run("Take", func(t *testing.T) {
+		bucket, err := models.RateLimitBuckets.Take(ctx, key, perHour, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, key, bucket.Key)
+		assert.InDelta(t, 1, bucket.Tokens, 0.01)
+
+		bucket, err = models.RateLimitBuckets.Take(ctx, key, perHour, 2)
+		assert.NoError(t, err)
+		assert.InDelta(t, 0, bucket.Tokens, 0.01)
+	})
+
+	t.Run("TakeEmpty", func(t *testing.T) {
+		_, err := models.RateLimitBuckets.Take(ctx, key, perHour, 2)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("Select", func(t *testing.T) {
+		bucket, err := models.RateLimitBuckets.Select(ctx, key, perHour, 2)
+		assert.NoError(t, err)
+		assert.InDelta(t, 0, bucket.Tokens, 0.01)
+
+		// Refilling faster than the bucket can hold caps the tokens at its capacity.
+		bucket, err = models.RateLimitBuckets.Select(ctx, key, 1e9, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(2), bucket.Tokens)
+	})
+
+	t.Run("DeleteIdle", func(t *testing.T) {
+		deleted, err := models.RateLimitBuckets.DeleteIdle(ctx, time.Hour)
+		assert.NoError(t, err)
+		assert.Zero(t, deleted)
+
+		deleted, err = models.RateLimitBuckets.DeleteIdle(ctx, -time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		_, err = models.RateLimitBuckets.Select(ctx, key, perHour, 2)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,182 @@
+package data
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// PostRevision is the content of a post at some point in time.
+//
+// Revisions are recorded by database triggers whenever a post is created or its content changes,
+// and are never created by the application.
+type PostRevision struct {
+	// PostID is the post the revision belongs to.
+	PostID uuid.UUID `json:"postId"`
+	// Revision is the number of the revision, starting at 1 for the original content.
+	Revision int `json:"revision"`
+	// Content is the content of the post as of the revision.
+	Content string `json:"content"`
+	// CreatedAt denotes when the revision was made.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type PostRevisionModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+// SelectAll selects every revision of a post, oldest first.
+func (m *PostRevisionModel) SelectAll(
+	ctx context.Context,
+	postID uuid.UUID,
+) ([]*PostRevision, error) {
+	const query string = `
+SELECT post_id, revision, content, created_at
+FROM forum.post_revisions
+WHERE post_id = $1::UUID
+ORDER BY revision;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("postId", postID.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, postID)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	defer rows.Close()
+
+	revisions := []*PostRevision{}
+
+	for rows.Next() {
+		var r PostRevision
+
+		err := rows.Scan(&r.PostID, &r.Revision, &r.Content, &r.CreatedAt)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		revisions = append(revisions, &r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("post revisions selected", slog.Int("length", len(revisions)))
+
+	return revisions, nil
+}
+
+// Select selects a single revision of a post.
+func (m *PostRevisionModel) Select(
+	ctx context.Context,
+	postID uuid.UUID,
+	revision int,
+) (*PostRevision, error) {
+	const query string = `
+SELECT post_id, revision, content, created_at
+FROM forum.post_revisions
+WHERE post_id = $1::UUID
+  AND revision = $2::INTEGER;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("postId", postID.String()),
+		slog.Int("revision", revision),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var r PostRevision
+	err := m.DB.QueryRow(ctx, query, postID, revision).
+		Scan(&r.PostID, &r.Revision, &r.Content, &r.CreatedAt)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("post revision selected", slog.Any("revision", r))
+
+	return &r, nil
+}
+
+// ThreadRevision is the title of a thread at some point in time.
+//
+// Revisions are recorded by database triggers whenever a thread is created or its title changes,
+// and are never created by the application.
+type ThreadRevision struct {
+	// ThreadID is the thread the revision belongs to.
+	ThreadID uuid.UUID `json:"threadId"`
+	// Revision is the number of the revision, starting at 1 for the original title.
+	Revision int `json:"revision"`
+	// Title is the title of the thread as of the revision.
+	Title string `json:"title"`
+	// CreatedAt denotes when the revision was made.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ThreadRevisionModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+// SelectAll selects every revision of a thread title, oldest first.
+func (m *ThreadRevisionModel) SelectAll(
+	ctx context.Context,
+	threadID uuid.UUID,
+) ([]*ThreadRevision, error) {
+	const query string = `
+SELECT thread_id, revision, title, created_at
+FROM forum.thread_revisions
+WHERE thread_id = $1::UUID
+ORDER BY revision;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("threadId", threadID.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, threadID)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	defer rows.Close()
+
+	revisions := []*ThreadRevision{}
+
+	for rows.Next() {
+		var r ThreadRevision
+
+		err := rows.Scan(&r.ThreadID, &r.Revision, &r.Title, &r.CreatedAt)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		revisions = append(revisions, &r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("thread revisions selected", slog.Int("length", len(revisions)))
+
+	return revisions, nil
+}
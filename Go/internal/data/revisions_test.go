@@ -0,0 +1,97 @@
+package data_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevisionModels(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Judy Alvarez",
+		Username: "judy",
+		Email:    "judy@mox.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := models.Forums.Insert(ctx, data.ForumInput{
+		OwnerID: user.ID,
+		Name:    "Braindance editing",
+	})
+	assert.NoError(t, err)
+
+	thread, err := models.Threads.Insert(ctx, data.ThreadInput{
+		AuthorID: user.ID,
+		ForumID:  forum.ID,
+		Title:    "Clouds recordings",
+	})
+	assert.NoError(t, err)
+
+	post, err := models.Posts.Insert(ctx, data.PostInput{
+		ThreadID: thread.ID,
+		ReplyTo:  uuid.NullUUID{Valid: false},
+		Content:  "Got a fresh braindance\nNeeds some editing",
+		AuthorID: user.ID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, post.EditCount)
+
+	t.Run("PostRevisions", func(t *testing.T) {
+		edited, err := models.Posts.Update(ctx, data.PostPatch{
+			ID:       post.ID,
+			ThreadID: thread.ID,
+			Content:  sql.NullString{Valid: true, String: "Got a fresh braindance\nAll edited"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, edited.EditCount)
+
+		// Changes without new content are not revisions.
+		deleted, err := models.Posts.SoftDelete(ctx, post.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, deleted.EditCount)
+
+		revisions, err := models.PostRevisions.SelectAll(ctx, post.ID)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+		assert.Equal(t, 1, revisions[0].Revision)
+		assert.Equal(t, post.Content, revisions[0].Content)
+		assert.Equal(t, 2, revisions[1].Revision)
+		assert.Equal(t, edited.Content, revisions[1].Content)
+
+		revision, err := models.PostRevisions.Select(ctx, post.ID, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, edited.Content, revision.Content)
+
+		_, err = models.PostRevisions.Select(ctx, post.ID, 3)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("ThreadRevisions", func(t *testing.T) {
+		_, err := models.Threads.Update(ctx, data.ThreadPatch{
+			ID:    thread.ID,
+			Title: sql.NullString{Valid: true, String: "Clouds recordings, edited"},
+		})
+		assert.NoError(t, err)
+
+		revisions, err := models.ThreadRevisions.SelectAll(ctx, thread.ID)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+		assert.Equal(t, thread.Title, revisions[0].Title)
+		assert.Equal(t, "Clouds recordings, edited", revisions[1].Title)
+	})
+
+	_, err = models.Posts.Delete(ctx, post.ID)
+	assert.NoError(t, err)
+
+	revisions, err := models.PostRevisions.SelectAll(ctx, post.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, revisions)
+}
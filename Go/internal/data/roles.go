@@ -0,0 +1,218 @@
+package data
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// UserRole represents a role granted to a user. Forum scoped roles, such as moderators, reference
+// the forum the role is granted for.
+type UserRole struct {
+	// UserID is the unique identifier of the user the role is granted to.
+	UserID uuid.UUID `json:"userId"`
+	// Role is the name of the granted role.
+	Role string `json:"role"`
+	// ForumID is the forum the role is granted for. Global roles, such as admins, have no forum.
+	ForumID uuid.NullUUID `json:"forumId"`
+	// CreatedAt denotes when the role was granted.
+	//
+	// Upon granting a role, any existing values in this field is ignored. The database handles
+	// setting the value upon insertion.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type RoleModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+// SelectAll retrieves the granted roles matching the role name and forum. If the forum ID is
+// not valid, only roles without a forum are returned.
+func (m *RoleModel) SelectAll(
+	ctx context.Context,
+	role string,
+	forumID uuid.NullUUID,
+) ([]*UserRole, error) {
+	const query string = `
+SELECT user_id, role, forum_id, created_at
+FROM forum.user_roles
+WHERE role = $1
+  AND forum_id IS NOT DISTINCT FROM $2::UUID
+ORDER BY created_at, user_id;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("role", role),
+		slog.Any("forumId", forumID),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, role, forumID)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+
+	roles := []*UserRole{}
+
+	for rows.Next() {
+		var r UserRole
+
+		err := rows.Scan(&r.UserID, &r.Role, &r.ForumID, &r.CreatedAt)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		roles = append(roles, &r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("roles selected", slog.Int("length", len(roles)))
+
+	return roles, nil
+}
+
+// SelectForUser retrieves the names of the roles a user holds, either globally or within the
+// given forum. Ownership of the forum is included as the owner role.
+func (m *RoleModel) SelectForUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	forumID uuid.NullUUID,
+) ([]string, error) {
+	const query string = `
+SELECT role
+FROM forum.user_roles
+WHERE user_id = $1
+  AND (forum_id IS NULL OR forum_id = $2::UUID)
+UNION
+SELECT 'owner'
+FROM forum.forums
+WHERE id = $2::UUID
+  AND owner_id = $1;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("userId", userID.String()),
+		slog.Any("forumId", forumID),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, userID, forumID)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+
+	roles := []string{}
+
+	for rows.Next() {
+		var role string
+
+		err := rows.Scan(&role)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		roles = append(roles, role)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("user roles selected", slog.Any("roles", roles))
+
+	return roles, nil
+}
+
+func (m *RoleModel) Insert(ctx context.Context, input UserRole) (*UserRole, error) {
+	const query string = `
+INSERT INTO forum.user_roles (user_id, role, forum_id)
+VALUES ($1, $2, $3)
+RETURNING user_id, role, forum_id, created_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Any("input", input),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var r UserRole
+	err := m.DB.QueryRow(
+		ctx,
+		query,
+		input.UserID,
+		input.Role,
+		input.ForumID,
+	).Scan(
+		&r.UserID,
+		&r.Role,
+		&r.ForumID,
+		&r.CreatedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("role granted", slog.Any("role", r))
+
+	return &r, nil
+}
+
+func (m *RoleModel) Delete(ctx context.Context, input UserRole) (*UserRole, error) {
+	const query string = `
+DELETE
+FROM forum.user_roles
+WHERE user_id = $1
+  AND role = $2
+  AND forum_id IS NOT DISTINCT FROM $3::UUID
+RETURNING user_id, role, forum_id, created_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Any("input", input),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var r UserRole
+	err := m.DB.QueryRow(
+		ctx,
+		query,
+		input.UserID,
+		input.Role,
+		input.ForumID,
+	).Scan(
+		&r.UserID,
+		&r.Role,
+		&r.ForumID,
+		&r.CreatedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("role revoked", slog.Any("role", r))
+
+	return &r, nil
+}
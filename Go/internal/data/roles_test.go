@@ -0,0 +1,76 @@
+package data_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Rogue Amendiares",
+		Username: "rogue",
+		Email:    "rogue@afterlife.com",
+	})
+	assert.NoError(t, err)
+
+	moderator, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Claire Russel",
+		Username: "c.russel",
+		Email:    "c.russel@afterlife.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := models.Forums.Insert(ctx, data.ForumInput{
+		OwnerID: owner.ID,
+		Name:    "The Afterlife",
+	})
+	assert.NoError(t, err)
+
+	role := data.UserRole{
+		UserID:  moderator.ID,
+		Role:    "moderator",
+		ForumID: uuid.NullUUID{UUID: forum.ID, Valid: true},
+	}
+
+	t.Run("Insert", func(t *testing.T) {
+		r, err := models.Roles.Insert(ctx, role)
+		assert.NoError(t, err)
+		assert.Equal(t, role.UserID, r.UserID)
+		assert.Equal(t, role.ForumID, r.ForumID)
+	})
+
+	t.Run("InsertInvalidScope", func(t *testing.T) {
+		_, err := models.Roles.Insert(ctx, data.UserRole{UserID: moderator.ID, Role: "moderator"})
+		assert.ErrorIs(t, err, data.ErrCheckConstraintViolation)
+	})
+
+	t.Run("SelectAll", func(t *testing.T) {
+		roles, err := models.Roles.SelectAll(ctx, "moderator", role.ForumID)
+		assert.NoError(t, err)
+		assert.Len(t, roles, 1)
+	})
+
+	t.Run("SelectForUser", func(t *testing.T) {
+		roles, err := models.Roles.SelectForUser(ctx, moderator.ID, role.ForumID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"moderator"}, roles)
+
+		roles, err = models.Roles.SelectForUser(ctx, owner.ID, role.ForumID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"owner"}, roles)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		r, err := models.Roles.Delete(ctx, role)
+		assert.NoError(t, err)
+		assert.Equal(t, role.UserID, r.UserID)
+	})
+}
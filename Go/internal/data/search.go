@@ -0,0 +1,196 @@
+package data
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+const (
+	SearchTypeForum  string = "forum"
+	SearchTypeThread string = "thread"
+	SearchTypePost   string = "post"
+)
+
+// SearchTypes lists every type of resource that can be searched.
+var SearchTypes = []string{SearchTypeForum, SearchTypeThread, SearchTypePost}
+
+// SearchResult is a forum, thread or post matching a full-text search.
+type SearchResult struct {
+	// Type is the type of the matching resource, either forum, thread or post.
+	Type string `json:"type"`
+	// ID is the unique identifier of the matching resource.
+	ID uuid.UUID `json:"id"`
+	// ForumID is the forum the resource belongs to. For forums, this is the ID of the forum itself.
+	ForumID uuid.UUID `json:"forumId"`
+	// ThreadID is the thread the resource belongs to. Forums do not belong to any thread.
+	ThreadID uuid.NullUUID `json:"threadId"`
+	// Title is the name of the forum, or the title of the thread the resource belongs to.
+	Title string `json:"title"`
+	// Snippet is an HTML excerpt of the matching text, with the matching terms highlighted. The
+	// text itself is escaped.
+	Snippet string `json:"snippet"`
+	// Rank denotes how well the resource matches the search, where higher is better.
+	Rank float32 `json:"rank"`
+	// CreatedAt denotes when the resource was created.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+var searchSortColumns = sortColumns{
+	"rank": "REAL",
+}
+
+// searchOrderBy is the only ordering of search results, best matches first.
+var searchOrderBy = []string{"-rank"}
+
+func (s *SearchResult) keysetID() uuid.UUID {
+	return s.ID
+}
+
+func (s *SearchResult) keysetValue(column string) any {
+	switch column {
+	case "rank":
+		return s.Rank
+	default:
+		return nil
+	}
+}
+
+type SearchModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+// SelectAll performs a full-text search of the types of resources given in the filters, ranking
+// the results by relevance. Deleted resources are never included.
+//
+// The search terms are parsed with websearch_to_tsquery, supporting quoted phrases, "or" and
+// negation with "-".
+func (m *SearchModel) SelectAll(
+	ctx context.Context,
+	filters Filters,
+) ([]*SearchResult, *Metadata, error) {
+	filters.OrderBy = searchOrderBy
+	if len(filters.Types) == 0 {
+		filters.Types = SearchTypes
+	}
+	where, orderBy, keysetArgs, err := createKeysetClauses(filters, searchSortColumns, 5)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+WITH search AS (SELECT websearch_to_tsquery('english', $2::TEXT) AS query)
+SELECT type, id, forum_id, thread_id, title, snippet, rank, created_at
+FROM (SELECT 'forum'    AS type,
+             f.id,
+             f.id       AS forum_id,
+             NULL::UUID AS thread_id,
+             f.name     AS title,
+             ts_headline('english',
+                         forum.escape_html(f.name || ' ' || COALESCE(f.description, '')),
+                         s.query,
+                         'StartSel=<mark>, StopSel=</mark>, MaxFragments=2') AS snippet,
+             ts_rank(f.search_vector, s.query) AS rank,
+             f.created_at
+      FROM forum.forums f,
+           search s
+      WHERE 'forum' = ANY ($3::TEXT[])
+        AND f.search_vector @@ s.query
+        AND f.deleted IS NOT TRUE
+        AND ($4::UUID IS NULL OR f.id = $4::UUID)
+      UNION ALL
+      SELECT 'thread',
+             t.id,
+             t.forum_id,
+             t.id,
+             t.title,
+             ts_headline('english', forum.escape_html(t.title), s.query,
+                         'StartSel=<mark>, StopSel=</mark>'),
+             ts_rank(t.search_vector, s.query),
+             t.created_at
+      FROM forum.threads t,
+           search s
+      WHERE 'thread' = ANY ($3::TEXT[])
+        AND t.search_vector @@ s.query
+        AND t.deleted IS NOT TRUE
+        AND ($4::UUID IS NULL OR t.forum_id = $4::UUID)
+      UNION ALL
+      SELECT 'post',
+             p.id,
+             t.forum_id,
+             p.thread_id,
+             t.title,
+             ts_headline('english', forum.escape_html(p.content), s.query,
+                         'StartSel=<mark>, StopSel=</mark>, MaxFragments=2'),
+             ts_rank(p.search_vector, s.query),
+             p.created_at
+      FROM forum.posts p
+               INNER JOIN forum.threads t ON t.id = p.thread_id,
+           search s
+      WHERE 'post' = ANY ($3::TEXT[])
+        AND p.search_vector @@ s.query
+        AND p.deleted IS NOT TRUE
+        AND t.deleted IS NOT TRUE
+        AND ($4::UUID IS NULL OR t.forum_id = $4::UUID)) results
+WHERE TRUE
+` + where + `
+` + orderBy + `
+LIMIT $1::INTEGER;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Any("filters", filters),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	args := append([]any{
+		filters.PageSize + 1,
+		filters.Query,
+		filters.Types,
+		filters.ForumID,
+	}, keysetArgs...)
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("unable to perform query", slog.String("error", err.Error()))
+		return nil, nil, err
+	}
+
+	results := []*SearchResult{}
+
+	for rows.Next() {
+		var s SearchResult
+
+		err := rows.Scan(
+			&s.Type,
+			&s.ID,
+			&s.ForumID,
+			&s.ThreadID,
+			&s.Title,
+			&s.Snippet,
+			&s.Rank,
+			&s.CreatedAt,
+		)
+		if err != nil {
+			return nil, nil, handleError(err, logger)
+		}
+		results = append(results, &s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, handleError(err, logger)
+	}
+	results, metadata := paginate(results, filters)
+
+	logger.Info("search results selected", slog.Any("metadata", metadata))
+	return results, &metadata, nil
+}
@@ -0,0 +1,113 @@
+package data_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Viktor Vektor",
+		Username: "v.vektor.search",
+		Email:    "v.vektor.search@ripperdoc.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := models.Forums.Insert(ctx, data.ForumInput{
+		OwnerID: user.ID,
+		Name:    "Ripperdocs",
+	})
+	assert.NoError(t, err)
+
+	thread, err := models.Threads.Insert(ctx, data.ThreadInput{
+		AuthorID: user.ID,
+		ForumID:  forum.ID,
+		Title:    "Installing mantis blades",
+	})
+	assert.NoError(t, err)
+
+	post, err := models.Posts.Insert(ctx, data.PostInput{
+		ThreadID: thread.ID,
+		AuthorID: user.ID,
+		Content:  "Mantis blades require a steady hand and a good ripperdoc",
+	})
+	assert.NoError(t, err)
+
+	t.Run("SelectAll", func(t *testing.T) {
+		query := "mantis"
+		results, metadata, err := models.Search.SelectAll(ctx, data.Filters{
+			Query:    &query,
+			ForumID:  &forum.ID,
+			PageSize: 25,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, metadata.ResponseLength)
+		for _, result := range results {
+			assert.Contains(t, []string{thread.ID.String(), post.ID.String()}, result.ID.String())
+			assert.Contains(t, result.Snippet, "<mark>")
+		}
+	})
+
+	t.Run("SelectAllTypes", func(t *testing.T) {
+		query := "mantis"
+		results, _, err := models.Search.SelectAll(ctx, data.Filters{
+			Query:    &query,
+			Types:    []string{data.SearchTypePost},
+			ForumID:  &forum.ID,
+			PageSize: 25,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, post.ID, results[0].ID)
+		assert.Equal(t, data.SearchTypePost, results[0].Type)
+	})
+
+	t.Run("Paginate", func(t *testing.T) {
+		query := "mantis"
+		filters := data.Filters{Query: &query, ForumID: &forum.ID, PageSize: 1}
+
+		first, metadata, err := models.Search.SelectAll(ctx, filters)
+		assert.NoError(t, err)
+		assert.Len(t, first, 1)
+		assert.True(t, metadata.Next)
+
+		filters.Cursor, err = data.DecodeCursor(metadata.NextCursor)
+		assert.NoError(t, err)
+
+		second, metadata, err := models.Search.SelectAll(ctx, filters)
+		assert.NoError(t, err)
+		assert.Len(t, second, 1)
+		assert.NotEqual(t, first[0].ID, second[0].ID)
+		assert.False(t, metadata.Next)
+	})
+
+	t.Run("EscapeHTML", func(t *testing.T) {
+		_, err := models.Posts.Insert(ctx, data.PostInput{
+			ThreadID: thread.ID,
+			AuthorID: user.ID,
+			Content:  `Cyberware <script>alert("flatline")</script> & <b>chrome</b>`,
+		})
+		assert.NoError(t, err)
+
+		query := "cyberware"
+		results, _, err := models.Search.SelectAll(ctx, data.Filters{
+			Query:    &query,
+			Types:    []string{data.SearchTypePost},
+			ForumID:  &forum.ID,
+			PageSize: 25,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Contains(t, results[0].Snippet, "<mark>Cyberware</mark>")
+		assert.Contains(t, results[0].Snippet, "&lt;script&gt;")
+		assert.NotContains(t, results[0].Snippet, "<script>")
+		assert.NotContains(t, results[0].Snippet, "<b>")
+	})
+}
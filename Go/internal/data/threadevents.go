@@ -0,0 +1,96 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+const (
+	// ThreadEventChannel is the channel thread events are published to with NOTIFY.
+	ThreadEventChannel string = "thread_events"
+
+	ThreadEventPostCreated string = "post.created"
+	ThreadEventPostEdited  string = "post.edited"
+	ThreadEventPostDeleted string = "post.deleted"
+	ThreadEventVoteChanged string = "vote.changed"
+)
+
+// ThreadEvent is an activity within a thread, such as a new post or a change of votes.
+//
+// Events are recorded by database triggers, and are never created by the application.
+type ThreadEvent struct {
+	// ID is the unique, ever-increasing identifier of the event.
+	ID int64 `json:"id"`
+	// ThreadID is the thread the event happened within.
+	ThreadID uuid.UUID `json:"threadId"`
+	// Type is the type of the event, such as post.created.
+	Type string `json:"type"`
+	// Payload describes the event. The contents depend on the type of the event.
+	Payload json.RawMessage `json:"payload"`
+	// CreatedAt denotes when the event happened.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ThreadEventModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+// SelectAfter selects the events of a thread with an ID greater than the given ID, oldest first.
+func (m *ThreadEventModel) SelectAfter(
+	ctx context.Context,
+	threadID uuid.UUID,
+	afterID int64,
+	limit int,
+) ([]*ThreadEvent, error) {
+	const query string = `
+SELECT id, thread_id, type, payload, created_at
+FROM forum.thread_events
+WHERE thread_id = $1::UUID
+  AND id > $2::BIGINT
+ORDER BY id
+LIMIT $3::INTEGER;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("threadId", threadID.String()),
+		slog.Int64("afterId", afterID),
+		slog.Int("limit", limit),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, threadID, afterID, limit)
+	if err != nil {
+		logger.Error("unable to perform query", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	events := []*ThreadEvent{}
+
+	for rows.Next() {
+		var e ThreadEvent
+
+		err := rows.Scan(&e.ID, &e.ThreadID, &e.Type, &e.Payload, &e.CreatedAt)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		events = append(events, &e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("thread events selected", slog.Int("length", len(events)))
+
+	return events, nil
+}
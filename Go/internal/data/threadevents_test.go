@@ -0,0 +1,95 @@
+package data_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreadEventModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Kerry Eurodyne",
+		Username: "kerry",
+		Email:    "kerry@samurai.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := models.Forums.Insert(ctx, data.ForumInput{
+		OwnerID: user.ID,
+		Name:    "Samurai reunion",
+	})
+	assert.NoError(t, err)
+
+	thread, err := models.Threads.Insert(ctx, data.ThreadInput{
+		AuthorID: user.ID,
+		ForumID:  forum.ID,
+		Title:    "One last gig at the Arasaka tower",
+	})
+	assert.NoError(t, err)
+
+	post, err := models.Posts.Insert(ctx, data.PostInput{
+		ThreadID: thread.ID,
+		ReplyTo:  uuid.NullUUID{Valid: false},
+		Content:  "Who is in for the reunion concert?",
+		AuthorID: user.ID,
+	})
+	assert.NoError(t, err)
+
+	t.Run("SelectAfter", func(t *testing.T) {
+		_, err := models.Posts.Update(ctx, data.PostPatch{
+			ID:       post.ID,
+			ThreadID: thread.ID,
+			Content:  sql.NullString{Valid: true, String: "Who is in for the reunion gig?"},
+		})
+		assert.NoError(t, err)
+
+		_, err = models.Posts.SoftDelete(ctx, post.ID)
+		assert.NoError(t, err)
+
+		events, err := models.ThreadEvents.SelectAfter(ctx, thread.ID, 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, events, 3)
+		assert.Equal(t, data.ThreadEventPostCreated, events[0].Type)
+		assert.Equal(t, data.ThreadEventPostEdited, events[1].Type)
+		assert.Equal(t, data.ThreadEventPostDeleted, events[2].Type)
+
+		var payload struct {
+			PostID uuid.UUID `json:"postId"`
+		}
+		assert.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+		assert.Equal(t, post.ID, payload.PostID)
+
+		events, err = models.ThreadEvents.SelectAfter(ctx, thread.ID, events[0].ID, 10)
+		assert.NoError(t, err)
+		assert.Len(t, events, 2)
+	})
+
+	t.Run("DeleteExpiredThreadEvents", func(t *testing.T) {
+		_, err := models.Purges.DeleteExpiredThreadEvents(ctx, time.Hour, 1000)
+		assert.NoError(t, err)
+
+		events, err := models.ThreadEvents.SelectAfter(ctx, thread.ID, 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, events, 3)
+
+		deleted, err := models.Purges.DeleteExpiredThreadEvents(ctx, 0, 1000)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(3))
+
+		events, err = models.ThreadEvents.SelectAfter(ctx, thread.ID, 0, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	_, err = models.Posts.Delete(ctx, post.ID)
+	assert.NoError(t, err)
+}
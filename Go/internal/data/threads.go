@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
 )
 
@@ -37,6 +37,14 @@ type Thread struct {
 	//
 	// This field is ignored when updating or creating new threads.
 	IsLocked bool `json:"isLocked"`
+	// LockedBy is the unique identifier of the user which locked the thread.
+	//
+	// This field is ignored when updating or creating new threads.
+	LockedBy uuid.NullUUID `json:"lockedBy,omitzero"`
+	// LockedAt denotes when the thread was locked.
+	//
+	// This field is ignored when updating or creating new threads.
+	LockedAt sql.NullTime `json:"lockedAt,omitzero"`
 	// Deleted is a soft delete flag for a thread.
 	Deleted bool `json:"deleted,omitzero"`
 	// DeletedAt denotes when a thread was marked as deleted.
@@ -47,6 +55,35 @@ type Thread struct {
 	Likes int64 `json:"likes"`
 }
 
+var threadSortColumns = sortColumns{
+	"title":      "VARCHAR(128)",
+	"created_at": "TIMESTAMP",
+	"updated_at": "TIMESTAMP",
+	"likes":      "BIGINT",
+}
+
+// ThreadOrderBySafeList lists the values threads may be ordered by.
+var ThreadOrderBySafeList = threadSortColumns.safeList()
+
+func (t *Thread) keysetID() uuid.UUID {
+	return t.ID
+}
+
+func (t *Thread) keysetValue(column string) any {
+	switch column {
+	case "title":
+		return t.Title
+	case "created_at":
+		return t.CreatedAt
+	case "updated_at":
+		return t.UpdatedAt
+	case "likes":
+		return t.Likes
+	default:
+		return nil
+	}
+}
+
 type ThreadInput struct {
 	// ForumID is the parent forum this thread belongs to.
 	ForumID uuid.UUID `json:"forumId"`
@@ -68,10 +105,13 @@ type ThreadPatch struct {
 	Title sql.NullString `json:"title"`
 	// AuthorID is the unique identifier of the author of the thread.
 	AuthorID uuid.NullUUID `json:"authorId"`
+	// UpdatedAt is the time the thread is expected to have last been updated at. If set, the patch
+	// is only applied if the thread has not been updated since.
+	UpdatedAt sql.NullTime `json:"updatedAt,omitzero"`
 }
 
 type ThreadModel struct {
-	DB      *pgxpool.Pool
+	DB      Querier
 	Timeout *time.Duration
 }
 
@@ -80,11 +120,43 @@ func (m *ThreadModel) Select(
 	forumID uuid.UUID,
 	threadID uuid.UUID,
 ) (*Thread, error) {
-	const query string = `
-SELECT id, forum_id, title, author_id, created_at, updated_at, is_locked, deleted, deleted_at, likes
+	return m.selectThread(ctx, forumID, threadID, "")
+}
+
+// SelectForShare selects a thread, and locks it against changes until the end of the transaction,
+// such as being locked or deleted. The lock is shared, allowing the thread to be selected for
+// share by concurrent transactions.
+func (m *ThreadModel) SelectForShare(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+) (*Thread, error) {
+	return m.selectThread(ctx, forumID, threadID, "FOR SHARE")
+}
+
+// SelectForUpdate selects a thread, and locks it exclusively until the end of the transaction. It
+// is meant for transactions updating the thread after checking it, as upgrading a shared lock may
+// deadlock with concurrent transactions doing the same.
+func (m *ThreadModel) SelectForUpdate(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+) (*Thread, error) {
+	return m.selectThread(ctx, forumID, threadID, "FOR NO KEY UPDATE")
+}
+
+func (m *ThreadModel) selectThread(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+	lock string,
+) (*Thread, error) {
+	query := `
+SELECT id, forum_id, title, author_id, created_at, updated_at, is_locked, locked_by, locked_at, deleted, deleted_at, likes
 FROM forum.threads
 WHERE id = $1::UUID
-  AND forum_id = $2::UUID;
+  AND forum_id = $2::UUID
+` + lock + `;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -113,6 +185,8 @@ WHERE id = $1::UUID
 		&t.CreatedAt,
 		&t.UpdatedAt,
 		&t.IsLocked,
+		&t.LockedBy,
+		&t.LockedAt,
 		&t.Deleted,
 		&t.DeletedAt,
 		&t.Likes,
@@ -125,12 +199,88 @@ WHERE id = $1::UUID
 	return &t, nil
 }
 
+// SelectMany retrieves the threads with the given IDs, regardless of the forums they belong to.
+// IDs of threads that do not exist are ignored.
+func (m *ThreadModel) SelectMany(ctx context.Context, ids []uuid.UUID) ([]*Thread, error) {
+	const query string = `
+SELECT id,
+       forum_id,
+       title,
+       author_id,
+       created_at,
+       updated_at,
+       is_locked,
+       locked_by,
+       locked_at,
+       deleted,
+       deleted_at,
+       likes
+FROM forum.threads
+WHERE id = ANY ($1::UUID[]);
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Int("ids", len(ids)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	threads := []*Thread{}
+	if len(ids) == 0 {
+		return threads, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, ids)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t Thread
+		err := rows.Scan(
+			&t.ID,
+			&t.ForumID,
+			&t.Title,
+			&t.AuthorID,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+			&t.IsLocked,
+			&t.LockedBy,
+			&t.LockedAt,
+			&t.Deleted,
+			&t.DeletedAt,
+			&t.Likes,
+		)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		threads = append(threads, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("threads selected", slog.Int("length", len(threads)))
+
+	return threads, nil
+}
+
 func (m *ThreadModel) SelectAll(
 	ctx context.Context,
 	filters Filters,
 ) ([]*Thread, *Metadata, error) {
+	where, orderBy, keysetArgs, err := createKeysetClauses(filters, threadSortColumns, 14)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	query := `
-SELECT id, forum_id, title, author_id, created_at, updated_at, is_locked, deleted, deleted_at, likes
+SELECT id, forum_id, title, author_id, created_at, updated_at, is_locked, locked_by, locked_at, deleted, deleted_at, likes
 FROM forum.threads
 WHERE ($2::UUID IS NULL OR id = $2::UUID)
   AND ($3::UUID IS NULL OR forum_id = $3::UUID)
@@ -144,8 +294,8 @@ WHERE ($2::UUID IS NULL OR id = $2::UUID)
   AND ($11::BOOLEAN IS NULL or deleted = $11::BOOLEAN)
   AND ($12::TIMESTAMP IS NULL or deleted_at >= $12::TIMESTAMP)
   AND ($13::TIMESTAMP IS NULL or deleted_at <= $13::TIMESTAMP)
-  AND id > $14::UUID
-` + CreateOrderByClause(filters.OrderBy) + `
+` + where + `
+` + orderBy + `
 LIMIT $1::INTEGER;
 `
 
@@ -156,11 +306,8 @@ LIMIT $1::INTEGER;
 		slog.Duration("timeout", *m.Timeout),
 	))
 
-	logger.Info("performing query")
-	rows, err := m.DB.Query(
-		ctx,
-		query,
-		filters.PageSize,
+	args := append([]any{
+		filters.PageSize + 1,
 		filters.ID,
 		filters.ForumID,
 		filters.Title,
@@ -173,8 +320,10 @@ LIMIT $1::INTEGER;
 		filters.Deleted,
 		filters.DeletedAtFrom,
 		filters.DeletedAtTo,
-		filters.LastSeen,
-	)
+	}, keysetArgs...)
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, args...)
 	if err != nil {
 		logger.Error("unable to perform query", slog.String("error", err.Error()))
 		return nil, nil, err
@@ -193,6 +342,8 @@ LIMIT $1::INTEGER;
 			&t.CreatedAt,
 			&t.UpdatedAt,
 			&t.IsLocked,
+			&t.LockedBy,
+			&t.LockedAt,
 			&t.Deleted,
 			&t.DeletedAt,
 			&t.Likes,
@@ -205,15 +356,7 @@ LIMIT $1::INTEGER;
 	if err = rows.Err(); err != nil {
 		return nil, nil, handleError(err, logger)
 	}
-	length := len(threads)
-	var metadata Metadata
-	if length > 0 {
-		metadata.LastSeen = threads[length-1].ID
-	}
-	if length >= filters.PageSize {
-		metadata.Next = true
-	}
-	metadata.ResponseLength = length
+	threads, metadata := paginate(threads, filters)
 
 	logger.Info("threads selected", slog.Any("metadata", metadata))
 	return threads, &metadata, nil
@@ -275,11 +418,26 @@ WHERE ($1::UUID IS NULL OR id = $1::UUID)
 	return &count, nil
 }
 
+// SelectCounts counts the threads of each of the given forums. Forums without threads are left out.
+func (m *ThreadModel) SelectCounts(
+	ctx context.Context,
+	forumIDs []uuid.UUID,
+) (map[uuid.UUID]int, error) {
+	const query string = `
+SELECT forum_id, COUNT(*)
+FROM forum.threads
+WHERE forum_id = ANY ($1::UUID[])
+GROUP BY forum_id;
+`
+
+	return selectTotals(ctx, m.DB, *m.Timeout, query, forumIDs)
+}
+
 func (m *ThreadModel) Insert(ctx context.Context, input ThreadInput) (*Thread, error) {
 	const query string = `
 INSERT INTO forum.threads(forum_id, title, author_id)
 VALUES($1::UUID, $2::VARCHAR(256), $3::UUID)
-RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, deleted, deleted_at, likes;
+RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, locked_by, locked_at, deleted, deleted_at, likes;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -308,6 +466,8 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 		&t.CreatedAt,
 		&t.UpdatedAt,
 		&t.IsLocked,
+		&t.LockedBy,
+		&t.LockedAt,
 		&t.Deleted,
 		&t.DeletedAt,
 		&t.Likes,
@@ -324,10 +484,12 @@ func (m *ThreadModel) Update(ctx context.Context, input ThreadPatch) (*Thread, e
 	const query string = `
 UPDATE forum.threads
 SET title = COALESCE($3::VARCHAR(256), title),
-    author_id = COALESCE($4::UUID, author_id)
+    author_id = COALESCE($4::UUID, author_id),
+    updated_at = NOW()
 WHERE id = $1::UUID
   AND forum_id = $2::UUID
-RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, deleted, deleted_at, likes;
+  AND ($5::TIMESTAMP IS NULL OR updated_at = $5::TIMESTAMP)
+RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, locked_by, locked_at, deleted, deleted_at, likes;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -349,6 +511,7 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 		input.ForumID,
 		input.Title,
 		input.AuthorID,
+		input.UpdatedAt,
 	).Scan(
 		&t.ID,
 		&t.ForumID,
@@ -357,6 +520,8 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 		&t.CreatedAt,
 		&t.UpdatedAt,
 		&t.IsLocked,
+		&t.LockedBy,
+		&t.LockedAt,
 		&t.Deleted,
 		&t.DeletedAt,
 		&t.Likes,
@@ -369,10 +534,130 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 	return &t, nil
 }
 
+// Lock marks a thread as locked, recording which user locked it and when.
+func (m *ThreadModel) Lock(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+	userID uuid.UUID,
+) (*Thread, error) {
+	const query string = `
+UPDATE forum.threads
+SET is_locked  = TRUE,
+    locked_by  = $3::UUID,
+    locked_at  = NOW(),
+    updated_at = NOW()
+WHERE id = $1::UUID
+  AND forum_id = $2::UUID
+RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, locked_by, locked_at, deleted, deleted_at, likes;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("forumId", forumID.String()),
+		slog.String("threadId", threadID.String()),
+		slog.String("userId", userID.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var t Thread
+	err := m.DB.QueryRow(
+		ctx,
+		query,
+		threadID,
+		forumID,
+		userID,
+	).Scan(
+		&t.ID,
+		&t.ForumID,
+		&t.Title,
+		&t.AuthorID,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.IsLocked,
+		&t.LockedBy,
+		&t.LockedAt,
+		&t.Deleted,
+		&t.DeletedAt,
+		&t.Likes,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("thread locked", slog.Any("thread", t))
+
+	return &t, nil
+}
+
+// Unlock marks a thread as unlocked, clearing who locked it and when.
+func (m *ThreadModel) Unlock(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+) (*Thread, error) {
+	const query string = `
+UPDATE forum.threads
+SET is_locked  = FALSE,
+    locked_by  = NULL,
+    locked_at  = NULL,
+    updated_at = NOW()
+WHERE id = $1::UUID
+  AND forum_id = $2::UUID
+RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, locked_by, locked_at, deleted, deleted_at, likes;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("forumId", forumID.String()),
+		slog.String("threadId", threadID.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var t Thread
+	err := m.DB.QueryRow(
+		ctx,
+		query,
+		threadID,
+		forumID,
+	).Scan(
+		&t.ID,
+		&t.ForumID,
+		&t.Title,
+		&t.AuthorID,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.IsLocked,
+		&t.LockedBy,
+		&t.LockedAt,
+		&t.Deleted,
+		&t.DeletedAt,
+		&t.Likes,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("thread unlocked", slog.Any("thread", t))
+
+	return &t, nil
+}
+
+// SoftDelete marks a thread and its posts as deleted within a single deletion batch. If updatedAt
+// is set, the thread is only deleted if it has not been updated since.
 func (m *ThreadModel) SoftDelete(
 	ctx context.Context,
 	forumID uuid.UUID,
 	threadID uuid.UUID,
+	updatedAt sql.NullTime,
 ) (*Thread, error) {
 	const query string = `
 UPDATE forum.threads
@@ -381,7 +666,8 @@ SET deleted    = TRUE,
     updated_at = NOW()
 WHERE id = $1::UUID
   AND forum_id = $2::UUID
-RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, deleted, deleted_at, likes;
+  AND ($3::TIMESTAMP IS NULL OR updated_at = $3::TIMESTAMP)
+RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, locked_by, locked_at, deleted, deleted_at, likes;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -389,6 +675,7 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 		slog.String("query", logging.MinifySQL(query)),
 		slog.String("forumId", forumID.String()),
 		slog.String("threadId", threadID.String()),
+		slog.Any("updatedAt", updatedAt),
 		slog.Duration("timeout", *m.Timeout),
 	))
 
@@ -402,6 +689,7 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 		query,
 		threadID,
 		forumID,
+		updatedAt,
 	).Scan(
 		&t.ID,
 		&t.ForumID,
@@ -410,6 +698,8 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 		&t.CreatedAt,
 		&t.UpdatedAt,
 		&t.IsLocked,
+		&t.LockedBy,
+		&t.LockedAt,
 		&t.Deleted,
 		&t.DeletedAt,
 		&t.Likes,
@@ -422,6 +712,8 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 	return &t, nil
 }
 
+// Restore restores a thread and the posts within its deletion batch. Posts deleted before the
+// thread was deleted remain deleted.
 func (m *ThreadModel) Restore(
 	ctx context.Context,
 	forumID uuid.UUID,
@@ -434,7 +726,7 @@ SET deleted    = FALSE,
     updated_at = NOW()
 WHERE id = $1::UUID
   AND forum_id = $2::UUID
-RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, deleted, deleted_at, likes;
+RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, locked_by, locked_at, deleted, deleted_at, likes;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -463,6 +755,8 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 		&t.CreatedAt,
 		&t.UpdatedAt,
 		&t.IsLocked,
+		&t.LockedBy,
+		&t.LockedAt,
 		&t.Deleted,
 		&t.DeletedAt,
 		&t.Likes,
@@ -485,7 +779,7 @@ DELETE
 FROM forum.threads
 WHERE id = $1::UUID
   AND forum_id = $2::UUID
-RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, deleted, deleted_at, likes;
+RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, locked_by, locked_at, deleted, deleted_at, likes;
 `
 
 	logger := logging.LoggerFromContext(ctx).With(slog.Group(
@@ -514,6 +808,8 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 		&t.CreatedAt,
 		&t.UpdatedAt,
 		&t.IsLocked,
+		&t.LockedBy,
+		&t.LockedAt,
 		&t.Deleted,
 		&t.DeletedAt,
 		&t.Likes,
@@ -525,3 +821,34 @@ RETURNING id, forum_id, title, author_id, created_at, updated_at, is_locked, del
 
 	return &t, nil
 }
+
+// Copy bulk inserts threads with their IDs and timestamps as given, returning the number of
+// threads inserted. Likes are left to be counted from the votes of the threads.
+func (m *ThreadModel) Copy(ctx context.Context, threads []*Thread) (int64, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("table", "forum.threads"),
+		slog.Int("rows", len(threads)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing copy")
+	n, err := m.DB.CopyFrom(
+		ctx,
+		pgx.Identifier{"forum", "threads"},
+		[]string{"id", "forum_id", "title", "author_id", "created_at", "updated_at"},
+		pgx.CopyFromSlice(len(threads), func(i int) ([]any, error) {
+			t := threads[i]
+			return []any{t.ID, t.ForumID, t.Title, t.AuthorID, t.CreatedAt, t.UpdatedAt}, nil
+		}),
+	)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("threads copied", slog.Int64("rowsAffected", n))
+
+	return n, nil
+}
@@ -55,18 +55,33 @@ func TestThreadModel(t *testing.T) {
 		}
 	})
 
+	t.Run("SelectForShare", func(t *testing.T) {
+		err := models.WithTx(ctx, func(tx data.Models) error {
+			_, err := tx.Threads.SelectForShare(ctx, forum.ID, newThread.ID)
+			assert.NoError(t, err)
+
+			lockCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+			defer cancel()
+			_, err = models.Threads.Lock(lockCtx, forum.ID, newThread.ID, user.ID)
+			assert.ErrorIs(t, err, context.DeadlineExceeded, "thread locked while shared")
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("SelectMany", func(t *testing.T) {
+		threads, err := models.Threads.SelectMany(ctx, []uuid.UUID{newThread.ID, uuid.New()})
+		assert.NoError(t, err)
+		assert.Equal(t, []*data.Thread{&newThread}, threads)
+	})
+
 	t.Run("SelectAll", func(t *testing.T) {
 		threads, metadata, err := models.Threads.SelectAll(ctx, data.Filters{PageSize: 100})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, threads)
 		assert.NotEmpty(t, metadata)
-		if !assert.Equal(t, threads[len(threads)-1].ID, metadata.LastSeen) {
-			t.Errorf(
-				"last seen ID %s does not match the last selected user ID %s\n",
-				metadata.LastSeen,
-				threads[len(threads)-1].ID,
-			)
-		}
+		assert.Equal(t, len(threads), metadata.ResponseLength)
+		assert.False(t, metadata.Prev)
 	})
 
 	t.Run("SelectCount", func(t *testing.T) {
@@ -78,6 +93,12 @@ func TestThreadModel(t *testing.T) {
 		assert.GreaterOrEqual(t, *countedPosts, 0)
 	})
 
+	t.Run("SelectCounts", func(t *testing.T) {
+		counts, err := models.Threads.SelectCounts(ctx, []uuid.UUID{forum.ID, uuid.New()})
+		assert.NoError(t, err)
+		assert.Equal(t, map[uuid.UUID]int{forum.ID: 1}, counts)
+	})
+
 	t.Run("Update", func(t *testing.T) {
 		newTitle := "Neurochipped Johnny Boy"
 		updatedThread, err := models.Threads.Update(ctx, data.ThreadPatch{
@@ -89,8 +110,24 @@ func TestThreadModel(t *testing.T) {
 		assert.Equal(t, newTitle, updatedThread.Title)
 	})
 
+	t.Run("Lock", func(t *testing.T) {
+		lockedThread, err := models.Threads.Lock(ctx, forum.ID, newThread.ID, user.ID)
+		assert.NoError(t, err)
+		assert.True(t, lockedThread.IsLocked)
+		assert.Equal(t, user.ID, lockedThread.LockedBy.UUID)
+		assert.True(t, lockedThread.LockedAt.Valid)
+	})
+
+	t.Run("Unlock", func(t *testing.T) {
+		unlockedThread, err := models.Threads.Unlock(ctx, forum.ID, newThread.ID)
+		assert.NoError(t, err)
+		assert.False(t, unlockedThread.IsLocked)
+		assert.False(t, unlockedThread.LockedBy.Valid)
+		assert.False(t, unlockedThread.LockedAt.Valid)
+	})
+
 	t.Run("SoftDelete", func(t *testing.T) {
-		deletedThread, err := models.Threads.SoftDelete(ctx, forum.ID, newThread.ID)
+		deletedThread, err := models.Threads.SoftDelete(ctx, forum.ID, newThread.ID, sql.NullTime{})
 		assert.NoError(t, err)
 		assert.Equal(t, deletedThread.Deleted, true)
 	})
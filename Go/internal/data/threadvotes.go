@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
 )
 
@@ -21,10 +21,45 @@ type ThreadVote struct {
 }
 
 type ThreadVoteModel struct {
-	DB      *pgxpool.Pool
+	DB      Querier
 	Timeout *time.Duration
 }
 
+// Select retrieves the vote a given user has cast on a thread.
+func (m *ThreadVoteModel) Select(
+	ctx context.Context,
+	threadID uuid.UUID,
+	userID uuid.UUID,
+) (*ThreadVote, error) {
+	const query string = `
+SELECT thread_id, user_id, vote
+FROM forum.thread_votes
+WHERE thread_id = $1::UUID
+  AND user_id = $2::UUID;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("threadId", threadID.String()),
+		slog.String("userId", userID.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var v ThreadVote
+	err := m.DB.QueryRow(ctx, query, threadID, userID).Scan(&v.ThreadID, &v.UserID, &v.Vote)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("vote selected", slog.Any("vote", v))
+
+	return &v, nil
+}
+
 func (m *ThreadVoteModel) SelectSum(ctx context.Context, filters Filters) (*int, error) {
 	const query string = `
 SELECT CASE
@@ -54,6 +89,21 @@ WHERE ($1::UUID IS NULL OR thread_id = $1::UUID)
 	return &count, nil
 }
 
+// SelectSums sums the votes of each of the given threads. Threads without votes are left out.
+func (m *ThreadVoteModel) SelectSums(
+	ctx context.Context,
+	threadIDs []uuid.UUID,
+) (map[uuid.UUID]int, error) {
+	const query string = `
+SELECT thread_id, SUM(vote)
+FROM forum.thread_votes
+WHERE thread_id = ANY ($1::UUID[])
+GROUP BY thread_id;
+`
+
+	return selectTotals(ctx, m.DB, *m.Timeout, query, threadIDs)
+}
+
 // Vote performs a upsert for to record any votes for any thread. If the vote is 0, the record is
 // deleted.
 func (m *ThreadVoteModel) Vote(ctx context.Context, vote ThreadVote) (*ThreadVote, error) {
@@ -93,3 +143,67 @@ ON CONFLICT (thread_id, user_id) DO UPDATE
 
 	return &vote, nil
 }
+
+// RecountLikes sets the likes of every thread to the sum of its votes, returning the number of
+// threads whose likes were out of date.
+func (m *ThreadVoteModel) RecountLikes(ctx context.Context) (int64, error) {
+	const query string = `
+UPDATE forum.threads AS target
+SET likes = counted.likes
+FROM (SELECT t.id, COALESCE(SUM(v.vote), 0) AS likes
+      FROM forum.threads AS t
+               LEFT JOIN forum.thread_votes AS v ON v.thread_id = t.id
+      GROUP BY t.id) AS counted
+WHERE target.id = counted.id
+  AND target.likes <> counted.likes;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	tag, err := m.DB.Exec(ctx, query)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("thread likes recounted", slog.Int64("rowsAffected", tag.RowsAffected()))
+
+	return tag.RowsAffected(), nil
+}
+
+// Copy bulk inserts thread votes, returning the number of votes inserted. The likes of the voted
+// threads are updated by the database as the votes are inserted.
+func (m *ThreadVoteModel) Copy(ctx context.Context, votes []*ThreadVote) (int64, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("table", "forum.thread_votes"),
+		slog.Int("rows", len(votes)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing copy")
+	n, err := m.DB.CopyFrom(
+		ctx,
+		pgx.Identifier{"forum", "thread_votes"},
+		[]string{"thread_id", "user_id", "vote"},
+		pgx.CopyFromSlice(len(votes), func(i int) ([]any, error) {
+			v := votes[i]
+			return []any{v.ThreadID, v.UserID, v.Vote}, nil
+		}),
+	)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("thread votes copied", slog.Int64("rowsAffected", n))
+
+	return n, nil
+}
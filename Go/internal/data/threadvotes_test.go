@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/stretchr/testify/assert"
 )
@@ -46,6 +47,18 @@ func TestThreadVoteModel(t *testing.T) {
 		assert.NotEqual(t, newVote, vote)
 	})
 
+	t.Run("Select", func(t *testing.T) {
+		vote, err := models.ThreadVotes.Select(ctx, insertedThread.ID, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, newVote, *vote)
+	})
+
+	t.Run("SelectSums", func(t *testing.T) {
+		sums, err := models.ThreadVotes.SelectSums(ctx, []uuid.UUID{insertedThread.ID, uuid.New()})
+		assert.NoError(t, err)
+		assert.Equal(t, map[uuid.UUID]int{insertedThread.ID: 1}, sums)
+	})
+
 	t.Run("SelectSum", func(t *testing.T) {
 		count, err := models.ThreadVotes.SelectSum(ctx, data.Filters{
 			ThreadID: &insertedThread.ID,
@@ -54,4 +67,19 @@ func TestThreadVoteModel(t *testing.T) {
 		assert.NoError(t, err)
 		assert.GreaterOrEqual(t, *count, 0, "thread vote less than 0")
 	})
+
+	t.Run("RecountLikes", func(t *testing.T) {
+		_, err := db.Exec(
+			ctx, "UPDATE forum.threads SET likes = -7 WHERE id = $1;", insertedThread.ID,
+		)
+		assert.NoError(t, err)
+
+		corrected, err := models.ThreadVotes.RecountLikes(ctx)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, corrected, int64(1))
+
+		recounted, err := models.Threads.Select(ctx, forum.ID, insertedThread.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), recounted.Likes)
+	})
 }
@@ -0,0 +1,106 @@
+package data
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+const (
+	// ScopeAuthentication is the scope of tokens used to authenticate requests.
+	ScopeAuthentication string = "authentication"
+)
+
+// Token represents a token issued to a user. Only the hash of the token is persisted.
+type Token struct {
+	// Hash is the SHA-256 hash of the plaintext token.
+	Hash []byte `json:"-"`
+	// UserID is the unique identifier of the user the token was issued to.
+	UserID uuid.UUID `json:"userId"`
+	// Expiry denotes when the token is no longer valid.
+	Expiry time.Time `json:"expiry"`
+	// Scope denotes what the token can be used for.
+	Scope string `json:"scope"`
+}
+
+type TokenModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+func (m *TokenModel) Insert(ctx context.Context, token Token) (*Token, error) {
+	const query string = `
+INSERT INTO forum.tokens(hash, user_id, expiry, scope)
+VALUES ($1, $2, $3, $4)
+RETURNING hash, user_id, expiry, scope;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Any("token", token),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var t Token
+	err := m.DB.QueryRow(
+		ctx,
+		query,
+		token.Hash,
+		token.UserID,
+		token.Expiry,
+		token.Scope,
+	).Scan(
+		&t.Hash,
+		&t.UserID,
+		&t.Expiry,
+		&t.Scope,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("token created", slog.Any("token", t))
+
+	return &t, nil
+}
+
+// DeleteAllForUser deletes every token within the given scope belonging to a user.
+func (m *TokenModel) DeleteAllForUser(
+	ctx context.Context,
+	scope string,
+	userID uuid.UUID,
+) (int64, error) {
+	const query string = `
+DELETE
+FROM forum.tokens
+WHERE scope = $1
+  AND user_id = $2;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("scope", scope),
+		slog.String("userId", userID.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	result, err := m.DB.Exec(ctx, query, scope, userID)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("tokens deleted", slog.Int64("affectedRows", result.RowsAffected()))
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,61 @@
+package data_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := models.Users.Insert(ctx, data.UserInput{
+		Name:         "Viktor Vektor",
+		Username:     "v.vektor",
+		Email:        "v.vektor@ripperdoc.com",
+		PasswordHash: []byte("not-a-real-hash"),
+	})
+	assert.NoError(t, err)
+
+	hash := sha256.Sum256([]byte("plaintext-token"))
+	token := data.Token{
+		Hash:   hash[:],
+		UserID: user.ID,
+		Expiry: time.Now().Add(time.Hour).Truncate(time.Second),
+		Scope:  data.ScopeAuthentication,
+	}
+
+	t.Run("SelectCredentials", func(t *testing.T) {
+		c, err := models.Users.SelectCredentials(ctx, user.Email)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, c.ID)
+		assert.Equal(t, []byte("not-a-real-hash"), c.PasswordHash)
+	})
+
+	t.Run("Insert", func(t *testing.T) {
+		inserted, err := models.Tokens.Insert(ctx, token)
+		assert.NoError(t, err)
+		assert.Equal(t, token.Hash, inserted.Hash)
+		assert.True(t, token.Expiry.Equal(inserted.Expiry))
+	})
+
+	t.Run("SelectByToken", func(t *testing.T) {
+		u, err := models.Users.SelectByToken(ctx, data.ScopeAuthentication, token.Hash)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, u.ID)
+	})
+
+	t.Run("DeleteAllForUser", func(t *testing.T) {
+		deleted, err := models.Tokens.DeleteAllForUser(ctx, data.ScopeAuthentication, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		_, err = models.Users.SelectByToken(ctx, data.ScopeAuthentication, token.Hash)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
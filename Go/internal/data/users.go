@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
 )
 
@@ -39,6 +39,38 @@ type User struct {
 	DeletedAt sql.NullTime `json:"deletedAt,omitzero"`
 }
 
+var userSortColumns = sortColumns{
+	"name":       "VARCHAR(256)",
+	"username":   "VARCHAR(256)",
+	"email":      "VARCHAR(256)",
+	"created_at": "TIMESTAMP",
+	"updated_at": "TIMESTAMP",
+}
+
+// UserOrderBySafeList lists the values users may be ordered by.
+var UserOrderBySafeList = userSortColumns.safeList()
+
+func (u *User) keysetID() uuid.UUID {
+	return u.ID
+}
+
+func (u *User) keysetValue(column string) any {
+	switch column {
+	case "name":
+		return u.Name
+	case "username":
+		return u.Username
+	case "email":
+		return u.Email
+	case "created_at":
+		return u.CreatedAt
+	case "updated_at":
+		return u.UpdatedAt
+	default:
+		return nil
+	}
+}
+
 type UserInput struct {
 	// Name is the full name of the user.
 	Name string `json:"name"`
@@ -46,6 +78,22 @@ type UserInput struct {
 	Username string `json:"username,omitzero"`
 	// Email is the unique email beloging to a given user account.
 	Email string `json:"email,omitzero"`
+	// PasswordHash is the bcrypt hash of the password of the user.
+	//
+	// The plaintext password is never stored. Users without a password hash are unable to
+	// authenticate.
+	PasswordHash []byte `json:"-"`
+}
+
+// UserCredentials contains the information required to authenticate a user.
+type UserCredentials struct {
+	// ID is the unique identifier of a user.
+	ID uuid.UUID `json:"id"`
+	// PasswordHash is the bcrypt hash of the password of the user. The hash is nil if the user
+	// does not have a password.
+	PasswordHash []byte `json:"-"`
+	// Deleted is a soft delete flag for a user.
+	Deleted bool `json:"deleted,omitzero"`
 }
 
 type UserPatch struct {
@@ -70,10 +118,13 @@ type UserPatch struct {
 	// Upon creating a new user, any existing values in this field is ignored. The database handles
 	// setting the value upon insertion.
 	DeletedAt *time.Time `json:"deletedAt,omitzero"`
+	// UpdatedAt is the time the user is expected to have last been updated at. If set, the patch
+	// is only applied if the user has not been updated since.
+	UpdatedAt *time.Time `json:"updatedAt,omitzero"`
 }
 
 type UserModel struct {
-	DB      *pgxpool.Pool
+	DB      Querier
 	Timeout *time.Duration
 }
 
@@ -118,7 +169,146 @@ WHERE id = $1;
 	return &u, nil
 }
 
+// SelectMany retrieves the users with the given IDs. IDs of users that do not exist are ignored.
+func (m *UserModel) SelectMany(ctx context.Context, ids []uuid.UUID) ([]*User, error) {
+	const query string = `
+SELECT id, name, username, email, created_at, updated_at, deleted, deleted_at
+FROM forum.users
+WHERE id = ANY ($1::UUID[]);
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Int("ids", len(ids)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	users := []*User{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, ids)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u User
+		err := rows.Scan(
+			&u.ID,
+			&u.Name,
+			&u.Username,
+			&u.Email,
+			&u.CreatedAt,
+			&u.UpdatedAt,
+			&u.Deleted,
+			&u.DeletedAt,
+		)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		users = append(users, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("users selected", slog.Int("length", len(users)))
+
+	return users, nil
+}
+
+// SelectCredentials retrieves the credentials of the user with the given email.
+func (m *UserModel) SelectCredentials(ctx context.Context, email string) (*UserCredentials, error) {
+	const query string = `
+SELECT id, password_hash, deleted
+FROM forum.users
+WHERE email = $1;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("email", email),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var c UserCredentials
+	err := m.DB.QueryRow(ctx, query, email).Scan(&c.ID, &c.PasswordHash, &c.Deleted)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("user credentials selected", slog.String("id", c.ID.String()))
+
+	return &c, nil
+}
+
+// SelectByToken retrieves the user owning a token with the given hash and scope. Expired tokens
+// and tokens belonging to deleted users are ignored.
+func (m *UserModel) SelectByToken(ctx context.Context, scope string, hash []byte) (*User, error) {
+	const query string = `
+SELECT u.id,
+       u.name,
+       u.username,
+       u.email,
+       u.created_at,
+       u.updated_at,
+       u.deleted,
+       u.deleted_at
+FROM forum.users u
+         INNER JOIN forum.tokens t ON u.id = t.user_id
+WHERE t.hash = $1
+  AND t.scope = $2
+  AND t.expiry > NOW()
+  AND u.deleted = FALSE;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("scope", scope),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var u User
+	err := m.DB.QueryRow(ctx, query, hash, scope).Scan(
+		&u.ID,
+		&u.Name,
+		&u.Username,
+		&u.Email,
+		&u.CreatedAt,
+		&u.UpdatedAt,
+		&u.Deleted,
+		&u.DeletedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("user selected", slog.Any("user", u))
+
+	return &u, nil
+}
+
 func (m *UserModel) SelectAll(ctx context.Context, filters Filters) ([]*User, *Metadata, error) {
+	where, orderBy, keysetArgs, err := createKeysetClauses(filters, userSortColumns, 10)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	query := `
 SELECT id, name, username, email, created_at, updated_at, deleted, deleted_at
 FROM forum.users
@@ -130,7 +320,8 @@ WHERE ($2::UUID IS NULL OR id = $2::UUID)
   AND ($7::TIMESTAMP IS NULL or created_at <= $7::TIMESTAMP)
   AND ($8::TIMESTAMP IS NULL or updated_at >= $8::TIMESTAMP)
   AND ($9::TIMESTAMP IS NULL or updated_at <= $9::TIMESTAMP)
-` + CreateOrderByClause(filters.OrderBy) + `
+` + where + `
+` + orderBy + `
 LIMIT $1::INTEGER
 `
 
@@ -143,11 +334,8 @@ LIMIT $1::INTEGER
 		slog.Any("filters", filters),
 	))
 
-	logger.Info("performing query")
-	rows, err := m.DB.Query(
-		ctx,
-		query,
-		filters.PageSize,
+	args := append([]any{
+		filters.PageSize + 1,
 		filters.ID,
 		filters.Name,
 		filters.Username,
@@ -156,7 +344,10 @@ LIMIT $1::INTEGER
 		filters.CreatedAtTo,
 		filters.UpdatedAtFrom,
 		filters.UpdatedAtTo,
-	)
+	}, keysetArgs...)
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, args...)
 	if err != nil {
 		logger.Error("unable to perform query", slog.String("error", err.Error()))
 		return nil, nil, err
@@ -185,15 +376,7 @@ LIMIT $1::INTEGER
 	if err = rows.Err(); err != nil {
 		return nil, nil, handleError(err, logger)
 	}
-	length := len(users)
-	var metadata Metadata
-	if length > 0 {
-		metadata.LastSeen = users[length-1].ID
-	}
-	if length >= filters.PageSize {
-		metadata.Next = true
-	}
-	metadata.ResponseLength = length
+	users, metadata := paginate(users, filters)
 
 	logger.Info("users selected", slog.Any("metadata", metadata))
 	return users, &metadata, nil
@@ -201,8 +384,8 @@ LIMIT $1::INTEGER
 
 func (m *UserModel) Insert(ctx context.Context, input UserInput) (*User, error) {
 	const query string = `
-INSERT INTO forum.users(name, username, email)
-VALUES ($1, $2, $3)
+INSERT INTO forum.users(name, username, email, password_hash)
+VALUES ($1, $2, $3, $4)
 RETURNING id, name, username, email, created_at, updated_at, deleted, deleted_at;
 `
 
@@ -224,6 +407,7 @@ RETURNING id, name, username, email, created_at, updated_at, deleted, deleted_at
 		input.Name,
 		input.Username,
 		input.Email,
+		input.PasswordHash,
 	).Scan(
 		&u.ID,
 		&u.Name,
@@ -252,6 +436,7 @@ SET name       = COALESCE($2, name),
     deleted_at = COALESCE($6, deleted_at),
     updated_at = NOW()
 WHERE id = $1
+  AND ($7::TIMESTAMP IS NULL OR updated_at = $7::TIMESTAMP)
 RETURNING id, name, username, email, created_at, updated_at, deleted, deleted_at;
 `
 
@@ -276,6 +461,7 @@ RETURNING id, name, username, email, created_at, updated_at, deleted, deleted_at
 		input.Email,
 		input.Deleted,
 		input.DeletedAt,
+		input.UpdatedAt,
 	).Scan(
 		&u.ID,
 		&u.Name,
@@ -294,13 +480,21 @@ RETURNING id, name, username, email, created_at, updated_at, deleted, deleted_at
 	return &u, nil
 }
 
-func (m *UserModel) SoftDelete(ctx context.Context, id uuid.UUID) (*User, error) {
+// SoftDelete marks a user as deleted, alongside the threads and posts authored by the user, and
+// the posts within those threads. If updatedAt is set, the user is only deleted if it has not been
+// updated since.
+func (m *UserModel) SoftDelete(
+	ctx context.Context,
+	id uuid.UUID,
+	updatedAt sql.NullTime,
+) (*User, error) {
 	const query string = `
 UPDATE forum.users
 SET deleted    = TRUE,
     deleted_at = NOW(),
     updated_at = NOW()
 WHERE id = $1
+  AND ($2::TIMESTAMP IS NULL OR updated_at = $2::TIMESTAMP)
 RETURNING id, name, username, email, created_at, updated_at, deleted, deleted_at;
 `
 
@@ -308,6 +502,7 @@ RETURNING id, name, username, email, created_at, updated_at, deleted, deleted_at
 		"query",
 		slog.String("query", logging.MinifySQL(query)),
 		slog.String("id", id.String()),
+		slog.Any("updatedAt", updatedAt),
 		slog.Duration("timeout", *m.Timeout),
 	))
 
@@ -320,6 +515,7 @@ RETURNING id, name, username, email, created_at, updated_at, deleted, deleted_at
 		ctx,
 		query,
 		id,
+		updatedAt,
 	).Scan(
 		&u.ID,
 		&u.Name,
@@ -338,6 +534,7 @@ RETURNING id, name, username, email, created_at, updated_at, deleted, deleted_at
 	return &u, nil
 }
 
+// Restore restores a user, and the threads and posts hidden when the user was deleted.
 func (m *UserModel) Restore(ctx context.Context, id uuid.UUID) (*User, error) {
 	const query string = `
 UPDATE forum.users
@@ -423,3 +620,36 @@ RETURNING id, name, username, email, created_at, updated_at, deleted, deleted_at
 
 	return &u, nil
 }
+
+// Copy bulk inserts users, returning the number of users inserted. Unlike Insert, the IDs and
+// timestamps are kept as given. Every user is given the same password hash, which may be nil.
+func (m *UserModel) Copy(ctx context.Context, users []*User, passwordHash []byte) (int64, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("table", "forum.users"),
+		slog.Int("rows", len(users)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing copy")
+	n, err := m.DB.CopyFrom(
+		ctx,
+		pgx.Identifier{"forum", "users"},
+		[]string{"id", "name", "username", "email", "password_hash", "created_at", "updated_at"},
+		pgx.CopyFromSlice(len(users), func(i int) ([]any, error) {
+			u := users[i]
+			return []any{
+				u.ID, u.Name, u.Username, u.Email, passwordHash, u.CreatedAt, u.UpdatedAt,
+			}, nil
+		}),
+	)
+	if err != nil {
+		return 0, handleError(err, logger)
+	}
+	logger.Info("users copied", slog.Int64("rowsAffected", n))
+
+	return n, nil
+}
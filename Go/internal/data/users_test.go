@@ -2,6 +2,7 @@ package data_test
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -41,18 +42,19 @@ func TestUserModel(t *testing.T) {
 		}
 	})
 
+	t.Run("SelectMany", func(t *testing.T) {
+		users, err := models.Users.SelectMany(ctx, []uuid.UUID{user.ID, uuid.New()})
+		assert.NoError(t, err)
+		assert.Equal(t, []*data.User{&user}, users)
+	})
+
 	t.Run("SelectAll", func(t *testing.T) {
 		users, metadata, err := models.Users.SelectAll(ctx, data.Filters{PageSize: 100})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, users)
 		assert.NotEmpty(t, metadata)
-		if !assert.Equal(t, users[len(users)-1].ID, metadata.LastSeen) {
-			t.Errorf(
-				"last seen ID %s does not match the last selected user ID %s\n",
-				metadata.LastSeen,
-				users[len(users)-1].ID,
-			)
-		}
+		assert.Equal(t, len(users), metadata.ResponseLength)
+		assert.False(t, metadata.Prev)
 	})
 
 	t.Run("Update", func(t *testing.T) {
@@ -67,7 +69,7 @@ func TestUserModel(t *testing.T) {
 	})
 
 	t.Run("SoftDelete", func(t *testing.T) {
-		deletedUser, err := models.Users.SoftDelete(ctx, user.ID)
+		deletedUser, err := models.Users.SoftDelete(ctx, user.ID, sql.NullTime{})
 		assert.NoError(t, err)
 		assert.Equal(t, deletedUser.Deleted, true)
 	})
@@ -84,3 +86,72 @@ func TestUserModel(t *testing.T) {
 		assert.NotEmpty(t, deletedUser)
 	})
 }
+
+func TestUserModelCascade(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	author, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Jackie Welles",
+		Username: "jackie",
+		Email:    "jwelles@heywood.com",
+	})
+	assert.NoError(t, err)
+
+	owner, err := models.Users.Insert(ctx, data.UserInput{
+		Name:     "Misty Olszewski",
+		Username: "misty",
+		Email:    "misty@esoterica.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := models.Forums.Insert(ctx, data.ForumInput{
+		OwnerID: owner.ID,
+		Name:    "Esoterica",
+	})
+	assert.NoError(t, err)
+
+	thread, err := models.Threads.Insert(ctx, data.ThreadInput{
+		AuthorID: author.ID,
+		ForumID:  forum.ID,
+		Title:    "Big plans",
+	})
+	assert.NoError(t, err)
+
+	reply, err := models.Posts.Insert(ctx, data.PostInput{
+		ThreadID: thread.ID,
+		Content:  "The cards say you should be careful",
+		AuthorID: owner.ID,
+	})
+	assert.NoError(t, err)
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		_, err := models.Users.SoftDelete(ctx, author.ID, sql.NullTime{})
+		assert.NoError(t, err)
+
+		f, err := models.Forums.Select(ctx, forum.ID)
+		assert.NoError(t, err)
+		assert.False(t, f.Deleted)
+
+		th, err := models.Threads.Select(ctx, forum.ID, thread.ID)
+		assert.NoError(t, err)
+		assert.True(t, th.Deleted)
+
+		p, err := models.Posts.Select(ctx, thread.ID, reply.ID)
+		assert.NoError(t, err)
+		assert.True(t, p.Deleted)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		_, err := models.Users.Restore(ctx, author.ID)
+		assert.NoError(t, err)
+
+		th, err := models.Threads.Select(ctx, forum.ID, thread.ID)
+		assert.NoError(t, err)
+		assert.False(t, th.Deleted)
+
+		p, err := models.Posts.Select(ctx, thread.ID, reply.ID)
+		assert.NoError(t, err)
+		assert.False(t, p.Deleted)
+	})
+}
@@ -0,0 +1,483 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+const (
+	WebhookDeliveryPending   string = "pending"
+	WebhookDeliveryDelivered string = "delivered"
+	// WebhookDeliveryDead denotes a delivery that failed too many times, and is no longer retried
+	// unless requested.
+	WebhookDeliveryDead string = "dead"
+)
+
+// WebhookDeliveryStatuses lists every status of a webhook delivery.
+var WebhookDeliveryStatuses = []string{
+	WebhookDeliveryPending, WebhookDeliveryDelivered, WebhookDeliveryDead,
+}
+
+// OutboxEvent is a change to a forum, thread, post or user.
+//
+// Events are recorded by database triggers within the transaction performing the change, and are
+// never created by the application.
+type OutboxEvent struct {
+	// ID is the unique, ever-increasing identifier of the event.
+	ID int64 `json:"id"`
+	// AggregateType is the type of the changed resource, such as post.
+	AggregateType string `json:"aggregateType"`
+	// AggregateID is the unique identifier of the changed resource.
+	AggregateID uuid.UUID `json:"aggregateId"`
+	// Type is the type of the event, such as post.created.
+	Type string `json:"type"`
+	// Payload is the state of the resource after the change, or before it was purged.
+	Payload json.RawMessage `json:"payload"`
+	// CreatedAt denotes when the change happened.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookDelivery tracks the delivery of a single outbox event to a webhook.
+type WebhookDelivery struct {
+	// ID is the unique identifier of the delivery.
+	ID uuid.UUID `json:"id"`
+	// WebhookID is the webhook the event is delivered to.
+	WebhookID uuid.UUID `json:"webhookId"`
+	// EventID is the outbox event being delivered.
+	EventID int64 `json:"eventId"`
+	// Status is either pending, delivered or dead.
+	Status string `json:"status"`
+	// Attempts is the number of times delivery has been attempted.
+	Attempts int `json:"attempts"`
+	// NextAttemptAt denotes when delivery is next attempted, if pending.
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	// LastError describes why the last attempt failed.
+	LastError sql.NullString `json:"lastError"`
+	// ResponseStatus is the HTTP status code of the last response from the webhook.
+	ResponseStatus sql.NullInt32 `json:"responseStatus"`
+	// CreatedAt denotes when the delivery was scheduled.
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt denotes when the delivery was last attempted or changed.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+var webhookDeliverySortColumns = sortColumns{
+	"created_at": "TIMESTAMP",
+}
+
+// webhookDeliveryOrderBy is the only ordering of deliveries, newest first.
+var webhookDeliveryOrderBy = []string{"-created_at"}
+
+func (d *WebhookDelivery) keysetID() uuid.UUID {
+	return d.ID
+}
+
+func (d *WebhookDelivery) keysetValue(column string) any {
+	switch column {
+	case "created_at":
+		return d.CreatedAt
+	default:
+		return nil
+	}
+}
+
+// ClaimedWebhookDelivery is a delivery due to be attempted, alongside the webhook and event
+// required to perform it.
+type ClaimedWebhookDelivery struct {
+	WebhookDelivery
+	// URL is the address of the webhook.
+	URL string `json:"url"`
+	// Secret is the key used to sign the event.
+	Secret string `json:"-"`
+	// Event is the event to deliver.
+	Event OutboxEvent `json:"event"`
+}
+
+type WebhookDeliveryModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+// Claim leases up to limit pending deliveries which are due, and counts the attempt. Claimed
+// deliveries are not claimed again until the lease expires, so that several dispatchers may run
+// concurrently. Deliveries of inactive webhooks are never claimed.
+//
+// Every claimed delivery must be concluded with MarkDelivered or MarkFailed, otherwise it is
+// retried once the lease expires.
+func (m *WebhookDeliveryModel) Claim(
+	ctx context.Context,
+	limit int,
+	lease time.Duration,
+) ([]*ClaimedWebhookDelivery, error) {
+	const query string = `
+WITH due AS (SELECT d.id
+             FROM forum.webhook_deliveries d
+                      INNER JOIN forum.webhooks w ON w.id = d.webhook_id
+             WHERE d.status = 'pending'
+               AND d.next_attempt_at <= NOW()
+               AND w.active
+             ORDER BY d.next_attempt_at
+             LIMIT $1::INTEGER FOR UPDATE OF d SKIP LOCKED)
+UPDATE forum.webhook_deliveries d
+SET attempts        = d.attempts + 1,
+    next_attempt_at = NOW() + make_interval(secs => $2::DOUBLE PRECISION),
+    updated_at      = NOW()
+FROM due,
+     forum.webhooks w,
+     forum.outbox_events e
+WHERE d.id = due.id
+  AND w.id = d.webhook_id
+  AND e.id = d.event_id
+RETURNING d.id,
+    d.webhook_id,
+    d.event_id,
+    d.status,
+    d.attempts,
+    d.next_attempt_at,
+    d.last_error,
+    d.response_status,
+    d.created_at,
+    d.updated_at,
+    w.url,
+    w.secret,
+    e.id,
+    e.aggregate_type,
+    e.aggregate_id,
+    e.type,
+    e.payload,
+    e.created_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Int("limit", limit),
+		slog.Duration("lease", lease),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+
+	deliveries := []*ClaimedWebhookDelivery{}
+
+	for rows.Next() {
+		var d ClaimedWebhookDelivery
+
+		err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.EventID,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.LastError,
+			&d.ResponseStatus,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+			&d.URL,
+			&d.Secret,
+			&d.Event.ID,
+			&d.Event.AggregateType,
+			&d.Event.AggregateID,
+			&d.Event.Type,
+			&d.Event.Payload,
+			&d.Event.CreatedAt,
+		)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		deliveries = append(deliveries, &d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("webhook deliveries claimed", slog.Int("length", len(deliveries)))
+
+	return deliveries, nil
+}
+
+// MarkDelivered concludes a delivery which the webhook accepted.
+func (m *WebhookDeliveryModel) MarkDelivered(
+	ctx context.Context,
+	id uuid.UUID,
+	responseStatus int,
+) (*WebhookDelivery, error) {
+	const query string = `
+UPDATE forum.webhook_deliveries
+SET status          = 'delivered',
+    response_status = $2::INTEGER,
+    last_error      = NULL,
+    updated_at      = NOW()
+WHERE id = $1
+RETURNING id,
+    webhook_id,
+    event_id,
+    status,
+    attempts,
+    next_attempt_at,
+    last_error,
+    response_status,
+    created_at,
+    updated_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("id", id.String()),
+		slog.Int("responseStatus", responseStatus),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var d WebhookDelivery
+	err := m.DB.QueryRow(ctx, query, id, responseStatus).Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastError,
+		&d.ResponseStatus,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("webhook delivery marked as delivered", slog.Any("delivery", d))
+
+	return &d, nil
+}
+
+// MarkFailed records a failed attempt of a delivery. The delivery is attempted again once the
+// retry delay has passed, or marked as dead if there is no delay.
+func (m *WebhookDeliveryModel) MarkFailed(
+	ctx context.Context,
+	id uuid.UUID,
+	responseStatus sql.NullInt32,
+	lastError string,
+	retryAfter *time.Duration,
+) (*WebhookDelivery, error) {
+	const query string = `
+UPDATE forum.webhook_deliveries
+SET status          = CASE WHEN $4::DOUBLE PRECISION IS NULL THEN 'dead' ELSE 'pending' END,
+    next_attempt_at = COALESCE(
+            NOW() + make_interval(secs => $4::DOUBLE PRECISION), next_attempt_at),
+    response_status = $2::INTEGER,
+    last_error      = $3::TEXT,
+    updated_at      = NOW()
+WHERE id = $1
+RETURNING id,
+    webhook_id,
+    event_id,
+    status,
+    attempts,
+    next_attempt_at,
+    last_error,
+    response_status,
+    created_at,
+    updated_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("id", id.String()),
+		slog.Any("responseStatus", responseStatus),
+		slog.String("lastError", lastError),
+		slog.Any("retryAfter", retryAfter),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	var delay sql.NullFloat64
+	if retryAfter != nil {
+		delay = sql.NullFloat64{Float64: retryAfter.Seconds(), Valid: true}
+	}
+
+	logger.Info("performing query")
+	var d WebhookDelivery
+	err := m.DB.QueryRow(ctx, query, id, responseStatus, lastError, delay).Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastError,
+		&d.ResponseStatus,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("webhook delivery marked as failed", slog.Any("delivery", d))
+
+	return &d, nil
+}
+
+// Retry schedules a dead delivery to be attempted again immediately, resetting the number of
+// attempts.
+func (m *WebhookDeliveryModel) Retry(
+	ctx context.Context,
+	webhookID uuid.UUID,
+	id uuid.UUID,
+) (*WebhookDelivery, error) {
+	const query string = `
+UPDATE forum.webhook_deliveries
+SET status          = 'pending',
+    attempts        = 0,
+    next_attempt_at = NOW(),
+    updated_at      = NOW()
+WHERE id = $2
+  AND webhook_id = $1
+  AND status = 'dead'
+RETURNING id,
+    webhook_id,
+    event_id,
+    status,
+    attempts,
+    next_attempt_at,
+    last_error,
+    response_status,
+    created_at,
+    updated_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("webhookId", webhookID.String()),
+		slog.String("id", id.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var d WebhookDelivery
+	err := m.DB.QueryRow(ctx, query, webhookID, id).Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastError,
+		&d.ResponseStatus,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("webhook delivery scheduled for retry", slog.Any("delivery", d))
+
+	return &d, nil
+}
+
+// SelectAll retrieves the deliveries to the webhook in the filters, newest first, optionally
+// limited to the statuses in the filters.
+func (m *WebhookDeliveryModel) SelectAll(
+	ctx context.Context,
+	filters Filters,
+) ([]*WebhookDelivery, *Metadata, error) {
+	filters.OrderBy = webhookDeliveryOrderBy
+	where, orderBy, keysetArgs, err := createKeysetClauses(filters, webhookDeliverySortColumns, 4)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+SELECT id,
+       webhook_id,
+       event_id,
+       status,
+       attempts,
+       next_attempt_at,
+       last_error,
+       response_status,
+       created_at,
+       updated_at
+FROM forum.webhook_deliveries
+WHERE webhook_id = $2::UUID
+  AND ($3::TEXT[] IS NULL OR status = ANY ($3::TEXT[]))
+` + where + `
+` + orderBy + `
+LIMIT $1::INTEGER;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Any("filters", filters),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	args := append([]any{
+		filters.PageSize + 1,
+		filters.WebhookID,
+		filters.Statuses,
+	}, keysetArgs...)
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, handleError(err, logger)
+	}
+
+	deliveries := []*WebhookDelivery{}
+
+	for rows.Next() {
+		var d WebhookDelivery
+
+		err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.EventID,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.LastError,
+			&d.ResponseStatus,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		)
+		if err != nil {
+			return nil, nil, handleError(err, logger)
+		}
+		deliveries = append(deliveries, &d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, handleError(err, logger)
+	}
+	deliveries, metadata := paginate(deliveries, filters)
+
+	logger.Info("webhook deliveries selected", slog.Any("metadata", metadata))
+	return deliveries, &metadata, nil
+}
@@ -0,0 +1,299 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// DomainEventTypes lists every type of event recorded in the outbox. Every forum, thread, post
+// and user is either created, updated, deleted, restored or purged.
+var DomainEventTypes = []string{
+	"forum.created", "forum.updated", "forum.deleted", "forum.restored", "forum.purged",
+	"thread.created", "thread.updated", "thread.deleted", "thread.restored", "thread.purged",
+	"post.created", "post.updated", "post.deleted", "post.restored", "post.purged",
+	"user.created", "user.updated", "user.deleted", "user.restored", "user.purged",
+}
+
+// Webhook is a subscription to the domain events recorded in the outbox. Events are delivered to
+// the URL of the webhook, signed with its secret.
+type Webhook struct {
+	// ID is the unique identifier of the webhook.
+	//
+	// Upon creating a new webhook, any existing values in this field is ignored. The database
+	// handles setting the value upon insertion.
+	ID uuid.UUID `json:"id"`
+	// URL is the address events are delivered to.
+	URL string `json:"url"`
+	// Secret is the key used to sign the events delivered to the webhook.
+	Secret string `json:"-"`
+	// EventTypes are the types of events delivered to the webhook, such as post.created. Webhooks
+	// without any event types receive every event.
+	EventTypes []string `json:"eventTypes"`
+	// Active denotes whether events are delivered to the webhook.
+	Active bool `json:"active"`
+	// CreatedAt denotes when the webhook was created.
+	//
+	// Upon creating a new webhook, any existing values in this field is ignored. The database
+	// handles setting the value upon insertion.
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt denotes when the webhook was last updated.
+	//
+	// Upon creating a new webhook, any existing values in this field is ignored. The database
+	// handles setting the value upon insertion.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type WebhookInput struct {
+	// URL is the address events are delivered to.
+	URL string `json:"url"`
+	// Secret is the key used to sign the events delivered to the webhook.
+	Secret string `json:"-"`
+	// EventTypes are the types of events delivered to the webhook.
+	EventTypes []string `json:"eventTypes"`
+	// Active denotes whether events are delivered to the webhook.
+	Active bool `json:"active"`
+}
+
+type WebhookPatch struct {
+	// ID is the unique identifier of the webhook.
+	ID uuid.UUID `json:"id"`
+	// URL is the address events are delivered to.
+	URL sql.NullString `json:"url"`
+	// Secret is the key used to sign the events delivered to the webhook.
+	Secret sql.NullString `json:"-"`
+	// EventTypes are the types of events delivered to the webhook. The event types are left
+	// unchanged if nil.
+	EventTypes []string `json:"eventTypes"`
+	// Active denotes whether events are delivered to the webhook.
+	Active sql.NullBool `json:"active"`
+}
+
+type WebhookModel struct {
+	DB      Querier
+	Timeout *time.Duration
+}
+
+func (m *WebhookModel) Select(ctx context.Context, id uuid.UUID) (*Webhook, error) {
+	const query string = `
+SELECT id, url, secret, event_types, active, created_at, updated_at
+FROM forum.webhooks
+WHERE id = $1;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("id", id.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var w Webhook
+	err := m.DB.QueryRow(ctx, query, id).Scan(
+		&w.ID,
+		&w.URL,
+		&w.Secret,
+		&w.EventTypes,
+		&w.Active,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("webhook selected", slog.Any("webhook", w))
+
+	return &w, nil
+}
+
+// SelectAll retrieves every webhook, oldest first.
+func (m *WebhookModel) SelectAll(ctx context.Context) ([]*Webhook, error) {
+	const query string = `
+SELECT id, url, secret, event_types, active, created_at, updated_at
+FROM forum.webhooks
+ORDER BY created_at, id;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	rows, err := m.DB.Query(ctx, query)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+
+	webhooks := []*Webhook{}
+
+	for rows.Next() {
+		var w Webhook
+
+		err := rows.Scan(
+			&w.ID,
+			&w.URL,
+			&w.Secret,
+			&w.EventTypes,
+			&w.Active,
+			&w.CreatedAt,
+			&w.UpdatedAt,
+		)
+		if err != nil {
+			return nil, handleError(err, logger)
+		}
+		webhooks = append(webhooks, &w)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("webhooks selected", slog.Int("length", len(webhooks)))
+
+	return webhooks, nil
+}
+
+func (m *WebhookModel) Insert(ctx context.Context, input WebhookInput) (*Webhook, error) {
+	const query string = `
+INSERT INTO forum.webhooks (url, secret, event_types, active)
+VALUES ($1::TEXT, $2::VARCHAR(256), $3::TEXT[], $4::BOOLEAN)
+RETURNING id, url, secret, event_types, active, created_at, updated_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Any("input", input),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	eventTypes := input.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	logger.Info("performing query")
+	var w Webhook
+	err := m.DB.QueryRow(
+		ctx,
+		query,
+		input.URL,
+		input.Secret,
+		eventTypes,
+		input.Active,
+	).Scan(
+		&w.ID,
+		&w.URL,
+		&w.Secret,
+		&w.EventTypes,
+		&w.Active,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("webhook inserted", slog.Any("webhook", w))
+
+	return &w, nil
+}
+
+func (m *WebhookModel) Update(ctx context.Context, input WebhookPatch) (*Webhook, error) {
+	const query string = `
+UPDATE forum.webhooks
+SET url = COALESCE($2::TEXT, url),
+    secret = COALESCE($3::VARCHAR(256), secret),
+    event_types = COALESCE($4::TEXT[], event_types),
+    active = COALESCE($5::BOOLEAN, active),
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, url, secret, event_types, active, created_at, updated_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.Any("input", input),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var w Webhook
+	err := m.DB.QueryRow(
+		ctx,
+		query,
+		input.ID,
+		input.URL,
+		input.Secret,
+		input.EventTypes,
+		input.Active,
+	).Scan(
+		&w.ID,
+		&w.URL,
+		&w.Secret,
+		&w.EventTypes,
+		&w.Active,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("webhook updated", slog.Any("webhook", w))
+
+	return &w, nil
+}
+
+// Delete permanently deletes a webhook, alongside every delivery to it.
+func (m *WebhookModel) Delete(ctx context.Context, id uuid.UUID) (*Webhook, error) {
+	const query string = `
+DELETE FROM forum.webhooks
+WHERE id = $1
+RETURNING id, url, secret, event_types, active, created_at, updated_at;
+`
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"query",
+		slog.String("query", logging.MinifySQL(query)),
+		slog.String("id", id.String()),
+		slog.Duration("timeout", *m.Timeout),
+	))
+
+	ctx, cancel := context.WithTimeout(ctx, *m.Timeout)
+	defer cancel()
+
+	logger.Info("performing query")
+	var w Webhook
+	err := m.DB.QueryRow(ctx, query, id).Scan(
+		&w.ID,
+		&w.URL,
+		&w.Secret,
+		&w.EventTypes,
+		&w.Active,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, handleError(err, logger)
+	}
+	logger.Info("webhook deleted", slog.Any("webhook", w))
+
+	return &w, nil
+}
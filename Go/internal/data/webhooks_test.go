@@ -0,0 +1,167 @@
+package data_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var webhook data.Webhook
+
+	t.Run("Insert", func(t *testing.T) {
+		inserted, err := models.Webhooks.Insert(ctx, data.WebhookInput{
+			URL:        "https://fixers.nc/hooks/rogue",
+			Secret:     "afterlife",
+			EventTypes: []string{"forum.created"},
+			Active:     true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "afterlife", inserted.Secret)
+
+		webhook = *inserted
+	})
+
+	t.Run("Select", func(t *testing.T) {
+		selected, err := models.Webhooks.Select(ctx, webhook.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, webhook, *selected)
+	})
+
+	t.Run("SelectAll", func(t *testing.T) {
+		selected, err := models.Webhooks.SelectAll(ctx)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, selected)
+	})
+
+	t.Run("Deliveries", func(t *testing.T) {
+		user, err := models.Users.Insert(ctx, data.UserInput{
+			Name:     "Dexter DeShawn",
+			Username: "dex",
+			Email:    "dex@afterlife.com",
+		})
+		assert.NoError(t, err)
+
+		forum, err := models.Forums.Insert(ctx, data.ForumInput{
+			OwnerID: user.ID,
+			Name:    "Gigs for hire",
+		})
+		assert.NoError(t, err)
+
+		claimed, err := models.WebhookDeliveries.Claim(ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		assert.Len(t, claimed, 1)
+		delivery := claimed[0]
+		assert.Equal(t, webhook.URL, delivery.URL)
+		assert.Equal(t, "forum.created", delivery.Event.Type)
+		assert.Equal(t, forum.ID, delivery.Event.AggregateID)
+		assert.Equal(t, 1, delivery.Attempts)
+
+		// Leased deliveries are not claimed again.
+		claimed, err = models.WebhookDeliveries.Claim(ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		assert.Empty(t, claimed)
+
+		retryAfter := time.Duration(0)
+		failed, err := models.WebhookDeliveries.MarkFailed(
+			ctx,
+			delivery.ID,
+			sql.NullInt32{Int32: 503, Valid: true},
+			"unexpected response status: 503 Service Unavailable",
+			&retryAfter,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, data.WebhookDeliveryPending, failed.Status)
+
+		claimed, err = models.WebhookDeliveries.Claim(ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		assert.Len(t, claimed, 1)
+		assert.Equal(t, 2, claimed[0].Attempts)
+
+		dead, err := models.WebhookDeliveries.MarkFailed(
+			ctx, delivery.ID, sql.NullInt32{}, "connection refused", nil,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, data.WebhookDeliveryDead, dead.Status)
+
+		retried, err := models.WebhookDeliveries.Retry(ctx, webhook.ID, delivery.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, data.WebhookDeliveryPending, retried.Status)
+		assert.Equal(t, 0, retried.Attempts)
+
+		_, err = models.WebhookDeliveries.Retry(ctx, webhook.ID, delivery.ID)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+
+		claimed, err = models.WebhookDeliveries.Claim(ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		assert.Len(t, claimed, 1)
+
+		delivered, err := models.WebhookDeliveries.MarkDelivered(ctx, delivery.ID, 204)
+		assert.NoError(t, err)
+		assert.Equal(t, data.WebhookDeliveryDelivered, delivered.Status)
+		assert.False(t, delivered.LastError.Valid)
+
+		deliveries, metadata, err := models.WebhookDeliveries.SelectAll(ctx, data.Filters{
+			WebhookID: &webhook.ID,
+			Statuses:  []string{data.WebhookDeliveryDelivered},
+			PageSize:  25,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, metadata.ResponseLength)
+		assert.Equal(t, delivery.ID, deliveries[0].ID)
+	})
+
+	t.Run("DeleteExpiredOutboxEvents", func(t *testing.T) {
+		user, err := models.Users.Insert(ctx, data.UserInput{
+			Name:     "Wakako Okada",
+			Username: "wakako",
+			Email:    "wakako@westbrook.com",
+		})
+		assert.NoError(t, err)
+
+		// The event of the new forum is awaiting delivery, and outlives the retention period.
+		_, err = models.Forums.Insert(ctx, data.ForumInput{OwnerID: user.ID, Name: "Tyger Claws"})
+		assert.NoError(t, err)
+
+		deleted, err := models.Purges.DeleteExpiredOutboxEvents(ctx, 0, 1000)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(1))
+
+		deliveries, _, err := models.WebhookDeliveries.SelectAll(ctx, data.Filters{
+			WebhookID: &webhook.ID,
+			PageSize:  25,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, deliveries, 1)
+		assert.Equal(t, data.WebhookDeliveryPending, deliveries[0].Status)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		updated, err := models.Webhooks.Update(ctx, data.WebhookPatch{
+			ID:         webhook.ID,
+			EventTypes: []string{},
+			Active:     sql.NullBool{Bool: false, Valid: true},
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, updated.EventTypes)
+		assert.False(t, updated.Active)
+		assert.Equal(t, webhook.URL, updated.URL)
+		assert.Equal(t, webhook.Secret, updated.Secret)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		deleted, err := models.Webhooks.Delete(ctx, webhook.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, webhook.ID, deleted.ID)
+
+		_, err = models.Webhooks.Select(ctx, webhook.ID)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
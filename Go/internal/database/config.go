@@ -7,6 +7,7 @@ type DatabaseConfig struct {
 	MaxOpenConns    int32  `json:"maxOpenConns"`
 	IdleTimeMinutes int    `json:"idleTimeMinutes"`
 	TimeoutSeconds  int    `json:"timeoutSeconds"`
+	AutoMigrate     bool   `json:"autoMigrate"`
 }
 
 func (c *DatabaseConfig) TimeoutDuration() time.Duration {
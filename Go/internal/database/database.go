@@ -121,3 +121,10 @@ func NullFloat64ToPtr(nf sql.NullFloat64) *float64 {
 	}
 	return &nf.Float64
 }
+
+func NullUUIDToPtr(nu uuid.NullUUID) *uuid.UUID {
+	if !nu.Valid {
+		return nil
+	}
+	return &nu.UUID
+}
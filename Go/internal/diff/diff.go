@@ -0,0 +1,167 @@
+// Package diff computes line-based differences between two texts.
+package diff
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+const (
+	OpEqual  string = "equal"
+	OpInsert string = "insert"
+	OpDelete string = "delete"
+)
+
+// Edit is a single line of a diff, and whether the line is kept, inserted or deleted.
+type Edit struct {
+	// Op is the operation performed on the line, being one of equal, insert or delete.
+	Op string `json:"op"`
+	// Text is the line, without the trailing newline.
+	Text string `json:"text"`
+}
+
+// MaxLines is the largest number of differing lines compared line by line. Texts differing in
+// more lines than this are diffed as the removal of every differing line of a, followed by the
+// insertion of every differing line of b, bounding the time spent on any pair of texts.
+const MaxLines int = 2000
+
+// Lines returns the edits turning the lines of a into the lines of b, based on the longest common
+// subsequence of lines. Deletions are listed before insertions wherever lines are replaced.
+//
+// The common subsequence is found with Hirschberg's algorithm, using space linear in the number
+// of lines.
+func Lines(a, b string) []Edit {
+	x, y := split(a), split(b)
+
+	// Common leading and trailing lines are kept as is, leaving only the differing lines to
+	// compare.
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix &&
+		x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]Edit, 0, max(len(x), len(y)))
+	edits = appendLines(edits, OpEqual, x[:prefix])
+
+	dx, dy := x[prefix:len(x)-suffix], y[prefix:len(y)-suffix]
+	if len(dx) > MaxLines || len(dy) > MaxLines {
+		edits = appendLines(edits, OpDelete, dx)
+		edits = appendLines(edits, OpInsert, dy)
+	} else {
+		edits = hirschberg(edits, dx, dy)
+	}
+
+	edits = appendLines(edits, OpEqual, x[len(x)-suffix:])
+
+	return deletionsFirst(edits)
+}
+
+// hirschberg appends the edits turning x into y, splitting x in half and y where the longest
+// common subsequences of the halves meet, until either is trivial to diff.
+func hirschberg(edits []Edit, x, y []string) []Edit {
+	switch {
+	case len(x) == 0:
+		return appendLines(edits, OpInsert, y)
+	case len(y) == 0:
+		return appendLines(edits, OpDelete, x)
+	case len(x) == 1:
+		for j, line := range y {
+			if line == x[0] {
+				edits = appendLines(edits, OpInsert, y[:j])
+				edits = append(edits, Edit{Op: OpEqual, Text: line})
+				return appendLines(edits, OpInsert, y[j+1:])
+			}
+		}
+		edits = appendLines(edits, OpDelete, x)
+		return appendLines(edits, OpInsert, y)
+	}
+
+	mid := len(x) / 2
+	forward := lcsLengths(x[:mid], y, false)
+	backward := lcsLengths(x[mid:], y, true)
+
+	split, best := 0, -1
+	for j := range len(y) + 1 {
+		if n := forward[j] + backward[len(y)-j]; n > best {
+			split, best = j, n
+		}
+	}
+
+	edits = hirschberg(edits, x[:mid], y[:split])
+	return hirschberg(edits, x[mid:], y[split:])
+}
+
+// lcsLengths returns the lengths of the longest common subsequences of x and every prefix of y,
+// indexed by the length of the prefix. If reverse is set, x and y are read back to front, giving
+// the lengths for every suffix of y instead.
+func lcsLengths(x, y []string, reverse bool) []int {
+	at := func(s []string, i int) string {
+		if reverse {
+			return s[len(s)-1-i]
+		}
+		return s[i]
+	}
+
+	prev := make([]int, len(y)+1)
+	curr := make([]int, len(y)+1)
+	for i := range x {
+		for j := range y {
+			if at(x, i) == at(y, j) {
+				curr[j+1] = prev[j] + 1
+			} else {
+				curr[j+1] = max(prev[j+1], curr[j])
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev
+}
+
+// deletionsFirst reorders every run of changed lines, listing its deletions before its
+// insertions.
+func deletionsFirst(edits []Edit) []Edit {
+	for start := 0; start < len(edits); {
+		if edits[start].Op == OpEqual {
+			start++
+			continue
+		}
+		end := start
+		for end < len(edits) && edits[end].Op != OpEqual {
+			end++
+		}
+		slices.SortStableFunc(edits[start:end], func(a, b Edit) int {
+			return cmp.Compare(rank(a.Op), rank(b.Op))
+		})
+		start = end
+	}
+
+	return edits
+}
+
+func rank(op string) int {
+	if op == OpDelete {
+		return 0
+	}
+	return 1
+}
+
+func appendLines(edits []Edit, op string, lines []string) []Edit {
+	for _, line := range lines {
+		edits = append(edits, Edit{Op: op, Text: line})
+	}
+	return edits
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,78 @@
+package diff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/r3d5un/rosetta/Go/internal/diff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	t.Run("Equal", func(t *testing.T) {
+		edits := diff.Lines("wake up\nsamurai", "wake up\nsamurai")
+		assert.Equal(t, []diff.Edit{
+			{Op: diff.OpEqual, Text: "wake up"},
+			{Op: diff.OpEqual, Text: "samurai"},
+		}, edits)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		edits := diff.Lines(
+			"wake up\nsamurai\nwe have a city to burn",
+			"wake up\nchoom\nwe have a city to burn",
+		)
+		assert.Equal(t, []diff.Edit{
+			{Op: diff.OpEqual, Text: "wake up"},
+			{Op: diff.OpDelete, Text: "samurai"},
+			{Op: diff.OpInsert, Text: "choom"},
+			{Op: diff.OpEqual, Text: "we have a city to burn"},
+		}, edits)
+	})
+
+	t.Run("Append", func(t *testing.T) {
+		edits := diff.Lines("wake up", "wake up\nsamurai\n")
+		assert.Equal(t, []diff.Edit{
+			{Op: diff.OpEqual, Text: "wake up"},
+			{Op: diff.OpInsert, Text: "samurai"},
+		}, edits)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		edits := diff.Lines("wake up", "")
+		assert.Equal(t, []diff.Edit{{Op: diff.OpDelete, Text: "wake up"}}, edits)
+		assert.Empty(t, diff.Lines("", ""))
+	})
+
+	t.Run("Minimal", func(t *testing.T) {
+		edits := diff.Lines("a\nb\nc\nd\ne\nf", "b\nx\nc\ne\nf\ny")
+		assert.Equal(t, []diff.Edit{
+			{Op: diff.OpDelete, Text: "a"},
+			{Op: diff.OpEqual, Text: "b"},
+			{Op: diff.OpInsert, Text: "x"},
+			{Op: diff.OpEqual, Text: "c"},
+			{Op: diff.OpDelete, Text: "d"},
+			{Op: diff.OpEqual, Text: "e"},
+			{Op: diff.OpEqual, Text: "f"},
+			{Op: diff.OpInsert, Text: "y"},
+		}, edits)
+	})
+
+	t.Run("MaxLines", func(t *testing.T) {
+		a := make([]string, diff.MaxLines+1)
+		b := make([]string, diff.MaxLines+1)
+		for i := range a {
+			a[i] = fmt.Sprintf("line %d", i)
+			b[i] = fmt.Sprintf("line %d", len(b)-i)
+		}
+		a = append([]string{"wake up"}, a...)
+		b = append([]string{"wake up"}, b...)
+
+		edits := diff.Lines(strings.Join(a, "\n"), strings.Join(b, "\n"))
+		assert.Len(t, edits, 1+2*(diff.MaxLines+1))
+		assert.Equal(t, diff.Edit{Op: diff.OpEqual, Text: "wake up"}, edits[0])
+		assert.Equal(t, diff.Edit{Op: diff.OpDelete, Text: "line 0"}, edits[1])
+		assert.Equal(t, diff.OpInsert, edits[len(edits)-1].Op)
+	})
+}
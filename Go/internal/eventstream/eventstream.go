@@ -0,0 +1,227 @@
+// Package eventstream delivers thread events to subscribers as they happen.
+//
+// Events are recorded and published by database triggers using NOTIFY. Every instance of the
+// application runs a Broker which LISTENs for the events, and fans them out to the subscribers of
+// each thread. Events missed by a subscriber, for instance while reconnecting, can be replayed
+// from the database.
+package eventstream
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+const (
+	// subscriptionBuffer is the number of events buffered for each subscription. Subscribers
+	// falling further behind are closed, and expected to resume by replaying.
+	subscriptionBuffer int = 64
+	// ReplayLimit is the maximum number of events replayed at once.
+	ReplayLimit int = 500
+	// reconnectDelay is the time to wait before listening again after losing the connection.
+	reconnectDelay = 2 * time.Second
+)
+
+// Subscription receives the events of a single thread.
+type Subscription struct {
+	// ThreadID is the thread the subscription receives events for.
+	ThreadID uuid.UUID
+	// Events receives the events of the thread in order. The channel is closed when the
+	// subscription ends, either by calling Close, or by the broker if the subscriber falls behind
+	// or events may have been missed.
+	Events <-chan data.ThreadEvent
+
+	events chan data.ThreadEvent
+	broker *Broker
+	once   sync.Once
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+type Broker struct {
+	pool   *pgxpool.Pool
+	models *data.Models
+
+	mu            sync.Mutex
+	subscriptions map[uuid.UUID]map[*Subscription]struct{}
+}
+
+func NewBroker(pool *pgxpool.Pool, models *data.Models) *Broker {
+	return &Broker{
+		pool:          pool,
+		models:        models,
+		subscriptions: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// Run listens for thread events until the context is cancelled, publishing every event to the
+// subscribers of its thread. If the connection is lost, every subscription is closed, as events
+// may have been missed, and the broker listens again after a short delay. Every subscription is
+// also closed when the broker stops.
+func (b *Broker) Run(ctx context.Context) {
+	logger := logging.LoggerFromContext(ctx).With(slog.String("channel", data.ThreadEventChannel))
+	defer b.closeAll()
+
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			logger.LogAttrs(ctx, slog.LevelInfo, "stopped listening for thread events")
+			return
+		}
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"lost connection while listening for thread events",
+			slog.String("error", err.Error()),
+		)
+		b.closeAll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (b *Broker) listen(ctx context.Context) error {
+	logger := logging.LoggerFromContext(ctx).With(slog.String("channel", data.ThreadEventChannel))
+
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "LISTEN "+data.ThreadEventChannel)
+	if err != nil {
+		return err
+	}
+	// The connection is returned to the pool afterwards, and must not keep listening.
+	defer func() {
+		_, _ = conn.Exec(context.Background(), "UNLISTEN *")
+	}()
+	logger.LogAttrs(ctx, slog.LevelInfo, "listening for thread events")
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event data.ThreadEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			logger.LogAttrs(
+				ctx,
+				slog.LevelError,
+				"unable to parse thread event",
+				slog.String("payload", notification.Payload),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		b.Publish(event)
+	}
+}
+
+// Subscribe starts receiving the events of a thread. The subscription must be closed when no
+// longer needed.
+func (b *Broker) Subscribe(threadID uuid.UUID) *Subscription {
+	events := make(chan data.ThreadEvent, subscriptionBuffer)
+	s := &Subscription{ThreadID: threadID, Events: events, events: events, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscriptions[threadID] == nil {
+		b.subscriptions[threadID] = make(map[*Subscription]struct{})
+	}
+	b.subscriptions[threadID][s] = struct{}{}
+
+	return s
+}
+
+// Publish delivers an event to every subscriber of its thread without blocking. Subscribers
+// unable to keep up are closed.
+func (b *Broker) Publish(event data.ThreadEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subscriptions[event.ThreadID] {
+		select {
+		case s.events <- event:
+		default:
+			b.remove(s)
+		}
+	}
+}
+
+// Replay returns the events of a thread after the given event ID, oldest first. At most
+// ReplayLimit events are returned.
+func (b *Broker) Replay(
+	ctx context.Context,
+	threadID uuid.UUID,
+	afterID int64,
+) ([]*data.ThreadEvent, error) {
+	return b.models.ThreadEvents.SelectAfter(ctx, threadID, afterID, ReplayLimit)
+}
+
+// Replayed is the set of events sent to a subscriber while replaying. The events may also be
+// received by the subscription, which was started before replaying, and must not be sent twice.
+//
+// Event IDs are assigned when an event is recorded, but events are published when the
+// transaction recording them commits, so events are not necessarily published in the order of
+// their IDs. Only the replayed events themselves are skipped for that reason, rather than every
+// event up to the last replayed ID.
+type Replayed map[int64]struct{}
+
+// Add records an event as sent.
+func (r Replayed) Add(id int64) {
+	r[id] = struct{}{}
+}
+
+// Sent reports whether the event was sent while replaying. The event is forgotten afterwards, as
+// each event is only published once.
+func (r Replayed) Sent(id int64) bool {
+	_, ok := r[id]
+	delete(r, id)
+
+	return ok
+}
+
+func (b *Broker) unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(s)
+}
+
+func (b *Broker) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subscriptions := range b.subscriptions {
+		for s := range subscriptions {
+			b.remove(s)
+		}
+	}
+}
+
+// remove must be called while holding the lock.
+func (b *Broker) remove(s *Subscription) {
+	delete(b.subscriptions[s.ThreadID], s)
+	if len(b.subscriptions[s.ThreadID]) == 0 {
+		delete(b.subscriptions, s.ThreadID)
+	}
+	s.once.Do(func() { close(s.events) })
+}
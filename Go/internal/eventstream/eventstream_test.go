@@ -0,0 +1,79 @@
+package eventstream_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/eventstream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+	broker := eventstream.NewBroker(nil, nil)
+	threadID := uuid.New()
+
+	t.Run("Publish", func(t *testing.T) {
+		subscription := broker.Subscribe(threadID)
+		defer subscription.Close()
+		other := broker.Subscribe(uuid.New())
+		defer other.Close()
+
+		broker.Publish(data.ThreadEvent{
+			ID:       1,
+			ThreadID: threadID,
+			Type:     data.ThreadEventPostCreated,
+		})
+
+		event := <-subscription.Events
+		assert.Equal(t, int64(1), event.ID)
+		assert.Empty(t, other.Events)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		subscription := broker.Subscribe(threadID)
+		subscription.Close()
+		subscription.Close()
+
+		_, ok := <-subscription.Events
+		assert.False(t, ok)
+	})
+
+	t.Run("SlowSubscriber", func(t *testing.T) {
+		subscription := broker.Subscribe(threadID)
+		defer subscription.Close()
+
+		for i := range 100 {
+			broker.Publish(data.ThreadEvent{ID: int64(i), ThreadID: threadID})
+		}
+
+		received := 0
+		for range subscription.Events {
+			received++
+		}
+		assert.Less(t, received, 100)
+	})
+
+	t.Run("OutOfOrder", func(t *testing.T) {
+		subscription := broker.Subscribe(threadID)
+		defer subscription.Close()
+
+		// Event 3 was replayed, while events 4 and 2 were committed after the replay, in the
+		// opposite order of their IDs.
+		replayed := eventstream.Replayed{}
+		replayed.Add(3)
+		for _, id := range []int64{4, 3, 2} {
+			broker.Publish(data.ThreadEvent{ID: id, ThreadID: threadID})
+		}
+
+		var sent []int64
+		for range 3 {
+			event := <-subscription.Events
+			if !replayed.Sent(event.ID) {
+				sent = append(sent, event.ID)
+			}
+		}
+		assert.Equal(t, []int64{4, 2}, sent)
+		assert.Empty(t, replayed)
+	})
+}
@@ -0,0 +1,376 @@
+// Package migrate applies the SQL migrations of the database schema.
+//
+// The applied version is kept in the schema_migrations table, in the same format as the migrate
+// CLI, so databases migrated by either can be migrated further by the other. Every migration is
+// applied in a transaction alongside the change of version, and runners are serialised by an
+// advisory lock, allowing several instances of the application to migrate on startup.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+var (
+	ErrDirty            = errors.New("database is dirty, a migration was left partially applied")
+	ErrUnknownVersion   = errors.New("no migration with the given version")
+	ErrMissingMigration = errors.New("migration is missing an up or down script")
+)
+
+// lockKey identifies the advisory lock held while migrating.
+const lockKey int64 = 0x726f7365747461
+
+var filenameRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	// Version is the number the migration is ordered by.
+	Version uint64 `json:"version"`
+	// Name describes the migration.
+	Name string `json:"name"`
+	// Up is the script applying the migration.
+	Up string `json:"-"`
+	// Down is the script reverting the migration.
+	Down string `json:"-"`
+}
+
+// Load reads every migration in the root of the file system, ordered by version. Files not named
+// like migrations are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		match := filenameRegexp.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		switch match[3] {
+		case "up":
+			m.Up = string(script)
+		default:
+			m.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: %06d_%s", ErrMissingMigration, m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
+
+// Status is the state of the schema of the database.
+type Status struct {
+	// Version is the version of the most recently applied migration, or zero if none is applied.
+	Version uint64 `json:"version"`
+	// Dirty denotes whether the most recent migration failed halfway through.
+	Dirty bool `json:"dirty"`
+	// Latest is the version of the most recent known migration.
+	Latest uint64 `json:"latest"`
+	// Pending are the migrations not yet applied.
+	Pending []Migration `json:"pending"`
+}
+
+type Migrator struct {
+	db         *pgxpool.Pool
+	migrations []Migration
+}
+
+// New creates a migrator applying the migrations found in the root of the file system.
+func New(db *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest returns the version of the most recent migration, or zero if there are none.
+func (m *Migrator) Latest() uint64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.Goto(ctx, m.Latest())
+}
+
+// Down reverts the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		current, dirty, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+
+		i := m.index(current)
+		switch {
+		case current == 0:
+			return nil
+		case i < 0:
+			return fmt.Errorf("%w: %d", ErrUnknownVersion, current)
+		case i == 0:
+			return m.migrate(ctx, conn, current, 0)
+		default:
+			return m.migrate(ctx, conn, current, m.migrations[i-1].Version)
+		}
+	})
+}
+
+// Goto applies or reverts migrations until the schema is at the given version. Version zero
+// reverts every migration.
+func (m *Migrator) Goto(ctx context.Context, target uint64) error {
+	if target != 0 && m.index(target) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		current, dirty, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+		if current != 0 && m.index(current) < 0 {
+			return fmt.Errorf("%w: %d", ErrUnknownVersion, current)
+		}
+
+		return m.migrate(ctx, conn, current, target)
+	})
+}
+
+// Status returns the applied version of the schema, and the migrations still pending.
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	var status *Status
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		current, dirty, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		status = &Status{Version: current, Dirty: dirty, Latest: m.Latest(), Pending: []Migration{}}
+		for _, migration := range m.migrations {
+			if migration.Version > current {
+				status.Pending = append(status.Pending, migration)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+func (m *Migrator) index(version uint64) int {
+	return slices.IndexFunc(m.migrations, func(migration Migration) bool {
+		return migration.Version == version
+	})
+}
+
+// migrate applies the migrations between the current and the target version, one transaction at
+// a time, so that a failure leaves the schema at the last successful migration.
+func (m *Migrator) migrate(
+	ctx context.Context,
+	conn *pgxpool.Conn,
+	current uint64,
+	target uint64,
+) error {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"migration",
+		slog.Uint64("current", current),
+		slog.Uint64("target", target),
+	))
+
+	if current == target {
+		logger.LogAttrs(ctx, slog.LevelInfo, "schema up to date")
+		return nil
+	}
+
+	for _, migration := range m.migrations {
+		if current >= migration.Version || migration.Version > target {
+			continue
+		}
+		logger.LogAttrs(
+			ctx,
+			slog.LevelInfo,
+			"applying migration",
+			slog.Uint64("version", migration.Version),
+			slog.String("name", migration.Name),
+		)
+		if err := apply(ctx, conn, migration.Up, migration.Version); err != nil {
+			return failed(ctx, logger, migration, err)
+		}
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if target >= migration.Version || migration.Version > current {
+			continue
+		}
+		previous := uint64(0)
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+		logger.LogAttrs(
+			ctx,
+			slog.LevelInfo,
+			"reverting migration",
+			slog.Uint64("version", migration.Version),
+			slog.String("name", migration.Name),
+		)
+		if err := apply(ctx, conn, migration.Down, previous); err != nil {
+			return failed(ctx, logger, migration, err)
+		}
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "schema migrated")
+
+	return nil
+}
+
+func failed(
+	ctx context.Context,
+	logger *slog.Logger,
+	migration Migration,
+	err error,
+) error {
+	logger.LogAttrs(
+		ctx,
+		slog.LevelError,
+		"unable to migrate schema",
+		slog.Uint64("version", migration.Version),
+		slog.String("name", migration.Name),
+		slog.String("error", err.Error()),
+	)
+
+	return fmt.Errorf("migration %06d_%s: %w", migration.Version, migration.Name, err)
+}
+
+// withLock runs fn on a single connection holding the advisory lock of the migrations, creating
+// the version table if it does not exist.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	logger := logging.LoggerFromContext(ctx)
+
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "acquiring migration lock")
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1::BIGINT);", lockKey); err != nil {
+		return err
+	}
+	defer func() {
+		_, err := conn.Exec(
+			context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1::BIGINT);", lockKey,
+		)
+		if err != nil {
+			logger.LogAttrs(
+				ctx,
+				slog.LevelError,
+				"unable to release migration lock",
+				slog.String("error", err.Error()),
+			)
+		}
+	}()
+
+	const query string = `
+CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version BIGINT  NOT NULL PRIMARY KEY,
+    dirty   BOOLEAN NOT NULL
+);
+`
+	if _, err := conn.Exec(ctx, query); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func version(ctx context.Context, conn *pgxpool.Conn) (uint64, bool, error) {
+	const query string = `
+SELECT version, dirty
+FROM schema_migrations
+LIMIT 1;
+`
+
+	var v int64
+	var dirty bool
+	err := conn.QueryRow(ctx, query).Scan(&v, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return uint64(v), dirty, nil
+}
+
+// apply runs a migration script and records the resulting version in the same transaction.
+func apply(ctx context.Context, conn *pgxpool.Conn, script string, version uint64) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, script); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations;"); err != nil {
+			return err
+		}
+		if version == 0 {
+			return nil
+		}
+		_, err := tx.Exec(
+			ctx,
+			"INSERT INTO schema_migrations (version, dirty) VALUES ($1::BIGINT, FALSE);",
+			int64(version),
+		)
+		return err
+	})
+}
@@ -0,0 +1,48 @@
+package migrate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/r3d5un/rosetta/Go/internal/migrate"
+	"github.com/r3d5un/rosetta/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_create_netrunners.up.sql":   {Data: []byte("CREATE TABLE netrunners ();")},
+		"000002_create_netrunners.down.sql": {Data: []byte("DROP TABLE netrunners;")},
+		"000001_create_fixers.up.sql":       {Data: []byte("CREATE TABLE fixers ();")},
+		"000001_create_fixers.down.sql":     {Data: []byte("DROP TABLE fixers;")},
+		"migrations.go":                     {Data: []byte("package migrations")},
+	}
+
+	loaded, err := migrate.Load(fsys)
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 2)
+	assert.Equal(t, uint64(1), loaded[0].Version)
+	assert.Equal(t, "create_fixers", loaded[0].Name)
+	assert.Equal(t, "CREATE TABLE fixers ();", loaded[0].Up)
+	assert.Equal(t, "DROP TABLE fixers;", loaded[0].Down)
+	assert.Equal(t, uint64(2), loaded[1].Version)
+}
+
+func TestLoadMissingDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_create_fixers.up.sql": {Data: []byte("CREATE TABLE fixers ();")},
+	}
+
+	_, err := migrate.Load(fsys)
+	assert.ErrorIs(t, err, migrate.ErrMissingMigration)
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	loaded, err := migrate.Load(migrations.FS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, loaded)
+
+	for i, m := range loaded {
+		assert.Equal(t, uint64(i+1), m.Version, "migrations should be numbered without gaps")
+	}
+}
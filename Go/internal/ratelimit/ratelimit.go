@@ -0,0 +1,233 @@
+// Package ratelimit throttles the requests made by every client with token buckets.
+//
+// Every client has a bucket per group of routes, holding up to Burst tokens, which is refilled at
+// the steady rate of the policy of the group. Every request takes a token, and requests finding
+// the bucket empty are rejected until it has been refilled. Buckets are kept in a Store, which is
+// shared by every instance of the application.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+const (
+	// GroupReads are requests retrieving resources.
+	GroupReads string = "reads"
+	// GroupWrites are requests creating, changing or removing resources.
+	GroupWrites string = "writes"
+	// GroupVotes are requests voting on threads and posts.
+	GroupVotes string = "votes"
+)
+
+type Policy struct {
+	// RequestsPerMinute is the steady rate requests are permitted at. Zero permits every request.
+	RequestsPerMinute int `json:"requestsPerMinute"`
+	// Burst is the number of requests permitted at once, after a period of inactivity. Zero
+	// permits the requests of a whole minute at once.
+	Burst int `json:"burst"`
+}
+
+// Limited reports whether the policy limits requests at all.
+func (p Policy) Limited() bool {
+	return p.RequestsPerMinute > 0
+}
+
+// Rate returns the number of tokens added to a bucket every second.
+func (p Policy) Rate() float64 {
+	return float64(p.RequestsPerMinute) / 60
+}
+
+// Capacity returns the maximum number of tokens held by a bucket.
+func (p Policy) Capacity() int {
+	if p.Burst <= 0 {
+		return p.RequestsPerMinute
+	}
+
+	return p.Burst
+}
+
+// Refill returns the time it takes to refill an empty bucket.
+func (p Policy) Refill() time.Duration {
+	return time.Duration(float64(p.Capacity()) / p.Rate() * float64(time.Second))
+}
+
+type RateLimitConfig struct {
+	// Enabled denotes whether requests are limited at all.
+	Enabled bool `json:"enabled"`
+	// Reads is the policy of requests retrieving resources.
+	Reads Policy `json:"reads"`
+	// Writes is the policy of requests creating, changing or removing resources.
+	Writes Policy `json:"writes"`
+	// Votes is the policy of requests voting on threads and posts.
+	Votes Policy `json:"votes"`
+}
+
+// Policy returns the policy of the given group of routes.
+func (c *RateLimitConfig) Policy(group string) Policy {
+	switch group {
+	case GroupReads:
+		return c.Reads
+	case GroupWrites:
+		return c.Writes
+	case GroupVotes:
+		return c.Votes
+	default:
+		return Policy{}
+	}
+}
+
+// Result is the state of a bucket after a request attempted to take a token from it.
+type Result struct {
+	// Allowed denotes whether the request is permitted.
+	Allowed bool
+	// Limit is the maximum number of requests permitted at once.
+	Limit int
+	// Remaining is the number of requests permitted before the bucket is empty.
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is permitted. Zero if the request is
+	// permitted.
+	RetryAfter time.Duration
+}
+
+func newResult(policy Policy, tokens float64, allowed bool) Result {
+	seconds := func(tokens float64) time.Duration {
+		return time.Duration(math.Max(tokens, 0) / policy.Rate() * float64(time.Second))
+	}
+
+	r := Result{
+		Allowed:   allowed,
+		Limit:     policy.Capacity(),
+		Remaining: int(math.Max(math.Floor(tokens), 0)),
+		Reset:     seconds(float64(policy.Capacity()) - tokens),
+	}
+	if !allowed {
+		r.RetryAfter = seconds(1 - tokens)
+	}
+
+	return r
+}
+
+// Store keeps the buckets of every client.
+type Store interface {
+	// Take refills the bucket with the given key and takes a token from it. The tokens left in
+	// the bucket are returned, alongside whether a token was taken.
+	Take(ctx context.Context, key string, rate float64, capacity int) (float64, bool, error)
+	// DeleteIdle deletes every bucket which has not been used within the given duration.
+	DeleteIdle(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+// PostgresStore keeps buckets in the database, sharing them between every instance of the
+// application.
+type PostgresStore struct {
+	models *data.Models
+}
+
+func NewPostgresStore(models *data.Models) *PostgresStore {
+	return &PostgresStore{models: models}
+}
+
+func (s *PostgresStore) Take(
+	ctx context.Context,
+	key string,
+	rate float64,
+	capacity int,
+) (float64, bool, error) {
+	bucket, err := s.models.RateLimitBuckets.Take(ctx, key, rate, float64(capacity))
+	if err == nil {
+		return bucket.Tokens, true, nil
+	}
+	if !errors.Is(err, data.ErrRecordNotFound) {
+		return 0, false, err
+	}
+
+	bucket, err = s.models.RateLimitBuckets.Select(ctx, key, rate, float64(capacity))
+	if err != nil {
+		return 0, false, err
+	}
+
+	return bucket.Tokens, false, nil
+}
+
+func (s *PostgresStore) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	return s.models.RateLimitBuckets.DeleteIdle(ctx, idle)
+}
+
+type Limiter struct {
+	config RateLimitConfig
+	store  Store
+
+	// Interval is the time between every removal of idle buckets.
+	Interval time.Duration
+}
+
+func NewLimiter(config RateLimitConfig, store Store) *Limiter {
+	return &Limiter{config: config, store: store, Interval: time.Hour}
+}
+
+// Allow takes a token from the bucket of the client for the given group of routes. A nil result
+// is returned if the requests of the group are not limited.
+func (l *Limiter) Allow(ctx context.Context, group string, client string) (*Result, error) {
+	if !l.config.Enabled {
+		return nil, nil
+	}
+	policy := l.config.Policy(group)
+	if !policy.Limited() {
+		return nil, nil
+	}
+
+	tokens, allowed, err := l.store.Take(ctx, group+":"+client, policy.Rate(), policy.Capacity())
+	if err != nil {
+		return nil, err
+	}
+	result := newResult(policy, tokens, allowed)
+
+	return &result, nil
+}
+
+// Run removes idle buckets at every interval until the context is cancelled. Buckets are removed
+// once they would have been refilled, as a full bucket is no different from a missing one.
+func (l *Limiter) Run(ctx context.Context) {
+	logger := logging.LoggerFromContext(ctx)
+
+	if !l.config.Enabled {
+		logger.LogAttrs(ctx, slog.LevelInfo, "rate limiting disabled")
+		return
+	}
+
+	var idle time.Duration
+	for _, group := range []string{GroupReads, GroupWrites, GroupVotes} {
+		if policy := l.config.Policy(group); policy.Limited() {
+			idle = max(idle, policy.Refill())
+		}
+	}
+
+	ticker := time.NewTicker(l.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.LogAttrs(ctx, slog.LevelInfo, "stopped removing idle rate limit buckets")
+			return
+		case <-ticker.C:
+			_, err := l.store.DeleteIdle(ctx, idle)
+			if err != nil {
+				logger.LogAttrs(
+					ctx,
+					slog.LevelError,
+					"unable to remove idle rate limit buckets",
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStore holds buckets in memory, refilling nothing, so that the outcome of every request
+// depends only on the requests made before it.
+type fakeStore struct {
+	tokens map[string]float64
+}
+
+func (s *fakeStore) Take(
+	_ context.Context,
+	key string,
+	_ float64,
+	capacity int,
+) (float64, bool, error) {
+	tokens, ok := s.tokens[key]
+	if !ok {
+		tokens = float64(capacity)
+	}
+	if tokens < 1 {
+		return tokens, false, nil
+	}
+	s.tokens[key] = tokens - 1
+
+	return tokens - 1, true, nil
+}
+
+func (s *fakeStore) DeleteIdle(_ context.Context, _ time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func TestPolicy(t *testing.T) {
+	policy := ratelimit.Policy{RequestsPerMinute: 30, Burst: 10}
+	assert.True(t, policy.Limited())
+	assert.Equal(t, 0.5, policy.Rate())
+	assert.Equal(t, 10, policy.Capacity())
+	assert.Equal(t, 20*time.Second, policy.Refill())
+
+	policy = ratelimit.Policy{RequestsPerMinute: 60}
+	assert.Equal(t, 60, policy.Capacity())
+
+	assert.False(t, ratelimit.Policy{}.Limited())
+}
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	config := ratelimit.RateLimitConfig{
+		Enabled: true,
+		Writes:  ratelimit.Policy{RequestsPerMinute: 60, Burst: 2},
+	}
+	limiter := ratelimit.NewLimiter(config, &fakeStore{tokens: map[string]float64{}})
+
+	t.Run("Allowed", func(t *testing.T) {
+		result, err := limiter.Allow(ctx, ratelimit.GroupWrites, "user:delamain")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2, result.Limit)
+		assert.Equal(t, 1, result.Remaining)
+		assert.Equal(t, time.Second, result.Reset)
+		assert.Zero(t, result.RetryAfter)
+	})
+
+	t.Run("Exceeded", func(t *testing.T) {
+		_, err := limiter.Allow(ctx, ratelimit.GroupWrites, "user:delamain")
+		assert.NoError(t, err)
+
+		result, err := limiter.Allow(ctx, ratelimit.GroupWrites, "user:delamain")
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+		assert.Equal(t, 2*time.Second, result.Reset)
+		assert.Equal(t, time.Second, result.RetryAfter)
+	})
+
+	t.Run("SeparateClients", func(t *testing.T) {
+		result, err := limiter.Allow(ctx, ratelimit.GroupWrites, "ip:10.0.0.77")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		result, err := limiter.Allow(ctx, ratelimit.GroupReads, "user:delamain")
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		config.Enabled = false
+		limiter := ratelimit.NewLimiter(config, &fakeStore{tokens: map[string]float64{}})
+
+		result, err := limiter.Allow(ctx, ratelimit.GroupWrites, "user:delamain")
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+	})
+}
@@ -0,0 +1,17 @@
+package repo
+
+import "errors"
+
+var (
+	// ErrInvalidCredentials is returned when a user cannot be authenticated with the given
+	// credentials.
+	ErrInvalidCredentials = errors.New("invalid authentication credentials")
+	// ErrThreadLocked is returned when attempting to post or vote within a locked thread.
+	ErrThreadLocked = errors.New("thread is locked")
+	// ErrInvalidReply is returned when a post replies to a post which is not within the same
+	// thread.
+	ErrInvalidReply = errors.New("replied to post does not exist within the thread")
+	// ErrUserOwnsForums is returned when purging a user which still owns forums. The forums must
+	// be transferred or purged first.
+	ErrUserOwnsForums = errors.New("user owns forums")
+)
@@ -3,7 +3,6 @@ package repo
 import (
 	"context"
 	"log/slog"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -88,6 +87,9 @@ type ForumPatch struct {
 	Name *string `json:"name,omitzero"`
 	// Description contains a description about the purposes and topics of a forum.
 	Description *string `json:"description,omitzero"`
+	// UpdatedAt is the time the forum is expected to have last been updated at. If set, the patch
+	// is only applied if the forum has not been updated since.
+	UpdatedAt *time.Time `json:"-"`
 }
 
 func (f *ForumPatch) Row() data.ForumPatch {
@@ -96,6 +98,7 @@ func (f *ForumPatch) Row() data.ForumPatch {
 		OwnerID:     database.NewNullUUID(f.OwnerID),
 		Name:        database.NewNullString(f.Name),
 		Description: database.NewNullString(f.Description),
+		UpdatedAt:   database.NewNullTime(f.UpdatedAt),
 	}
 }
 
@@ -106,22 +109,18 @@ type ForumReader interface {
 
 type ForumWriter interface {
 	Create(context.Context, ForumInput) (*Forum, error)
-	Delete(context.Context, uuid.UUID) (*Forum, error)
+	Delete(context.Context, uuid.UUID, *time.Time) (*Forum, error)
 	Update(context.Context, ForumPatch) (*Forum, error)
 	Restore(context.Context, uuid.UUID) (*Forum, error)
-	PermanentlyDelete(context.Context, uuid.UUID) (*Forum, error)
+	PermanentlyDelete(context.Context, uuid.UUID, *time.Time, bool) (*PurgeSummary, error)
 }
 
 type ForumRepository struct {
-	models     *data.Models
-	userReader UserReader
+	models *data.Models
 }
 
-func NewForumRepository(models *data.Models, userReader UserReader) ForumRepository {
-	return ForumRepository{
-		models:     models,
-		userReader: userReader,
-	}
+func NewForumRepository(models *data.Models) ForumRepository {
+	return ForumRepository{models: models}
 }
 
 func (r *ForumRepository) Read(ctx context.Context, id uuid.UUID, include bool) (*Forum, error) {
@@ -143,45 +142,8 @@ func (r *ForumRepository) Read(ctx context.Context, id uuid.UUID, include bool)
 		return forum, nil
 	}
 
-	var wg sync.WaitGroup
-	errCh := make(chan error, 2)
-	var forumMu sync.Mutex
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		owner, err := r.userReader.Read(ctx, forum.OwnerID, false)
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		forumMu.Lock()
-		forum.Owner = owner
-		forumMu.Unlock()
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		count, err := r.models.Threads.SelectCount(ctx, data.Filters{ForumID: &forum.ID})
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		forumMu.Lock()
-		forum.ThreadCount = count
-		forumMu.Unlock()
-	}()
-
-	wg.Wait()
-	close(errCh)
-
-	for err := range errCh {
-		if err != nil {
-			logger.Error("unable to include all data", slog.String("error", err.Error()))
-		}
+	if err := loaderFromContext(ctx, r.models).includeForums(ctx, []*Forum{forum}); err != nil {
+		logger.Error("unable to include all data", slog.String("error", err.Error()))
 	}
 
 	return forum, nil
@@ -201,6 +163,7 @@ func (r *ForumRepository) List(
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to select forum", slog.String("error", err.Error()),
 		)
+		return nil, nil, err
 	}
 	logger = logging.LoggerFromContext(ctx).With(slog.Group(
 		"parameters",
@@ -210,51 +173,12 @@ func (r *ForumRepository) List(
 	logger.LogAttrs(ctx, slog.LevelInfo, "forums retrieved")
 
 	forums := make([]*Forum, len(rows))
-	var wg sync.WaitGroup
-	errCh := make(chan error, len(rows)*2)
-	var forumsMu sync.Mutex
-
 	for i, row := range rows {
 		forums[i] = newForumFromRow(*row)
-
-		if !include {
-			continue
-		}
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			owner, err := r.userReader.Read(ctx, forums[i].OwnerID, false)
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			forumsMu.Lock()
-			forums[i].Owner = owner
-			forumsMu.Unlock()
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			count, err := r.models.Threads.SelectCount(ctx, data.Filters{ForumID: &forums[i].ID})
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			forumsMu.Lock()
-			forums[i].ThreadCount = count
-			forumsMu.Unlock()
-		}()
 	}
 
-	wg.Wait()
-	close(errCh)
-
-	for err := range errCh {
-		if err != nil {
+	if include {
+		if err := loaderFromContext(ctx, r.models).includeForums(ctx, forums); err != nil {
 			logger.Error("unable to include all data", slog.String("error", err.Error()))
 		}
 	}
@@ -272,6 +196,7 @@ func (r *ForumRepository) Create(ctx context.Context, input ForumInput) (*Forum,
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to create forum", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "forum created")
 
@@ -295,16 +220,24 @@ func (r *ForumRepository) Update(ctx context.Context, patch ForumPatch) (*Forum,
 	return newForumFromRow(*row), nil
 }
 
-func (r *ForumRepository) Delete(ctx context.Context, id uuid.UUID) (*Forum, error) {
-	logger := logging.LoggerFromContext(ctx).
-		With(slog.Group("parameters", slog.String("id", id.String())))
+// Delete marks a forum as deleted. If updatedAt is set, the forum is only deleted if it has not
+// been updated since.
+func (r *ForumRepository) Delete(
+	ctx context.Context,
+	id uuid.UUID,
+	updatedAt *time.Time,
+) (*Forum, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"parameters", slog.String("id", id.String()), slog.Any("updatedAt", updatedAt),
+	))
 
 	logger.LogAttrs(ctx, slog.LevelInfo, "deleting forum")
-	row, err := r.models.Forums.SoftDelete(ctx, id)
+	row, err := r.models.Forums.SoftDelete(ctx, id, database.NewNullTime(updatedAt))
 	if err != nil {
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to delete forum", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "forum deleted")
 
@@ -321,24 +254,25 @@ func (r *ForumRepository) Restore(ctx context.Context, id uuid.UUID) (*Forum, er
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to restore forum", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "forum restored")
 
 	return newForumFromRow(*row), nil
 }
 
-func (r *ForumRepository) PermanentlyDelete(ctx context.Context, id uuid.UUID) (*Forum, error) {
-	logger := logging.LoggerFromContext(ctx).
-		With(slog.Group("parameters", slog.String("id", id.String())))
-
-	logger.LogAttrs(ctx, slog.LevelInfo, "deleting forum")
-	row, err := r.models.Forums.Delete(ctx, id)
-	if err != nil {
-		logger.LogAttrs(
-			ctx, slog.LevelError, "unable to delete forum", slog.String("error", err.Error()),
-		)
+// PermanentlyDelete removes a forum alongside its threads, their posts and every vote cast on
+// them. If dryRun is set, the summary of what would be removed is returned without removing
+// anything. If updatedAt is set, nothing is removed if the forum has been updated since.
+func (r *ForumRepository) PermanentlyDelete(
+	ctx context.Context,
+	id uuid.UUID,
+	updatedAt *time.Time,
+	dryRun bool,
+) (*PurgeSummary, error) {
+	scope := data.PurgeScope{
+		ForumID:   uuid.NullUUID{UUID: id, Valid: true},
+		UpdatedAt: database.NewNullTime(updatedAt),
 	}
-	logger.LogAttrs(ctx, slog.LevelInfo, "forum deleted")
-
-	return newForumFromRow(*row), nil
+	return purge(ctx, r.models, scope, dryRun)
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/repo"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +34,14 @@ func TestForumRepository(t *testing.T) {
 		forum = *f
 	})
 
+	t.Run("CreateUnknownOwner", func(t *testing.T) {
+		_, err := repository.ForumWriter.Create(ctx, repo.ForumInput{
+			OwnerID: uuid.New(),
+			Name:    "Ghost town",
+		})
+		assert.ErrorIs(t, err, data.ErrForeignKeyConstraintViolation)
+	})
+
 	t.Run("Read", func(t *testing.T) {
 		f, err := repository.ForumReader.Read(ctx, forum.ID, true)
 		assert.NoError(t, err)
@@ -46,6 +55,19 @@ func TestForumRepository(t *testing.T) {
 		assert.GreaterOrEqual(t, len(f), 1)
 	})
 
+	t.Run("ListInvalidCursor", func(t *testing.T) {
+		_, _, err := repository.ForumReader.List(ctx, data.Filters{
+			OrderBy:  []string{"created_at"},
+			PageSize: 100,
+			Cursor: &data.Cursor{
+				OrderBy: []string{"created_at"},
+				Values:  []string{"yesterday"},
+				ID:      uuid.New(),
+			},
+		}, true)
+		assert.ErrorIs(t, err, data.ErrInvalidCursor)
+	})
+
 	t.Run("Update", func(t *testing.T) {
 		forumName := "Surviving Militech"
 		f, err := repository.ForumWriter.Update(
@@ -58,7 +80,7 @@ func TestForumRepository(t *testing.T) {
 	})
 
 	t.Run("Delete", func(t *testing.T) {
-		f, err := repository.ForumWriter.Delete(ctx, forum.ID)
+		f, err := repository.ForumWriter.Delete(ctx, forum.ID, nil)
 		assert.NoError(t, err)
 		assert.Equal(t, f.Deleted, true)
 	})
@@ -69,8 +91,49 @@ func TestForumRepository(t *testing.T) {
 		assert.Equal(t, f.Deleted, false)
 	})
 
+	t.Run("RestoreMissing", func(t *testing.T) {
+		_, err := repository.ForumWriter.Restore(ctx, uuid.New())
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("PermanentlyDeleteDryRun", func(t *testing.T) {
+		thread, err := repository.ThreadWriter.Create(ctx, repo.ThreadInput{
+			AuthorID: u.ID,
+			ForumID:  forum.ID,
+			Title:    "Militech Crusher review",
+			Content:  "Devastating at close range, useless anywhere else",
+		})
+		assert.NoError(t, err)
+
+		_, err = repository.ThreadVoteWriter.Vote(ctx, repo.ThreadVoteInput{
+			ForumID:  forum.ID,
+			ThreadID: thread.ID,
+			UserID:   u.ID,
+			Vote:     1,
+		})
+		assert.NoError(t, err)
+
+		summary, err := repository.ForumWriter.PermanentlyDelete(ctx, forum.ID, nil, true)
+		assert.NoError(t, err)
+		assert.Equal(t, repo.PurgeSummary{
+			DryRun:      true,
+			Forums:      1,
+			Threads:     1,
+			Posts:       1,
+			ThreadVotes: 1,
+		}, *summary)
+
+		_, err = repository.ForumReader.Read(ctx, forum.ID, false)
+		assert.NoError(t, err)
+	})
+
 	t.Run("PermanentlyDelete", func(t *testing.T) {
-		_, err := repository.ForumWriter.PermanentlyDelete(ctx, forum.ID)
+		summary, err := repository.ForumWriter.PermanentlyDelete(ctx, forum.ID, nil, false)
 		assert.NoError(t, err)
+		assert.False(t, summary.DryRun)
+		assert.Equal(t, int64(1), summary.Threads)
+
+		_, err = repository.ForumReader.Read(ctx, forum.ID, false)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
 	})
 }
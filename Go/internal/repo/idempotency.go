@@ -0,0 +1,264 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// IdempotencyKey is a request made with an Idempotency-Key header, alongside the response to it
+// once the request has been processed.
+type IdempotencyKey struct {
+	// UserID is the user which made the request. Keys are unique per user.
+	UserID uuid.UUID `json:"userId"`
+	// Key is the value of the Idempotency-Key header chosen by the client.
+	Key string `json:"key"`
+	// Fingerprint is a hash of the request, used to reject reuse of the key for other requests.
+	Fingerprint string `json:"fingerprint"`
+	// Status is the HTTP status code of the response, or nil while the request is being processed.
+	Status *int `json:"status,omitzero"`
+	// Headers are the headers of the response.
+	Headers map[string][]string `json:"headers,omitzero"`
+	// Body is the body of the response.
+	Body []byte `json:"body,omitzero"`
+	// CreatedAt denotes when the request was first received.
+	CreatedAt time.Time `json:"createdAt"`
+	// ExpiresAt denotes when the key may be reused for another request.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func newIdempotencyKeyFromRow(row data.IdempotencyKey) (*IdempotencyKey, error) {
+	k := IdempotencyKey{
+		UserID:      row.UserID,
+		Key:         row.Key,
+		Fingerprint: row.Fingerprint,
+		Body:        row.Body,
+		CreatedAt:   row.CreatedAt,
+		ExpiresAt:   row.ExpiresAt,
+	}
+	if row.Status.Valid {
+		status := int(row.Status.Int32)
+		k.Status = &status
+	}
+	if len(row.Headers) > 0 {
+		if err := json.Unmarshal(row.Headers, &k.Headers); err != nil {
+			return nil, err
+		}
+	}
+
+	return &k, nil
+}
+
+// LogValue implements slog.LogValuer to keep stored responses, which may hold tokens, out of the
+// logs.
+func (k IdempotencyKey) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("userId", k.UserID.String()),
+		slog.String("key", k.Key),
+		slog.Bool("completed", k.Status != nil),
+		slog.Time("expiresAt", k.ExpiresAt),
+	)
+}
+
+type IdempotencyKeyInput struct {
+	// UserID is the user which made the request.
+	UserID uuid.UUID `json:"userId"`
+	// Key is the value of the Idempotency-Key header chosen by the client.
+	Key string `json:"key"`
+	// Fingerprint is a hash of the request.
+	Fingerprint string `json:"fingerprint"`
+	// Lease is the duration the key is reserved for while the request is processed, after which
+	// it may be taken over by a retry.
+	Lease time.Duration `json:"lease"`
+}
+
+func (k *IdempotencyKeyInput) Row() data.IdempotencyKeyInput {
+	return data.IdempotencyKeyInput{
+		UserID:      k.UserID,
+		Key:         k.Key,
+		Fingerprint: k.Fingerprint,
+		Lease:       k.Lease,
+	}
+}
+
+type IdempotencyKeyPatch struct {
+	// UserID is the user which made the request.
+	UserID uuid.UUID `json:"userId"`
+	// Key is the value of the Idempotency-Key header chosen by the client.
+	Key string `json:"key"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Headers are the headers of the response.
+	Headers map[string][]string `json:"headers"`
+	// Body is the body of the response.
+	Body []byte `json:"body"`
+	// TTL is the duration the response is kept for.
+	TTL time.Duration `json:"ttl"`
+}
+
+func (k *IdempotencyKeyPatch) Row() (data.IdempotencyKeyPatch, error) {
+	headers, err := json.Marshal(k.Headers)
+	if err != nil {
+		return data.IdempotencyKeyPatch{}, err
+	}
+
+	return data.IdempotencyKeyPatch{
+		UserID:  k.UserID,
+		Key:     k.Key,
+		Status:  int32(k.Status),
+		Headers: headers,
+		Body:    k.Body,
+		TTL:     k.TTL,
+	}, nil
+}
+
+type IdempotencyKeyWriter interface {
+	Reserve(context.Context, IdempotencyKeyInput) (*IdempotencyKey, bool, error)
+	Complete(context.Context, IdempotencyKeyPatch) error
+	Release(context.Context, uuid.UUID, string) error
+	DeleteExpired(context.Context) (int64, error)
+}
+
+type IdempotencyKeyRepository struct {
+	models *data.Models
+}
+
+func NewIdempotencyKeyRepository(models *data.Models) IdempotencyKeyRepository {
+	return IdempotencyKeyRepository{models: models}
+}
+
+// Reserve reserves a key for a request about to be processed, returning true if the key was
+// reserved. If the key is already in use, the existing key is returned alongside false, allowing
+// the caller to compare the requests and replay the stored response.
+func (r *IdempotencyKeyRepository) Reserve(
+	ctx context.Context,
+	input IdempotencyKeyInput,
+) (*IdempotencyKey, bool, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("userId", input.UserID.String()),
+			slog.String("key", input.Key)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "reserving idempotency key")
+	row, err := r.models.IdempotencyKeys.Insert(ctx, input.Row())
+	reserved := err == nil
+	if errors.Is(err, data.ErrRecordNotFound) {
+		logger.LogAttrs(ctx, slog.LevelInfo, "idempotency key in use, retrieving existing key")
+		row, err = r.models.IdempotencyKeys.Select(ctx, input.UserID, input.Key)
+	}
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to reserve idempotency key",
+			slog.String("error", err.Error()),
+		)
+		return nil, false, err
+	}
+
+	key, err := newIdempotencyKeyFromRow(*row)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to decode idempotency key",
+			slog.String("error", err.Error()),
+		)
+		return nil, false, err
+	}
+	logger.LogAttrs(
+		ctx, slog.LevelInfo, "idempotency key retrieved", slog.Bool("reserved", reserved),
+	)
+
+	return key, reserved, nil
+}
+
+// Complete stores the response to the request a key was reserved for.
+func (r *IdempotencyKeyRepository) Complete(ctx context.Context, patch IdempotencyKeyPatch) error {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("userId", patch.UserID.String()),
+			slog.String("key", patch.Key),
+			slog.Int("status", patch.Status)))
+
+	row, err := patch.Row()
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to encode response headers",
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "storing response")
+	_, err = r.models.IdempotencyKeys.Update(ctx, row)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to store response", slog.String("error", err.Error()),
+		)
+		return err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "response stored")
+
+	return nil
+}
+
+// Release frees a reserved key without storing a response, allowing the request to be retried.
+func (r *IdempotencyKeyRepository) Release(
+	ctx context.Context,
+	userID uuid.UUID,
+	key string,
+) error {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("userId", userID.String()),
+			slog.String("key", key)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "releasing idempotency key")
+	err := r.models.IdempotencyKeys.Delete(ctx, userID, key)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to release idempotency key",
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "idempotency key released")
+
+	return nil
+}
+
+// DeleteExpired removes every expired key, returning the number of removed keys.
+func (r *IdempotencyKeyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	logger := logging.LoggerFromContext(ctx)
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "deleting expired idempotency keys")
+	deleted, err := r.models.IdempotencyKeys.DeleteExpired(ctx)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to delete expired idempotency keys",
+			slog.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	logger.LogAttrs(
+		ctx, slog.LevelInfo, "expired idempotency keys deleted", slog.Int64("deleted", deleted),
+	)
+
+	return deleted, nil
+}
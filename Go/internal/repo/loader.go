@@ -0,0 +1,259 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+)
+
+type contextKey string
+
+const loaderContextKey contextKey = "loader"
+
+// Loader includes the related resources of forums, threads and posts, such as their authors and
+// votes. Each relation is loaded for every given resource at once, so the number of queries does
+// not grow with the number of resources, and every loaded resource is cached for the lifetime of
+// the loader.
+//
+// The cache is never invalidated, so a loader is meant to live no longer than a single request.
+// See WithLoader.
+type Loader struct {
+	users        cache[*User]
+	forums       cache[*Forum]
+	threads      cache[*Thread]
+	threadCounts cache[int]
+	postCounts   cache[int]
+	threadVotes  cache[int]
+	postVotes    cache[int]
+}
+
+func NewLoader(models *data.Models) *Loader {
+	return &Loader{
+		users: cache[*User]{fetch: func(ctx context.Context, ids []uuid.UUID) (
+			map[uuid.UUID]*User, error,
+		) {
+			rows, err := models.Users.SelectMany(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			users := make(map[uuid.UUID]*User, len(rows))
+			for _, row := range rows {
+				users[row.ID] = newUserFromRow(*row)
+			}
+			return users, nil
+		}},
+		forums: cache[*Forum]{fetch: func(ctx context.Context, ids []uuid.UUID) (
+			map[uuid.UUID]*Forum, error,
+		) {
+			rows, err := models.Forums.SelectMany(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			forums := make(map[uuid.UUID]*Forum, len(rows))
+			for _, row := range rows {
+				forums[row.ID] = newForumFromRow(*row)
+			}
+			return forums, nil
+		}},
+		threads: cache[*Thread]{fetch: func(ctx context.Context, ids []uuid.UUID) (
+			map[uuid.UUID]*Thread, error,
+		) {
+			rows, err := models.Threads.SelectMany(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			threads := make(map[uuid.UUID]*Thread, len(rows))
+			for _, row := range rows {
+				threads[row.ID] = newThreadFromRow(*row)
+			}
+
+			openings, err := models.Posts.SelectOpenings(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			for _, opening := range openings {
+				if thread, ok := threads[opening.ThreadID]; ok {
+					thread.OpeningPost = newPostFromRow(*opening)
+				}
+			}
+			return threads, nil
+		}},
+		threadCounts: cache[int]{fetch: models.Threads.SelectCounts},
+		postCounts:   cache[int]{fetch: models.Posts.SelectCounts},
+		threadVotes:  cache[int]{fetch: models.ThreadVotes.SelectSums},
+		postVotes:    cache[int]{fetch: models.PostVotes.SelectSums},
+	}
+}
+
+// WithLoader embeds a loader in the given context, sharing its cache between every repository
+// including related resources with the context.
+func WithLoader(ctx context.Context, loader *Loader) context.Context {
+	return context.WithValue(ctx, loaderContextKey, loader)
+}
+
+// loaderFromContext returns the loader embedded in the context. If there is none, a new loader is
+// returned, which only caches the resources for the caller.
+func loaderFromContext(ctx context.Context, models *data.Models) *Loader {
+	loader, ok := ctx.Value(loaderContextKey).(*Loader)
+	if !ok {
+		return NewLoader(models)
+	}
+
+	return loader
+}
+
+// includeForums includes the owner and the thread count of every forum.
+func (l *Loader) includeForums(ctx context.Context, forums []*Forum) error {
+	ownerIDs := make([]uuid.UUID, len(forums))
+	forumIDs := make([]uuid.UUID, len(forums))
+	for i, forum := range forums {
+		ownerIDs[i] = forum.OwnerID
+		forumIDs[i] = forum.ID
+	}
+
+	owners, ownersErr := l.users.load(ctx, ownerIDs)
+	counts, countsErr := l.threadCounts.load(ctx, forumIDs)
+	for _, forum := range forums {
+		if ownersErr == nil {
+			forum.Owner = owners[forum.OwnerID]
+		}
+		if countsErr == nil {
+			forum.ThreadCount = ptr(counts[forum.ID])
+		}
+	}
+
+	return errors.Join(ownersErr, countsErr)
+}
+
+// includeThreads includes the author, the forum, the votes and the post count of every thread.
+// The forums are included with their own relations.
+func (l *Loader) includeThreads(ctx context.Context, threads []*Thread) error {
+	authorIDs := make([]uuid.UUID, len(threads))
+	forumIDs := make([]uuid.UUID, len(threads))
+	threadIDs := make([]uuid.UUID, len(threads))
+	for i, thread := range threads {
+		authorIDs[i] = thread.AuthorID
+		forumIDs[i] = thread.ForumID
+		threadIDs[i] = thread.ID
+	}
+
+	authors, authorsErr := l.users.load(ctx, authorIDs)
+	forums, forumsErr := l.forums.load(ctx, forumIDs)
+	if forumsErr == nil {
+		forumsErr = l.includeForums(ctx, values(forums))
+	}
+	votes, votesErr := l.threadVotes.load(ctx, threadIDs)
+	counts, countsErr := l.postCounts.load(ctx, threadIDs)
+	for _, thread := range threads {
+		if authorsErr == nil {
+			thread.Author = authors[thread.AuthorID]
+		}
+		if forums != nil {
+			thread.Forum = forums[thread.ForumID]
+		}
+		if votesErr == nil {
+			thread.Votes = ptr(votes[thread.ID])
+		}
+		if countsErr == nil {
+			thread.PostCount = ptr(counts[thread.ID])
+		}
+	}
+
+	return errors.Join(authorsErr, forumsErr, votesErr, countsErr)
+}
+
+// includePosts includes the author, the thread and the votes of every post. The threads are
+// included with their own relations.
+func (l *Loader) includePosts(ctx context.Context, posts []*Post) error {
+	authorIDs := make([]uuid.UUID, len(posts))
+	threadIDs := make([]uuid.UUID, len(posts))
+	postIDs := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		authorIDs[i] = post.AuthorID
+		threadIDs[i] = post.ThreadID
+		postIDs[i] = post.ID
+	}
+
+	authors, authorsErr := l.users.load(ctx, authorIDs)
+	threads, threadsErr := l.threads.load(ctx, threadIDs)
+	if threadsErr == nil {
+		threadsErr = l.includeThreads(ctx, values(threads))
+	}
+	votes, votesErr := l.postVotes.load(ctx, postIDs)
+	for _, post := range posts {
+		if authorsErr == nil {
+			post.Author = authors[post.AuthorID]
+		}
+		if threads != nil {
+			post.Thread = threads[post.ThreadID]
+		}
+		if votesErr == nil {
+			post.Votes = ptr(votes[post.ID])
+		}
+	}
+
+	return errors.Join(authorsErr, threadsErr, votesErr)
+}
+
+// cache holds the values loaded by ID, loading missing values in batches.
+type cache[T any] struct {
+	mu     sync.Mutex
+	values map[uuid.UUID]T
+	fetch  func(context.Context, []uuid.UUID) (map[uuid.UUID]T, error)
+}
+
+// load returns the values of the given IDs, fetching every ID missing from the cache at once. IDs
+// without a value are cached as the zero value, and are not fetched again.
+func (c *cache[T]) load(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.values == nil {
+		c.values = make(map[uuid.UUID]T)
+	}
+
+	var missing []uuid.UUID
+	queued := make(map[uuid.UUID]bool)
+	for _, id := range ids {
+		if _, ok := c.values[id]; !ok && !queued[id] {
+			queued[id] = true
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		fetched, err := c.fetch(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			c.values[id] = fetched[id]
+		}
+	}
+
+	loaded := make(map[uuid.UUID]T, len(ids))
+	for _, id := range ids {
+		loaded[id] = c.values[id]
+	}
+
+	return loaded, nil
+}
+
+// values returns the values of the map which are not nil.
+func values[T any](m map[uuid.UUID]*T) []*T {
+	s := make([]*T, 0, len(m))
+	for _, v := range m {
+		if v != nil {
+			s = append(s, v)
+		}
+	}
+
+	return s
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
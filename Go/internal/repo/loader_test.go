@@ -0,0 +1,137 @@
+package repo_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingQuerier counts the queries performed through it.
+type countingQuerier struct {
+	data.Querier
+	queries atomic.Int64
+}
+
+func (q *countingQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	q.queries.Add(1)
+	return q.Querier.Query(ctx, sql, args...)
+}
+
+func (q *countingQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	q.queries.Add(1)
+	return q.Querier.QueryRow(ctx, sql, args...)
+}
+
+func TestLoader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	fixer, err := repository.UserWriter.Create(ctx, repo.UserInput{
+		Name:     "Wakako Okada",
+		Username: "wakako",
+		Email:    "wakako@tygerclaws.com",
+	})
+	assert.NoError(t, err)
+
+	merc, err := repository.UserWriter.Create(ctx, repo.UserInput{
+		Name:     "Takemura Goro",
+		Username: "takemura",
+		Email:    "takemura@arasaka.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := repository.ForumWriter.Create(ctx, repo.ForumInput{
+		OwnerID: fixer.ID,
+		Name:    "Japantown gigs",
+	})
+	assert.NoError(t, err)
+
+	thread, err := repository.ThreadWriter.Create(ctx, repo.ThreadInput{
+		AuthorID: fixer.ID,
+		ForumID:  forum.ID,
+		Title:    "Bring me the shard",
+		Content:  "Discretion is expected, as always",
+	})
+	assert.NoError(t, err)
+
+	var voted uuid.UUID
+	for i := range 10 {
+		post, err := repository.PostWriter.Create(ctx, repo.PostInput{
+			ForumID:  forum.ID,
+			ThreadID: thread.ID,
+			AuthorID: merc.ID,
+			Content:  fmt.Sprintf("Shard %d secured", i+1),
+		})
+		assert.NoError(t, err)
+		if i == 0 {
+			voted = post.ID
+		}
+	}
+
+	_, err = repository.PostVoteWriter.Vote(ctx, repo.PostVoteInput{
+		ForumID:  forum.ID,
+		ThreadID: thread.ID,
+		PostID:   voted,
+		UserID:   merc.ID,
+		Vote:     1,
+	})
+	assert.NoError(t, err)
+
+	timeout := 5 * time.Second
+	counter := &countingQuerier{Querier: db}
+	counted := data.NewModels(counter, &timeout)
+	reader := repo.NewRepository(&counted).PostReader
+
+	// list counts the queries performed to list the posts of the thread.
+	list := func(ctx context.Context, pageSize int, include bool) ([]*repo.Post, int64) {
+		counter.queries.Store(0)
+		posts, _, err := reader.List(
+			ctx, forum.ID, thread.ID, data.Filters{PageSize: pageSize}, include,
+		)
+		assert.NoError(t, err)
+		return posts, counter.queries.Load()
+	}
+
+	_, plain := list(ctx, 100, false)
+
+	t.Run("Batched", func(t *testing.T) {
+		_, few := list(repo.WithLoader(ctx, repo.NewLoader(&counted)), 2, true)
+		posts, many := list(repo.WithLoader(ctx, repo.NewLoader(&counted)), 100, true)
+		// The opening post is listed with the thread rather than among its replies.
+		assert.Len(t, posts, 10)
+		assert.Equal(t, few, many, "the number of queries grew with the number of posts")
+
+		for _, post := range posts {
+			assert.Equal(t, merc.ID, post.Author.ID)
+			assert.Equal(t, thread.ID, post.Thread.ID)
+			assert.Equal(t, fixer.ID, post.Thread.Author.ID)
+			assert.Equal(t, thread.OpeningPost.ID, post.Thread.OpeningPost.ID)
+			assert.Equal(t, forum.ID, post.Thread.Forum.ID)
+			assert.Equal(t, fixer.ID, post.Thread.Forum.Owner.ID)
+			assert.Equal(t, 11, *post.Thread.PostCount)
+			assert.Equal(t, 1, *post.Thread.Forum.ThreadCount)
+			if post.ID == voted {
+				assert.Equal(t, 1, *post.Votes)
+			} else {
+				assert.Equal(t, 0, *post.Votes)
+			}
+		}
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		ctx := repo.WithLoader(ctx, repo.NewLoader(&counted))
+		list(ctx, 100, true)
+
+		posts, cached := list(ctx, 100, true)
+		assert.Equal(t, plain, cached, "cached relations were loaded again")
+		assert.Equal(t, merc.ID, posts[0].Author.ID)
+	})
+}
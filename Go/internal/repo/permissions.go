@@ -0,0 +1,208 @@
+package repo
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/database"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// Role is the name of a role a user may hold.
+//
+// Roles are ordered, where each role is permitted to do anything the roles ranked below it are.
+// From the lowest to the highest rank, the roles are member, moderator, owner and admin.
+type Role string
+
+const (
+	// RoleMember is implicitly held by every authenticated user.
+	RoleMember Role = "member"
+	// RoleModerator is granted per forum, and permits moderating its threads and posts.
+	RoleModerator Role = "moderator"
+	// RoleOwner is derived from the owner of a forum.
+	RoleOwner Role = "owner"
+	// RoleAdmin is granted globally, and permits everything.
+	RoleAdmin Role = "admin"
+)
+
+func (r Role) rank() int {
+	switch r {
+	case RoleMember:
+		return 1
+	case RoleModerator:
+		return 2
+	case RoleOwner:
+		return 3
+	case RoleAdmin:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// Permissions are the roles a user holds, either globally or within a forum.
+type Permissions struct {
+	// UserID is the unique identifier of the user holding the roles.
+	UserID uuid.UUID `json:"userId"`
+	// ForumID is the forum the permissions were resolved for. If nil, only global roles are
+	// included.
+	ForumID *uuid.UUID `json:"forumId,omitzero"`
+	// Roles are the roles held by the user.
+	Roles []Role `json:"roles"`
+}
+
+// Has reports whether the user holds the given role, or any role ranked above it.
+func (p *Permissions) Has(role Role) bool {
+	return slices.ContainsFunc(p.Roles, func(r Role) bool { return r.rank() >= role.rank() })
+}
+
+// RoleGrant is a role granted to a user.
+type RoleGrant struct {
+	// UserID is the unique identifier of the user the role is granted to.
+	UserID uuid.UUID `json:"userId"`
+	// Role is the granted role. Only admins and moderators can be granted.
+	Role Role `json:"role"`
+	// ForumID is the forum the role is granted for. Must be set for moderators, and nil for
+	// admins.
+	ForumID *uuid.UUID `json:"forumId,omitzero"`
+	// CreatedAt denotes when the role was granted.
+	//
+	// Upon granting a role, any existing values in this field is ignored.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newRoleGrantFromRow(row data.UserRole) *RoleGrant {
+	grant := RoleGrant{
+		UserID:    row.UserID,
+		Role:      Role(row.Role),
+		CreatedAt: row.CreatedAt,
+	}
+	if row.ForumID.Valid {
+		grant.ForumID = &row.ForumID.UUID
+	}
+
+	return &grant
+}
+
+func (g *RoleGrant) Row() data.UserRole {
+	return data.UserRole{
+		UserID:  g.UserID,
+		Role:    string(g.Role),
+		ForumID: database.NewNullUUID(g.ForumID),
+	}
+}
+
+type PermissionReader interface {
+	Read(context.Context, uuid.UUID, *uuid.UUID) (*Permissions, error)
+	List(context.Context, Role, *uuid.UUID) ([]*RoleGrant, error)
+}
+
+type PermissionWriter interface {
+	Grant(context.Context, RoleGrant) (*RoleGrant, error)
+	Revoke(context.Context, RoleGrant) (*RoleGrant, error)
+}
+
+type PermissionRepository struct {
+	models *data.Models
+}
+
+func NewPermissionRepository(models *data.Models) PermissionRepository {
+	return PermissionRepository{models: models}
+}
+
+// Read resolves the roles of a user. If a forum ID is given, roles held within the forum are
+// included alongside the global roles.
+func (r *PermissionRepository) Read(
+	ctx context.Context,
+	userID uuid.UUID,
+	forumID *uuid.UUID,
+) (*Permissions, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("userId", userID.String()),
+			slog.Any("forumId", forumID)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving user roles")
+	rows, err := r.models.Roles.SelectForUser(ctx, userID, database.NewNullUUID(forumID))
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select user roles", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	permissions := Permissions{UserID: userID, ForumID: forumID, Roles: []Role{RoleMember}}
+	for _, row := range rows {
+		permissions.Roles = append(permissions.Roles, Role(row))
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "user roles retrieved", slog.Any("roles", permissions.Roles))
+
+	return &permissions, nil
+}
+
+// List returns every grant of a role. Moderators are listed per forum, while admins are listed
+// with a nil forum ID.
+func (r *PermissionRepository) List(
+	ctx context.Context,
+	role Role,
+	forumID *uuid.UUID,
+) ([]*RoleGrant, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("role", role), slog.Any("forumId", forumID)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving role grants")
+	rows, err := r.models.Roles.SelectAll(ctx, string(role), database.NewNullUUID(forumID))
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select role grants", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "role grants retrieved", slog.Int("length", len(rows)))
+
+	grants := make([]*RoleGrant, len(rows))
+	for i, row := range rows {
+		grants[i] = newRoleGrantFromRow(*row)
+	}
+
+	return grants, nil
+}
+
+func (r *PermissionRepository) Grant(ctx context.Context, grant RoleGrant) (*RoleGrant, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("grant", grant)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "granting role")
+	row, err := r.models.Roles.Insert(ctx, grant.Row())
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to grant role", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "role granted")
+
+	return newRoleGrantFromRow(*row), nil
+}
+
+func (r *PermissionRepository) Revoke(ctx context.Context, grant RoleGrant) (*RoleGrant, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("grant", grant)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "revoking role")
+	row, err := r.models.Roles.Delete(ctx, grant.Row())
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to revoke role", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "role revoked")
+
+	return newRoleGrantFromRow(*row), nil
+}
@@ -0,0 +1,91 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissionRepository(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := repository.UserWriter.Create(ctx, repo.UserInput{
+		Name:     "Dexter DeShawn",
+		Username: "d.deshawn",
+		Email:    "d.deshawn@afterlife.com",
+	})
+	assert.NoError(t, err)
+
+	u, err := repository.UserWriter.Create(ctx, repo.UserInput{
+		Name:     "T-Bug",
+		Username: "t.bug",
+		Email:    "t.bug@netrunners.com",
+	})
+	assert.NoError(t, err)
+
+	f, err := repository.ForumWriter.Create(ctx, repo.ForumInput{
+		OwnerID: owner.ID,
+		Name:    "Fixers",
+	})
+	assert.NoError(t, err)
+
+	t.Run("ReadOwner", func(t *testing.T) {
+		p, err := repository.PermissionReader.Read(ctx, owner.ID, &f.ID)
+		assert.NoError(t, err)
+		assert.True(t, p.Has(repo.RoleOwner))
+		assert.True(t, p.Has(repo.RoleModerator))
+		assert.False(t, p.Has(repo.RoleAdmin))
+	})
+
+	t.Run("GrantModerator", func(t *testing.T) {
+		g, err := repository.PermissionWriter.Grant(ctx, repo.RoleGrant{
+			UserID:  u.ID,
+			Role:    repo.RoleModerator,
+			ForumID: &f.ID,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, repo.RoleModerator, g.Role)
+
+		p, err := repository.PermissionReader.Read(ctx, u.ID, &f.ID)
+		assert.NoError(t, err)
+		assert.True(t, p.Has(repo.RoleModerator))
+		assert.False(t, p.Has(repo.RoleOwner))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		grants, err := repository.PermissionReader.List(ctx, repo.RoleModerator, &f.ID)
+		assert.NoError(t, err)
+		assert.Len(t, grants, 1)
+		assert.Equal(t, u.ID, grants[0].UserID)
+	})
+
+	t.Run("RevokeModerator", func(t *testing.T) {
+		_, err := repository.PermissionWriter.Revoke(ctx, repo.RoleGrant{
+			UserID:  u.ID,
+			Role:    repo.RoleModerator,
+			ForumID: &f.ID,
+		})
+		assert.NoError(t, err)
+
+		p, err := repository.PermissionReader.Read(ctx, u.ID, &f.ID)
+		assert.NoError(t, err)
+		assert.True(t, p.Has(repo.RoleMember))
+		assert.False(t, p.Has(repo.RoleModerator))
+	})
+
+	t.Run("GrantAdmin", func(t *testing.T) {
+		_, err := repository.PermissionWriter.Grant(ctx, repo.RoleGrant{
+			UserID: u.ID,
+			Role:   repo.RoleAdmin,
+		})
+		assert.NoError(t, err)
+
+		p, err := repository.PermissionReader.Read(ctx, u.ID, nil)
+		assert.NoError(t, err)
+		assert.True(t, p.Has(repo.RoleAdmin))
+	})
+}
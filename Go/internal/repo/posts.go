@@ -2,8 +2,8 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"log/slog"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -41,6 +41,21 @@ type Post struct {
 	//
 	// This field is ignored when updating or creating new post.
 	DeletedAt *time.Time `json:"deletedAt,omitzero"`
+	// Edited denotes whether the content of the post has been changed since it was created.
+	//
+	// This field is ignored when updating or creating new post.
+	Edited bool `json:"edited"`
+	// EditCount is the number of times the content of the post has been changed. Every edit is
+	// kept as a revision of the post.
+	//
+	// This field is ignored when updating or creating new post.
+	EditCount int `json:"editCount"`
+	// Opening denotes whether the post is the opening post of its thread, created alongside it.
+	//
+	// This field is ignored when updating or creating new post.
+	Opening bool `json:"opening,omitzero"`
+	// Redacted denotes whether the content was withheld from the caller.
+	Redacted bool `json:"redacted,omitzero"`
 	// Forum that the thread belongs to.
 	Thread *Thread `json:"forum,omitzero"`
 	// Author of the post.
@@ -49,6 +64,12 @@ type Post struct {
 	Votes *int `json:"votes,omitzero"`
 }
 
+// Redact withholds the content of the post.
+func (p *Post) Redact() {
+	p.Content = ""
+	p.Redacted = true
+}
+
 func newPostFromRow(row data.Post) *Post {
 	return &Post{
 		ID:        row.ID,
@@ -61,6 +82,9 @@ func newPostFromRow(row data.Post) *Post {
 		Likes:     row.Likes,
 		Deleted:   row.Deleted,
 		DeletedAt: database.NullTimeToPtr(row.DeletedAt),
+		Edited:    row.EditCount > 0,
+		EditCount: row.EditCount,
+		Opening:   row.Opening,
 	}
 }
 
@@ -103,9 +127,40 @@ func (p *PostPatch) Row() data.PostPatch {
 	}
 }
 
+// PostNode is a post within a reply tree, alongside the replies to the post.
+type PostNode struct {
+	Post
+	// Depth is the distance from the root of the tree, where the roots have a depth of 0.
+	Depth int `json:"depth"`
+	// ReplyCount is the total number of direct replies to the post. The count may exceed the
+	// number of replies included if the replies were limited.
+	ReplyCount int `json:"replyCount"`
+	// Replies are the direct replies to the post, oldest first.
+	Replies []*PostNode `json:"replies"`
+	// NextCursor continues the replies to the post after the last reply included, if the replies
+	// were limited. It is used together with the ID of the post as the root of the tree.
+	NextCursor string `json:"nextCursor,omitzero"`
+}
+
+// PostTreeOptions limits the size of the reply trees returned by PostReader.Tree.
+type PostTreeOptions struct {
+	// RootID is the post to start the tree at. If nil, every post which is not a reply is a root.
+	RootID *uuid.UUID `json:"rootId,omitzero"`
+	// MaxDepth is the maximum depth of replies to include, where the roots have a depth of 0.
+	MaxDepth int `json:"maxDepth"`
+	// Limit is the maximum number of roots, and the maximum number of replies per post.
+	Limit int `json:"limit"`
+	// After continues the replies to the root, or to the thread if there is no root, after the
+	// cursor. The replies following the cursor are the roots of the returned trees.
+	After *data.Cursor `json:"after,omitzero"`
+}
+
 type PostReader interface {
 	Read(context.Context, uuid.UUID, uuid.UUID, uuid.UUID, bool) (*Post, error)
 	List(context.Context, uuid.UUID, uuid.UUID, data.Filters, bool) ([]*Post, *data.Metadata, error)
+	Tree(context.Context, uuid.UUID, uuid.UUID, PostTreeOptions) ([]*PostNode, *data.Metadata, error)
+	ListRevisions(context.Context, uuid.UUID) ([]*PostRevision, error)
+	DiffRevisions(context.Context, uuid.UUID, int, int) (*PostRevisionDiff, error)
 }
 
 type PostWriter interface {
@@ -113,25 +168,15 @@ type PostWriter interface {
 	Update(context.Context, PostPatch) (*Post, error)
 	Delete(context.Context, uuid.UUID) (*Post, error)
 	Restore(context.Context, uuid.UUID) (*Post, error)
-	PermanentlyDelete(context.Context, uuid.UUID) (*Post, error)
+	PermanentlyDelete(context.Context, uuid.UUID, bool) (*PurgeSummary, error)
 }
 
 type PostRepository struct {
-	models       *data.Models
-	threadReader ThreadReader
-	userReader   UserReader
+	models *data.Models
 }
 
-func NewPostRepository(
-	models *data.Models,
-	threadReader ThreadReader,
-	userReader UserReader,
-) PostRepository {
-	return PostRepository{
-		models:       models,
-		threadReader: threadReader,
-		userReader:   userReader,
-	}
+func NewPostRepository(models *data.Models) PostRepository {
+	return PostRepository{models: models}
 }
 
 func (r *PostRepository) Read(
@@ -147,6 +192,17 @@ func (r *PostRepository) Read(
 		slog.Bool("include", include)),
 	)
 
+	// Posts are only selected by their thread, so the thread must be verified to belong to the
+	// forum. Otherwise, permissions granted in one forum would apply to the posts of every other.
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving thread")
+	_, err := r.models.Threads.Select(ctx, forumID, threadID)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select thread", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
 	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving post")
 	row, err := r.models.Posts.Select(ctx, threadID, postID)
 	if err != nil {
@@ -162,62 +218,8 @@ func (r *PostRepository) Read(
 		return post, nil
 	}
 
-	var wg sync.WaitGroup
-	errCh := make(chan error, 3)
-	var threadMu sync.Mutex
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		author, err := r.userReader.Read(ctx, post.AuthorID, false)
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		threadMu.Lock()
-		post.Author = author
-		threadMu.Unlock()
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		thread, err := r.threadReader.Read(ctx, forumID, threadID, true)
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		threadMu.Lock()
-		post.Thread = thread
-		threadMu.Unlock()
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		votes, err := r.models.ThreadVotes.SelectSum(
-			ctx,
-			data.Filters{ThreadID: &post.ID},
-		)
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		threadMu.Lock()
-		post.Votes = votes
-		threadMu.Unlock()
-	}()
-
-	wg.Wait()
-	close(errCh)
-
-	for err := range errCh {
-		if err != nil {
-			logger.Error("unable to include all data", slog.String("error", err.Error()))
-		}
+	if err := loaderFromContext(ctx, r.models).includePosts(ctx, []*Post{post}); err != nil {
+		logger.Error("unable to include all data", slog.String("error", err.Error()))
 	}
 
 	return post, nil
@@ -240,11 +242,13 @@ func (r *PostRepository) List(
 		)
 
 	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving posts")
+	filter.ThreadID = &threadID
 	rows, metadata, err := r.models.Posts.SelectAll(ctx, filter)
 	if err != nil {
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to select posts", slog.String("error", err.Error()),
 		)
+		return nil, nil, err
 	}
 	logger = logging.LoggerFromContext(ctx).With(slog.Group(
 		"parameters",
@@ -254,86 +258,154 @@ func (r *PostRepository) List(
 	logger.LogAttrs(ctx, slog.LevelInfo, "posts retrieved")
 
 	posts := make([]*Post, len(rows))
-	var wg sync.WaitGroup
-	errCh := make(chan error, len(rows)*3)
-	var postsMu sync.Mutex
-
 	for i, row := range rows {
 		posts[i] = newPostFromRow(*row)
+	}
 
-		if !include {
-			continue
+	if include {
+		if err := loaderFromContext(ctx, r.models).includePosts(ctx, posts); err != nil {
+			logger.Error("unable to include all data", slog.String("error", err.Error()))
 		}
+	}
 
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			author, err := r.userReader.Read(ctx, posts[i].AuthorID, false)
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			postsMu.Lock()
-			posts[i].Author = author
-			postsMu.Unlock()
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+	return posts, metadata, nil
+}
 
-			thread, err := r.threadReader.Read(ctx, forumID, threadID, true)
-			if err != nil {
-				errCh <- err
-				return
-			}
+// Tree returns the reply trees of a thread, limited by the given options. If a root is given that
+// does not exist within the thread, data.ErrRecordNotFound is returned.
+//
+// The metadata holds the cursor continuing the roots, if they were limited. Nodes with limited
+// replies hold the cursor continuing their replies.
+func (r *PostRepository) Tree(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+	options PostTreeOptions,
+) ([]*PostNode, *data.Metadata, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("forumId", forumID.String()),
+			slog.String("threadId", threadID.String()),
+			slog.Any("options", options)))
 
-			postsMu.Lock()
-			posts[i].Thread = thread
-			postsMu.Unlock()
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			votes, err := r.models.PostVotes.SelectSum(
-				ctx,
-				data.Filters{ThreadID: &posts[i].ID},
-			)
-			if err != nil {
-				errCh <- err
-				return
-			}
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving thread")
+	_, err := r.models.Threads.Select(ctx, forumID, threadID)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select thread", slog.String("error", err.Error()),
+		)
+		return nil, nil, err
+	}
 
-			postsMu.Lock()
-			posts[i].Votes = votes
-			postsMu.Unlock()
-		}()
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving post tree")
+	rows, total, err := r.models.Posts.SelectTree(
+		ctx,
+		threadID,
+		database.NewNullUUID(options.RootID),
+		options.After,
+		options.MaxDepth,
+		options.Limit,
+	)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select post tree", slog.String("error", err.Error()),
+		)
+		return nil, nil, err
 	}
+	// Replies following a cursor may have run out, while the root itself must exist.
+	if options.RootID != nil && options.After == nil && len(rows) == 0 {
+		logger.LogAttrs(ctx, slog.LevelInfo, "root post not found")
+		return nil, nil, data.ErrRecordNotFound
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "post tree retrieved", slog.Int("length", len(rows)))
+
+	// The rows are ordered by depth, so every parent is visited before its replies.
+	roots := []*PostNode{}
+	nodes := make(map[uuid.UUID]*PostNode, len(rows))
+	last := make(map[uuid.UUID]*data.Post, len(rows))
+	for _, row := range rows {
+		node := &PostNode{
+			Post:       *newPostFromRow(row.Post),
+			Depth:      row.Depth,
+			ReplyCount: row.ReplyCount,
+			Replies:    []*PostNode{},
+		}
+		nodes[node.ID] = node
 
-	wg.Wait()
-	close(errCh)
+		parent, ok := nodes[row.ReplyTo.UUID]
+		if row.Depth == 0 || !ok {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Replies = append(parent.Replies, node)
+		last[parent.ID] = &row.Post
+	}
 
-	for err := range errCh {
-		if err != nil {
-			logger.Error("unable to include all data", slog.String("error", err.Error()))
+	for id, reply := range last {
+		if node := nodes[id]; node.ReplyCount > len(node.Replies) {
+			node.NextCursor = data.NewPostTreeCursor(reply).Encode()
 		}
 	}
 
-	return posts, metadata, nil
+	metadata := data.Metadata{ResponseLength: len(roots), Next: total > len(roots)}
+	if metadata.Next && len(roots) > 0 {
+		metadata.NextCursor = data.NewPostTreeCursor(&rows[len(roots)-1].Post).Encode()
+	}
+
+	return roots, &metadata, nil
 }
 
 func (r *PostRepository) Create(ctx context.Context, input PostInput) (*Post, error) {
 	logger := logging.LoggerFromContext(ctx).
 		With(slog.Group("parameters", slog.Any("input", input)))
 
-	logger.LogAttrs(ctx, slog.LevelInfo, "creating post")
-	row, err := r.models.Posts.Insert(ctx, input.Row())
+	var row *data.Post
+	err := r.models.WithTx(ctx, func(models data.Models) error {
+		// The thread is locked for share, so it cannot be locked before the post is created.
+		logger.LogAttrs(ctx, slog.LevelInfo, "retrieving thread")
+		thread, err := models.Threads.SelectForShare(ctx, input.ForumID, input.ThreadID)
+		if err != nil {
+			logger.LogAttrs(
+				ctx, slog.LevelError, "unable to select thread", slog.String("error", err.Error()),
+			)
+			return err
+		}
+		if thread.IsLocked {
+			logger.LogAttrs(ctx, slog.LevelInfo, "thread is locked")
+			return ErrThreadLocked
+		}
+
+		if input.ReplyTo != nil {
+			logger.LogAttrs(ctx, slog.LevelInfo, "retrieving replied to post")
+			_, err := models.Posts.Select(ctx, input.ThreadID, *input.ReplyTo)
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				logger.LogAttrs(ctx, slog.LevelInfo, "replied to post not within thread")
+				return ErrInvalidReply
+			case err != nil:
+				logger.LogAttrs(
+					ctx,
+					slog.LevelError,
+					"unable to select post",
+					slog.String("error", err.Error()),
+				)
+				return err
+			}
+		}
+
+		logger.LogAttrs(ctx, slog.LevelInfo, "creating post")
+		row, err = models.Posts.Insert(ctx, input.Row())
+		if err != nil {
+			logger.LogAttrs(
+				ctx, slog.LevelError, "unable to create post", slog.String("error", err.Error()),
+			)
+			return err
+		}
+
+		return nil
+	})
 	if err != nil {
-		logger.LogAttrs(
-			ctx, slog.LevelError, "unable to create post", slog.String("error", err.Error()),
-		)
 		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "post created")
@@ -368,6 +440,7 @@ func (r *PostRepository) Delete(ctx context.Context, id uuid.UUID) (*Post, error
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to delete post", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "post deleted")
 
@@ -384,24 +457,20 @@ func (r *PostRepository) Restore(ctx context.Context, id uuid.UUID) (*Post, erro
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to restore post", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "post restored")
 
 	return newPostFromRow(*row), nil
 }
 
-func (r *PostRepository) PermanentlyDelete(ctx context.Context, id uuid.UUID) (*Post, error) {
-	logger := logging.LoggerFromContext(ctx).
-		With(slog.Group("parameters", slog.String("id", id.String())))
-
-	logger.LogAttrs(ctx, slog.LevelInfo, "deleting post")
-	row, err := r.models.Posts.Delete(ctx, id)
-	if err != nil {
-		logger.LogAttrs(
-			ctx, slog.LevelError, "unable to delete post", slog.String("error", err.Error()),
-		)
-	}
-	logger.LogAttrs(ctx, slog.LevelInfo, "post deleted")
-
-	return newPostFromRow(*row), nil
+// PermanentlyDelete removes a post alongside every reply to it, and the votes cast on them. If
+// dryRun is set, nothing is removed.
+func (r *PostRepository) PermanentlyDelete(
+	ctx context.Context,
+	id uuid.UUID,
+	dryRun bool,
+) (*PurgeSummary, error) {
+	scope := data.PurgeScope{PostID: uuid.NullUUID{UUID: id, Valid: true}}
+	return purge(ctx, r.models, scope, dryRun)
 }
@@ -31,6 +31,7 @@ func TestPostRepository(t *testing.T) {
 		AuthorID: u.ID,
 		ForumID:  f.ID,
 		Title:    "Rouge cars",
+		Content:  "Every cab in the city is driving itself off a cliff",
 	})
 	assert.NoError(t, err)
 
@@ -38,6 +39,7 @@ func TestPostRepository(t *testing.T) {
 
 	t.Run("Create", func(t *testing.T) {
 		p, err := repository.PostWriter.Create(ctx, repo.PostInput{
+			ForumID:  f.ID,
 			ThreadID: thread.ID,
 			Content:  "A rogue taxi is nearby, here are the precise coordinates",
 			AuthorID: u.ID,
@@ -47,12 +49,102 @@ func TestPostRepository(t *testing.T) {
 		post = *p
 	})
 
+	t.Run("Reply", func(t *testing.T) {
+		reply, err := repository.PostWriter.Create(ctx, repo.PostInput{
+			ForumID:  f.ID,
+			ThreadID: thread.ID,
+			ReplyTo:  &post.ID,
+			Content:  "Coordinates received, dispatching a tow truck",
+			AuthorID: u.ID,
+		})
+		assert.NoError(t, err)
+
+		tree, metadata, err := repository.PostReader.Tree(ctx, f.ID, thread.ID, repo.PostTreeOptions{
+			RootID:   &post.ID,
+			MaxDepth: 5,
+			Limit:    25,
+		})
+		assert.NoError(t, err)
+		assert.False(t, metadata.Next)
+		assert.Len(t, tree, 1)
+		assert.Equal(t, post.ID, tree[0].ID)
+		assert.Len(t, tree[0].Replies, 1)
+		assert.Equal(t, reply.ID, tree[0].Replies[0].ID)
+		assert.Empty(t, tree[0].NextCursor)
+
+		next, err := repository.PostWriter.Create(ctx, repo.PostInput{
+			ForumID:  f.ID,
+			ThreadID: thread.ID,
+			ReplyTo:  &post.ID,
+			Content:  "The tow truck is being chased by the taxi",
+			AuthorID: u.ID,
+		})
+		assert.NoError(t, err)
+
+		tree, _, err = repository.PostReader.Tree(ctx, f.ID, thread.ID, repo.PostTreeOptions{
+			RootID:   &post.ID,
+			MaxDepth: 5,
+			Limit:    1,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, tree[0].Replies, 1)
+		assert.NotEmpty(t, tree[0].NextCursor)
+
+		after, err := data.DecodeCursor(tree[0].NextCursor)
+		assert.NoError(t, err)
+		tree, metadata, err = repository.PostReader.Tree(ctx, f.ID, thread.ID, repo.PostTreeOptions{
+			RootID:   &post.ID,
+			MaxDepth: 5,
+			Limit:    1,
+			After:    after,
+		})
+		assert.NoError(t, err)
+		assert.False(t, metadata.Next)
+		assert.Len(t, tree, 1)
+		assert.Equal(t, next.ID, tree[0].ID)
+
+		_, err = repository.PostWriter.PermanentlyDelete(ctx, next.ID, false)
+		assert.NoError(t, err)
+		_, err = repository.PostWriter.PermanentlyDelete(ctx, reply.ID, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("ReplyOtherThread", func(t *testing.T) {
+		otherThread, err := repository.ThreadWriter.Create(ctx, repo.ThreadInput{
+			AuthorID: u.ID,
+			ForumID:  f.ID,
+			Title:    "Cabs with opinions",
+			Content:  "My cab keeps lecturing me about my playlist",
+		})
+		assert.NoError(t, err)
+
+		_, err = repository.PostWriter.Create(ctx, repo.PostInput{
+			ForumID:  f.ID,
+			ThreadID: otherThread.ID,
+			ReplyTo:  &post.ID,
+			Content:  "This reply belongs elsewhere",
+			AuthorID: u.ID,
+		})
+		assert.ErrorIs(t, err, repo.ErrInvalidReply)
+	})
+
 	t.Run("Read", func(t *testing.T) {
 		p, err := repository.PostReader.Read(ctx, f.ID, thread.ID, post.ID, true)
 		assert.NoError(t, err)
 		assert.Equal(t, p.ID, post.ID)
 	})
 
+	t.Run("ReadOtherForum", func(t *testing.T) {
+		other, err := repository.ForumWriter.Create(ctx, repo.ForumInput{
+			OwnerID: u.ID,
+			Name:    "Unrelated cab company",
+		})
+		assert.NoError(t, err)
+
+		_, err = repository.PostReader.Read(ctx, other.ID, thread.ID, post.ID, false)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
 	t.Run("List", func(t *testing.T) {
 		posts, metadata, err := repository.PostReader.List(
 			ctx, f.ID, thread.ID, data.Filters{PageSize: 100}, true,
@@ -60,6 +152,10 @@ func TestPostRepository(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotEmpty(t, metadata)
 		assert.GreaterOrEqual(t, len(posts), 1)
+		for _, p := range posts {
+			assert.Equal(t, thread.ID, p.ThreadID)
+			assert.NotEqual(t, thread.OpeningPost.ID, p.ID)
+		}
 	})
 
 	t.Run("Update", func(t *testing.T) {
@@ -72,12 +168,40 @@ func TestPostRepository(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotEqual(t, thread, *p)
 		assert.Equal(t, updatedContent, p.Content)
+		assert.True(t, p.Edited)
+		assert.Equal(t, 1, p.EditCount)
+	})
+
+	t.Run("Revisions", func(t *testing.T) {
+		revisions, err := repository.PostReader.ListRevisions(ctx, post.ID)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+		assert.Equal(t, post.Content, revisions[0].Content)
+
+		d, err := repository.PostReader.DiffRevisions(ctx, post.ID, 1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, d.From.Revision)
+		assert.Equal(t, 2, d.To.Revision)
+		assert.NotEmpty(t, d.Edits)
+
+		_, err = repository.PostReader.DiffRevisions(ctx, post.ID, 1, 10)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+
+		d.Redact()
+		assert.True(t, d.From.Redacted)
+		assert.Empty(t, d.From.Content)
+		assert.Empty(t, d.To.Content)
+		assert.Empty(t, d.Edits)
 	})
 
 	t.Run("Delete", func(t *testing.T) {
 		p, err := repository.PostWriter.Delete(ctx, post.ID)
 		assert.NoError(t, err)
 		assert.Equal(t, p.Deleted, true)
+
+		p.Redact()
+		assert.True(t, p.Redacted)
+		assert.Empty(t, p.Content)
 	})
 
 	t.Run("Restore", func(t *testing.T) {
@@ -87,7 +211,7 @@ func TestPostRepository(t *testing.T) {
 	})
 
 	t.Run("PermanentlyDelete", func(t *testing.T) {
-		_, err := repository.PostWriter.PermanentlyDelete(ctx, post.ID)
+		_, err := repository.PostWriter.PermanentlyDelete(ctx, post.ID, false)
 		assert.NoError(t, err)
 	})
 }
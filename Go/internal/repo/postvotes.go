@@ -0,0 +1,242 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// PostVote is the tally of votes a post has received, alongside the vote cast by a single user.
+type PostVote struct {
+	// PostID is the unique identifier of the post that was voted on.
+	PostID uuid.UUID `json:"postId"`
+	// UserID is the unique identifier of the user which voted.
+	UserID uuid.UUID `json:"userId"`
+	// Vote is the value of the vote cast by the user. The value is 0 if the user has not voted.
+	Vote int8 `json:"vote"`
+	// Likes is the sum of votes the post has received.
+	Likes int `json:"likes"`
+}
+
+type PostVoteInput struct {
+	// ForumID is the forum the post belongs to.
+	ForumID uuid.UUID `json:"forumId"`
+	// ThreadID is the parent thread of the post.
+	ThreadID uuid.UUID `json:"threadId"`
+	// PostID is the unique identifier of the post that is voted on.
+	PostID uuid.UUID `json:"postId"`
+	// UserID is the unique identifier of the user which votes.
+	UserID uuid.UUID `json:"userId"`
+	// Vote is the value of the vote. Must be either -1, 0 or 1, where 0 removes the vote.
+	Vote int8 `json:"vote"`
+}
+
+func (v *PostVoteInput) Row() data.PostVote {
+	return data.PostVote{
+		PostID: v.PostID,
+		UserID: v.UserID,
+		Vote:   v.Vote,
+	}
+}
+
+type PostVoteReader interface {
+	Read(context.Context, uuid.UUID, uuid.UUID, uuid.UUID, uuid.UUID) (*PostVote, error)
+}
+
+type PostVoteWriter interface {
+	Vote(context.Context, PostVoteInput) (*PostVote, error)
+	Delete(context.Context, uuid.UUID, uuid.UUID, uuid.UUID, uuid.UUID) (*PostVote, error)
+	RecountLikes(context.Context) (int64, error)
+}
+
+type PostVoteRepository struct {
+	models *data.Models
+}
+
+func NewPostVoteRepository(models *data.Models) PostVoteRepository {
+	return PostVoteRepository{models: models}
+}
+
+// Read returns the tally of votes for a post, and the vote cast by the given user.
+func (r *PostVoteRepository) Read(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+	postID uuid.UUID,
+	userID uuid.UUID,
+) (*PostVote, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("forumId", forumID.String()),
+			slog.String("threadId", threadID.String()),
+			slog.String("postId", postID.String()),
+			slog.String("userId", userID.String())))
+
+	_, err := r.verifyPost(ctx, forumID, threadID, postID, false)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to verify post", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return r.tally(ctx, postID, userID)
+}
+
+// Vote records the vote of a user on a post, and returns the updated tally. A vote of 0 removes
+// any vote previously cast by the user.
+func (r *PostVoteRepository) Vote(ctx context.Context, input PostVoteInput) (*PostVote, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("input", input)))
+
+	var tally *PostVote
+	err := r.models.WithTx(ctx, func(models data.Models) error {
+		tx := PostVoteRepository{models: &models}
+
+		// The thread is locked for share, so it cannot be locked before the vote is cast.
+		thread, err := tx.verifyPost(ctx, input.ForumID, input.ThreadID, input.PostID, true)
+		if err != nil {
+			logger.LogAttrs(
+				ctx, slog.LevelError, "unable to verify post", slog.String("error", err.Error()),
+			)
+			return err
+		}
+		if thread.IsLocked {
+			logger.LogAttrs(ctx, slog.LevelInfo, "thread is locked")
+			return ErrThreadLocked
+		}
+
+		logger.LogAttrs(ctx, slog.LevelInfo, "voting on post")
+		_, err = models.PostVotes.Vote(ctx, input.Row())
+		if err != nil {
+			logger.LogAttrs(
+				ctx, slog.LevelError, "unable to vote on post", slog.String("error", err.Error()),
+			)
+			return err
+		}
+		logger.LogAttrs(ctx, slog.LevelInfo, "post voted on")
+
+		tally, err = tx.tally(ctx, input.PostID, input.UserID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tally, nil
+}
+
+// Delete removes the vote a user has cast on a post, and returns the updated tally.
+func (r *PostVoteRepository) Delete(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+	postID uuid.UUID,
+	userID uuid.UUID,
+) (*PostVote, error) {
+	return r.Vote(ctx, PostVoteInput{
+		ForumID:  forumID,
+		ThreadID: threadID,
+		PostID:   postID,
+		UserID:   userID,
+		Vote:     0,
+	})
+}
+
+// verifyPost checks that the post exists within the given thread, and that the thread belongs to
+// the given forum. The parent thread is returned. If lock is set, the thread is locked for share
+// until the end of the transaction.
+func (r *PostVoteRepository) verifyPost(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+	postID uuid.UUID,
+	lock bool,
+) (*data.Thread, error) {
+	logger := logging.LoggerFromContext(ctx)
+
+	selectThread := r.models.Threads.Select
+	if lock {
+		selectThread = r.models.Threads.SelectForShare
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving thread")
+	thread, err := selectThread(ctx, forumID, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving post")
+	_, err = r.models.Posts.Select(ctx, threadID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	return thread, nil
+}
+
+func (r *PostVoteRepository) tally(
+	ctx context.Context,
+	postID uuid.UUID,
+	userID uuid.UUID,
+) (*PostVote, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("postId", postID.String()),
+			slog.String("userId", userID.String())))
+
+	tally := PostVote{PostID: postID, UserID: userID}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving post votes")
+	likes, err := r.models.PostVotes.SelectSum(ctx, data.Filters{PostID: &postID})
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to sum post votes", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	tally.Likes = *likes
+
+	vote, err := r.models.PostVotes.Select(ctx, postID, userID)
+	switch {
+	case err == nil:
+		tally.Vote = vote.Vote
+	case errors.Is(err, data.ErrRecordNotFound):
+		tally.Vote = 0
+	default:
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select post vote", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "post votes retrieved", slog.Any("tally", tally))
+
+	return &tally, nil
+}
+
+// RecountLikes corrects the likes of every post which no longer matches its votes, returning the
+// number of corrected posts.
+func (r *PostVoteRepository) RecountLikes(ctx context.Context) (int64, error) {
+	logger := logging.LoggerFromContext(ctx)
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "recounting post likes")
+	corrected, err := r.models.PostVotes.RecountLikes(ctx)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to recount post likes",
+			slog.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "post likes recounted", slog.Int64("corrected", corrected))
+
+	return corrected, nil
+}
@@ -0,0 +1,71 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostVoteRepository(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u, err := repository.UserWriter.Create(ctx, repo.UserInput{
+		Name:     "Judy Alvarez",
+		Username: "j.alvarez",
+		Email:    "j.alvarez@mox.com",
+	})
+	assert.NoError(t, err)
+
+	f, err := repository.ForumWriter.Create(ctx, repo.ForumInput{
+		OwnerID: u.ID,
+		Name:    "Braindances",
+	})
+	assert.NoError(t, err)
+
+	thread, err := repository.ThreadWriter.Create(ctx, repo.ThreadInput{
+		AuthorID: u.ID,
+		ForumID:  f.ID,
+		Title:    "Editing tips",
+		Content:  "Share your favourite braindance editing tricks",
+	})
+	assert.NoError(t, err)
+
+	post, err := repository.PostWriter.Create(ctx, repo.PostInput{
+		ForumID:  f.ID,
+		ThreadID: thread.ID,
+		Content:  "Always check the thermal layer",
+		AuthorID: u.ID,
+	})
+	assert.NoError(t, err)
+
+	t.Run("Vote", func(t *testing.T) {
+		v, err := repository.PostVoteWriter.Vote(ctx, repo.PostVoteInput{
+			ForumID:  f.ID,
+			ThreadID: thread.ID,
+			PostID:   post.ID,
+			UserID:   u.ID,
+			Vote:     -1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int8(-1), v.Vote)
+		assert.Equal(t, -1, v.Likes)
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		v, err := repository.PostVoteReader.Read(ctx, f.ID, thread.ID, post.ID, u.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int8(-1), v.Vote)
+		assert.Equal(t, -1, v.Likes)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		v, err := repository.PostVoteWriter.Delete(ctx, f.ID, thread.ID, post.ID, u.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int8(0), v.Vote)
+		assert.Equal(t, 0, v.Likes)
+	})
+}
@@ -0,0 +1,274 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// PurgeSummary counts the resources removed by a purge, or the resources a dry run would remove.
+type PurgeSummary struct {
+	// DryRun denotes whether the summary describes a dry run, in which case nothing was removed.
+	DryRun bool `json:"dryRun"`
+	// Users is the number of removed users.
+	Users int64 `json:"users"`
+	// Forums is the number of removed forums.
+	Forums int64 `json:"forums"`
+	// Threads is the number of removed threads.
+	Threads int64 `json:"threads"`
+	// Posts is the number of removed posts.
+	Posts int64 `json:"posts"`
+	// ThreadVotes is the number of removed thread votes.
+	ThreadVotes int64 `json:"threadVotes"`
+	// PostVotes is the number of removed post votes.
+	PostVotes int64 `json:"postVotes"`
+}
+
+func (s *PurgeSummary) add(other PurgeSummary) {
+	s.Users += other.Users
+	s.Forums += other.Forums
+	s.Threads += other.Threads
+	s.Posts += other.Posts
+	s.ThreadVotes += other.ThreadVotes
+	s.PostVotes += other.PostVotes
+}
+
+type PurgeWriter interface {
+	PurgeExpired(context.Context, string, time.Duration, int) (*PurgeSummary, int, error)
+	PurgeOutboxEvents(context.Context, time.Duration, int) (int64, error)
+	PurgeThreadEvents(context.Context, time.Duration, int) (int64, error)
+}
+
+type PurgeRepository struct {
+	models *data.Models
+}
+
+func NewPurgeRepository(models *data.Models) PurgeRepository {
+	return PurgeRepository{models: models}
+}
+
+// PurgeExpired purges up to limit resources of the given kind which were soft deleted longer ago
+// than the retention period, alongside everything depending on them. Every resource is purged in
+// a transaction of its own, keeping transactions short. The summary of everything removed is
+// returned, alongside the number of expired resources found.
+func (r *PurgeRepository) PurgeExpired(
+	ctx context.Context,
+	kind string,
+	retention time.Duration,
+	limit int,
+) (*PurgeSummary, int, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"parameters",
+		slog.String("kind", kind),
+		slog.Duration("retention", retention),
+		slog.Int("limit", limit)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving expired resources")
+	scopes, err := r.models.Purges.SelectExpired(ctx, kind, retention, limit)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to select expired resources",
+			slog.String("error", err.Error()),
+		)
+		return nil, 0, err
+	}
+
+	var summary PurgeSummary
+	for _, scope := range scopes {
+		purged, err := purge(ctx, r.models, scope, false)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			// Purged alongside a resource earlier in the batch, such as the post it replied to.
+			continue
+		case errors.Is(err, ErrUserOwnsForums):
+			// The user is purged by a later run, once the forums are transferred or purged.
+			logger.LogAttrs(
+				ctx,
+				slog.LevelWarn,
+				"expired user still owns forums",
+				slog.String("userId", scope.UserID.UUID.String()),
+			)
+			continue
+		case err != nil:
+			return &summary, len(scopes), err
+		}
+		summary.add(*purged)
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "expired resources purged", slog.Any("summary", summary))
+
+	return &summary, len(scopes), nil
+}
+
+// PurgeOutboxEvents removes up to limit domain events recorded longer ago than the retention
+// period, alongside their concluded webhook deliveries. The number of removed events is returned.
+func (r *PurgeRepository) PurgeOutboxEvents(
+	ctx context.Context,
+	retention time.Duration,
+	limit int,
+) (int64, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"parameters",
+		slog.Duration("retention", retention),
+		slog.Int("limit", limit)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "purging expired outbox events")
+	n, err := r.models.Purges.DeleteExpiredOutboxEvents(ctx, retention, limit)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to purge expired outbox events",
+			slog.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "expired outbox events purged", slog.Int64("purged", n))
+
+	return n, nil
+}
+
+// PurgeThreadEvents removes up to limit thread events recorded longer ago than the retention
+// period, after which they can no longer be replayed. The number of removed events is returned.
+func (r *PurgeRepository) PurgeThreadEvents(
+	ctx context.Context,
+	retention time.Duration,
+	limit int,
+) (int64, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"parameters",
+		slog.Duration("retention", retention),
+		slog.Int("limit", limit)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "purging expired thread events")
+	n, err := r.models.Purges.DeleteExpiredThreadEvents(ctx, retention, limit)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to purge expired thread events",
+			slog.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "expired thread events purged", slog.Int64("purged", n))
+
+	return n, nil
+}
+
+// purge removes the root of the scope and everything depending on it within a single
+// transaction. Dependants are removed before the resources they depend on, so that no foreign key
+// is violated along the way.
+func purge(
+	ctx context.Context,
+	models *data.Models,
+	scope data.PurgeScope,
+	dryRun bool,
+) (*PurgeSummary, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("scope", scope), slog.Bool("dryRun", dryRun)))
+
+	summary := PurgeSummary{DryRun: dryRun}
+	var set *data.PurgeSet
+	err := models.WithTx(ctx, func(models data.Models) error {
+		var err error
+		logger.LogAttrs(ctx, slog.LevelInfo, "retrieving resources to purge")
+		set, err = models.Purges.Select(ctx, scope)
+		if err != nil {
+			logger.LogAttrs(
+				ctx,
+				slog.LevelError,
+				"unable to select purge set",
+				slog.String("error", err.Error()),
+			)
+			return err
+		}
+
+		var roots []uuid.UUID
+		switch {
+		case scope.UserID.Valid:
+			roots = set.UserIDs
+		case scope.ForumID.Valid:
+			roots = set.ForumIDs
+		case scope.ThreadID.Valid:
+			roots = set.ThreadIDs
+		case scope.PostID.Valid:
+			roots = set.PostIDs
+		}
+		if len(roots) == 0 {
+			logger.LogAttrs(ctx, slog.LevelInfo, "nothing to purge")
+			return data.ErrRecordNotFound
+		}
+		if len(set.OwnedForumIDs) > 0 {
+			logger.LogAttrs(
+				ctx,
+				slog.LevelInfo,
+				"user owns forums",
+				slog.Any("forumIds", set.OwnedForumIDs),
+			)
+			return ErrUserOwnsForums
+		}
+
+		summary.Users = int64(len(set.UserIDs))
+		summary.Forums = int64(len(set.ForumIDs))
+		summary.Threads = int64(len(set.ThreadIDs))
+		summary.Posts = int64(len(set.PostIDs))
+		summary.ThreadVotes = set.ThreadVotes
+		summary.PostVotes = set.PostVotes
+		if dryRun {
+			return nil
+		}
+
+		// Votes are removed by the database alongside the posts, threads and users they belong to.
+		steps := []struct {
+			name   string
+			delete func(context.Context, []uuid.UUID) (int64, error)
+			ids    []uuid.UUID
+		}{
+			{"posts", models.Purges.DeletePosts, set.PostIDs},
+			{"threads", models.Purges.DeleteThreads, set.ThreadIDs},
+			{"forums", models.Purges.DeleteForums, set.ForumIDs},
+			{"users", models.Purges.DeleteUsers, set.UserIDs},
+		}
+		for _, step := range steps {
+			logger.LogAttrs(ctx, slog.LevelInfo, "purging "+step.name)
+			_, err := step.delete(ctx, step.ids)
+			if err != nil {
+				logger.LogAttrs(
+					ctx,
+					slog.LevelError,
+					"unable to purge "+step.name,
+					slog.String("error", err.Error()),
+				)
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Every removed resource is logged by ID, leaving an audit trail of what the purge removed.
+	logger.LogAttrs(
+		ctx,
+		slog.LevelInfo,
+		"purged",
+		slog.Any("summary", summary),
+		slog.Group(
+			"purged",
+			slog.Any(data.PurgeKindUsers, set.UserIDs),
+			slog.Any(data.PurgeKindForums, set.ForumIDs),
+			slog.Any(data.PurgeKindThreads, set.ThreadIDs),
+			slog.Any(data.PurgeKindPosts, set.PostIDs),
+		),
+	)
+
+	return &summary, nil
+}
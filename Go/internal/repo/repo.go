@@ -3,32 +3,66 @@ package repo
 import "github.com/r3d5un/rosetta/Go/internal/data"
 
 type Repository struct {
-	models       *data.Models
-	ForumReader  ForumReader
-	ForumWriter  ForumWriter
-	ThreadReader ThreadReader
-	ThreadWriter ThreadWriter
-	PostReader   PostReader
-	PostWriter   PostWriter
-	UserReader   UserReader
-	UserWriter   UserWriter
+	models               *data.Models
+	ForumReader          ForumReader
+	ForumWriter          ForumWriter
+	ThreadReader         ThreadReader
+	ThreadWriter         ThreadWriter
+	ThreadVoteReader     ThreadVoteReader
+	ThreadVoteWriter     ThreadVoteWriter
+	PostReader           PostReader
+	PostWriter           PostWriter
+	PostVoteReader       PostVoteReader
+	PostVoteWriter       PostVoteWriter
+	UserReader           UserReader
+	UserWriter           UserWriter
+	TokenReader          TokenReader
+	TokenWriter          TokenWriter
+	PermissionReader     PermissionReader
+	PermissionWriter     PermissionWriter
+	SearchReader         SearchReader
+	WebhookReader        WebhookReader
+	WebhookWriter        WebhookWriter
+	PurgeWriter          PurgeWriter
+	IdempotencyKeyWriter IdempotencyKeyWriter
 }
 
 func NewRepository(models *data.Models) Repository {
 	userRepo := NewUserRepository(models)
-	forumRepo := NewForumRepository(models, &userRepo)
-	threadRepo := NewThreadRepository(models, &forumRepo, &userRepo)
-	postRepo := NewPostRepository(models, &threadRepo, &userRepo)
+	forumRepo := NewForumRepository(models)
+	threadRepo := NewThreadRepository(models)
+	postRepo := NewPostRepository(models)
+	threadVoteRepo := NewThreadVoteRepository(models)
+	postVoteRepo := NewPostVoteRepository(models)
+	tokenRepo := NewTokenRepository(models)
+	permissionRepo := NewPermissionRepository(models)
+	searchRepo := NewSearchRepository(models)
+	webhookRepo := NewWebhookRepository(models)
+	purgeRepo := NewPurgeRepository(models)
+	idempotencyKeyRepo := NewIdempotencyKeyRepository(models)
 
 	return Repository{
-		models:       models,
-		ForumReader:  &forumRepo,
-		ForumWriter:  &forumRepo,
-		ThreadReader: &threadRepo,
-		ThreadWriter: &threadRepo,
-		PostReader:   &postRepo,
-		PostWriter:   &postRepo,
-		UserReader:   &userRepo,
-		UserWriter:   &userRepo,
+		models:               models,
+		ForumReader:          &forumRepo,
+		ForumWriter:          &forumRepo,
+		ThreadReader:         &threadRepo,
+		ThreadWriter:         &threadRepo,
+		ThreadVoteReader:     &threadVoteRepo,
+		ThreadVoteWriter:     &threadVoteRepo,
+		PostReader:           &postRepo,
+		PostWriter:           &postRepo,
+		PostVoteReader:       &postVoteRepo,
+		PostVoteWriter:       &postVoteRepo,
+		UserReader:           &userRepo,
+		UserWriter:           &userRepo,
+		TokenReader:          &tokenRepo,
+		TokenWriter:          &tokenRepo,
+		PermissionReader:     &permissionRepo,
+		PermissionWriter:     &permissionRepo,
+		SearchReader:         &searchRepo,
+		WebhookReader:        &webhookRepo,
+		WebhookWriter:        &webhookRepo,
+		PurgeWriter:          &purgeRepo,
+		IdempotencyKeyWriter: &idempotencyKeyRepo,
 	}
 }
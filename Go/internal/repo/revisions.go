@@ -0,0 +1,192 @@
+package repo
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/diff"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+type PostRevision struct {
+	// PostID is the post the revision belongs to.
+	PostID uuid.UUID `json:"postId"`
+	// Revision is the number of the revision, starting at 1 for the original content.
+	Revision int `json:"revision"`
+	// Content is the content of the post as of the revision.
+	Content string `json:"content"`
+	// CreatedAt denotes when the revision was made.
+	CreatedAt time.Time `json:"createdAt"`
+	// Redacted denotes whether the content was withheld from the caller.
+	Redacted bool `json:"redacted,omitzero"`
+}
+
+// Redact withholds the content of the revision.
+func (r *PostRevision) Redact() {
+	r.Content = ""
+	r.Redacted = true
+}
+
+func newPostRevisionFromRow(row data.PostRevision) *PostRevision {
+	return &PostRevision{
+		PostID:    row.PostID,
+		Revision:  row.Revision,
+		Content:   row.Content,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// PostRevisionDiff is the line-based difference between two revisions of a post.
+type PostRevisionDiff struct {
+	// From is the revision the difference starts at.
+	From PostRevision `json:"from"`
+	// To is the revision the difference ends at.
+	To PostRevision `json:"to"`
+	// Edits are the lines turning the content of From into the content of To.
+	Edits []diff.Edit `json:"edits"`
+}
+
+// Redact withholds the content of both revisions, and the edits between them.
+func (d *PostRevisionDiff) Redact() {
+	d.From.Redact()
+	d.To.Redact()
+	d.Edits = []diff.Edit{}
+}
+
+type ThreadRevision struct {
+	// ThreadID is the thread the revision belongs to.
+	ThreadID uuid.UUID `json:"threadId"`
+	// Revision is the number of the revision, starting at 1 for the original title.
+	Revision int `json:"revision"`
+	// Title is the title of the thread as of the revision.
+	Title string `json:"title"`
+	// CreatedAt denotes when the revision was made.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newThreadRevisionFromRow(row data.ThreadRevision) *ThreadRevision {
+	return &ThreadRevision{
+		ThreadID:  row.ThreadID,
+		Revision:  row.Revision,
+		Title:     row.Title,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// ListRevisions lists every revision of the content of a post, oldest first.
+func (r *PostRepository) ListRevisions(
+	ctx context.Context,
+	postID uuid.UUID,
+) ([]*PostRevision, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.String("postId", postID.String())))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving post revisions")
+	rows, err := r.models.PostRevisions.SelectAll(ctx, postID)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to select post revisions",
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	revisions := make([]*PostRevision, len(rows))
+	for i, row := range rows {
+		revisions[i] = newPostRevisionFromRow(*row)
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "post revisions retrieved")
+
+	return revisions, nil
+}
+
+// DiffRevisions returns the difference between two revisions of a post. The revisions may be given
+// in any order, allowing a later revision to be compared against an earlier one.
+func (r *PostRepository) DiffRevisions(
+	ctx context.Context,
+	postID uuid.UUID,
+	from int,
+	to int,
+) (*PostRevisionDiff, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("postId", postID.String()),
+			slog.Int("from", from),
+			slog.Int("to", to)))
+
+	revisions := make([]*PostRevision, 2)
+	for i, revision := range []int{from, to} {
+		logger.LogAttrs(
+			ctx, slog.LevelInfo, "retrieving post revision", slog.Int("revision", revision),
+		)
+		row, err := r.models.PostRevisions.Select(ctx, postID, revision)
+		if err != nil {
+			logger.LogAttrs(
+				ctx,
+				slog.LevelError,
+				"unable to select post revision",
+				slog.Int("revision", revision),
+				slog.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		revisions[i] = newPostRevisionFromRow(*row)
+	}
+
+	d := PostRevisionDiff{
+		From:  *revisions[0],
+		To:    *revisions[1],
+		Edits: diff.Lines(revisions[0].Content, revisions[1].Content),
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "post revisions compared", slog.Int("edits", len(d.Edits)))
+
+	return &d, nil
+}
+
+// ListRevisions lists every revision of the title of a thread, oldest first.
+func (r *ThreadRepository) ListRevisions(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+) ([]*ThreadRevision, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("forumId", forumID.String()),
+			slog.String("threadId", threadID.String())))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving thread")
+	_, err := r.models.Threads.Select(ctx, forumID, threadID)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select thread", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving thread revisions")
+	rows, err := r.models.ThreadRevisions.SelectAll(ctx, threadID)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to select thread revisions",
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	revisions := make([]*ThreadRevision, len(rows))
+	for i, row := range rows {
+		revisions[i] = newThreadRevisionFromRow(*row)
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "thread revisions retrieved")
+
+	return revisions, nil
+}
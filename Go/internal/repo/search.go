@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/database"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// SearchResult is a forum, thread or post matching a full-text search.
+type SearchResult struct {
+	// Type is the type of the matching resource, either forum, thread or post.
+	Type string `json:"type"`
+	// ID is the unique identifier of the matching resource.
+	ID uuid.UUID `json:"id"`
+	// ForumID is the forum the resource belongs to. For forums, this is the ID of the forum itself.
+	ForumID uuid.UUID `json:"forumId"`
+	// ThreadID is the thread the resource belongs to. Forums do not belong to any thread.
+	ThreadID *uuid.UUID `json:"threadId,omitzero"`
+	// Title is the name of the forum, or the title of the thread the resource belongs to.
+	Title string `json:"title"`
+	// Snippet is an excerpt of the matching text as HTML. Matching terms are wrapped in <mark>
+	// tags, while any HTML within the text is escaped.
+	Snippet string `json:"snippet"`
+	// Rank denotes how well the resource matches the search, where higher is better.
+	Rank float32 `json:"rank"`
+	// CreatedAt denotes when the resource was created.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newSearchResultFromRow(row data.SearchResult) *SearchResult {
+	return &SearchResult{
+		Type:      row.Type,
+		ID:        row.ID,
+		ForumID:   row.ForumID,
+		ThreadID:  database.NullUUIDToPtr(row.ThreadID),
+		Title:     row.Title,
+		Snippet:   row.Snippet,
+		Rank:      row.Rank,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+type SearchReader interface {
+	Search(context.Context, data.Filters) ([]*SearchResult, *data.Metadata, error)
+}
+
+type SearchRepository struct {
+	models *data.Models
+}
+
+func NewSearchRepository(models *data.Models) SearchRepository {
+	return SearchRepository{models: models}
+}
+
+// Search returns the forums, threads and posts matching the query in the filters, best matches
+// first.
+func (r *SearchRepository) Search(
+	ctx context.Context,
+	filters data.Filters,
+) ([]*SearchResult, *data.Metadata, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("filters", filters)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "searching")
+	rows, metadata, err := r.models.Search.SelectAll(ctx, filters)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to search", slog.String("error", err.Error()),
+		)
+		return nil, nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "search performed", slog.Any("metadata", metadata))
+
+	results := make([]*SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = newSearchResultFromRow(*row)
+	}
+
+	return results, metadata, nil
+}
@@ -0,0 +1,51 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchRepository(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u, err := repository.UserWriter.Create(ctx, repo.UserInput{
+		Name:     "Rogue Amendiares",
+		Username: "r.amendiares",
+		Email:    "r.amendiares@afterlife.com",
+	})
+	assert.NoError(t, err)
+
+	f, err := repository.ForumWriter.Create(ctx, repo.ForumInput{
+		OwnerID: u.ID,
+		Name:    "Afterlife",
+	})
+	assert.NoError(t, err)
+
+	thread, err := repository.ThreadWriter.Create(ctx, repo.ThreadInput{
+		AuthorID: u.ID,
+		ForumID:  f.ID,
+		Title:    "Drinks named after legendary mercenaries",
+		Content:  "The Johnny Silverhand is just tequila and chili beer",
+	})
+	assert.NoError(t, err)
+
+	t.Run("Search", func(t *testing.T) {
+		query := "legendary mercenaries"
+		results, metadata, err := repository.SearchReader.Search(ctx, data.Filters{
+			Query:    &query,
+			ForumID:  &f.ID,
+			PageSize: 25,
+		})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, metadata)
+		assert.Len(t, results, 1)
+		assert.Equal(t, thread.ID, results[0].ID)
+		assert.Equal(t, &thread.ID, results[0].ThreadID)
+	})
+}
@@ -2,8 +2,8 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"log/slog"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -38,6 +38,14 @@ type Thread struct {
 	//
 	// This field is ignored when updating or creating new threads.
 	IsLocked bool `json:"isLocked"`
+	// LockedBy is the unique identifier of the user which locked the thread.
+	//
+	// This field is ignored when updating or creating new threads.
+	LockedBy *uuid.UUID `json:"lockedBy,omitzero"`
+	// LockedAt denotes when the thread was locked.
+	//
+	// This field is ignored when updating or creating new threads.
+	LockedAt *time.Time `json:"lockedAt,omitzero"`
 	// Deleted is a soft delete flag for a thread.
 	Deleted bool `json:"deleted,omitzero"`
 	// DeletedAt denotes when a thread was marked as deleted.
@@ -54,6 +62,9 @@ type Thread struct {
 	Votes *int `json:"votes,omitzero"`
 	// PostCount is the number of posts within a thread
 	PostCount *int `json:"post_count,omitzero"`
+	// OpeningPost is the first post of the thread, created alongside it. Replies to the thread are
+	// listed through the posts of the thread.
+	OpeningPost *Post `json:"openingPost,omitzero"`
 }
 
 func newThreadFromRow(row data.Thread) *Thread {
@@ -65,6 +76,8 @@ func newThreadFromRow(row data.Thread) *Thread {
 		CreatedAt: row.CreatedAt,
 		UpdatedAt: row.UpdatedAt,
 		IsLocked:  row.IsLocked,
+		LockedBy:  database.NullUUIDToPtr(row.LockedBy),
+		LockedAt:  database.NullTimeToPtr(row.LockedAt),
 		Deleted:   row.Deleted,
 		DeletedAt: database.NullTimeToPtr(row.DeletedAt),
 		Likes:     row.Likes,
@@ -78,6 +91,8 @@ type ThreadInput struct {
 	Title string `json:"title"`
 	// AuthorID is the unique identifier of the author of the thread.
 	AuthorID uuid.UUID `json:"authorId"`
+	// Content is the text content of the opening post of the thread.
+	Content string `json:"content"`
 }
 
 func (f *ThreadInput) Row() data.ThreadInput {
@@ -99,46 +114,45 @@ type ThreadPatch struct {
 	Title *string `json:"title"`
 	// AuthorID is the unique identifier of the author of the thread.
 	AuthorID *uuid.UUID `json:"authorId"`
+	// UpdatedAt is the time the thread is expected to have last been updated at. If set, the patch
+	// is only applied if the thread has not been updated since.
+	UpdatedAt *time.Time `json:"-"`
 }
 
 func (f *ThreadPatch) Row() data.ThreadPatch {
 	return data.ThreadPatch{
-		ID:       f.ID,
-		ForumID:  f.ForumID,
-		Title:    database.NewNullString(f.Title),
-		AuthorID: database.NewNullUUID(f.AuthorID),
+		ID:        f.ID,
+		ForumID:   f.ForumID,
+		Title:     database.NewNullString(f.Title),
+		AuthorID:  database.NewNullUUID(f.AuthorID),
+		UpdatedAt: database.NewNullTime(f.UpdatedAt),
 	}
 }
 
 type ThreadReader interface {
 	Read(context.Context, uuid.UUID, uuid.UUID, bool) (*Thread, error)
 	List(context.Context, data.Filters, bool) ([]*Thread, *data.Metadata, error)
+	ListRevisions(context.Context, uuid.UUID, uuid.UUID) ([]*ThreadRevision, error)
 }
 
 type ThreadWriter interface {
 	Create(context.Context, ThreadInput) (*Thread, error)
 	Update(context.Context, ThreadPatch) (*Thread, error)
-	Delete(context.Context, uuid.UUID, uuid.UUID) (*Thread, error)
+	Delete(context.Context, uuid.UUID, uuid.UUID, *time.Time) (*Thread, error)
 	Restore(context.Context, uuid.UUID, uuid.UUID) (*Thread, error)
-	PermanentlyDelete(context.Context, uuid.UUID, uuid.UUID) (*Thread, error)
+	PermanentlyDelete(
+		context.Context, uuid.UUID, uuid.UUID, *time.Time, bool,
+	) (*PurgeSummary, error)
+	Lock(context.Context, uuid.UUID, uuid.UUID, uuid.UUID) (*Thread, error)
+	Unlock(context.Context, uuid.UUID, uuid.UUID) (*Thread, error)
 }
 
 type ThreadRepository struct {
-	models      *data.Models
-	forumReader ForumReader
-	userReader  UserReader
+	models *data.Models
 }
 
-func NewThreadRepository(
-	models *data.Models,
-	forumReader ForumReader,
-	userReader UserReader,
-) ThreadRepository {
-	return ThreadRepository{
-		models:      models,
-		forumReader: forumReader,
-		userReader:  userReader,
-	}
+func NewThreadRepository(models *data.Models) ThreadRepository {
+	return ThreadRepository{models: models}
 }
 
 func (r *ThreadRepository) Read(
@@ -165,80 +179,30 @@ func (r *ThreadRepository) Read(
 	thread := newThreadFromRow(*row)
 	logger.LogAttrs(ctx, slog.LevelInfo, "thread retrieved")
 
-	if !include {
-		return thread, nil
-	}
-
-	var wg sync.WaitGroup
-	errCh := make(chan error, 4)
-	var threadMu sync.Mutex
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		author, err := r.userReader.Read(ctx, thread.AuthorID, false)
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		threadMu.Lock()
-		thread.Author = author
-		threadMu.Unlock()
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		forum, err := r.forumReader.Read(ctx, thread.ForumID, true)
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		threadMu.Lock()
-		thread.Forum = forum
-		threadMu.Unlock()
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		votes, err := r.models.ThreadVotes.SelectSum(
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving opening post")
+	post, err := r.models.Posts.SelectOpening(ctx, threadID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		// Threads created before opening posts were introduced may not have one.
+		logger.LogAttrs(ctx, slog.LevelInfo, "thread has no opening post")
+	case err != nil:
+		logger.LogAttrs(
 			ctx,
-			data.Filters{ThreadID: &thread.ID},
+			slog.LevelError,
+			"unable to select opening post",
+			slog.String("error", err.Error()),
 		)
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		threadMu.Lock()
-		thread.Votes = votes
-		threadMu.Unlock()
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		count, err := r.models.Posts.SelectCount(ctx, data.Filters{ThreadID: &thread.ID})
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		threadMu.Lock()
-		thread.PostCount = count
-		threadMu.Unlock()
-	}()
+		return nil, err
+	default:
+		thread.OpeningPost = newPostFromRow(*post)
+	}
 
-	wg.Wait()
-	close(errCh)
+	if !include {
+		return thread, nil
+	}
 
-	for err := range errCh {
-		if err != nil {
-			logger.Error("unable to include all data", slog.String("error", err.Error()))
-		}
+	if err := loaderFromContext(ctx, r.models).includeThreads(ctx, []*Thread{thread}); err != nil {
+		logger.Error("unable to include all data", slog.String("error", err.Error()))
 	}
 
 	return thread, nil
@@ -258,6 +222,7 @@ func (r *ThreadRepository) List(
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to select thread", slog.String("error", err.Error()),
 		)
+		return nil, nil, err
 	}
 	logger = logging.LoggerFromContext(ctx).With(slog.Group(
 		"parameters",
@@ -267,85 +232,12 @@ func (r *ThreadRepository) List(
 	logger.LogAttrs(ctx, slog.LevelInfo, "threads retrieved")
 
 	threads := make([]*Thread, len(rows))
-	var wg sync.WaitGroup
-	errCh := make(chan error, len(rows)*4)
-	var threadsMu sync.Mutex
-
 	for i, row := range rows {
 		threads[i] = newThreadFromRow(*row)
-
-		if !include {
-			continue
-		}
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			author, err := r.userReader.Read(ctx, threads[i].AuthorID, false)
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			threadsMu.Lock()
-			threads[i].Author = author
-			threadsMu.Unlock()
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			forum, err := r.forumReader.Read(ctx, threads[i].ForumID, true)
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			threadsMu.Lock()
-			threads[i].Forum = forum
-			threadsMu.Unlock()
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			votes, err := r.models.ThreadVotes.SelectSum(
-				ctx,
-				data.Filters{ThreadID: &threads[i].ID},
-			)
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			threadsMu.Lock()
-			threads[i].Votes = votes
-			threadsMu.Unlock()
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			count, err := r.models.Posts.SelectCount(
-				ctx,
-				data.Filters{ThreadID: &threads[i].ID},
-			)
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			threadsMu.Lock()
-			threads[i].PostCount = count
-			threadsMu.Unlock()
-		}()
 	}
 
-	wg.Wait()
-	close(errCh)
-
-	for err := range errCh {
-		if err != nil {
+	if include {
+		if err := loaderFromContext(ctx, r.models).includeThreads(ctx, threads); err != nil {
 			logger.Error("unable to include all data", slog.String("error", err.Error()))
 		}
 	}
@@ -353,20 +245,49 @@ func (r *ThreadRepository) List(
 	return threads, metadata, nil
 }
 
+// Create creates a thread alongside its opening post. Either both are created, or neither is.
 func (r *ThreadRepository) Create(ctx context.Context, input ThreadInput) (*Thread, error) {
 	logger := logging.LoggerFromContext(ctx).
 		With(slog.Group("parameters", slog.Any("thread", input)))
 
-	logger.LogAttrs(ctx, slog.LevelInfo, "creating thread")
-	row, err := r.models.Threads.Insert(ctx, input.Row())
+	var thread *Thread
+	err := r.models.WithTx(ctx, func(models data.Models) error {
+		logger.LogAttrs(ctx, slog.LevelInfo, "creating thread")
+		row, err := models.Threads.Insert(ctx, input.Row())
+		if err != nil {
+			logger.LogAttrs(
+				ctx, slog.LevelError, "unable to create thread", slog.String("error", err.Error()),
+			)
+			return err
+		}
+		thread = newThreadFromRow(*row)
+
+		logger.LogAttrs(ctx, slog.LevelInfo, "creating opening post")
+		post, err := models.Posts.Insert(ctx, data.PostInput{
+			ThreadID: row.ID,
+			AuthorID: input.AuthorID,
+			Content:  input.Content,
+			Opening:  true,
+		})
+		if err != nil {
+			logger.LogAttrs(
+				ctx,
+				slog.LevelError,
+				"unable to create opening post",
+				slog.String("error", err.Error()),
+			)
+			return err
+		}
+		thread.OpeningPost = newPostFromRow(*post)
+
+		return nil
+	})
 	if err != nil {
-		logger.LogAttrs(
-			ctx, slog.LevelError, "unable to create thread", slog.String("error", err.Error()),
-		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "thread created")
 
-	return newThreadFromRow(*row), nil
+	return thread, nil
 }
 
 func (r *ThreadRepository) Update(ctx context.Context, patch ThreadPatch) (*Thread, error) {
@@ -386,20 +307,30 @@ func (r *ThreadRepository) Update(ctx context.Context, patch ThreadPatch) (*Thre
 	return newThreadFromRow(*row), nil
 }
 
+// Delete marks a thread as deleted. If updatedAt is set, the thread is only deleted if it has not
+// been updated since.
 func (r *ThreadRepository) Delete(
 	ctx context.Context,
 	forumID uuid.UUID,
 	threadID uuid.UUID,
+	updatedAt *time.Time,
 ) (*Thread, error) {
 	logger := logging.LoggerFromContext(ctx).
-		With(slog.Group("parameters", slog.String("forumId", threadID.String()), slog.String("threadId", threadID.String())))
+		With(slog.Group(
+			"parameters",
+			slog.String("forumId", forumID.String()),
+			slog.String("threadId", threadID.String()),
+			slog.Any("updatedAt", updatedAt)))
 
 	logger.LogAttrs(ctx, slog.LevelInfo, "deleting thread")
-	row, err := r.models.Threads.SoftDelete(ctx, forumID, threadID)
+	row, err := r.models.Threads.SoftDelete(
+		ctx, forumID, threadID, database.NewNullTime(updatedAt),
+	)
 	if err != nil {
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to delete thread", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "thread deleted")
 
@@ -420,28 +351,92 @@ func (r *ThreadRepository) Restore(
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to restore thread", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "thread restored")
 
 	return newThreadFromRow(*row), nil
 }
 
+// PermanentlyDelete removes a thread alongside its posts, and the votes cast on them. If dryRun is
+// set, nothing is removed. If updatedAt is set, nothing is removed if the thread has been updated
+// since.
 func (r *ThreadRepository) PermanentlyDelete(
 	ctx context.Context,
 	forumID uuid.UUID,
 	threadID uuid.UUID,
+	updatedAt *time.Time,
+	dryRun bool,
+) (*PurgeSummary, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("forumId", forumID.String()),
+			slog.String("threadId", threadID.String())))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving thread")
+	_, err := r.models.Threads.Select(ctx, forumID, threadID)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select thread", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	scope := data.PurgeScope{
+		ThreadID:  uuid.NullUUID{UUID: threadID, Valid: true},
+		UpdatedAt: database.NewNullTime(updatedAt),
+	}
+	return purge(ctx, r.models, scope, dryRun)
+}
+
+// Lock prevents any further posts or votes within a thread, recording the user which locked it.
+func (r *ThreadRepository) Lock(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+	userID uuid.UUID,
 ) (*Thread, error) {
 	logger := logging.LoggerFromContext(ctx).
-		With(slog.Group("parameters", slog.String("forumID", forumID.String()), slog.String("id", threadID.String())))
+		With(slog.Group(
+			"parameters",
+			slog.String("forumId", forumID.String()),
+			slog.String("threadId", threadID.String()),
+			slog.String("userId", userID.String())))
 
-	logger.LogAttrs(ctx, slog.LevelInfo, "deleting thread")
-	row, err := r.models.Threads.Delete(ctx, forumID, threadID)
+	logger.LogAttrs(ctx, slog.LevelInfo, "locking thread")
+	row, err := r.models.Threads.Lock(ctx, forumID, threadID, userID)
 	if err != nil {
 		logger.LogAttrs(
-			ctx, slog.LevelError, "unable to delete thread", slog.String("error", err.Error()),
+			ctx, slog.LevelError, "unable to lock thread", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
-	logger.LogAttrs(ctx, slog.LevelInfo, "thread deleted")
+	logger.LogAttrs(ctx, slog.LevelInfo, "thread locked")
+
+	return newThreadFromRow(*row), nil
+}
+
+func (r *ThreadRepository) Unlock(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+) (*Thread, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("forumId", forumID.String()),
+			slog.String("threadId", threadID.String())))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "unlocking thread")
+	row, err := r.models.Threads.Unlock(ctx, forumID, threadID)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to unlock thread", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "thread unlocked")
 
 	return newThreadFromRow(*row), nil
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/repo"
 	"github.com/stretchr/testify/assert"
@@ -34,8 +35,12 @@ func TestThreadRepository(t *testing.T) {
 			AuthorID: u.ID,
 			ForumID:  f.ID,
 			Title:    "Johnny Boy",
+			Content:  "Wake up, samurai. We have a city to burn",
 		})
 		assert.NoError(t, err)
+		assert.NotNil(t, createdThread.OpeningPost)
+		assert.Equal(t, createdThread.ID, createdThread.OpeningPost.ThreadID)
+		assert.True(t, createdThread.OpeningPost.Opening)
 
 		thread = *createdThread
 	})
@@ -44,6 +49,7 @@ func TestThreadRepository(t *testing.T) {
 		readThread, err := repository.ThreadReader.Read(ctx, thread.ForumID, thread.ID, true)
 		assert.NoError(t, err)
 		assert.Equal(t, readThread.ID, thread.ID)
+		assert.Equal(t, thread.OpeningPost.ID, readThread.OpeningPost.ID)
 	})
 
 	t.Run("List", func(t *testing.T) {
@@ -55,6 +61,19 @@ func TestThreadRepository(t *testing.T) {
 		assert.GreaterOrEqual(t, len(listedThreads), 1)
 	})
 
+	t.Run("ListInvalidCursor", func(t *testing.T) {
+		_, _, err := repository.ThreadReader.List(ctx, data.Filters{
+			OrderBy:  []string{"created_at"},
+			PageSize: 100,
+			Cursor: &data.Cursor{
+				OrderBy: []string{"created_at"},
+				Values:  []string{"yesterday"},
+				ID:      uuid.New(),
+			},
+		}, true)
+		assert.ErrorIs(t, err, data.ErrInvalidCursor)
+	})
+
 	t.Run("Update", func(t *testing.T) {
 		newTitle := "Neurochipped Johnny Boy"
 		updatedThread, err := repository.ThreadWriter.Update(ctx, repo.ThreadPatch{
@@ -65,10 +84,48 @@ func TestThreadRepository(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotEqual(t, thread, *updatedThread)
 		assert.Equal(t, newTitle, updatedThread.Title)
+
+		revisions, err := repository.ThreadReader.ListRevisions(ctx, f.ID, thread.ID)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+		assert.Equal(t, thread.Title, revisions[0].Title)
+		assert.Equal(t, newTitle, revisions[1].Title)
+	})
+
+	t.Run("Lock", func(t *testing.T) {
+		lockedThread, err := repository.ThreadWriter.Lock(ctx, f.ID, thread.ID, u.ID)
+		assert.NoError(t, err)
+		assert.True(t, lockedThread.IsLocked)
+		assert.Equal(t, &u.ID, lockedThread.LockedBy)
+		assert.NotNil(t, lockedThread.LockedAt)
+
+		_, err = repository.PostWriter.Create(ctx, repo.PostInput{
+			ForumID:  f.ID,
+			ThreadID: thread.ID,
+			Content:  "Arasaka sends its regards",
+			AuthorID: u.ID,
+		})
+		assert.ErrorIs(t, err, repo.ErrThreadLocked)
+
+		_, err = repository.ThreadVoteWriter.Vote(ctx, repo.ThreadVoteInput{
+			ForumID:  f.ID,
+			ThreadID: thread.ID,
+			UserID:   u.ID,
+			Vote:     1,
+		})
+		assert.ErrorIs(t, err, repo.ErrThreadLocked)
+	})
+
+	t.Run("Unlock", func(t *testing.T) {
+		unlockedThread, err := repository.ThreadWriter.Unlock(ctx, f.ID, thread.ID)
+		assert.NoError(t, err)
+		assert.False(t, unlockedThread.IsLocked)
+		assert.Nil(t, unlockedThread.LockedBy)
+		assert.Nil(t, unlockedThread.LockedAt)
 	})
 
 	t.Run("Delete", func(t *testing.T) {
-		deletedThread, err := repository.ThreadWriter.Delete(ctx, f.ID, thread.ID)
+		deletedThread, err := repository.ThreadWriter.Delete(ctx, f.ID, thread.ID, nil)
 		assert.NoError(t, err)
 		assert.Equal(t, deletedThread.Deleted, true)
 	})
@@ -80,7 +137,13 @@ func TestThreadRepository(t *testing.T) {
 	})
 
 	t.Run("PermanentlyDelete", func(t *testing.T) {
-		_, err := repository.ThreadWriter.PermanentlyDelete(ctx, f.ID, thread.ID)
+		summary, err := repository.ThreadWriter.PermanentlyDelete(ctx, f.ID, thread.ID, nil, false)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), summary.Threads)
+		assert.Equal(t, int64(1), summary.Posts)
+
+		_, err = repository.ThreadWriter.PermanentlyDelete(ctx, f.ID, thread.ID, nil, false)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
 		assert.NoError(t, err)
 	})
 }
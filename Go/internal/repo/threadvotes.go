@@ -0,0 +1,208 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// ThreadVote is the tally of votes a thread has received, alongside the vote cast by a single
+// user.
+type ThreadVote struct {
+	// ThreadID is the unique identifier of the thread that was voted on.
+	ThreadID uuid.UUID `json:"threadId"`
+	// UserID is the unique identifier of the user which voted.
+	UserID uuid.UUID `json:"userId"`
+	// Vote is the value of the vote cast by the user. The value is 0 if the user has not voted.
+	Vote int8 `json:"vote"`
+	// Likes is the sum of votes the thread has received.
+	Likes int `json:"likes"`
+}
+
+type ThreadVoteInput struct {
+	// ForumID is the parent forum of the thread.
+	ForumID uuid.UUID `json:"forumId"`
+	// ThreadID is the unique identifier of the thread that is voted on.
+	ThreadID uuid.UUID `json:"threadId"`
+	// UserID is the unique identifier of the user which votes.
+	UserID uuid.UUID `json:"userId"`
+	// Vote is the value of the vote. Must be either -1, 0 or 1, where 0 removes the vote.
+	Vote int8 `json:"vote"`
+}
+
+func (v *ThreadVoteInput) Row() data.ThreadVote {
+	return data.ThreadVote{
+		ThreadID: v.ThreadID,
+		UserID:   v.UserID,
+		Vote:     v.Vote,
+	}
+}
+
+type ThreadVoteReader interface {
+	Read(context.Context, uuid.UUID, uuid.UUID, uuid.UUID) (*ThreadVote, error)
+}
+
+type ThreadVoteWriter interface {
+	Vote(context.Context, ThreadVoteInput) (*ThreadVote, error)
+	Delete(context.Context, uuid.UUID, uuid.UUID, uuid.UUID) (*ThreadVote, error)
+	RecountLikes(context.Context) (int64, error)
+}
+
+type ThreadVoteRepository struct {
+	models *data.Models
+}
+
+func NewThreadVoteRepository(models *data.Models) ThreadVoteRepository {
+	return ThreadVoteRepository{models: models}
+}
+
+// Read returns the tally of votes for a thread, and the vote cast by the given user.
+func (r *ThreadVoteRepository) Read(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+	userID uuid.UUID,
+) (*ThreadVote, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("forumId", forumID.String()),
+			slog.String("threadId", threadID.String()),
+			slog.String("userId", userID.String())))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving thread")
+	_, err := r.models.Threads.Select(ctx, forumID, threadID)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select thread", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return r.tally(ctx, threadID, userID)
+}
+
+// Vote records the vote of a user on a thread, and returns the updated tally. A vote of 0 removes
+// any vote previously cast by the user.
+func (r *ThreadVoteRepository) Vote(ctx context.Context, input ThreadVoteInput) (*ThreadVote, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("input", input)))
+
+	var tally *ThreadVote
+	err := r.models.WithTx(ctx, func(models data.Models) error {
+		tx := ThreadVoteRepository{models: &models}
+
+		// The thread is locked for update rather than for share, as the vote updates its likes.
+		// It cannot be locked before the vote is cast.
+		logger.LogAttrs(ctx, slog.LevelInfo, "retrieving thread")
+		thread, err := models.Threads.SelectForUpdate(ctx, input.ForumID, input.ThreadID)
+		if err != nil {
+			logger.LogAttrs(
+				ctx, slog.LevelError, "unable to select thread", slog.String("error", err.Error()),
+			)
+			return err
+		}
+		if thread.IsLocked {
+			logger.LogAttrs(ctx, slog.LevelInfo, "thread is locked")
+			return ErrThreadLocked
+		}
+
+		logger.LogAttrs(ctx, slog.LevelInfo, "voting on thread")
+		_, err = models.ThreadVotes.Vote(ctx, input.Row())
+		if err != nil {
+			logger.LogAttrs(
+				ctx, slog.LevelError, "unable to vote on thread", slog.String("error", err.Error()),
+			)
+			return err
+		}
+		logger.LogAttrs(ctx, slog.LevelInfo, "thread voted on")
+
+		tally, err = tx.tally(ctx, input.ThreadID, input.UserID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tally, nil
+}
+
+// Delete removes the vote a user has cast on a thread, and returns the updated tally.
+func (r *ThreadVoteRepository) Delete(
+	ctx context.Context,
+	forumID uuid.UUID,
+	threadID uuid.UUID,
+	userID uuid.UUID,
+) (*ThreadVote, error) {
+	return r.Vote(ctx, ThreadVoteInput{
+		ForumID:  forumID,
+		ThreadID: threadID,
+		UserID:   userID,
+		Vote:     0,
+	})
+}
+
+func (r *ThreadVoteRepository) tally(
+	ctx context.Context,
+	threadID uuid.UUID,
+	userID uuid.UUID,
+) (*ThreadVote, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group(
+			"parameters",
+			slog.String("threadId", threadID.String()),
+			slog.String("userId", userID.String())))
+
+	tally := ThreadVote{ThreadID: threadID, UserID: userID}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving thread votes")
+	likes, err := r.models.ThreadVotes.SelectSum(ctx, data.Filters{ThreadID: &threadID})
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to sum thread votes", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	tally.Likes = *likes
+
+	vote, err := r.models.ThreadVotes.Select(ctx, threadID, userID)
+	switch {
+	case err == nil:
+		tally.Vote = vote.Vote
+	case errors.Is(err, data.ErrRecordNotFound):
+		tally.Vote = 0
+	default:
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select thread vote", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "thread votes retrieved", slog.Any("tally", tally))
+
+	return &tally, nil
+}
+
+// RecountLikes corrects the likes of every thread which no longer matches its votes, returning the
+// number of corrected threads.
+func (r *ThreadVoteRepository) RecountLikes(ctx context.Context) (int64, error) {
+	logger := logging.LoggerFromContext(ctx)
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "recounting thread likes")
+	corrected, err := r.models.ThreadVotes.RecountLikes(ctx)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to recount thread likes",
+			slog.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "thread likes recounted", slog.Int64("corrected", corrected))
+
+	return corrected, nil
+}
@@ -0,0 +1,62 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreadVoteRepository(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u, err := repository.UserWriter.Create(ctx, repo.UserInput{
+		Name:     "Jackie Welles",
+		Username: "j.welles",
+		Email:    "j.welles@afterlife.com",
+	})
+	assert.NoError(t, err)
+
+	f, err := repository.ForumWriter.Create(ctx, repo.ForumInput{
+		OwnerID: u.ID,
+		Name:    "Heists",
+	})
+	assert.NoError(t, err)
+
+	thread, err := repository.ThreadWriter.Create(ctx, repo.ThreadInput{
+		AuthorID: u.ID,
+		ForumID:  f.ID,
+		Title:    "The Relic",
+		Content:  "Anyone know what the chip in my head does?",
+	})
+	assert.NoError(t, err)
+
+	t.Run("Vote", func(t *testing.T) {
+		v, err := repository.ThreadVoteWriter.Vote(ctx, repo.ThreadVoteInput{
+			ForumID:  f.ID,
+			ThreadID: thread.ID,
+			UserID:   u.ID,
+			Vote:     1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int8(1), v.Vote)
+		assert.Equal(t, 1, v.Likes)
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		v, err := repository.ThreadVoteReader.Read(ctx, f.ID, thread.ID, u.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int8(1), v.Vote)
+		assert.Equal(t, 1, v.Likes)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		v, err := repository.ThreadVoteWriter.Delete(ctx, f.ID, thread.ID, u.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int8(0), v.Vote)
+		assert.Equal(t, 0, v.Likes)
+	})
+}
@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/auth"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// AuthenticationTokenTTL is how long an authentication token is valid after being issued.
+const AuthenticationTokenTTL time.Duration = 24 * time.Hour
+
+type Token struct {
+	// Plaintext is the token presented by the client. It is only available when the token is
+	// created, as only the hash of the token is stored.
+	Plaintext string `json:"token"`
+	// UserID is the unique identifier of the user the token was issued to.
+	UserID uuid.UUID `json:"userId"`
+	// Expiry denotes when the token is no longer valid.
+	Expiry time.Time `json:"expiry"`
+}
+
+type TokenInput struct {
+	// Email is the email of the user to authenticate.
+	Email string `json:"email"`
+	// Password is the plaintext password of the user to authenticate.
+	Password string `json:"password"`
+}
+
+// LogValue implements slog.LogValuer to keep the plaintext password out of the logs.
+func (t TokenInput) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("email", t.Email))
+}
+
+type TokenReader interface {
+	ReadUser(context.Context, string) (*User, error)
+}
+
+type TokenWriter interface {
+	Create(context.Context, TokenInput) (*Token, error)
+}
+
+type TokenRepository struct {
+	models *data.Models
+}
+
+func NewTokenRepository(models *data.Models) TokenRepository {
+	return TokenRepository{models: models}
+}
+
+// ReadUser returns the user owning the given plaintext authentication token. If the token is
+// unknown or expired, ErrRecordNotFound is returned.
+func (r *TokenRepository) ReadUser(ctx context.Context, plaintext string) (*User, error) {
+	logger := logging.LoggerFromContext(ctx)
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving user by token")
+	row, err := r.models.Users.SelectByToken(ctx, data.ScopeAuthentication, auth.HashToken(plaintext))
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelInfo, "unable to select user by token", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "user retrieved", slog.String("id", row.ID.String()))
+
+	return newUserFromRow(*row), nil
+}
+
+// Create verifies the credentials of a user, and issues a new authentication token. If the
+// credentials do not match an active user, ErrInvalidCredentials is returned.
+func (r *TokenRepository) Create(ctx context.Context, input TokenInput) (*Token, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("input", input)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving user credentials")
+	credentials, err := r.models.Users.SelectCredentials(ctx, input.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			logger.LogAttrs(ctx, slog.LevelInfo, "user not found")
+			return nil, ErrInvalidCredentials
+		default:
+			logger.LogAttrs(
+				ctx, slog.LevelError, "unable to select credentials", slog.String("error", err.Error()),
+			)
+			return nil, err
+		}
+	}
+	if credentials.Deleted || credentials.PasswordHash == nil {
+		logger.LogAttrs(ctx, slog.LevelInfo, "user is deleted or has no password")
+		return nil, ErrInvalidCredentials
+	}
+
+	match, err := auth.MatchPassword(credentials.PasswordHash, input.Password)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to match password", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	if !match {
+		logger.LogAttrs(ctx, slog.LevelInfo, "password does not match")
+		return nil, ErrInvalidCredentials
+	}
+
+	plaintext, hash, err := auth.GenerateToken()
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to generate token", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "creating token")
+	row, err := r.models.Tokens.Insert(ctx, data.Token{
+		Hash:   hash,
+		UserID: credentials.ID,
+		Expiry: time.Now().Add(AuthenticationTokenTTL),
+		Scope:  data.ScopeAuthentication,
+	})
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to create token", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "token created", slog.Time("expiry", row.Expiry))
+
+	return &Token{Plaintext: plaintext, UserID: row.UserID, Expiry: row.Expiry}, nil
+}
@@ -0,0 +1,51 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRepository(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	u, err := repository.UserWriter.Create(ctx, repo.UserInput{
+		Name:     "Panam Palmer",
+		Username: "p.palmer",
+		Email:    "p.palmer@aldecaldos.com",
+		Password: "basilisk-pilot",
+	})
+	assert.NoError(t, err)
+
+	var token repo.Token
+
+	t.Run("Create", func(t *testing.T) {
+		tk, err := repository.TokenWriter.Create(ctx, repo.TokenInput{
+			Email:    "p.palmer@aldecaldos.com",
+			Password: "basilisk-pilot",
+		})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tk.Plaintext)
+		assert.Equal(t, u.ID, tk.UserID)
+
+		token = *tk
+	})
+
+	t.Run("CreateInvalidPassword", func(t *testing.T) {
+		_, err := repository.TokenWriter.Create(ctx, repo.TokenInput{
+			Email:    "p.palmer@aldecaldos.com",
+			Password: "not-the-password",
+		})
+		assert.ErrorIs(t, err, repo.ErrInvalidCredentials)
+	})
+
+	t.Run("ReadUser", func(t *testing.T) {
+		user, err := repository.TokenReader.ReadUser(ctx, token.Plaintext)
+		assert.NoError(t, err)
+		assert.Equal(t, u.ID, user.ID)
+	})
+}
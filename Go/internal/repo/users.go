@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/auth"
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/database"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
@@ -38,6 +39,14 @@ type User struct {
 	DeletedAt *time.Time `json:"deletedAt,omitzero"`
 }
 
+// AnonymousUser represents the user of a request that is not authenticated.
+var AnonymousUser = &User{}
+
+// IsAnonymous reports whether the user is the anonymous user.
+func (u *User) IsAnonymous() bool {
+	return u == AnonymousUser
+}
+
 func newUserFromRow(row data.User) *User {
 	return &User{
 		ID:        row.ID,
@@ -58,6 +67,8 @@ type UserInput struct {
 	Username string `json:"username,omitzero"`
 	// Email is the unique email beloging to a given user account.
 	Email string `json:"email,omitzero"`
+	// Password is the plaintext password of the user. Only a hash of the password is stored.
+	Password string `json:"password,omitzero"`
 }
 
 func (f *UserInput) Row() data.UserInput {
@@ -68,6 +79,15 @@ func (f *UserInput) Row() data.UserInput {
 	}
 }
 
+// LogValue implements slog.LogValuer to keep the plaintext password out of the logs.
+func (f UserInput) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("name", f.Name),
+		slog.String("username", f.Username),
+		slog.String("email", f.Email),
+	)
+}
+
 type UserPatch struct {
 	// ID is the unique identifier of a user.
 	ID uuid.UUID `json:"id"`
@@ -89,6 +109,9 @@ type UserPatch struct {
 	//
 	// Upon creating a new user, any existing values in this field is ignored.
 	DeletedAt *time.Time `json:"deletedAt,omitzero"`
+	// UpdatedAt is the time the user is expected to have last been updated at. If set, the patch
+	// is only applied if the user has not been updated since.
+	UpdatedAt *time.Time `json:"-"`
 }
 
 func (u *UserPatch) Row() data.UserPatch {
@@ -99,6 +122,7 @@ func (u *UserPatch) Row() data.UserPatch {
 		Email:     u.Email,
 		Deleted:   u.Deleted,
 		DeletedAt: u.DeletedAt,
+		UpdatedAt: u.UpdatedAt,
 	}
 }
 
@@ -110,9 +134,9 @@ type UserReader interface {
 type UserWriter interface {
 	Create(context.Context, UserInput) (*User, error)
 	Update(context.Context, UserPatch) (*User, error)
-	Delete(context.Context, uuid.UUID) (*User, error)
+	Delete(context.Context, uuid.UUID, *time.Time) (*User, error)
 	Restore(context.Context, uuid.UUID) (*User, error)
-	PermanentlyDelete(context.Context, uuid.UUID) (*User, error)
+	PermanentlyDelete(context.Context, uuid.UUID, *time.Time, bool) (*PurgeSummary, error)
 }
 
 type UserRepository struct {
@@ -154,6 +178,7 @@ func (r *UserRepository) List(
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to select user", slog.String("error", err.Error()),
 		)
+		return nil, nil, err
 	}
 	logger = logging.LoggerFromContext(ctx).With(slog.Group(
 		"parameters",
@@ -191,28 +216,50 @@ func (r *UserRepository) Create(ctx context.Context, input UserInput) (*User, er
 	logger := logging.LoggerFromContext(ctx).
 		With(slog.Group("parameters", slog.Any("input", input)))
 
+	userRow := input.Row()
+	if input.Password != "" {
+		logger.LogAttrs(ctx, slog.LevelInfo, "hashing password")
+		hash, err := auth.HashPassword(input.Password)
+		if err != nil {
+			logger.LogAttrs(
+				ctx, slog.LevelError, "unable to hash password", slog.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		userRow.PasswordHash = hash
+	}
+
 	logger.LogAttrs(ctx, slog.LevelInfo, "creating user")
-	row, err := r.models.Users.Insert(ctx, input.Row())
+	row, err := r.models.Users.Insert(ctx, userRow)
 	if err != nil {
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to create user", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "user created", slog.Any("row", row))
 
 	return newUserFromRow(*row), nil
 }
 
-func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) (*User, error) {
-	logger := logging.LoggerFromContext(ctx).
-		With(slog.Group("parameters", slog.String("id", id.String())))
+// Delete marks a user as deleted. If updatedAt is set, the user is only deleted if it has not been
+// updated since.
+func (r *UserRepository) Delete(
+	ctx context.Context,
+	id uuid.UUID,
+	updatedAt *time.Time,
+) (*User, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"parameters", slog.String("id", id.String()), slog.Any("updatedAt", updatedAt),
+	))
 
 	logger.LogAttrs(ctx, slog.LevelInfo, "deleting user")
-	row, err := r.models.Users.SoftDelete(ctx, id)
+	row, err := r.models.Users.SoftDelete(ctx, id, database.NewNullTime(updatedAt))
 	if err != nil {
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to delete user", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "user deleted")
 
@@ -229,24 +276,26 @@ func (r *UserRepository) Restore(ctx context.Context, id uuid.UUID) (*User, erro
 		logger.LogAttrs(
 			ctx, slog.LevelError, "unable to restore user", slog.String("error", err.Error()),
 		)
+		return nil, err
 	}
 	logger.LogAttrs(ctx, slog.LevelInfo, "user restored")
 
 	return newUserFromRow(*row), nil
 }
 
-func (r *UserRepository) PermanentlyDelete(ctx context.Context, id uuid.UUID) (*User, error) {
-	logger := logging.LoggerFromContext(ctx).
-		With(slog.Group("parameters", slog.String("id", id.String())))
-
-	logger.LogAttrs(ctx, slog.LevelInfo, "deleting user")
-	row, err := r.models.Users.Delete(ctx, id)
-	if err != nil {
-		logger.LogAttrs(
-			ctx, slog.LevelError, "unable to delete user", slog.String("error", err.Error()),
-		)
+// PermanentlyDelete removes a user alongside the forums owned by the user, and the threads and
+// posts authored by the user. Content depending on those, such as replies and votes by other
+// users, is removed as well. If dryRun is set, nothing is removed. If updatedAt is set, nothing is
+// removed if the user has been updated since.
+func (r *UserRepository) PermanentlyDelete(
+	ctx context.Context,
+	id uuid.UUID,
+	updatedAt *time.Time,
+	dryRun bool,
+) (*PurgeSummary, error) {
+	scope := data.PurgeScope{
+		UserID:    uuid.NullUUID{UUID: id, Valid: true},
+		UpdatedAt: database.NewNullTime(updatedAt),
 	}
-	logger.LogAttrs(ctx, slog.LevelInfo, "user deleted")
-
-	return newUserFromRow(*row), nil
+	return purge(ctx, r.models, scope, dryRun)
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/repo"
 	"github.com/stretchr/testify/assert"
@@ -40,6 +41,19 @@ func TestUserRepository(t *testing.T) {
 		assert.GreaterOrEqual(t, len(u), 1)
 	})
 
+	t.Run("ListInvalidCursor", func(t *testing.T) {
+		_, _, err := repository.UserReader.List(ctx, data.Filters{
+			OrderBy:  []string{"created_at"},
+			PageSize: 100,
+			Cursor: &data.Cursor{
+				OrderBy: []string{"created_at"},
+				Values:  []string{"yesterday"},
+				ID:      uuid.New(),
+			},
+		}, true)
+		assert.ErrorIs(t, err, data.ErrInvalidCursor)
+	})
+
 	t.Run("Update", func(t *testing.T) {
 		username := "silverhand"
 		u, err := repository.UserWriter.Update(
@@ -52,7 +66,7 @@ func TestUserRepository(t *testing.T) {
 	})
 
 	t.Run("Delete", func(t *testing.T) {
-		u, err := repository.UserWriter.Delete(ctx, user.ID)
+		u, err := repository.UserWriter.Delete(ctx, user.ID, nil)
 		assert.NoError(t, err)
 		assert.Equal(t, u.Deleted, true)
 	})
@@ -64,7 +78,67 @@ func TestUserRepository(t *testing.T) {
 	})
 
 	t.Run("PermanentlyDelete", func(t *testing.T) {
-		_, err := repository.UserWriter.PermanentlyDelete(ctx, user.ID)
+		_, err := repository.UserWriter.PermanentlyDelete(ctx, user.ID, nil, false)
+		assert.NoError(t, err)
+	})
+}
+
+func TestUserRepositoryPurgeForumOwner(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := repository.UserWriter.Create(ctx, repo.UserInput{
+		Name:     "Mr. Hands",
+		Username: "hands",
+		Email:    "hands@pacifica.com",
+	})
+	assert.NoError(t, err)
+
+	member, err := repository.UserWriter.Create(ctx, repo.UserInput{
+		Name:     "Placide",
+		Username: "placide",
+		Email:    "placide@voodooboys.com",
+	})
+	assert.NoError(t, err)
+
+	forum, err := repository.ForumWriter.Create(ctx, repo.ForumInput{
+		OwnerID: owner.ID,
+		Name:    "Pacifica contracts",
+	})
+	assert.NoError(t, err)
+
+	thread, err := repository.ThreadWriter.Create(ctx, repo.ThreadInput{
+		ForumID:  forum.ID,
+		AuthorID: member.ID,
+		Title:    "Grand Imperial Mall",
+		Content:  "The Voodoo Boys want a word",
+	})
+	assert.NoError(t, err)
+
+	t.Run("Refused", func(t *testing.T) {
+		_, err := repository.UserWriter.PermanentlyDelete(ctx, owner.ID, nil, true)
+		assert.ErrorIs(t, err, repo.ErrUserOwnsForums)
+
+		_, err = repository.UserWriter.PermanentlyDelete(ctx, owner.ID, nil, false)
+		assert.ErrorIs(t, err, repo.ErrUserOwnsForums)
+
+		// The content of other users within the forum is left untouched.
+		_, err = repository.ThreadReader.Read(ctx, forum.ID, thread.ID, false)
+		assert.NoError(t, err)
+		_, err = repository.UserReader.Read(ctx, owner.ID, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("AfterForumPurge", func(t *testing.T) {
+		_, err := repository.ForumWriter.PermanentlyDelete(ctx, forum.ID, nil, false)
+		assert.NoError(t, err)
+
+		summary, err := repository.UserWriter.PermanentlyDelete(ctx, owner.ID, nil, false)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), summary.Users)
+		assert.Equal(t, int64(0), summary.Forums)
+
+		_, err = repository.UserReader.Read(ctx, member.ID, false)
 		assert.NoError(t, err)
 	})
 }
@@ -0,0 +1,357 @@
+package repo
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/auth"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/database"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+type Webhook struct {
+	// ID is the unique identifier of the webhook.
+	//
+	// Upon creating a new webhook, any existing values in this field is ignored. The database
+	// handles setting the value upon insertion.
+	ID uuid.UUID `json:"id"`
+	// URL is the address events are delivered to.
+	URL string `json:"url"`
+	// Secret is the key used to sign the events delivered to the webhook. It is only available
+	// when the webhook is created, or the secret is changed.
+	Secret string `json:"secret,omitzero"`
+	// EventTypes are the types of events delivered to the webhook, such as post.created. Webhooks
+	// without any event types receive every event.
+	EventTypes []string `json:"eventTypes"`
+	// Active denotes whether events are delivered to the webhook.
+	Active bool `json:"active"`
+	// CreatedAt denotes when the webhook was created.
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt denotes when the webhook was last updated.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func newWebhookFromRow(row data.Webhook) *Webhook {
+	return &Webhook{
+		ID:         row.ID,
+		URL:        row.URL,
+		EventTypes: row.EventTypes,
+		Active:     row.Active,
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}
+}
+
+type WebhookInput struct {
+	// URL is the address events are delivered to.
+	URL string `json:"url"`
+	// Secret is the key used to sign the events delivered to the webhook. A random secret is
+	// generated if none is given.
+	Secret *string `json:"secret,omitzero"`
+	// EventTypes are the types of events delivered to the webhook. Every event is delivered if
+	// empty.
+	EventTypes []string `json:"eventTypes,omitzero"`
+	// Active denotes whether events are delivered to the webhook. Defaults to true.
+	Active *bool `json:"active,omitzero"`
+}
+
+func (w *WebhookInput) Row() data.WebhookInput {
+	row := data.WebhookInput{URL: w.URL, EventTypes: w.EventTypes, Active: true}
+	if w.Secret != nil {
+		row.Secret = *w.Secret
+	}
+	if w.Active != nil {
+		row.Active = *w.Active
+	}
+
+	return row
+}
+
+// LogValue implements slog.LogValuer to keep the secret out of the logs.
+func (w WebhookInput) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("url", w.URL),
+		slog.Any("eventTypes", w.EventTypes),
+		slog.Any("active", w.Active),
+	)
+}
+
+type WebhookPatch struct {
+	// ID is the unique identifier of the webhook.
+	ID uuid.UUID `json:"id"`
+	// URL is the address events are delivered to.
+	//
+	// If populated, will update the URL of the webhook.
+	URL *string `json:"url,omitzero"`
+	// Secret is the key used to sign the events delivered to the webhook.
+	//
+	// If populated, will replace the secret of the webhook.
+	Secret *string `json:"secret,omitzero"`
+	// EventTypes are the types of events delivered to the webhook.
+	//
+	// If populated, will replace the event types of the webhook. An empty list subscribes the
+	// webhook to every event.
+	EventTypes []string `json:"eventTypes,omitzero"`
+	// Active denotes whether events are delivered to the webhook.
+	//
+	// If populated, will activate or deactivate the webhook.
+	Active *bool `json:"active,omitzero"`
+}
+
+func (w *WebhookPatch) Row() data.WebhookPatch {
+	return data.WebhookPatch{
+		ID:         w.ID,
+		URL:        database.NewNullString(w.URL),
+		Secret:     database.NewNullString(w.Secret),
+		EventTypes: w.EventTypes,
+		Active:     database.NewNullBool(w.Active),
+	}
+}
+
+// LogValue implements slog.LogValuer to keep the secret out of the logs.
+func (w WebhookPatch) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", w.ID.String()),
+		slog.Any("url", w.URL),
+		slog.Any("eventTypes", w.EventTypes),
+		slog.Any("active", w.Active),
+	)
+}
+
+type WebhookDelivery struct {
+	// ID is the unique identifier of the delivery.
+	ID uuid.UUID `json:"id"`
+	// WebhookID is the webhook the event is delivered to.
+	WebhookID uuid.UUID `json:"webhookId"`
+	// EventID is the outbox event being delivered.
+	EventID int64 `json:"eventId"`
+	// Status is either pending, delivered or dead.
+	Status string `json:"status"`
+	// Attempts is the number of times delivery has been attempted.
+	Attempts int `json:"attempts"`
+	// NextAttemptAt denotes when delivery is next attempted, if pending.
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	// LastError describes why the last attempt failed.
+	LastError *string `json:"lastError,omitzero"`
+	// ResponseStatus is the HTTP status code of the last response from the webhook.
+	ResponseStatus *int `json:"responseStatus,omitzero"`
+	// CreatedAt denotes when the delivery was scheduled.
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt denotes when the delivery was last attempted or changed.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func newWebhookDeliveryFromRow(row data.WebhookDelivery) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:             row.ID,
+		WebhookID:      row.WebhookID,
+		EventID:        row.EventID,
+		Status:         row.Status,
+		Attempts:       row.Attempts,
+		NextAttemptAt:  row.NextAttemptAt,
+		LastError:      database.NullStringToPtr(row.LastError),
+		ResponseStatus: database.NullInt32ToPtr(row.ResponseStatus),
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+	}
+}
+
+type WebhookReader interface {
+	Read(context.Context, uuid.UUID) (*Webhook, error)
+	List(context.Context) ([]*Webhook, error)
+	ListDeliveries(context.Context, data.Filters) ([]*WebhookDelivery, *data.Metadata, error)
+}
+
+type WebhookWriter interface {
+	Create(context.Context, WebhookInput) (*Webhook, error)
+	Update(context.Context, WebhookPatch) (*Webhook, error)
+	Delete(context.Context, uuid.UUID) (*Webhook, error)
+	RetryDelivery(context.Context, uuid.UUID, uuid.UUID) (*WebhookDelivery, error)
+}
+
+type WebhookRepository struct {
+	models *data.Models
+}
+
+func NewWebhookRepository(models *data.Models) WebhookRepository {
+	return WebhookRepository{models: models}
+}
+
+func (r *WebhookRepository) Read(ctx context.Context, id uuid.UUID) (*Webhook, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.String("id", id.String())))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrieving webhook")
+	row, err := r.models.Webhooks.Select(ctx, id)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select webhook", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "webhook retrieved")
+
+	return newWebhookFromRow(*row), nil
+}
+
+func (r *WebhookRepository) List(ctx context.Context) ([]*Webhook, error) {
+	logger := logging.LoggerFromContext(ctx)
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "listing webhooks")
+	rows, err := r.models.Webhooks.SelectAll(ctx)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to select webhooks", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "webhooks retrieved", slog.Int("length", len(rows)))
+
+	webhooks := make([]*Webhook, len(rows))
+	for i, row := range rows {
+		webhooks[i] = newWebhookFromRow(*row)
+	}
+
+	return webhooks, nil
+}
+
+// ListDeliveries returns the deliveries to the webhook given in the filters, newest first.
+func (r *WebhookRepository) ListDeliveries(
+	ctx context.Context,
+	filters data.Filters,
+) ([]*WebhookDelivery, *data.Metadata, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("filters", filters)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "listing webhook deliveries")
+	rows, metadata, err := r.models.WebhookDeliveries.SelectAll(ctx, filters)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to select webhook deliveries",
+			slog.String("error", err.Error()),
+		)
+		return nil, nil, err
+	}
+	logger.LogAttrs(
+		ctx, slog.LevelInfo, "webhook deliveries retrieved", slog.Any("metadata", metadata),
+	)
+
+	deliveries := make([]*WebhookDelivery, len(rows))
+	for i, row := range rows {
+		deliveries[i] = newWebhookDeliveryFromRow(*row)
+	}
+
+	return deliveries, metadata, nil
+}
+
+// Create registers a new webhook. The returned webhook includes the secret, which is not
+// available afterwards.
+func (r *WebhookRepository) Create(ctx context.Context, input WebhookInput) (*Webhook, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("input", input)))
+
+	row := input.Row()
+	if input.Secret == nil {
+		logger.LogAttrs(ctx, slog.LevelInfo, "generating webhook secret")
+		secret, _, err := auth.GenerateToken()
+		if err != nil {
+			logger.LogAttrs(
+				ctx,
+				slog.LevelError,
+				"unable to generate webhook secret",
+				slog.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		row.Secret = secret
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "creating webhook")
+	inserted, err := r.models.Webhooks.Insert(ctx, row)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to create webhook", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "webhook created")
+
+	webhook := newWebhookFromRow(*inserted)
+	webhook.Secret = inserted.Secret
+
+	return webhook, nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, patch WebhookPatch) (*Webhook, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.Any("patch", patch)))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "updating webhook")
+	row, err := r.models.Webhooks.Update(ctx, patch.Row())
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to update webhook", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "webhook updated")
+
+	webhook := newWebhookFromRow(*row)
+	if patch.Secret != nil {
+		webhook.Secret = row.Secret
+	}
+
+	return webhook, nil
+}
+
+// Delete permanently deletes a webhook, alongside every delivery to it.
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) (*Webhook, error) {
+	logger := logging.LoggerFromContext(ctx).
+		With(slog.Group("parameters", slog.String("id", id.String())))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "deleting webhook")
+	row, err := r.models.Webhooks.Delete(ctx, id)
+	if err != nil {
+		logger.LogAttrs(
+			ctx, slog.LevelError, "unable to delete webhook", slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "webhook deleted")
+
+	return newWebhookFromRow(*row), nil
+}
+
+// RetryDelivery schedules a dead delivery to be attempted again. If the delivery is not dead,
+// ErrRecordNotFound is returned.
+func (r *WebhookRepository) RetryDelivery(
+	ctx context.Context,
+	webhookID uuid.UUID,
+	id uuid.UUID,
+) (*WebhookDelivery, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"parameters",
+		slog.String("webhookId", webhookID.String()),
+		slog.String("id", id.String()),
+	))
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "retrying webhook delivery")
+	row, err := r.models.WebhookDeliveries.Retry(ctx, webhookID, id)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to retry webhook delivery",
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "webhook delivery scheduled for retry")
+
+	return newWebhookDeliveryFromRow(*row), nil
+}
@@ -0,0 +1,79 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var webhook repo.Webhook
+
+	t.Run("Create", func(t *testing.T) {
+		created, err := repository.WebhookWriter.Create(ctx, repo.WebhookInput{
+			URL:        "https://netwatch.nc/hooks/breaches",
+			EventTypes: []string{"user.created"},
+		})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, created.Secret)
+		assert.True(t, created.Active)
+
+		webhook = *created
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		read, err := repository.WebhookReader.Read(ctx, webhook.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, read.Secret)
+		assert.Equal(t, webhook.URL, read.URL)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		webhooks, err := repository.WebhookReader.List(ctx)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, webhooks)
+	})
+
+	t.Run("ListDeliveries", func(t *testing.T) {
+		_, err := repository.UserWriter.Create(ctx, repo.UserInput{
+			Name:     "Bartmoss Collins",
+			Username: "bartmoss",
+			Email:    "bartmoss@blackwall.net",
+		})
+		assert.NoError(t, err)
+
+		deliveries, metadata, err := repository.WebhookReader.ListDeliveries(ctx, data.Filters{
+			WebhookID: &webhook.ID,
+			PageSize:  25,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, metadata.ResponseLength)
+		assert.Equal(t, data.WebhookDeliveryPending, deliveries[0].Status)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		secret := "blackwall"
+		active := false
+		updated, err := repository.WebhookWriter.Update(ctx, repo.WebhookPatch{
+			ID:     webhook.ID,
+			Secret: &secret,
+			Active: &active,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, secret, updated.Secret)
+		assert.False(t, updated.Active)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		deleted, err := repository.WebhookWriter.Delete(ctx, webhook.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, webhook.ID, deleted.ID)
+	})
+}
@@ -9,20 +9,33 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
 	"github.com/r3d5un/rosetta/Go/internal/logging"
 	"github.com/r3d5un/rosetta/Go/internal/validator"
 )
 
 var (
-	ErrPathParamID = errors.New("path parameter is invalid")
+	ErrPathParamID     = errors.New("path parameter is invalid")
+	ErrQueryParamValue = errors.New("query parameter is invalid")
 )
 
 const (
 	notFoundMsg string = "resource not found"
 	timeoutMsg  string = "the server took to long to respond"
+
+	invalidCredentialsMsg         string = "invalid authentication credentials"
+	invalidAuthenticationTokenMsg string = "invalid or missing authentication token"
+	authenticationRequiredMsg     string = "you must be authenticated to access this resource"
+	notPermittedMsg               string = "you are not permitted to access this resource"
+	lockedMsg                     string = "the resource is locked and cannot be changed"
+	preconditionFailedMsg         string = "the resource has been changed since it was retrieved"
+	idempotencyKeyReusedMsg       string = "the idempotency key was used for a different request"
+	idempotencyKeyInProgressMsg   string = "the request for the idempotency key is still in progress"
+	rateLimitExceededMsg          string = "rate limit exceeded, retry later"
 )
 
 type ErrorMessage struct {
@@ -95,6 +108,21 @@ func ValidationFailedResponse(
 	)
 }
 
+// InvalidQueryParameterResponse rejects a request with a malformed query parameter. Unlike
+// malformed path parameters, which cannot identify any resource, the request itself is at fault.
+func InvalidQueryParameterResponse(
+	ctx context.Context,
+	w http.ResponseWriter,
+	r *http.Request,
+	param string,
+	err error,
+) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, "parameter invalid", slog.String("error", err.Error()))
+
+	ErrorResponse(w, r, http.StatusBadRequest, fmt.Sprintf("%s is not a valid parameter", param))
+}
+
 func BadRequestResponse(w http.ResponseWriter, r *http.Request, err error, msg string) {
 	logger := logging.LoggerFromContext(r.Context())
 
@@ -113,6 +141,87 @@ func ConstraintViolationResponse(w http.ResponseWriter, r *http.Request, err err
 	ErrorResponse(w, r, http.StatusConflict, msg)
 }
 
+func InvalidCredentialsResponse(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, invalidCredentialsMsg)
+	ErrorResponse(w, r, http.StatusUnauthorized, invalidCredentialsMsg)
+}
+
+func InvalidAuthenticationTokenResponse(
+	ctx context.Context,
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, invalidAuthenticationTokenMsg)
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	ErrorResponse(w, r, http.StatusUnauthorized, invalidAuthenticationTokenMsg)
+}
+
+func AuthenticationRequiredResponse(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, authenticationRequiredMsg)
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	ErrorResponse(w, r, http.StatusUnauthorized, authenticationRequiredMsg)
+}
+
+func NotPermittedResponse(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, notPermittedMsg)
+	ErrorResponse(w, r, http.StatusForbidden, notPermittedMsg)
+}
+
+func LockedResponse(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, lockedMsg)
+	ErrorResponse(w, r, http.StatusLocked, lockedMsg)
+}
+
+func PreconditionFailedResponse(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, preconditionFailedMsg)
+	ErrorResponse(w, r, http.StatusPreconditionFailed, preconditionFailedMsg)
+}
+
+func IdempotencyKeyReusedResponse(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, idempotencyKeyReusedMsg)
+	ErrorResponse(w, r, http.StatusUnprocessableEntity, idempotencyKeyReusedMsg)
+}
+
+func IdempotencyKeyInProgressResponse(
+	ctx context.Context,
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, idempotencyKeyInProgressMsg)
+	ErrorResponse(w, r, http.StatusConflict, idempotencyKeyInProgressMsg)
+}
+
+// RateLimitExceededResponse tells the client to slow down, and to retry the request after the
+// given number of seconds.
+func RateLimitExceededResponse(
+	ctx context.Context,
+	w http.ResponseWriter,
+	r *http.Request,
+	retryAfter int,
+) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, rateLimitExceededMsg, slog.Int("retryAfter", retryAfter))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	ErrorResponse(w, r, http.StatusTooManyRequests, rateLimitExceededMsg)
+}
+
+// NotModifiedResponse tells the client that its copy of the resource identified by the entity tag
+// is still current. Responses without modifications carry no body.
+func NotModifiedResponse(ctx context.Context, w http.ResponseWriter, etag string) {
+	logger := logging.LoggerFromContext(ctx)
+	logger.LogAttrs(ctx, slog.LevelInfo, "resource not modified", slog.String("etag", etag))
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusNotModified)
+}
+
 func RespondWithJSON(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -185,6 +294,21 @@ func ReadRequiredQueryBoolean(
 	return b
 }
 
+// ReadQueryBoolean reads a boolean query parameter, returning the default value if it is absent.
+// Unlike ReadRequiredQueryBoolean, a value that is not a boolean is an error rather than the
+// default, which is required wherever a misspelled value must not change the outcome.
+func ReadQueryBoolean(qs url.Values, key string, defaultValue bool) (bool, error) {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s must be a boolean, got %q", ErrQueryParamValue, key, s)
+	}
+	return b, nil
+}
+
 func ReadOptionalQueryBoolean(qs url.Values, key string) *bool {
 	s := qs.Get(key)
 	if s == "" {
@@ -280,6 +404,54 @@ func ReadOptionalQueryDate(qs url.Values, key string, v *validator.Validator) *t
 	return nil
 }
 
+// ReadOptionalQueryStrings reads a comma separated list of values, where every value must be
+// present in the permitted values.
+func ReadOptionalQueryStrings(
+	qs url.Values,
+	key string,
+	permitted []string,
+	v *validator.Validator,
+) []string {
+	s := qs.Get(key)
+	if s == "" {
+		return nil
+	}
+
+	values := strings.Split(s, ",")
+	if value, ok := validator.PermittedValues(values, permitted); !ok {
+		v.AddError(key, fmt.Sprintf("invalid value %q, accepting %s", value, permitted))
+		return nil
+	}
+
+	return values
+}
+
+// ReadOptionalQueryOrderBy reads a comma separated list of columns to order by, where columns
+// prefixed with "-" are ordered descending. Every value must be present in the safe list.
+func ReadOptionalQueryOrderBy(
+	qs url.Values,
+	key string,
+	safeList []string,
+	v *validator.Validator,
+) []string {
+	return ReadOptionalQueryStrings(qs, key, safeList, v)
+}
+
+func ReadOptionalQueryCursor(qs url.Values, key string, v *validator.Validator) *data.Cursor {
+	s := qs.Get(key)
+	if s == "" {
+		return nil
+	}
+
+	cursor, err := data.DecodeCursor(s)
+	if err != nil {
+		v.AddError(key, "not a valid cursor")
+		return nil
+	}
+
+	return cursor
+}
+
 func ReadJSON(r *http.Request, data any) error {
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
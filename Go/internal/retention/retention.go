@@ -0,0 +1,279 @@
+// Package retention permanently removes resources which have been soft deleted for longer than
+// their retention period.
+//
+// Resources are purged in bounded batches, alongside everything depending on them, the same way
+// as purging them by hand. Kinds of resources without a retention period are kept forever.
+// Resources hidden by the deletion of another, such as the posts of a deleted thread, follow the
+// retention period of the deleted resource rather than their own.
+//
+// Domain events recorded in the outbox are swept the same way once their retention period has
+// passed, alongside their webhook deliveries, as long as none of the deliveries are pending. Thread
+// events are swept once they fall outside the replay window.
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type RetentionConfig struct {
+	// Enabled denotes whether expired resources are purged at all.
+	Enabled bool `json:"enabled"`
+	// IntervalMinutes is the time between every check for expired resources.
+	IntervalMinutes int `json:"intervalMinutes"`
+	// BatchSize is the maximum number of expired resources of a kind purged at once.
+	BatchSize int `json:"batchSize"`
+	// MaxBatches is the maximum number of batches of a kind purged within a single run.
+	MaxBatches int `json:"maxBatches"`
+	// UserDays is the number of days deleted users are retained. Zero retains them forever.
+	UserDays int `json:"userDays"`
+	// ForumDays is the number of days deleted forums are retained. Zero retains them forever.
+	ForumDays int `json:"forumDays"`
+	// ThreadDays is the number of days deleted threads are retained. Zero retains them forever.
+	ThreadDays int `json:"threadDays"`
+	// PostDays is the number of days deleted posts are retained. Zero retains them forever.
+	PostDays int `json:"postDays"`
+	// OutboxDays is the number of days domain events and their concluded webhook deliveries are
+	// retained. Zero retains them forever.
+	OutboxDays int `json:"outboxDays"`
+	// ThreadEventHours is the number of hours thread events can be replayed by reconnecting
+	// clients. Zero retains them forever.
+	ThreadEventHours int `json:"threadEventHours"`
+}
+
+func (c *RetentionConfig) Interval() time.Duration {
+	return time.Duration(c.IntervalMinutes) * time.Minute
+}
+
+// Period returns the retention period of the given kind of resource, or zero if the resources are
+// retained forever.
+func (c *RetentionConfig) Period(kind string) time.Duration {
+	var days int
+	switch kind {
+	case data.PurgeKindUsers:
+		days = c.UserDays
+	case data.PurgeKindForums:
+		days = c.ForumDays
+	case data.PurgeKindThreads:
+		days = c.ThreadDays
+	case data.PurgeKindPosts:
+		days = c.PostDays
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// OutboxPeriod returns the retention period of domain events, or zero if the events are retained
+// forever.
+func (c *RetentionConfig) OutboxPeriod() time.Duration {
+	return time.Duration(c.OutboxDays) * 24 * time.Hour
+}
+
+// ThreadEventPeriod returns the replay window of thread events, or zero if the events are
+// retained forever.
+func (c *RetentionConfig) ThreadEventPeriod() time.Duration {
+	return time.Duration(c.ThreadEventHours) * time.Hour
+}
+
+type Job struct {
+	config RetentionConfig
+	writer repo.PurgeWriter
+
+	purged metric.Int64Counter
+	failed metric.Int64Counter
+}
+
+// NewJob creates a job purging expired resources through the given writer. Unset intervals and
+// batch limits fall back to hourly runs of at most ten batches of 100 resources.
+func NewJob(config RetentionConfig, writer repo.PurgeWriter) (*Job, error) {
+	if config.IntervalMinutes <= 0 {
+		config.IntervalMinutes = 60
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.MaxBatches <= 0 {
+		config.MaxBatches = 10
+	}
+
+	meter := otel.Meter("github.com/r3d5un/rosetta/Go/internal/retention")
+	purged, err := meter.Int64Counter(
+		"rosetta.retention.purged",
+		metric.WithDescription("Number of resources permanently removed by the retention job."),
+		metric.WithUnit("{resource}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	failed, err := meter.Int64Counter(
+		"rosetta.retention.failures",
+		metric.WithDescription("Number of batches the retention job was unable to purge."),
+		metric.WithUnit("{batch}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Job{config: config, writer: writer, purged: purged, failed: failed}, nil
+}
+
+// Run purges expired resources at every interval until the context is cancelled. Nothing is done
+// if retention is disabled.
+func (j *Job) Run(ctx context.Context) {
+	logger := logging.LoggerFromContext(ctx)
+
+	if !j.config.Enabled {
+		logger.LogAttrs(ctx, slog.LevelInfo, "retention disabled")
+		return
+	}
+
+	ticker := time.NewTicker(j.config.Interval())
+	defer ticker.Stop()
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "purging expired resources", slog.Any("config", j.config))
+	for {
+		j.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			logger.LogAttrs(ctx, slog.LevelInfo, "stopped purging expired resources")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge performs a single run, purging the expired resources of every kind with a retention
+// period. Dependants are purged before the resources they depend on. Expired domain events and
+// thread events are swept afterwards.
+func (j *Job) Purge(ctx context.Context) {
+	j.purgeResources(ctx)
+	j.sweep(ctx, "outbox_events", j.config.OutboxPeriod(), j.writer.PurgeOutboxEvents)
+	j.sweep(ctx, "thread_events", j.config.ThreadEventPeriod(), j.writer.PurgeThreadEvents)
+}
+
+func (j *Job) purgeResources(ctx context.Context) {
+	for _, kind := range data.PurgeKinds {
+		retention := j.config.Period(kind)
+		if retention <= 0 {
+			continue
+		}
+
+		logger := logging.LoggerFromContext(ctx).With(slog.Group(
+			"retention",
+			slog.String("kind", kind),
+			slog.Duration("period", retention),
+		))
+
+		for range j.config.MaxBatches {
+			if ctx.Err() != nil {
+				return
+			}
+
+			summary, expired, err := j.writer.PurgeExpired(ctx, kind, retention, j.config.BatchSize)
+			if summary != nil {
+				j.record(ctx, *summary)
+			}
+			if err != nil {
+				logger.LogAttrs(
+					ctx,
+					slog.LevelError,
+					"unable to purge expired resources",
+					slog.String("error", err.Error()),
+				)
+				j.failed.Add(ctx, 1, metric.WithAttributes(attribute.String("kind", kind)))
+				break
+			}
+			if expired > 0 {
+				logger.LogAttrs(
+					ctx,
+					slog.LevelInfo,
+					"purged expired resources",
+					slog.Int("expired", expired),
+					slog.Any("summary", summary),
+				)
+			}
+			if expired < j.config.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// sweep removes rows older than the retention period in batches through remove, until a batch
+// comes up short or the batch limit is reached. Nothing is done without a retention period.
+func (j *Job) sweep(
+	ctx context.Context,
+	resource string,
+	retention time.Duration,
+	remove func(context.Context, time.Duration, int) (int64, error),
+) {
+	if retention <= 0 {
+		return
+	}
+
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"retention",
+		slog.String("kind", resource),
+		slog.Duration("period", retention),
+	))
+
+	for range j.config.MaxBatches {
+		if ctx.Err() != nil {
+			return
+		}
+
+		removed, err := remove(ctx, retention, j.config.BatchSize)
+		if err != nil {
+			logger.LogAttrs(
+				ctx,
+				slog.LevelError,
+				"unable to sweep expired rows",
+				slog.String("error", err.Error()),
+			)
+			j.failed.Add(ctx, 1, metric.WithAttributes(attribute.String("kind", resource)))
+			return
+		}
+		if removed > 0 {
+			logger.LogAttrs(
+				ctx,
+				slog.LevelInfo,
+				"swept expired rows",
+				slog.Int64("removed", removed),
+			)
+			attrs := metric.WithAttributes(attribute.String("resource", resource))
+			j.purged.Add(ctx, removed, attrs)
+		}
+		if removed < int64(j.config.BatchSize) {
+			return
+		}
+	}
+}
+
+func (j *Job) record(ctx context.Context, summary repo.PurgeSummary) {
+	counts := []struct {
+		resource string
+		count    int64
+	}{
+		{"users", summary.Users},
+		{"forums", summary.Forums},
+		{"threads", summary.Threads},
+		{"posts", summary.Posts},
+		{"thread_votes", summary.ThreadVotes},
+		{"post_votes", summary.PostVotes},
+	}
+	for _, c := range counts {
+		if c.count > 0 {
+			attrs := metric.WithAttributes(attribute.String("resource", c.resource))
+			j.purged.Add(ctx, c.count, attrs)
+		}
+	}
+}
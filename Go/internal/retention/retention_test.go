@@ -0,0 +1,142 @@
+package retention_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/repo"
+	"github.com/r3d5un/rosetta/Go/internal/retention"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionConfigPeriod(t *testing.T) {
+	config := retention.RetentionConfig{ForumDays: 90, PostDays: 1}
+
+	assert.Equal(t, 90*24*time.Hour, config.Period(data.PurgeKindForums))
+	assert.Equal(t, 24*time.Hour, config.Period(data.PurgeKindPosts))
+	assert.Zero(t, config.Period(data.PurgeKindUsers))
+	assert.Zero(t, config.Period(data.PurgeKindThreads))
+	assert.Zero(t, config.Period("unknown"))
+	assert.Zero(t, config.OutboxPeriod())
+	assert.Zero(t, config.ThreadEventPeriod())
+}
+
+// fakePurgeWriter reports a fixed number of expired resources per kind, recording every call.
+type fakePurgeWriter struct {
+	expired map[string]int
+	calls   []string
+}
+
+const (
+	outboxEvents = "outbox_events"
+	threadEvents = "thread_events"
+)
+
+func (w *fakePurgeWriter) PurgeExpired(
+	_ context.Context,
+	kind string,
+	_ time.Duration,
+	limit int,
+) (*repo.PurgeSummary, int, error) {
+	w.calls = append(w.calls, kind)
+	n := min(w.expired[kind], limit)
+	w.expired[kind] -= n
+
+	return &repo.PurgeSummary{Posts: int64(n)}, n, nil
+}
+
+func (w *fakePurgeWriter) PurgeOutboxEvents(
+	_ context.Context,
+	_ time.Duration,
+	limit int,
+) (int64, error) {
+	w.calls = append(w.calls, outboxEvents)
+	n := min(w.expired[outboxEvents], limit)
+	w.expired[outboxEvents] -= n
+
+	return int64(n), nil
+}
+
+func (w *fakePurgeWriter) PurgeThreadEvents(
+	_ context.Context,
+	_ time.Duration,
+	limit int,
+) (int64, error) {
+	w.calls = append(w.calls, threadEvents)
+	n := min(w.expired[threadEvents], limit)
+	w.expired[threadEvents] -= n
+
+	return int64(n), nil
+}
+
+func TestJobPurge(t *testing.T) {
+	writer := &fakePurgeWriter{expired: map[string]int{
+		data.PurgeKindPosts:   25,
+		data.PurgeKindThreads: 100,
+		data.PurgeKindUsers:   5,
+	}}
+	job, err := retention.NewJob(retention.RetentionConfig{
+		Enabled:    true,
+		BatchSize:  10,
+		MaxBatches: 3,
+		PostDays:   30,
+		ThreadDays: 30,
+	}, writer)
+	assert.NoError(t, err)
+
+	job.Purge(context.Background())
+
+	assert.Equal(t, []string{
+		data.PurgeKindPosts,
+		data.PurgeKindPosts,
+		data.PurgeKindPosts,
+		data.PurgeKindThreads,
+		data.PurgeKindThreads,
+		data.PurgeKindThreads,
+	}, writer.calls)
+	assert.Equal(t, 0, writer.expired[data.PurgeKindPosts])
+	assert.Equal(t, 70, writer.expired[data.PurgeKindThreads])
+	assert.Equal(t, 5, writer.expired[data.PurgeKindUsers])
+}
+
+func TestJobPurgeOutboxEvents(t *testing.T) {
+	writer := &fakePurgeWriter{expired: map[string]int{
+		data.PurgeKindPosts: 5,
+		outboxEvents:        15,
+	}}
+	job, err := retention.NewJob(retention.RetentionConfig{
+		Enabled:    true,
+		BatchSize:  10,
+		MaxBatches: 3,
+		PostDays:   30,
+		OutboxDays: 30,
+	}, writer)
+	assert.NoError(t, err)
+
+	job.Purge(context.Background())
+
+	assert.Equal(t, []string{data.PurgeKindPosts, outboxEvents, outboxEvents}, writer.calls)
+	assert.Equal(t, 0, writer.expired[outboxEvents])
+}
+
+func TestJobPurgeThreadEvents(t *testing.T) {
+	writer := &fakePurgeWriter{expired: map[string]int{
+		outboxEvents: 5,
+		threadEvents: 45,
+	}}
+	job, err := retention.NewJob(retention.RetentionConfig{
+		Enabled:          true,
+		BatchSize:        10,
+		MaxBatches:       3,
+		ThreadEventHours: 24,
+	}, writer)
+	assert.NoError(t, err)
+
+	job.Purge(context.Background())
+
+	assert.Equal(t, []string{threadEvents, threadEvents, threadEvents}, writer.calls)
+	assert.Equal(t, 5, writer.expired[outboxEvents])
+	assert.Equal(t, 15, writer.expired[threadEvents])
+}
@@ -0,0 +1,484 @@
+// Package seed generates synthetic users, forums, threads, posts and votes for development and
+// load testing environments.
+//
+// Everything generated is derived from the seed of the configuration, so the same configuration
+// always generates the same rows, down to the IDs and timestamps. Activity follows long-tailed
+// distributions: a few users author most of the content and cast most of the votes, a few forums
+// hold most of the threads, and most threads are short while some grow long, nested reply trees.
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/auth"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+// batchSize is the maximum number of rows copied to the database at a time.
+const batchSize int = 5000
+
+type SeedConfig struct {
+	// Seed determines every generated row.
+	Seed uint64 `json:"seed"`
+	// Users is the number of users generated.
+	Users int `json:"users"`
+	// Forums is the number of forums generated.
+	Forums int `json:"forums"`
+	// ThreadsPerForum is the average number of threads in a forum.
+	ThreadsPerForum int `json:"threadsPerForum"`
+	// PostsPerThread is the average number of posts in a thread, including the opening post.
+	PostsPerThread int `json:"postsPerThread"`
+	// MaxDepth is the deepest a post may be nested in a reply tree.
+	MaxDepth int `json:"maxDepth"`
+	// VotesPerThread is the average number of votes cast on a thread.
+	VotesPerThread float64 `json:"votesPerThread"`
+	// VotesPerPost is the average number of votes cast on a post.
+	VotesPerPost float64 `json:"votesPerPost"`
+	// Password is given to every user, allowing them to sign in. Users are created without a
+	// password if empty.
+	Password string `json:"-"`
+	// Start is when the first user and forum are created. Threads are spread out over Span from
+	// Start.
+	Start time.Time `json:"start"`
+	// Span is the period threads are created over.
+	Span time.Duration `json:"span"`
+}
+
+// WithDefaults returns a copy of the configuration where every unset setting is replaced by its
+// default, generating a small, but lively, development environment.
+func (c SeedConfig) WithDefaults() SeedConfig {
+	if c.Users <= 0 {
+		c.Users = 100
+	}
+	if c.Forums <= 0 {
+		c.Forums = 5
+	}
+	if c.ThreadsPerForum <= 0 {
+		c.ThreadsPerForum = 20
+	}
+	if c.PostsPerThread <= 0 {
+		c.PostsPerThread = 10
+	}
+	if c.MaxDepth <= 0 {
+		c.MaxDepth = 5
+	}
+	if c.VotesPerThread < 0 {
+		c.VotesPerThread = 0
+	}
+	if c.VotesPerPost < 0 {
+		c.VotesPerPost = 0
+	}
+	if c.Start.IsZero() {
+		c.Start = time.Date(2077, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if c.Span <= 0 {
+		c.Span = 365 * 24 * time.Hour
+	}
+
+	return c
+}
+
+// Summary is the number of rows of every kind generated.
+type Summary struct {
+	Users       int64 `json:"users"`
+	Forums      int64 `json:"forums"`
+	Threads     int64 `json:"threads"`
+	Posts       int64 `json:"posts"`
+	ThreadVotes int64 `json:"threadVotes"`
+	PostVotes   int64 `json:"postVotes"`
+}
+
+// Store inserts the generated rows in bulk, in the order they are given.
+type Store interface {
+	CopyUsers(ctx context.Context, users []*data.User, passwordHash []byte) (int64, error)
+	CopyForums(ctx context.Context, forums []*data.Forum) (int64, error)
+	CopyThreads(ctx context.Context, threads []*data.Thread) (int64, error)
+	CopyPosts(ctx context.Context, posts []*data.Post) (int64, error)
+	CopyThreadVotes(ctx context.Context, votes []*data.ThreadVote) (int64, error)
+	CopyPostVotes(ctx context.Context, votes []*data.PostVote) (int64, error)
+}
+
+// PostgresStore copies the generated rows into the database through the data models.
+type PostgresStore struct {
+	models *data.Models
+}
+
+func NewPostgresStore(models *data.Models) *PostgresStore {
+	return &PostgresStore{models: models}
+}
+
+func (s *PostgresStore) CopyUsers(
+	ctx context.Context,
+	users []*data.User,
+	passwordHash []byte,
+) (int64, error) {
+	return s.models.Users.Copy(ctx, users, passwordHash)
+}
+
+func (s *PostgresStore) CopyForums(ctx context.Context, forums []*data.Forum) (int64, error) {
+	return s.models.Forums.Copy(ctx, forums)
+}
+
+func (s *PostgresStore) CopyThreads(ctx context.Context, threads []*data.Thread) (int64, error) {
+	return s.models.Threads.Copy(ctx, threads)
+}
+
+func (s *PostgresStore) CopyPosts(ctx context.Context, posts []*data.Post) (int64, error) {
+	return s.models.Posts.Copy(ctx, posts)
+}
+
+func (s *PostgresStore) CopyThreadVotes(
+	ctx context.Context,
+	votes []*data.ThreadVote,
+) (int64, error) {
+	return s.models.ThreadVotes.Copy(ctx, votes)
+}
+
+func (s *PostgresStore) CopyPostVotes(ctx context.Context, votes []*data.PostVote) (int64, error) {
+	return s.models.PostVotes.Copy(ctx, votes)
+}
+
+type Generator struct {
+	config SeedConfig
+	store  Store
+
+	source *rand.ChaCha8
+	rng    *rand.Rand
+	// activity picks users by how active they are, favouring a few prolific users.
+	activity *rand.Zipf
+	// popularity picks forums by how popular they are.
+	popularity *rand.Zipf
+	users      []*data.User
+}
+
+func NewGenerator(config SeedConfig, store Store) *Generator {
+	config = config.WithDefaults()
+
+	var seed [32]byte
+	binary.LittleEndian.PutUint64(seed[:], config.Seed)
+	source := rand.NewChaCha8(seed)
+	rng := rand.New(source)
+
+	return &Generator{
+		config:     config,
+		store:      store,
+		source:     source,
+		rng:        rng,
+		activity:   rand.NewZipf(rng, 1.2, 1, uint64(config.Users-1)),
+		popularity: rand.NewZipf(rng, 1.5, 2, uint64(config.Forums-1)),
+	}
+}
+
+// Run generates and inserts every row. Rows are inserted one forum at a time, keeping the memory
+// used bounded by the size of the largest forum.
+func (g *Generator) Run(ctx context.Context) (*Summary, error) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"parameters",
+		slog.Any("config", g.config),
+	))
+
+	var summary Summary
+
+	var passwordHash []byte
+	if g.config.Password != "" {
+		var err error
+		passwordHash, err = auth.HashPassword(g.config.Password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "generating users")
+	g.users = g.generateUsers()
+	copyUsers := func(ctx context.Context, users []*data.User) (int64, error) {
+		return g.store.CopyUsers(ctx, users, passwordHash)
+	}
+	n, err := copyBatches(ctx, g.users, copyUsers)
+	summary.Users += n
+	if err != nil {
+		return g.failed(ctx, logger, &summary, err)
+	}
+
+	forums := g.generateForums()
+	n, err = copyBatches(ctx, forums, g.store.CopyForums)
+	summary.Forums += n
+	if err != nil {
+		return g.failed(ctx, logger, &summary, err)
+	}
+
+	// The number of threads of every forum is drawn up front, so that popular forums receive
+	// the most threads.
+	threadCounts := make([]int, len(forums))
+	for range g.config.Forums * g.config.ThreadsPerForum {
+		threadCounts[g.popularity.Uint64()]++
+	}
+
+	for i, forum := range forums {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelInfo,
+			"generating forum",
+			slog.String("forumId", forum.ID.String()),
+			slog.Int("threads", threadCounts[i]),
+		)
+		content := g.generateForumContent(forum, threadCounts[i])
+
+		n, err = copyBatches(ctx, content.threads, g.store.CopyThreads)
+		summary.Threads += n
+		if err != nil {
+			return g.failed(ctx, logger, &summary, err)
+		}
+		n, err = copyBatches(ctx, content.posts, g.store.CopyPosts)
+		summary.Posts += n
+		if err != nil {
+			return g.failed(ctx, logger, &summary, err)
+		}
+		n, err = copyBatches(ctx, content.threadVotes, g.store.CopyThreadVotes)
+		summary.ThreadVotes += n
+		if err != nil {
+			return g.failed(ctx, logger, &summary, err)
+		}
+		n, err = copyBatches(ctx, content.postVotes, g.store.CopyPostVotes)
+		summary.PostVotes += n
+		if err != nil {
+			return g.failed(ctx, logger, &summary, err)
+		}
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "seed data generated", slog.Any("summary", summary))
+
+	return &summary, nil
+}
+
+func (g *Generator) failed(
+	ctx context.Context,
+	logger *slog.Logger,
+	summary *Summary,
+	err error,
+) (*Summary, error) {
+	logger.LogAttrs(
+		ctx,
+		slog.LevelError,
+		"unable to insert seed data",
+		slog.Any("summary", summary),
+		slog.String("error", err.Error()),
+	)
+
+	return summary, err
+}
+
+// copyBatches copies rows in batches of at most batchSize rows, returning the number of rows
+// copied.
+func copyBatches[T any](
+	ctx context.Context,
+	rows []T,
+	copyFn func(ctx context.Context, rows []T) (int64, error),
+) (int64, error) {
+	var copied int64
+	for start := 0; start < len(rows); start += batchSize {
+		n, err := copyFn(ctx, rows[start:min(start+batchSize, len(rows))])
+		copied += n
+		if err != nil {
+			return copied, err
+		}
+	}
+
+	return copied, nil
+}
+
+// id generates the next UUID from the seed.
+func (g *Generator) id() uuid.UUID {
+	id, err := uuid.NewRandomFromReader(g.source)
+	if err != nil {
+		// ChaCha8 never fails to read.
+		panic(err)
+	}
+
+	return id
+}
+
+// user picks a user, favouring the most active users.
+func (g *Generator) user() *data.User {
+	return g.users[g.activity.Uint64()]
+}
+
+// count draws a long-tailed count with the given mean, which is at least one if the mean is.
+func (g *Generator) count(mean float64) int {
+	if mean <= 0 {
+		return 0
+	}
+	if mean <= 1 {
+		return int(g.rng.ExpFloat64() * mean)
+	}
+
+	return 1 + int(g.rng.ExpFloat64()*(mean-1))
+}
+
+func (g *Generator) generateUsers() []*data.User {
+	users := make([]*data.User, g.config.Users)
+	for i := range users {
+		first := pick(g.rng, firstNames)
+		last := pick(g.rng, lastNames)
+		username := fmt.Sprintf("%s.%s.%d", strings.ToLower(first), strings.ToLower(last), i)
+		createdAt := g.config.Start.Add(time.Duration(i) * time.Second)
+
+		users[i] = &data.User{
+			ID:        g.id(),
+			Name:      first + " " + last,
+			Username:  username,
+			Email:     username + "@example.com",
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+	}
+
+	return users
+}
+
+func (g *Generator) generateForums() []*data.Forum {
+	forums := make([]*data.Forum, g.config.Forums)
+	for i := range forums {
+		topic := pick(g.rng, topics)
+		createdAt := g.config.Start.Add(time.Duration(i) * time.Minute)
+
+		forums[i] = &data.Forum{
+			ID:      g.id(),
+			OwnerID: g.user().ID,
+			Name:    fmt.Sprintf("%s %s #%d", pick(g.rng, districts), topic, i+1),
+			Description: sql.NullString{
+				String: fmt.Sprintf(
+					"Everything about %s in %s.", strings.ToLower(topic), pick(g.rng, districts),
+				),
+				Valid: true,
+			},
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+	}
+
+	return forums
+}
+
+type forumContent struct {
+	threads     []*data.Thread
+	posts       []*data.Post
+	threadVotes []*data.ThreadVote
+	postVotes   []*data.PostVote
+}
+
+func (g *Generator) generateForumContent(forum *data.Forum, threads int) forumContent {
+	var content forumContent
+
+	for range threads {
+		createdAt := forum.CreatedAt.Add(time.Duration(g.rng.Int64N(int64(g.config.Span))))
+		thread := &data.Thread{
+			ID:        g.id(),
+			ForumID:   forum.ID,
+			Title:     sentence(g.rng, 3+g.rng.IntN(6)),
+			AuthorID:  g.user().ID,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+		content.threads = append(content.threads, thread)
+
+		posts := g.generatePosts(thread)
+		content.posts = append(content.posts, posts...)
+
+		for _, voter := range g.voters(g.config.VotesPerThread) {
+			content.threadVotes = append(content.threadVotes, &data.ThreadVote{
+				ThreadID: thread.ID,
+				UserID:   voter,
+				Vote:     g.vote(),
+			})
+		}
+		for _, post := range posts {
+			for _, voter := range g.voters(g.config.VotesPerPost) {
+				content.postVotes = append(content.postVotes, &data.PostVote{
+					PostID: post.ID,
+					UserID: voter,
+					Vote:   g.vote(),
+				})
+			}
+		}
+	}
+
+	return content
+}
+
+// generatePosts generates the posts of a thread, starting with the opening post written by the
+// author of the thread. Replies tend to follow up on recent posts, and are never nested deeper
+// than the maximum depth.
+func (g *Generator) generatePosts(thread *data.Thread) []*data.Post {
+	n := g.count(float64(g.config.PostsPerThread))
+	posts := make([]*data.Post, 0, n)
+	depths := make([]int, 0, n)
+
+	createdAt := thread.CreatedAt
+	for i := range n {
+		post := &data.Post{
+			ID:       g.id(),
+			ThreadID: thread.ID,
+			AuthorID: thread.AuthorID,
+			Content:  paragraph(g.rng, 1+g.rng.IntN(4)),
+			Opening:  i == 0,
+		}
+		depth := 0
+		if i > 0 {
+			// Replies are posted minutes to hours after the previous post.
+			createdAt = createdAt.Add(time.Duration(g.rng.ExpFloat64() * float64(time.Hour)))
+			post.AuthorID = g.user().ID
+
+			// Most posts reply to one of the latest posts, while the rest start new branches.
+			if g.rng.Float64() < 0.7 {
+				parent := max(0, len(posts)-1-int(g.rng.ExpFloat64()*3))
+				if depths[parent] < g.config.MaxDepth {
+					post.ReplyTo = uuid.NullUUID{UUID: posts[parent].ID, Valid: true}
+					depth = depths[parent] + 1
+				}
+			}
+		}
+		post.CreatedAt = createdAt
+		post.UpdatedAt = createdAt
+
+		posts = append(posts, post)
+		depths = append(depths, depth)
+	}
+
+	return posts
+}
+
+// voters draws the distinct users voting on a thread or post.
+func (g *Generator) voters(mean float64) []uuid.UUID {
+	n := min(g.count(mean), len(g.users))
+	voters := make([]uuid.UUID, 0, n)
+	seen := make(map[uuid.UUID]bool, n)
+
+	// Popular users are drawn repeatedly, so the attempts are bounded to end the draw.
+	for attempt := 0; len(voters) < n && attempt < 4*n; attempt++ {
+		voter := g.user().ID
+		if seen[voter] {
+			continue
+		}
+		seen[voter] = true
+		voters = append(voters, voter)
+	}
+
+	return voters
+}
+
+// vote draws the value of a vote, where most votes are upvotes.
+func (g *Generator) vote() int8 {
+	if g.rng.Float64() < 0.8 {
+		return 1
+	}
+
+	return -1
+}
@@ -0,0 +1,131 @@
+package seed_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/seed"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore keeps every row copied to it, in the order they were copied.
+type memoryStore struct {
+	users       []*data.User
+	forums      []*data.Forum
+	threads     []*data.Thread
+	posts       []*data.Post
+	threadVotes []*data.ThreadVote
+	postVotes   []*data.PostVote
+}
+
+func (s *memoryStore) CopyUsers(_ context.Context, users []*data.User, _ []byte) (int64, error) {
+	s.users = append(s.users, users...)
+	return int64(len(users)), nil
+}
+
+func (s *memoryStore) CopyForums(_ context.Context, forums []*data.Forum) (int64, error) {
+	s.forums = append(s.forums, forums...)
+	return int64(len(forums)), nil
+}
+
+func (s *memoryStore) CopyThreads(_ context.Context, threads []*data.Thread) (int64, error) {
+	s.threads = append(s.threads, threads...)
+	return int64(len(threads)), nil
+}
+
+func (s *memoryStore) CopyPosts(_ context.Context, posts []*data.Post) (int64, error) {
+	s.posts = append(s.posts, posts...)
+	return int64(len(posts)), nil
+}
+
+func (s *memoryStore) CopyThreadVotes(_ context.Context, votes []*data.ThreadVote) (int64, error) {
+	s.threadVotes = append(s.threadVotes, votes...)
+	return int64(len(votes)), nil
+}
+
+func (s *memoryStore) CopyPostVotes(_ context.Context, votes []*data.PostVote) (int64, error) {
+	s.postVotes = append(s.postVotes, votes...)
+	return int64(len(votes)), nil
+}
+
+func TestGenerator(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	config := seed.SeedConfig{
+		Seed:            2077,
+		Users:           50,
+		Forums:          3,
+		ThreadsPerForum: 10,
+		PostsPerThread:  8,
+		MaxDepth:        3,
+		VotesPerThread:  4,
+		VotesPerPost:    2,
+	}
+
+	store := &memoryStore{}
+	summary, err := seed.NewGenerator(config, store).Run(ctx)
+	assert.NoError(t, err)
+
+	t.Run("Summary", func(t *testing.T) {
+		assert.Equal(t, int64(50), summary.Users)
+		assert.Equal(t, int64(3), summary.Forums)
+		assert.Equal(t, int64(30), summary.Threads)
+		assert.Equal(t, int64(len(store.posts)), summary.Posts)
+		assert.Equal(t, int64(len(store.threadVotes)), summary.ThreadVotes)
+		assert.Equal(t, int64(len(store.postVotes)), summary.PostVotes)
+		assert.GreaterOrEqual(t, summary.Posts, summary.Threads)
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		again := &memoryStore{}
+		_, err := seed.NewGenerator(config, again).Run(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, store, again)
+
+		config.Seed++
+		other := &memoryStore{}
+		_, err = seed.NewGenerator(config, other).Run(ctx)
+		assert.NoError(t, err)
+		assert.NotEqual(t, store.users[0].ID, other.users[0].ID)
+	})
+
+	t.Run("Users", func(t *testing.T) {
+		usernames := make(map[string]bool)
+		for _, user := range store.users {
+			assert.False(t, usernames[user.Username], "duplicate username %s", user.Username)
+			usernames[user.Username] = true
+		}
+	})
+
+	t.Run("ReplyTrees", func(t *testing.T) {
+		posts := make(map[uuid.UUID]*data.Post)
+		depths := make(map[uuid.UUID]int)
+		for _, post := range store.posts {
+			if post.ReplyTo.Valid {
+				parent, ok := posts[post.ReplyTo.UUID]
+				if !assert.True(t, ok, "reply copied before its parent") {
+					continue
+				}
+				assert.Equal(t, parent.ThreadID, post.ThreadID)
+				assert.False(t, post.CreatedAt.Before(parent.CreatedAt))
+				depths[post.ID] = depths[parent.ID] + 1
+			}
+			assert.LessOrEqual(t, depths[post.ID], config.MaxDepth)
+			posts[post.ID] = post
+		}
+	})
+
+	t.Run("Votes", func(t *testing.T) {
+		voted := make(map[[2]uuid.UUID]bool)
+		for _, vote := range store.postVotes {
+			key := [2]uuid.UUID{vote.PostID, vote.UserID}
+			assert.False(t, voted[key], "user voted twice on post %s", vote.PostID)
+			assert.Contains(t, []int8{-1, 1}, vote.Vote)
+			voted[key] = true
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package seed
+
+import (
+	"math/rand/v2"
+	"strings"
+)
+
+var firstNames = []string{
+	"Alex", "Dexter", "Evelyn", "Goro", "Hanako", "Jackie", "Johnny", "Judy", "Kerry", "Lucy",
+	"Meredith", "Misty", "Panam", "River", "Rogue", "Saburo", "Takemura", "Vik", "Viktor",
+	"Yorinobu",
+}
+
+var lastNames = []string{
+	"Alvarez", "Arasaka", "DeShawn", "Eurodyne", "Kiroshi", "Mamoru", "Martinez", "Palmer",
+	"Silverhand", "Stout", "Vector", "Ward", "Welles", "Yamamoto", "Zetatech",
+}
+
+var districts = []string{
+	"Watson", "Westbrook", "City Center", "Heywood", "Pacifica", "Santo Domingo", "Badlands",
+	"Kabuki", "Japantown", "Northside",
+}
+
+var topics = []string{
+	"Braindances", "Chrome", "Cyberdecks", "Fixers", "Netrunning", "Nomads", "Ripperdocs",
+	"Street Food", "Vehicles", "Weapons",
+}
+
+var words = []string{
+	"afterlife", "arasaka", "black", "braindance", "chrome", "choom", "corpo", "cyberpsycho",
+	"daemon", "data", "deck", "delta", "eddies", "fixer", "flatline", "gig", "gonk", "ice",
+	"implant", "job", "kiroshi", "maxtac", "merc", "militech", "neon", "net", "nomad", "optics",
+	"preem", "relic", "ripperdoc", "run", "samurai", "scav", "shard", "street", "synth",
+	"tech", "tower", "trauma", "vending", "wall", "wire", "zen",
+}
+
+// pick chooses one of the values uniformly.
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.IntN(len(values))]
+}
+
+// sentence generates a capitalised sentence of n words, without punctuation.
+func sentence(rng *rand.Rand, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = pick(rng, words)
+	}
+	s := strings.Join(parts, " ")
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// paragraph generates n sentences.
+func paragraph(rng *rand.Rand, n int) string {
+	sentences := make([]string, n)
+	for i := range sentences {
+		sentences[i] = sentence(rng, 4+rng.IntN(12)) + "."
+	}
+
+	return strings.Join(sentences, " ")
+}
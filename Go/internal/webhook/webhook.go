@@ -0,0 +1,260 @@
+// Package webhook delivers the domain events recorded in the outbox to registered webhooks.
+//
+// Every delivery is a POST request with the event as a JSON body. Requests are signed with the
+// secret of the webhook, allowing receivers to verify the origin of the event, see Sign. Failed
+// deliveries are retried with exponential backoff, until the delivery is considered dead.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/data"
+	"github.com/r3d5un/rosetta/Go/internal/logging"
+)
+
+const (
+	// SignatureHeader carries the signature of the request body, see Sign.
+	SignatureHeader string = "X-Rosetta-Signature"
+	// TimestampHeader carries the Unix time the request was signed at.
+	TimestampHeader string = "X-Rosetta-Timestamp"
+	// EventHeader carries the type of the delivered event.
+	EventHeader string = "X-Rosetta-Event"
+	// DeliveryHeader carries the unique identifier of the delivery. Retries of a delivery share
+	// the identifier, allowing receivers to discard duplicates.
+	DeliveryHeader string = "X-Rosetta-Delivery"
+)
+
+// Sign returns the signature of a request body sent at the given Unix time. The signature is the
+// hex encoded HMAC-SHA256 of the timestamp and the body joined by a period, prefixed by
+// "sha256=".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay before retrying a delivery after the given number of failed
+// attempts. The delay doubles for every attempt, starting at base, and never exceeds limit.
+func Backoff(attempts int, base time.Duration, limit time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+
+	return min(delay, limit)
+}
+
+// Payload is the body of every delivery.
+type Payload struct {
+	// ID is the unique, ever-increasing identifier of the event.
+	ID int64 `json:"id"`
+	// Type is the type of the event, such as post.created.
+	Type string `json:"type"`
+	// AggregateType is the type of the changed resource, such as post.
+	AggregateType string `json:"aggregateType"`
+	// AggregateID is the unique identifier of the changed resource.
+	AggregateID string `json:"aggregateId"`
+	// CreatedAt denotes when the change happened.
+	CreatedAt time.Time `json:"createdAt"`
+	// Data is the state of the resource after the change, or before it was purged.
+	Data json.RawMessage `json:"data"`
+}
+
+type Dispatcher struct {
+	models *data.Models
+	client *http.Client
+
+	// Interval is the time between every check for due deliveries.
+	Interval time.Duration
+	// BatchSize is the maximum number of deliveries attempted at once.
+	BatchSize int
+	// MaxAttempts is the number of attempts before a delivery is considered dead.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry of a delivery.
+	BaseDelay time.Duration
+	// MaxDelay is the longest delay between two attempts of a delivery.
+	MaxDelay time.Duration
+	// Lease is the time a claimed delivery is reserved for this dispatcher. Deliveries not
+	// concluded within the lease are claimed again.
+	Lease time.Duration
+}
+
+func NewDispatcher(models *data.Models, client *http.Client) *Dispatcher {
+	return &Dispatcher{
+		models:      models,
+		client:      client,
+		Interval:    5 * time.Second,
+		BatchSize:   25,
+		MaxAttempts: 8,
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    6 * time.Hour,
+		Lease:       time.Minute,
+	}
+}
+
+// Run delivers due events until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	logger := logging.LoggerFromContext(ctx)
+
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "dispatching webhook deliveries")
+	for {
+		for {
+			n, err := d.Dispatch(ctx)
+			if err != nil {
+				logger.LogAttrs(
+					ctx,
+					slog.LevelError,
+					"unable to dispatch webhook deliveries",
+					slog.String("error", err.Error()),
+				)
+				break
+			}
+			// Full batches suggest a backlog, which is worked through without waiting.
+			if n < d.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.LogAttrs(ctx, slog.LevelInfo, "stopped dispatching webhook deliveries")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch claims a batch of due deliveries, and attempts every one of them concurrently. The
+// number of attempted deliveries is returned.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.models.WebhookDeliveries.Claim(ctx, d.BatchSize, d.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *data.ClaimedWebhookDelivery) {
+	logger := logging.LoggerFromContext(ctx).With(slog.Group(
+		"delivery",
+		slog.String("id", delivery.ID.String()),
+		slog.String("webhookId", delivery.WebhookID.String()),
+		slog.Int64("eventId", delivery.EventID),
+		slog.Int("attempts", delivery.Attempts),
+	))
+
+	status, err := d.send(ctx, delivery)
+	if err == nil {
+		logger.LogAttrs(ctx, slog.LevelInfo, "webhook delivered", slog.Int("status", status))
+		_, err = d.models.WebhookDeliveries.MarkDelivered(ctx, delivery.ID, status)
+		if err != nil {
+			logger.LogAttrs(
+				ctx,
+				slog.LevelError,
+				"unable to mark webhook delivery as delivered",
+				slog.String("error", err.Error()),
+			)
+		}
+		return
+	}
+
+	var retryAfter *time.Duration
+	if delivery.Attempts < d.MaxAttempts {
+		delay := Backoff(delivery.Attempts, d.BaseDelay, d.MaxDelay)
+		retryAfter = &delay
+	}
+	logger.LogAttrs(
+		ctx,
+		slog.LevelWarn,
+		"unable to deliver webhook",
+		slog.Int("status", status),
+		slog.Any("retryAfter", retryAfter),
+		slog.String("error", err.Error()),
+	)
+
+	responseStatus := sql.NullInt32{Int32: int32(status), Valid: status != 0}
+	_, err = d.models.WebhookDeliveries.MarkFailed(
+		ctx, delivery.ID, responseStatus, err.Error(), retryAfter,
+	)
+	if err != nil {
+		logger.LogAttrs(
+			ctx,
+			slog.LevelError,
+			"unable to mark webhook delivery as failed",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// send performs a single attempt of a delivery, returning the status code of the response, if
+// any. Responses outside the 2xx range are treated as failures.
+func (d *Dispatcher) send(ctx context.Context, delivery *data.ClaimedWebhookDelivery) (int, error) {
+	body, err := json.Marshal(Payload{
+		ID:            delivery.Event.ID,
+		Type:          delivery.Event.Type,
+		AggregateType: delivery.Event.AggregateType,
+		AggregateID:   delivery.Event.AggregateID.String(),
+		CreatedAt:     delivery.Event.CreatedAt,
+		Data:          delivery.Event.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, delivery.URL, bytes.NewReader(body),
+	)
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, body))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(EventHeader, delivery.Event.Type)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// Draining the body allows the connection to be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status: %s", res.Status)
+	}
+
+	return res.StatusCode, nil
+}
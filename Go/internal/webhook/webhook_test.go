@@ -0,0 +1,29 @@
+package webhook_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/r3d5un/rosetta/Go/internal/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":1,"type":"forum.created"}`)
+
+	signature := webhook.Sign("afterlife", 1700000000, body)
+	assert.Equal(t, signature, webhook.Sign("afterlife", 1700000000, body))
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", signature)
+	assert.NotEqual(t, signature, webhook.Sign("afterlife", 1700000001, body))
+	assert.NotEqual(t, signature, webhook.Sign("arasaka", 1700000000, body))
+}
+
+func TestBackoff(t *testing.T) {
+	base, limit := 30*time.Second, 10*time.Minute
+
+	assert.Equal(t, 30*time.Second, webhook.Backoff(1, base, limit))
+	assert.Equal(t, time.Minute, webhook.Backoff(2, base, limit))
+	assert.Equal(t, 4*time.Minute, webhook.Backoff(4, base, limit))
+	assert.Equal(t, limit, webhook.Backoff(6, base, limit))
+	assert.Equal(t, limit, webhook.Backoff(100, base, limit))
+}
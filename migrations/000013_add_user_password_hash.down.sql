@@ -0,0 +1,2 @@
+ALTER TABLE forum.users
+    DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,2 @@
+ALTER TABLE forum.users
+    ADD COLUMN IF NOT EXISTS password_hash BYTEA NULL;
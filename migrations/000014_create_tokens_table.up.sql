@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS forum.tokens
+(
+    hash    BYTEA       NOT NULL,
+    user_id UUID        NOT NULL,
+    expiry  TIMESTAMPTZ NOT NULL,
+    scope   VARCHAR(64) NOT NULL,
+    CONSTRAINT pk_tokens PRIMARY KEY (hash),
+    CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES forum.users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tokens_user_id ON forum.tokens (user_id);
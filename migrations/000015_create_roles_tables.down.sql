@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS forum.user_roles;
+
+DROP TABLE IF EXISTS forum.roles;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS forum.roles
+(
+    name        VARCHAR(64)  NOT NULL,
+    description VARCHAR(512) NOT NULL,
+    CONSTRAINT pk_roles PRIMARY KEY (name)
+);
+
+INSERT INTO forum.roles (name, description)
+VALUES ('admin', 'Manages every forum, thread, post and user'),
+       ('owner', 'Owns a forum. Derived from the owner of the forum, and never granted directly'),
+       ('moderator', 'Moderates the threads and posts of a single forum'),
+       ('member', 'Any authenticated user. Implied, and never granted directly')
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS forum.user_roles
+(
+    user_id    UUID                    NOT NULL,
+    role       VARCHAR(64)             NOT NULL,
+    forum_id   UUID                    NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    CONSTRAINT uq_user_roles UNIQUE NULLS NOT DISTINCT (user_id, role, forum_id),
+    CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES forum.users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_role FOREIGN KEY (role) REFERENCES forum.roles (name),
+    CONSTRAINT fk_forum_id FOREIGN KEY (forum_id) REFERENCES forum.forums (id) ON DELETE CASCADE,
+    CONSTRAINT chk_role_scope CHECK (
+        (role = 'admin' AND forum_id IS NULL) OR (role = 'moderator' AND forum_id IS NOT NULL)
+        )
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_forum_id ON forum.user_roles (forum_id);
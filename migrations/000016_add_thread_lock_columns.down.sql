@@ -0,0 +1,4 @@
+ALTER TABLE forum.threads
+    DROP CONSTRAINT IF EXISTS fk_locked_by,
+    DROP COLUMN IF EXISTS locked_by,
+    DROP COLUMN IF EXISTS locked_at;
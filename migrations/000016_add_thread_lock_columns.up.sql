@@ -0,0 +1,4 @@
+ALTER TABLE forum.threads
+    ADD COLUMN IF NOT EXISTS locked_by UUID      NULL,
+    ADD COLUMN IF NOT EXISTS locked_at TIMESTAMP NULL,
+    ADD CONSTRAINT fk_locked_by FOREIGN KEY (locked_by) REFERENCES forum.users (id) ON DELETE SET NULL;
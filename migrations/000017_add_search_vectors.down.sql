@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS forum.idx_posts_search_vector;
+DROP INDEX IF EXISTS forum.idx_threads_search_vector;
+DROP INDEX IF EXISTS forum.idx_forums_search_vector;
+
+ALTER TABLE forum.posts
+    DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE forum.threads
+    DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE forum.forums
+    DROP COLUMN IF EXISTS search_vector;
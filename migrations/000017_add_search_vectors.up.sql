@@ -0,0 +1,19 @@
+ALTER TABLE forum.forums
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+        ) STORED;
+
+ALTER TABLE forum.threads
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('english', COALESCE(title, ''))
+        ) STORED;
+
+ALTER TABLE forum.posts
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('english', COALESCE(content, ''))
+        ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_forums_search_vector ON forum.forums USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_threads_search_vector ON forum.threads USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON forum.posts USING GIN (search_vector);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS trigger_thread_vote_thread_event ON forum.threads;
+DROP TRIGGER IF EXISTS trigger_post_thread_event ON forum.posts;
+
+DROP FUNCTION IF EXISTS forum.thread_vote_thread_event();
+DROP FUNCTION IF EXISTS forum.post_thread_event();
+DROP FUNCTION IF EXISTS forum.publish_thread_event(UUID, VARCHAR, JSONB);
+
+DROP TABLE IF EXISTS forum.thread_events;
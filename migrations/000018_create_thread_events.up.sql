@@ -0,0 +1,94 @@
+CREATE TABLE IF NOT EXISTS forum.thread_events
+(
+    id         BIGINT GENERATED ALWAYS AS IDENTITY,
+    thread_id  UUID                    NOT NULL,
+    type       VARCHAR(64)             NOT NULL,
+    payload    JSONB                   NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    CONSTRAINT pk_thread_events PRIMARY KEY (id),
+    CONSTRAINT fk_thread_id FOREIGN KEY (thread_id) REFERENCES forum.threads (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_thread_events_thread_id ON forum.thread_events (thread_id, id);
+
+-- publish_thread_event records an event, and notifies every listener of the thread_events channel.
+CREATE OR REPLACE FUNCTION forum.publish_thread_event(
+    event_thread_id UUID,
+    event_type VARCHAR(64),
+    event_payload JSONB
+) RETURNS VOID AS
+$$
+DECLARE
+    event forum.thread_events;
+BEGIN
+    INSERT INTO forum.thread_events (thread_id, type, payload)
+    VALUES (event_thread_id, event_type, event_payload)
+    RETURNING * INTO event;
+
+    PERFORM pg_notify('thread_events', json_build_object(
+            'id', event.id,
+            'threadId', event.thread_id,
+            'type', event.type,
+            'payload', event.payload,
+            'createdAt', to_char(event.created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+                                       )::TEXT);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION forum.post_thread_event()
+    RETURNS TRIGGER AS
+$$
+DECLARE
+    post forum.posts;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        post := OLD;
+    ELSE
+        post := NEW;
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        PERFORM forum.publish_thread_event(post.thread_id, 'post.created', jsonb_build_object(
+                'postId', post.id, 'replyTo', post.reply_to, 'authorId', post.author_id));
+    ELSIF TG_OP = 'DELETE' OR (NEW.deleted IS TRUE AND OLD.deleted IS NOT TRUE) THEN
+        PERFORM forum.publish_thread_event(post.thread_id, 'post.deleted', jsonb_build_object(
+                'postId', post.id));
+    ELSIF NEW.content IS DISTINCT FROM OLD.content THEN
+        PERFORM forum.publish_thread_event(post.thread_id, 'post.edited', jsonb_build_object(
+                'postId', post.id, 'updatedAt', post.updated_at));
+    ELSIF NEW.likes IS DISTINCT FROM OLD.likes THEN
+        PERFORM forum.publish_thread_event(post.thread_id, 'vote.changed', jsonb_build_object(
+                'postId', post.id, 'likes', post.likes));
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION forum.thread_vote_thread_event()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    IF NEW.likes IS DISTINCT FROM OLD.likes THEN
+        PERFORM forum.publish_thread_event(NEW.id, 'vote.changed', jsonb_build_object(
+                'threadId', NEW.id, 'likes', NEW.likes));
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_post_thread_event ON forum.posts;
+DROP TRIGGER IF EXISTS trigger_thread_vote_thread_event ON forum.threads;
+
+CREATE TRIGGER trigger_post_thread_event
+    AFTER INSERT OR UPDATE OR DELETE
+    ON forum.posts
+    FOR EACH ROW
+EXECUTE FUNCTION forum.post_thread_event();
+
+CREATE TRIGGER trigger_thread_vote_thread_event
+    AFTER UPDATE OF likes
+    ON forum.threads
+    FOR EACH ROW
+EXECUTE FUNCTION forum.thread_vote_thread_event();
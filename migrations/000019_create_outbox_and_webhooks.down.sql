@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS trigger_fan_out_outbox_event ON forum.outbox_events;
+DROP TRIGGER IF EXISTS trigger_user_domain_event ON forum.users;
+DROP TRIGGER IF EXISTS trigger_post_domain_event ON forum.posts;
+DROP TRIGGER IF EXISTS trigger_thread_domain_event ON forum.threads;
+DROP TRIGGER IF EXISTS trigger_forum_domain_event ON forum.forums;
+
+DROP FUNCTION IF EXISTS forum.fan_out_outbox_event();
+DROP FUNCTION IF EXISTS forum.record_domain_event();
+
+DROP TABLE IF EXISTS forum.webhook_deliveries;
+DROP TABLE IF EXISTS forum.webhooks;
+DROP TABLE IF EXISTS forum.outbox_events;
@@ -0,0 +1,146 @@
+CREATE TABLE IF NOT EXISTS forum.outbox_events
+(
+    id             BIGINT GENERATED ALWAYS AS IDENTITY,
+    aggregate_type VARCHAR(32)             NOT NULL,
+    aggregate_id   UUID                    NOT NULL,
+    type           VARCHAR(64)             NOT NULL,
+    payload        JSONB                   NOT NULL,
+    created_at     TIMESTAMP DEFAULT NOW() NOT NULL,
+    CONSTRAINT pk_outbox_events PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_created_at ON forum.outbox_events (created_at);
+
+CREATE TABLE IF NOT EXISTS forum.webhooks
+(
+    id          UUID        DEFAULT gen_random_uuid(),
+    url         TEXT                     NOT NULL,
+    secret      VARCHAR(256)             NOT NULL,
+    event_types TEXT[]      DEFAULT '{}' NOT NULL,
+    active      BOOLEAN     DEFAULT TRUE NOT NULL,
+    created_at  TIMESTAMP   DEFAULT NOW() NOT NULL,
+    updated_at  TIMESTAMP   DEFAULT NOW() NOT NULL,
+    CONSTRAINT pk_webhooks PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS forum.webhook_deliveries
+(
+    id              UUID        DEFAULT gen_random_uuid(),
+    webhook_id      UUID                               NOT NULL,
+    event_id        BIGINT                             NOT NULL,
+    status          VARCHAR(16) DEFAULT 'pending'      NOT NULL,
+    attempts        INTEGER     DEFAULT 0              NOT NULL,
+    next_attempt_at TIMESTAMP   DEFAULT NOW()          NOT NULL,
+    last_error      TEXT                               NULL,
+    response_status INTEGER                            NULL,
+    created_at      TIMESTAMP   DEFAULT NOW()          NOT NULL,
+    updated_at      TIMESTAMP   DEFAULT NOW()          NOT NULL,
+    CONSTRAINT pk_webhook_deliveries PRIMARY KEY (id),
+    CONSTRAINT uq_webhook_deliveries UNIQUE (webhook_id, event_id),
+    CONSTRAINT fk_webhook_id FOREIGN KEY (webhook_id) REFERENCES forum.webhooks (id) ON DELETE CASCADE,
+    CONSTRAINT fk_event_id FOREIGN KEY (event_id) REFERENCES forum.outbox_events (id) ON DELETE CASCADE,
+    CONSTRAINT chk_status CHECK (status IN ('pending', 'delivered', 'dead'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+    ON forum.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON forum.webhook_deliveries (event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id
+    ON forum.webhook_deliveries (webhook_id, created_at);
+
+-- record_domain_event records a change to a forum, thread, post or user in the outbox, within the
+-- transaction performing the change. Columns which are internal or secret are left out.
+CREATE OR REPLACE FUNCTION forum.record_domain_event()
+    RETURNS TRIGGER AS
+$$
+DECLARE
+    aggregate  VARCHAR(32);
+    event_type VARCHAR(64);
+    row_data   JSONB;
+BEGIN
+    aggregate := CASE TG_TABLE_NAME
+                     WHEN 'forums' THEN 'forum'
+                     WHEN 'threads' THEN 'thread'
+                     WHEN 'posts' THEN 'post'
+                     WHEN 'users' THEN 'user'
+        END;
+
+    IF TG_OP = 'DELETE' THEN
+        row_data := to_jsonb(OLD);
+        event_type := 'purged';
+    ELSE
+        row_data := to_jsonb(NEW);
+        IF TG_OP = 'INSERT' THEN
+            event_type := 'created';
+        ELSIF to_jsonb(NEW) = to_jsonb(OLD) THEN
+            RETURN NULL;
+        ELSIF NEW.deleted IS TRUE AND OLD.deleted IS NOT TRUE THEN
+            event_type := 'deleted';
+        ELSIF NEW.deleted IS NOT TRUE AND OLD.deleted IS TRUE THEN
+            event_type := 'restored';
+        ELSE
+            event_type := 'updated';
+        END IF;
+    END IF;
+
+    INSERT INTO forum.outbox_events (aggregate_type, aggregate_id, type, payload)
+    VALUES (aggregate,
+            (row_data ->> 'id')::UUID,
+            aggregate || '.' || event_type,
+            row_data - 'search_vector' - 'password_hash');
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- fan_out_outbox_event schedules a delivery of a new event to every active webhook subscribed to
+-- the type of the event. Webhooks without any event types receive every event.
+CREATE OR REPLACE FUNCTION forum.fan_out_outbox_event()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO forum.webhook_deliveries (webhook_id, event_id)
+    SELECT w.id, NEW.id
+    FROM forum.webhooks w
+    WHERE w.active
+      AND (cardinality(w.event_types) = 0 OR NEW.type = ANY (w.event_types));
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_forum_domain_event ON forum.forums;
+DROP TRIGGER IF EXISTS trigger_thread_domain_event ON forum.threads;
+DROP TRIGGER IF EXISTS trigger_post_domain_event ON forum.posts;
+DROP TRIGGER IF EXISTS trigger_user_domain_event ON forum.users;
+DROP TRIGGER IF EXISTS trigger_fan_out_outbox_event ON forum.outbox_events;
+
+CREATE TRIGGER trigger_forum_domain_event
+    AFTER INSERT OR UPDATE OR DELETE
+    ON forum.forums
+    FOR EACH ROW
+EXECUTE FUNCTION forum.record_domain_event();
+
+CREATE TRIGGER trigger_thread_domain_event
+    AFTER INSERT OR UPDATE OR DELETE
+    ON forum.threads
+    FOR EACH ROW
+EXECUTE FUNCTION forum.record_domain_event();
+
+CREATE TRIGGER trigger_post_domain_event
+    AFTER INSERT OR UPDATE OR DELETE
+    ON forum.posts
+    FOR EACH ROW
+EXECUTE FUNCTION forum.record_domain_event();
+
+CREATE TRIGGER trigger_user_domain_event
+    AFTER INSERT OR UPDATE OR DELETE
+    ON forum.users
+    FOR EACH ROW
+EXECUTE FUNCTION forum.record_domain_event();
+
+CREATE TRIGGER trigger_fan_out_outbox_event
+    AFTER INSERT
+    ON forum.outbox_events
+    FOR EACH ROW
+EXECUTE FUNCTION forum.fan_out_outbox_event();
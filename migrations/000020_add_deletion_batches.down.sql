@@ -0,0 +1,25 @@
+DROP TRIGGER IF EXISTS trigger_cascade_deletion ON forum.users;
+DROP TRIGGER IF EXISTS trigger_cascade_deletion ON forum.threads;
+DROP TRIGGER IF EXISTS trigger_cascade_deletion ON forum.forums;
+DROP TRIGGER IF EXISTS trigger_assign_deletion_batch ON forum.users;
+DROP TRIGGER IF EXISTS trigger_assign_deletion_batch ON forum.posts;
+DROP TRIGGER IF EXISTS trigger_assign_deletion_batch ON forum.threads;
+DROP TRIGGER IF EXISTS trigger_assign_deletion_batch ON forum.forums;
+
+DROP FUNCTION IF EXISTS forum.cascade_deletion();
+DROP FUNCTION IF EXISTS forum.assign_deletion_batch();
+
+DROP INDEX IF EXISTS forum.idx_posts_deletion_batch_id;
+DROP INDEX IF EXISTS forum.idx_threads_deletion_batch_id;
+
+ALTER TABLE forum.posts
+    DROP COLUMN IF EXISTS deletion_batch_id;
+
+ALTER TABLE forum.threads
+    DROP COLUMN IF EXISTS deletion_batch_id;
+
+ALTER TABLE forum.forums
+    DROP COLUMN IF EXISTS deletion_batch_id;
+
+ALTER TABLE forum.users
+    DROP COLUMN IF EXISTS deletion_batch_id;
@@ -0,0 +1,133 @@
+ALTER TABLE forum.users
+    ADD COLUMN IF NOT EXISTS deletion_batch_id UUID NULL;
+
+ALTER TABLE forum.forums
+    ADD COLUMN IF NOT EXISTS deletion_batch_id UUID NULL;
+
+ALTER TABLE forum.threads
+    ADD COLUMN IF NOT EXISTS deletion_batch_id UUID NULL;
+
+ALTER TABLE forum.posts
+    ADD COLUMN IF NOT EXISTS deletion_batch_id UUID NULL;
+
+CREATE INDEX IF NOT EXISTS idx_threads_deletion_batch_id ON forum.threads (deletion_batch_id);
+CREATE INDEX IF NOT EXISTS idx_posts_deletion_batch_id ON forum.posts (deletion_batch_id);
+
+-- assign_deletion_batch starts a new deletion batch whenever a row is soft deleted, unless the row
+-- is hidden as part of an existing batch. Restored rows leave their batch.
+CREATE OR REPLACE FUNCTION forum.assign_deletion_batch()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    IF NEW.deleted IS TRUE AND OLD.deleted IS NOT TRUE THEN
+        NEW.deletion_batch_id := COALESCE(NEW.deletion_batch_id, gen_random_uuid());
+    ELSIF NEW.deleted IS NOT TRUE THEN
+        NEW.deletion_batch_id := NULL;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- cascade_deletion soft deletes the rows below a deleted row within the same batch. Restoring the
+-- row restores exactly the rows hidden by its batch, leaving rows deleted on their own untouched.
+--
+-- Forums cascade to their threads, users to the threads and posts they authored, and threads to
+-- their posts. Threads hidden by a cascade, cascade further to their own posts.
+CREATE OR REPLACE FUNCTION forum.cascade_deletion()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    IF NEW.deleted IS TRUE AND OLD.deleted IS NOT TRUE THEN
+        IF TG_TABLE_NAME IN ('forums', 'users') THEN
+            UPDATE forum.threads
+            SET deleted           = TRUE,
+                deleted_at        = NEW.deleted_at,
+                deletion_batch_id = NEW.deletion_batch_id,
+                updated_at        = NOW()
+            WHERE deleted IS NOT TRUE
+              AND CASE TG_TABLE_NAME WHEN 'forums' THEN forum_id ELSE author_id END = NEW.id;
+        END IF;
+
+        IF TG_TABLE_NAME IN ('threads', 'users') THEN
+            UPDATE forum.posts
+            SET deleted           = TRUE,
+                deleted_at        = NEW.deleted_at,
+                deletion_batch_id = NEW.deletion_batch_id,
+                updated_at        = NOW()
+            WHERE deleted IS NOT TRUE
+              AND CASE TG_TABLE_NAME WHEN 'threads' THEN thread_id ELSE author_id END = NEW.id;
+        END IF;
+    ELSIF NEW.deleted IS NOT TRUE AND OLD.deleted IS TRUE AND OLD.deletion_batch_id IS NOT NULL THEN
+        IF TG_TABLE_NAME IN ('forums', 'users') THEN
+            UPDATE forum.threads
+            SET deleted    = FALSE,
+                deleted_at = NULL,
+                updated_at = NOW()
+            WHERE deletion_batch_id = OLD.deletion_batch_id
+              AND CASE TG_TABLE_NAME WHEN 'forums' THEN forum_id ELSE author_id END = NEW.id;
+        END IF;
+
+        IF TG_TABLE_NAME IN ('threads', 'users') THEN
+            UPDATE forum.posts
+            SET deleted    = FALSE,
+                deleted_at = NULL,
+                updated_at = NOW()
+            WHERE deletion_batch_id = OLD.deletion_batch_id
+              AND CASE TG_TABLE_NAME WHEN 'threads' THEN thread_id ELSE author_id END = NEW.id;
+        END IF;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_assign_deletion_batch ON forum.forums;
+DROP TRIGGER IF EXISTS trigger_assign_deletion_batch ON forum.threads;
+DROP TRIGGER IF EXISTS trigger_assign_deletion_batch ON forum.posts;
+DROP TRIGGER IF EXISTS trigger_assign_deletion_batch ON forum.users;
+DROP TRIGGER IF EXISTS trigger_cascade_deletion ON forum.forums;
+DROP TRIGGER IF EXISTS trigger_cascade_deletion ON forum.threads;
+DROP TRIGGER IF EXISTS trigger_cascade_deletion ON forum.users;
+
+CREATE TRIGGER trigger_assign_deletion_batch
+    BEFORE UPDATE OF deleted
+    ON forum.forums
+    FOR EACH ROW
+EXECUTE FUNCTION forum.assign_deletion_batch();
+
+CREATE TRIGGER trigger_assign_deletion_batch
+    BEFORE UPDATE OF deleted
+    ON forum.threads
+    FOR EACH ROW
+EXECUTE FUNCTION forum.assign_deletion_batch();
+
+CREATE TRIGGER trigger_assign_deletion_batch
+    BEFORE UPDATE OF deleted
+    ON forum.posts
+    FOR EACH ROW
+EXECUTE FUNCTION forum.assign_deletion_batch();
+
+CREATE TRIGGER trigger_assign_deletion_batch
+    BEFORE UPDATE OF deleted
+    ON forum.users
+    FOR EACH ROW
+EXECUTE FUNCTION forum.assign_deletion_batch();
+
+CREATE TRIGGER trigger_cascade_deletion
+    AFTER UPDATE OF deleted
+    ON forum.forums
+    FOR EACH ROW
+EXECUTE FUNCTION forum.cascade_deletion();
+
+CREATE TRIGGER trigger_cascade_deletion
+    AFTER UPDATE OF deleted
+    ON forum.threads
+    FOR EACH ROW
+EXECUTE FUNCTION forum.cascade_deletion();
+
+CREATE TRIGGER trigger_cascade_deletion
+    AFTER UPDATE OF deleted
+    ON forum.users
+    FOR EACH ROW
+EXECUTE FUNCTION forum.cascade_deletion();
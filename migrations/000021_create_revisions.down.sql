@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS trigger_record_thread_revision ON forum.threads;
+DROP TRIGGER IF EXISTS trigger_record_post_revision_update ON forum.posts;
+DROP TRIGGER IF EXISTS trigger_record_post_revision_insert ON forum.posts;
+
+DROP FUNCTION IF EXISTS forum.record_thread_revision();
+DROP FUNCTION IF EXISTS forum.record_post_revision();
+
+DROP TABLE IF EXISTS forum.thread_revisions;
+DROP TABLE IF EXISTS forum.post_revisions;
+
+ALTER TABLE forum.posts
+    DROP COLUMN IF EXISTS edit_count;
@@ -0,0 +1,93 @@
+ALTER TABLE forum.posts
+    ADD COLUMN IF NOT EXISTS edit_count INTEGER DEFAULT 0 NOT NULL;
+
+CREATE TABLE IF NOT EXISTS forum.post_revisions
+(
+    post_id    UUID                    NOT NULL,
+    revision   INTEGER                 NOT NULL,
+    content    TEXT                    NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    CONSTRAINT pk_post_revisions PRIMARY KEY (post_id, revision),
+    CONSTRAINT fk_post_id FOREIGN KEY (post_id) REFERENCES forum.posts (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS forum.thread_revisions
+(
+    thread_id  UUID                    NOT NULL,
+    revision   INTEGER                 NOT NULL,
+    title      VARCHAR(128)            NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    CONSTRAINT pk_thread_revisions PRIMARY KEY (thread_id, revision),
+    CONSTRAINT fk_thread_id FOREIGN KEY (thread_id) REFERENCES forum.threads (id) ON DELETE CASCADE
+);
+
+-- Existing posts and threads start out with their current state as the first revision.
+INSERT INTO forum.post_revisions (post_id, revision, content, created_at)
+SELECT id, 1, content, created_at
+FROM forum.posts
+ON CONFLICT DO NOTHING;
+
+INSERT INTO forum.thread_revisions (thread_id, revision, title, created_at)
+SELECT id, 1, title, created_at
+FROM forum.threads
+ON CONFLICT DO NOTHING;
+
+-- record_post_revision records the content of a post as a new revision whenever it is created or
+-- changed, counting the number of edits on the post itself.
+CREATE OR REPLACE FUNCTION forum.record_post_revision()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO forum.post_revisions (post_id, revision, content, created_at)
+        VALUES (NEW.id, 1, NEW.content, NEW.created_at);
+    ELSIF NEW.content IS DISTINCT FROM OLD.content THEN
+        NEW.edit_count := OLD.edit_count + 1;
+        INSERT INTO forum.post_revisions (post_id, revision, content)
+        VALUES (NEW.id, NEW.edit_count + 1, NEW.content);
+    ELSE
+        NEW.edit_count := OLD.edit_count;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- record_thread_revision records the title of a thread as a new revision whenever it is created or
+-- changed.
+CREATE OR REPLACE FUNCTION forum.record_thread_revision()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'INSERT' OR NEW.title IS DISTINCT FROM OLD.title THEN
+        INSERT INTO forum.thread_revisions (thread_id, revision, title)
+        SELECT NEW.id, COALESCE(MAX(revision), 0) + 1, NEW.title
+        FROM forum.thread_revisions
+        WHERE thread_id = NEW.id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_record_post_revision_insert ON forum.posts;
+DROP TRIGGER IF EXISTS trigger_record_post_revision_update ON forum.posts;
+DROP TRIGGER IF EXISTS trigger_record_thread_revision ON forum.threads;
+
+CREATE TRIGGER trigger_record_post_revision_insert
+    AFTER INSERT
+    ON forum.posts
+    FOR EACH ROW
+EXECUTE FUNCTION forum.record_post_revision();
+
+CREATE TRIGGER trigger_record_post_revision_update
+    BEFORE UPDATE
+    ON forum.posts
+    FOR EACH ROW
+EXECUTE FUNCTION forum.record_post_revision();
+
+CREATE TRIGGER trigger_record_thread_revision
+    AFTER INSERT OR UPDATE OF title
+    ON forum.threads
+    FOR EACH ROW
+EXECUTE FUNCTION forum.record_thread_revision();
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS forum.idempotency_keys;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS forum.idempotency_keys
+(
+    user_id     UUID                    NOT NULL,
+    key         VARCHAR(256)            NOT NULL,
+    fingerprint VARCHAR(64)             NOT NULL,
+    status      INTEGER                 NULL,
+    headers     JSONB                   NULL,
+    body        BYTEA                   NULL,
+    created_at  TIMESTAMP DEFAULT NOW() NOT NULL,
+    expires_at  TIMESTAMP               NOT NULL,
+    CONSTRAINT pk_idempotency_keys PRIMARY KEY (user_id, key),
+    CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES forum.users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON forum.idempotency_keys (expires_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS forum.rate_limit_buckets;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS forum.rate_limit_buckets
+(
+    key        VARCHAR(256)            NOT NULL,
+    tokens     DOUBLE PRECISION        NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    CONSTRAINT pk_rate_limit_buckets PRIMARY KEY (key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON forum.rate_limit_buckets (updated_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS forum.import_id_mappings;
+DROP TABLE IF EXISTS forum.imports;
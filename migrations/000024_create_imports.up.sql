@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS forum.imports
+(
+    id           UUID      DEFAULT gen_random_uuid(),
+    id_mode      VARCHAR(16)             NOT NULL,
+    on_conflict  VARCHAR(16)             NOT NULL,
+    line         BIGINT    DEFAULT 0     NOT NULL,
+    created_at   TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at   TIMESTAMP DEFAULT NOW() NOT NULL,
+    completed_at TIMESTAMP               NULL,
+    CONSTRAINT pk_imports PRIMARY KEY (id),
+    CONSTRAINT chk_id_mode CHECK (id_mode IN ('preserve', 'remap')),
+    CONSTRAINT chk_on_conflict CHECK (on_conflict IN ('skip', 'overwrite', 'fail'))
+);
+
+-- import_id_mappings records the row each archived ID was imported as. A NULL target denotes a
+-- record which was skipped, along with every record depending on it.
+CREATE TABLE IF NOT EXISTS forum.import_id_mappings
+(
+    import_id UUID NOT NULL,
+    source_id UUID NOT NULL,
+    target_id UUID NULL,
+    CONSTRAINT pk_import_id_mappings PRIMARY KEY (import_id, source_id),
+    CONSTRAINT fk_import_id FOREIGN KEY (import_id) REFERENCES forum.imports (id) ON DELETE CASCADE
+);
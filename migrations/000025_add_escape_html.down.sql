@@ -0,0 +1 @@
+DROP FUNCTION IF EXISTS forum.escape_html(TEXT);
@@ -0,0 +1,17 @@
+-- escape_html escapes the characters of the text with a meaning in HTML, allowing user content to
+-- be embedded in HTML such as the snippets of search results.
+CREATE OR REPLACE FUNCTION forum.escape_html(content TEXT)
+    RETURNS TEXT
+    LANGUAGE SQL
+    IMMUTABLE
+    STRICT
+AS
+$$
+SELECT replace(replace(replace(replace(replace(
+    content,
+    '&', '&amp;'),
+    '<', '&lt;'),
+    '>', '&gt;'),
+    '"', '&quot;'),
+    '''', '&#39;');
+$$;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS forum.idx_posts_depth;
+
+DROP TRIGGER IF EXISTS trigger_assign_post_depth ON forum.posts;
+
+DROP FUNCTION IF EXISTS forum.assign_post_depth();
+
+ALTER TABLE forum.posts
+    DROP COLUMN IF EXISTS depth;
@@ -0,0 +1,45 @@
+ALTER TABLE forum.posts
+    ADD COLUMN IF NOT EXISTS depth INTEGER DEFAULT 0 NOT NULL;
+
+-- assign_post_depth places a new post one level below the post it replies to, so posts ordered by
+-- their depth always follow the posts they reply to.
+CREATE OR REPLACE FUNCTION forum.assign_post_depth()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.depth := COALESCE((SELECT depth + 1 FROM forum.posts WHERE id = NEW.reply_to), 0);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Existing posts are backfilled without firing the triggers of the table, as the depth is no
+-- change to the post itself.
+ALTER TABLE forum.posts
+    DISABLE TRIGGER USER;
+
+WITH RECURSIVE tree AS (SELECT id, 0 AS depth
+                        FROM forum.posts
+                        WHERE reply_to IS NULL
+                        UNION ALL
+                        SELECT p.id, tree.depth + 1
+                        FROM forum.posts p
+                                 INNER JOIN tree ON p.reply_to = tree.id)
+UPDATE forum.posts p
+SET depth = tree.depth
+FROM tree
+WHERE p.id = tree.id
+  AND p.depth <> tree.depth;
+
+ALTER TABLE forum.posts
+    ENABLE TRIGGER USER;
+
+DROP TRIGGER IF EXISTS trigger_assign_post_depth ON forum.posts;
+
+CREATE TRIGGER trigger_assign_post_depth
+    BEFORE INSERT
+    ON forum.posts
+    FOR EACH ROW
+EXECUTE FUNCTION forum.assign_post_depth();
+
+CREATE INDEX IF NOT EXISTS idx_posts_depth ON forum.posts (depth, created_at, id);
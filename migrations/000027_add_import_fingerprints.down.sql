@@ -0,0 +1,2 @@
+ALTER TABLE forum.imports
+    DROP COLUMN IF EXISTS fingerprint;
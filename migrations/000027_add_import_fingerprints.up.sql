@@ -0,0 +1,4 @@
+-- fingerprint is the hash of the archive lines written so far, identifying the archive an import
+-- may be resumed with.
+ALTER TABLE forum.imports
+    ADD COLUMN IF NOT EXISTS fingerprint BYTEA NULL;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS forum.idx_thread_events_created_at;
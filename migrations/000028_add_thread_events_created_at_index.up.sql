@@ -0,0 +1,2 @@
+-- idx_thread_events_created_at supports sweeping the events older than the replay window.
+CREATE INDEX IF NOT EXISTS idx_thread_events_created_at ON forum.thread_events (created_at);
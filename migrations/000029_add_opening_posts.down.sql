@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS forum.idx_posts_opening;
+
+ALTER TABLE forum.posts
+    DROP COLUMN IF EXISTS opening;
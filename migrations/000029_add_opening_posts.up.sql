@@ -0,0 +1,26 @@
+-- opening denotes the post created alongside its thread. The opening post is presented with the
+-- thread itself, rather than among the replies to the thread.
+ALTER TABLE forum.posts
+    ADD COLUMN IF NOT EXISTS opening BOOLEAN DEFAULT FALSE NOT NULL;
+
+-- Existing opening posts are backfilled without firing the triggers of the table, as the flag is
+-- no change to the post itself. Opening posts were created by the author of the thread in the same
+-- transaction as the thread, sharing its creation time. Threads created before opening posts were
+-- introduced are left without one.
+ALTER TABLE forum.posts
+    DISABLE TRIGGER USER;
+
+UPDATE forum.posts
+SET opening = TRUE
+WHERE id IN (SELECT DISTINCT ON (p.thread_id) p.id
+             FROM forum.posts p
+                      INNER JOIN forum.threads t ON t.id = p.thread_id
+             WHERE p.reply_to IS NULL
+               AND p.author_id = t.author_id
+               AND p.created_at = t.created_at
+             ORDER BY p.thread_id, p.id);
+
+ALTER TABLE forum.posts
+    ENABLE TRIGGER USER;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_opening ON forum.posts (thread_id) WHERE opening;
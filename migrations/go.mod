@@ -0,0 +1,3 @@
+module github.com/r3d5un/rosetta/migrations
+
+go 1.24.1
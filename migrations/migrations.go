@@ -0,0 +1,11 @@
+// Package migrations embeds the SQL migrations of the database schema, shared by every
+// implementation of the API.
+//
+// Every migration is a pair of files named after its version and description, such as
+// 000001_create_forum_schema.up.sql and 000001_create_forum_schema.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS